@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type AuditUsecase interface {
+	List(context.Context, *dto.AuditListRequest) (*dto.AuditListResponse, error)
+	Verify(context.Context, *dto.AuditVerifyRequest) (*dto.AuditVerifyResponse, error)
+}
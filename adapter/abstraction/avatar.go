@@ -9,4 +9,9 @@ import (
 type AvatarUsecase interface {
 	GetUploadToken(context.Context, *dto.AvatarGetUploadTokenRequest) (*dto.AvatarGetUploadTokenResponse, error)
 	Update(context.Context, *dto.AvatarUpdateRequest) (*dto.AvatarUpdateResponse, error)
+	Remove(context.Context, *dto.AvatarRemoveRequest) (*dto.AvatarRemoveResponse, error)
+	GetDefault(context.Context, *dto.AvatarGetDefaultRequest) (*dto.AvatarGetDefaultResponse, error)
+	ListHistory(context.Context, *dto.AvatarListHistoryRequest) (*dto.AvatarListHistoryResponse, error)
+	Restore(context.Context, *dto.AvatarRestoreRequest) (*dto.AvatarRestoreResponse, error)
+	FindDangling(context.Context, *dto.AvatarFindDanglingRequest) (*dto.AvatarFindDanglingResponse, error)
 }
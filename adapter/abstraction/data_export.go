@@ -0,0 +1,14 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type DataExportUsecase interface {
+	Request(context.Context, *dto.DataExportRequestRequest) (*dto.DataExportRequestResponse, error)
+	Get(context.Context, *dto.DataExportGetRequest) (*dto.DataExportGetResponse, error)
+	Download(context.Context, *dto.DataExportDownloadRequest) (*dto.DataExportDownloadResponse, error)
+	Process(context.Context, *dto.DataExportProcessRequest) (*dto.DataExportProcessResponse, error)
+}
@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type EmailVerificationUsecase interface {
+	Send(context.Context, *dto.EmailVerificationSendRequest) (*dto.EmailVerificationSendResponse, error)
+	Verify(context.Context, *dto.EmailVerificationVerifyRequest) (*dto.EmailVerificationVerifyResponse, error)
+}
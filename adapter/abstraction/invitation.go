@@ -0,0 +1,14 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type InvitationUsecase interface {
+	Create(context.Context, *dto.InvitationCreateRequest) (*dto.InvitationCreateResponse, error)
+	List(context.Context, *dto.InvitationListRequest) (*dto.InvitationListResponse, error)
+	Revoke(context.Context, *dto.InvitationRevokeRequest) (*dto.InvitationRevokeResponse, error)
+	Redeem(context.Context, *dto.InvitationRedeemRequest) (*dto.InvitationRedeemResponse, error)
+}
@@ -0,0 +1,11 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type LDAPUsecase interface {
+	Sync(context.Context, *dto.LDAPSyncRequest) (*dto.LDAPSyncResponse, error)
+}
@@ -0,0 +1,15 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type LinkedIdentityUsecase interface {
+	List(context.Context, *dto.LinkedIdentityListRequest) (*dto.LinkedIdentityListResponse, error)
+	Link(context.Context, *dto.LinkedIdentityLinkRequest) (*dto.LinkedIdentityLinkResponse, error)
+	Unlink(context.Context, *dto.LinkedIdentityUnlinkRequest) (*dto.LinkedIdentityUnlinkResponse, error)
+	GetUser(context.Context, *dto.LinkedIdentityGetUserRequest) (*dto.LinkedIdentityGetUserResponse, error)
+	Provision(context.Context, *dto.LinkedIdentityProvisionRequest) (*dto.LinkedIdentityProvisionResponse, error)
+}
@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type MagicLinkUsecase interface {
+	Send(context.Context, *dto.MagicLinkSendRequest) (*dto.MagicLinkSendResponse, error)
+	Redeem(context.Context, *dto.MagicLinkRedeemRequest) (*dto.MagicLinkRedeemResponse, error)
+}
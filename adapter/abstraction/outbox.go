@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type OutboxUsecase interface {
+	Relay(context.Context, *dto.OutboxRelayRequest) (*dto.OutboxRelayResponse, error)
+	ReviveDead(context.Context, *dto.OutboxReviveDeadRequest) (*dto.OutboxReviveDeadResponse, error)
+}
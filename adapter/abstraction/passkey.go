@@ -0,0 +1,17 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type PasskeyUsecase interface {
+	BeginRegistration(context.Context, *dto.PasskeyBeginRegistrationRequest) (*dto.PasskeyBeginRegistrationResponse, error)
+	FinishRegistration(context.Context, *dto.PasskeyFinishRegistrationRequest) (*dto.PasskeyFinishRegistrationResponse, error)
+	List(context.Context, *dto.PasskeyListRequest) (*dto.PasskeyListResponse, error)
+	Rename(context.Context, *dto.PasskeyRenameRequest) (*dto.PasskeyRenameResponse, error)
+	Delete(context.Context, *dto.PasskeyDeleteRequest) (*dto.PasskeyDeleteResponse, error)
+	BeginAssertion(context.Context, *dto.PasskeyBeginAssertionRequest) (*dto.PasskeyBeginAssertionResponse, error)
+	VerifyAssertion(context.Context, *dto.PasskeyVerifyAssertionRequest) (*dto.PasskeyVerifyAssertionResponse, error)
+}
@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type PasswordResetUsecase interface {
+	Issue(context.Context, *dto.PasswordResetIssueRequest) (*dto.PasswordResetIssueResponse, error)
+	Reset(context.Context, *dto.PasswordResetRequest) (*dto.PasswordResetResponse, error)
+}
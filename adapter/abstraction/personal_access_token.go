@@ -0,0 +1,14 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type PersonalAccessTokenUsecase interface {
+	Create(context.Context, *dto.PersonalAccessTokenCreateRequest) (*dto.PersonalAccessTokenCreateResponse, error)
+	List(context.Context, *dto.PersonalAccessTokenListRequest) (*dto.PersonalAccessTokenListResponse, error)
+	Revoke(context.Context, *dto.PersonalAccessTokenRevokeRequest) (*dto.PersonalAccessTokenRevokeResponse, error)
+	Introspect(context.Context, *dto.PersonalAccessTokenIntrospectRequest) (*dto.PersonalAccessTokenIntrospectResponse, error)
+}
@@ -0,0 +1,11 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type ReauthenticationUsecase interface {
+	Reauthenticate(context.Context, *dto.ReauthenticateRequest) (*dto.ReauthenticateResponse, error)
+}
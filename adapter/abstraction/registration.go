@@ -0,0 +1,13 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type RegistrationUsecase interface {
+	GetPolicy(context.Context, *dto.RegistrationGetPolicyRequest) (*dto.RegistrationGetPolicyResponse, error)
+	Register(context.Context, *dto.RegistrationRegisterRequest) (*dto.RegistrationRegisterResponse, error)
+	Approve(context.Context, *dto.RegistrationApproveRequest) (*dto.RegistrationApproveResponse, error)
+}
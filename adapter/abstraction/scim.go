@@ -0,0 +1,15 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type ScimUsecase interface {
+	CreateUser(context.Context, *dto.ScimCreateUserRequest) (*dto.ScimCreateUserResponse, error)
+	GetUser(context.Context, *dto.ScimGetUserRequest) (*dto.ScimGetUserResponse, error)
+	ListUsers(context.Context, *dto.ScimListUsersRequest) (*dto.ScimListUsersResponse, error)
+	ReplaceUser(context.Context, *dto.ScimReplaceUserRequest) (*dto.ScimReplaceUserResponse, error)
+	PatchUser(context.Context, *dto.ScimPatchUserRequest) (*dto.ScimPatchUserResponse, error)
+}
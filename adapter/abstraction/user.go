@@ -11,8 +11,11 @@ type UserUsecase interface {
 	RegisterFirst(ctx context.Context, req *dto.UserRegisterFirstRequest) (*dto.UserRegisterFirstResponse, error)
 	GetByID(ctx context.Context, req *dto.UserGetByIDRequest) (*dto.UserGetByIDResponse, error)
 	GetByUsername(ctx context.Context, req *dto.UserGetByUsernameRequest) (*dto.UserGetByUsernameResponse, error)
+	Search(ctx context.Context, req *dto.UserSearchRequest) (*dto.UserSearchResponse, error)
+	CheckUsername(ctx context.Context, req *dto.UserCheckUsernameRequest) (*dto.UserCheckUsernameResponse, error)
 	ValidateCredentials(
 		ctx context.Context,
 		req *dto.UserValidateCredentialsRequest,
 	) (*dto.UserValidateCredentialsResponse, error)
+	ChangePassword(ctx context.Context, req *dto.UserChangePasswordRequest) (*dto.UserChangePasswordResponse, error)
 }
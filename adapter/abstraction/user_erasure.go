@@ -0,0 +1,13 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type UserErasureUsecase interface {
+	Schedule(context.Context, *dto.UserErasureScheduleRequest) (*dto.UserErasureScheduleResponse, error)
+	Cancel(context.Context, *dto.UserErasureCancelRequest) (*dto.UserErasureCancelResponse, error)
+	Process(context.Context, *dto.UserErasureProcessRequest) (*dto.UserErasureProcessResponse, error)
+}
@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type UserExportUsecase interface {
+	Export(context.Context, *dto.UserExportRequest) (*dto.UserExportResponse, error)
+	OperatorExport(context.Context, *dto.UserExportRequest) (*dto.UserExportResponse, error)
+}
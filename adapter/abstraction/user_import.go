@@ -0,0 +1,12 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type UserImportUsecase interface {
+	Import(context.Context, *dto.UserImportRequest) (*dto.UserImportResponse, error)
+	OperatorImport(context.Context, *dto.UserImportRequest) (*dto.UserImportResponse, error)
+}
@@ -0,0 +1,17 @@
+package abstraction
+
+import (
+	"context"
+
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type WebhookUsecase interface {
+	Create(context.Context, *dto.WebhookCreateRequest) (*dto.WebhookCreateResponse, error)
+	List(context.Context, *dto.WebhookListRequest) (*dto.WebhookListResponse, error)
+	Delete(context.Context, *dto.WebhookDeleteRequest) (*dto.WebhookDeleteResponse, error)
+	ListDeliveryLogs(context.Context, *dto.WebhookListDeliveryLogsRequest) (*dto.WebhookListDeliveryLogsResponse, error)
+	ListDeadLetters(context.Context, *dto.WebhookListDeadLettersRequest) (*dto.WebhookListDeadLettersResponse, error)
+	Dispatch(context.Context, *dto.WebhookDispatchRequest) (*dto.WebhookDispatchResponse, error)
+	Replay(context.Context, *dto.WebhookReplayRequest) (*dto.WebhookReplayResponse, error)
+}
@@ -2,7 +2,11 @@ package cli
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/todennus/user-service/adapter/cli/audit"
+	"github.com/todennus/user-service/adapter/cli/avatar"
 	"github.com/todennus/user-service/adapter/cli/seed"
+	"github.com/todennus/user-service/adapter/cli/user"
+	"github.com/todennus/user-service/adapter/cli/webhook"
 )
 
 var Command = &cobra.Command{
@@ -12,4 +16,9 @@ var Command = &cobra.Command{
 
 func init() {
 	Command.AddCommand(seed.Command)
+	Command.AddCommand(avatar.CheckCommand)
+	Command.AddCommand(webhook.ReplayCommand)
+	Command.AddCommand(audit.VerifyCommand)
+	Command.AddCommand(user.ImportCommand)
+	Command.AddCommand(user.ExportCommand)
 }
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/wiring"
+)
+
+var batchSize int
+
+var VerifyCommand = &cobra.Command{
+	Use:   "audit-verify",
+	Short: "Verify the hash chain of the audit logs",
+	Long: "Recompute the hash of every audit log from the first one and check that each log links\n" +
+		"to the previous one. Exit with a non-zero status if the chain is broken.\n" +
+		"Keep the printed head hash somewhere else, a chain rewritten from an older log can\n" +
+		"only be detected by comparing it with a previous head.",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		system, err := wiring.InitializeSystem(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		ctx := middleware.WithBasicContext(context.Background(), system.Config)
+
+		resp, err := system.Usecases.AuditUsecase.Verify(ctx, &dto.AuditVerifyRequest{BatchSize: batchSize})
+		if err != nil {
+			fmt.Println("Failed:", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Verified audit logs:", resp.Verified)
+		if resp.LastSeq != 0 {
+			fmt.Printf("Head: seq=%d hash=%s\n", resp.LastSeq, resp.LastHash)
+		}
+	},
+}
+
+func init() {
+	VerifyCommand.Flags().IntVar(&batchSize, "batch-size", 1000, "number of logs loaded per query")
+}
@@ -0,0 +1,79 @@
+package avatar
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/wiring"
+)
+
+var fix bool
+var reviveDead bool
+var batchSize int
+
+var CheckCommand = &cobra.Command{
+	Use:   "avatar-check",
+	Short: "Find avatars whose file no longer exists in the file service",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		system, err := wiring.InitializeSystem(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		ctx := middleware.WithBasicContext(context.Background(), system.Config)
+
+		dead, err := system.Usecases.OutboxUsecase.ReviveDead(ctx, &dto.OutboxReviveDeadRequest{
+			Topic:  domain.OutboxTopicFileRefcount,
+			DryRun: !reviveDead,
+		})
+		if err != nil {
+			fmt.Println("Failed:", err)
+			return
+		}
+
+		for _, msg := range dead.Messages {
+			fmt.Printf("Dead refcount change: id=%s attempts=%d payload=%s error=%q\n",
+				msg.ID, msg.Attempts, msg.Payload, msg.LastError)
+		}
+
+		if reviveDead {
+			fmt.Println("Revived dead refcount changes:", len(dead.Messages))
+		} else if len(dead.Messages) > 0 {
+			fmt.Println("Run with --revive-dead to deliver them again")
+		}
+
+		resp, err := system.Usecases.AvatarUsecase.FindDangling(ctx, &dto.AvatarFindDanglingRequest{
+			BatchSize: batchSize,
+			Fix:       fix,
+		})
+		if err != nil {
+			fmt.Println("Failed:", err)
+			return
+		}
+
+		for _, dangling := range resp.Dangling {
+			fmt.Printf("Dangling avatar: user_id=%s ownership_id=%s\n", dangling.UserID, dangling.OwnershipID)
+		}
+
+		fmt.Println("Checked users:", resp.Checked)
+		fmt.Println("Dangling avatars:", len(resp.Dangling))
+		if fix {
+			fmt.Println("Dangling avatars have been reset to the default avatar")
+		}
+	},
+}
+
+func init() {
+	CheckCommand.Flags().BoolVar(&fix, "fix", false, "reset dangling avatars to the default avatar")
+	CheckCommand.Flags().BoolVar(&reviveDead, "revive-dead", false, "deliver dead refcount changes again")
+	CheckCommand.Flags().IntVar(&batchSize, "batch-size", 100, "number of users checked per query")
+}
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/wiring"
+)
+
+var exportOutput string
+var exportFormat string
+var exportIncludeHashes bool
+var exportIncludeAvatars bool
+var exportBatchSize int
+
+var ExportCommand = &cobra.Command{
+	Use:   "export",
+	Short: "Export every user to a CSV or NDJSON file",
+	Long: "Export every user in the format read by the import command, ordered by id. The\n" +
+		"password hashes and the avatar ownership ids are only exported if requested. The\n" +
+		"rows without a hash are only imported once force_reset is set on them.",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		system, err := wiring.InitializeSystem(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		// The export may contain the password hashes, only the owner can
+		// read it.
+		f, err := os.OpenFile(exportOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			fmt.Println("Failed:", err)
+			os.Exit(1)
+		}
+
+		ctx := middleware.WithBasicContext(context.Background(), system.Config)
+
+		resp, err := system.Usecases.UserExportUsecase.OperatorExport(ctx, &dto.UserExportRequest{
+			Format:         exportFormat,
+			Writer:         f,
+			IncludeHashes:  exportIncludeHashes,
+			IncludeAvatars: exportIncludeAvatars,
+			BatchSize:      exportBatchSize,
+		})
+		if err == nil {
+			err = f.Close()
+		}
+
+		if err != nil {
+			fmt.Println("Failed:", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Exported users:", resp.Exported)
+	},
+}
+
+func init() {
+	ExportCommand.Flags().StringVarP(&exportOutput, "output", "o", "", "path of the exported file")
+	ExportCommand.Flags().StringVar(&exportFormat, "format", domain.UserImportFormatNDJSON,
+		fmt.Sprintf("%s or %s", domain.UserImportFormatNDJSON, domain.UserImportFormatCSV))
+	ExportCommand.Flags().BoolVar(&exportIncludeHashes, "include-hashes", false, "export the password hashes")
+	ExportCommand.Flags().BoolVar(&exportIncludeAvatars, "include-avatars", false, "export the avatar ownership ids")
+	ExportCommand.Flags().IntVar(&exportBatchSize, "batch-size", dto.DefaultUserExportBatchSize,
+		fmt.Sprintf("number of users read per query, up to %d", dto.MaximumUserExportBatchSize))
+	ExportCommand.MarkFlagRequired("output")
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/wiring"
+)
+
+var importFile string
+var importFormat string
+var importDryRun bool
+var importBatchSize int
+
+var ImportCommand = &cobra.Command{
+	Use:   "import",
+	Short: "Import users from a CSV or NDJSON file",
+	Long: "Import users from a CSV file with a header row or from a newline delimited JSON file.\n" +
+		"The columns are username, display_name, role, password_hash and force_reset. The\n" +
+		"password hash is a bcrypt or argon2id hash, force_reset requires the user to reset\n" +
+		"the password before signing in. Every row is validated and reported, a failed row\n" +
+		"does not prevent the other rows from being imported. Exit with a non-zero status if\n" +
+		"any row failed.",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		if importFormat == "" {
+			importFormat = strings.TrimPrefix(strings.ToLower(filepath.Ext(importFile)), ".")
+		}
+
+		f, err := os.Open(importFile)
+		if err != nil {
+			fmt.Println("Failed:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		system, err := wiring.InitializeSystem(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		ctx := middleware.WithBasicContext(context.Background(), system.Config)
+
+		resp, err := system.Usecases.UserImportUsecase.OperatorImport(ctx, &dto.UserImportRequest{
+			Format:    importFormat,
+			Reader:    f,
+			DryRun:    importDryRun,
+			BatchSize: importBatchSize,
+		})
+		if err != nil {
+			fmt.Println("Failed:", err)
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "LINE\tUSERNAME\tSTATUS\tUSER ID\tERROR")
+		for _, result := range resp.Results {
+			userID := ""
+			if result.UserID != 0 {
+				userID = result.UserID.String()
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", result.Line, result.Username, result.Status, userID, result.Error)
+		}
+		w.Flush()
+
+		if resp.DryRun {
+			fmt.Printf("Dry run: %d valid, %d failed\n", resp.Valid, resp.Failed)
+		} else {
+			fmt.Printf("Imported: %d created, %d failed\n", resp.Created, resp.Failed)
+		}
+
+		if resp.Failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ImportCommand.Flags().StringVarP(&importFile, "file", "f", "", "path of the file to import")
+	ImportCommand.Flags().StringVar(&importFormat, "format", "",
+		fmt.Sprintf("%s or %s, default to the file extension", domain.UserImportFormatCSV, domain.UserImportFormatNDJSON))
+	ImportCommand.Flags().BoolVar(&importDryRun, "dry-run", false, "only validate the rows")
+	ImportCommand.Flags().IntVar(&importBatchSize, "batch-size", dto.DefaultUserImportBatchSize,
+		fmt.Sprintf("number of users created per transaction, up to %d", dto.MaximumUserImportBatchSize))
+	ImportCommand.MarkFlagRequired("file")
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/wiring"
+	"github.com/xybor-x/snowflake"
+)
+
+var webhookID int64
+var deadLetterID int64
+
+var ReplayCommand = &cobra.Command{
+	Use:   "webhook-replay",
+	Short: "Replay the webhook deliveries which failed every attempt",
+	Long: "Move the dead letters back to the pending deliveries, the worker sends them again.\n" +
+		"Replay a single dead letter with --dead-letter-id, the dead letters of a webhook with\n" +
+		"--webhook-id, or every dead letter if none of them is given.",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		system, err := wiring.InitializeSystem(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		ctx := middleware.WithBasicContext(context.Background(), system.Config)
+
+		resp, err := system.Usecases.WebhookUsecase.Replay(ctx, &dto.WebhookReplayRequest{
+			WebhookID:    snowflake.ID(webhookID),
+			DeadLetterID: snowflake.ID(deadLetterID),
+		})
+		if err != nil {
+			fmt.Println("Failed:", err)
+			return
+		}
+
+		fmt.Println("Replayed dead letters:", resp.Replayed)
+	},
+}
+
+func init() {
+	ReplayCommand.Flags().Int64Var(&webhookID, "webhook-id", 0, "replay the dead letters of this webhook")
+	ReplayCommand.Flags().Int64Var(&deadLetterID, "dead-letter-id", 0, "replay only this dead letter")
+}
@@ -1,15 +1,19 @@
 package grpc
 
 import (
+	"crypto/tls"
+
 	"github.com/todennus/proto/gen/service"
 	"github.com/todennus/shared/config"
 	"github.com/todennus/shared/interceptor"
 	"github.com/todennus/user-service/wiring"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-func App(config *config.Config, usecases *wiring.Usecases) *grpc.Server {
-	s := grpc.NewServer(
+// App creates the gRPC server, it serves TLS if tlsConfig is not nil.
+func App(config *config.Config, tlsConfig *tls.Config, usecases *wiring.Usecases) *grpc.Server {
+	opts := []grpc.ServerOption{
 		grpc.UnaryInterceptor(
 			interceptor.NewUnaryInterceptor().
 				WithBasicContext().
@@ -18,7 +22,13 @@ func App(config *config.Config, usecases *wiring.Usecases) *grpc.Server {
 				WithAuthenticate().
 				Interceptor(config),
 		),
-	)
+	}
+
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s := grpc.NewServer(opts...)
 
 	service.RegisterUserServer(s, NewUserServer(usecases.AvatarUsecase, usecases.UserUsecase))
 
@@ -1,12 +1,15 @@
 package conversion
 
 import (
+	"strconv"
+
 	pbdto "github.com/todennus/proto/gen/service/dto"
 	pbresource "github.com/todennus/proto/gen/service/dto/resource"
 	ucdto "github.com/todennus/user-service/usecase/dto"
 	ucresource "github.com/todennus/user-service/usecase/dto/resource"
 	"github.com/todennus/x/conversion"
 	"github.com/xybor-x/snowflake"
+	"google.golang.org/grpc/metadata"
 )
 
 func NewPbUser(user *ucresource.User) *pbresource.User {
@@ -35,6 +38,25 @@ func NewPbUserValidateResponse(resp *ucdto.UserValidateCredentialsResponse) *pbd
 	}
 }
 
+// NewPasswordStatusMetadata returns the password status of a validation as
+// response headers, the released UserValidateResponse has no field for it.
+// The passwords which never expire have an expiration time of 0.
+func NewPasswordStatusMetadata(resp *ucdto.UserValidateCredentialsResponse) metadata.MD {
+	if resp == nil {
+		return nil
+	}
+
+	var passwordExpiresAt int64
+	if !resp.PasswordExpiresAt.IsZero() {
+		passwordExpiresAt = resp.PasswordExpiresAt.Unix()
+	}
+
+	return metadata.Pairs(
+		"x-password-status", resp.PasswordStatus,
+		"x-password-expires-at", strconv.FormatInt(passwordExpiresAt, 10),
+	)
+}
+
 func NewUsecaseUserGetByIDRequest(req *pbdto.UserGetByIDRequest) *ucdto.UserGetByIDRequest {
 	return &ucdto.UserGetByIDRequest{
 		UserID: snowflake.ID(req.GetId()),
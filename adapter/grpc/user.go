@@ -8,8 +8,10 @@ import (
 	"github.com/todennus/shared/errordef"
 	"github.com/todennus/shared/interceptor"
 	"github.com/todennus/shared/response"
+	"github.com/todennus/shared/xcontext"
 	"github.com/todennus/user-service/adapter/abstraction"
 	"github.com/todennus/user-service/adapter/grpc/conversion"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
 
@@ -40,6 +42,8 @@ func (s *UserServer) GetByID(ctx context.Context, req *pbdto.UserGetByIDRequest)
 		Finalize(ctx)
 }
 
+// Validate returns the user of the credentials, the status of the password is
+// sent in the x-password-status and x-password-expires-at headers.
 func (s *UserServer) Validate(ctx context.Context, req *pbdto.UserValidateRequest) (*pbdto.UserValidateResponse, error) {
 	if err := interceptor.RequireAuthentication(ctx); err != nil {
 		return nil, err
@@ -47,6 +51,11 @@ func (s *UserServer) Validate(ctx context.Context, req *pbdto.UserValidateReques
 
 	ucreq := conversion.NewUsecaseUserValidateRequest(req)
 	resp, err := s.userUsecase.ValidateCredentials(ctx, ucreq)
+	if md := conversion.NewPasswordStatusMetadata(resp); md != nil {
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-set-password-status-header", "err", err)
+		}
+	}
 
 	return response.NewGRPCResponseHandler(ctx, conversion.NewPbUserValidateResponse(resp), err).
 		Map(codes.InvalidArgument, errordef.ErrRequestInvalid).
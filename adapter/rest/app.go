@@ -11,6 +11,7 @@ import (
 
 func App(
 	config *config.Config,
+	variable *wiring.Variable,
 	usecases *wiring.Usecases,
 ) chi.Router {
 	r := chi.NewRouter()
@@ -22,7 +23,29 @@ func App(
 	r.Use(middleware.Authentication(config.TokenEngine))
 	r.Use(middleware.WithSession(config.SessionManager))
 
-	r.Route("/users", NewUserAdapter(usecases.UserUsecase, usecases.AvatarUsecase).Router)
+	r.Route("/users", NewUserAdapter(
+		usecases.UserUsecase,
+		usecases.AvatarUsecase,
+		usecases.UserImportUsecase,
+		usecases.UserExportUsecase,
+		usecases.UserErasureUsecase,
+	).Router)
+	r.Route("/registration", NewRegistrationAdapter(usecases.RegistrationUsecase).Router)
+	r.Route("/invitations", NewInvitationAdapter(usecases.InvitationUsecase).Router)
+	r.Route("/email_verifications", NewEmailVerificationAdapter(usecases.EmailVerificationUsecase).Router)
+	r.Route("/users/@me/export", NewDataExportAdapter(usecases.DataExportUsecase).Router)
+	r.Route("/users/@me/tokens", NewPersonalAccessTokenAdapter(usecases.PersonalAccessTokenUsecase).Router)
+	r.Route("/users/@me/passkeys", NewPasskeyAdapter(usecases.PasskeyUsecase).Router)
+	r.Route("/users/{user_id}/identities", NewLinkedIdentityAdapter(usecases.LinkedIdentityUsecase).Router)
+	r.Route("/users/{user_id}/reauthentication", NewReauthenticationAdapter(usecases.ReauthenticationUsecase).Router)
+	r.Route("/federation", NewFederationAdapter(usecases.LinkedIdentityUsecase).Router)
+	r.Route("/tokens", NewTokenIntrospectionAdapter(usecases.PersonalAccessTokenUsecase).Router)
+	r.Route("/passkeys", NewPasskeyAssertionAdapter(usecases.PasskeyUsecase).Router)
+	r.Route("/magic_links", NewMagicLinkAdapter(usecases.MagicLinkUsecase).Router)
+	r.Route("/password_resets", NewPasswordResetAdapter(usecases.PasswordResetUsecase).Router)
+	r.Route("/webhooks", NewWebhookAdapter(usecases.WebhookUsecase).Router)
+	r.Route("/audit_logs", NewAuditAdapter(usecases.AuditUsecase).Router)
+	r.Route("/scim/v2", NewScimAdapter(usecases.ScimUsecase, variable.User.DefaultAvatarBaseURL+"/scim/v2/Users").Router)
 
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
 
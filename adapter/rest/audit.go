@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type AuditAdapter struct {
+	auditUsecase abstraction.AuditUsecase
+}
+
+func NewAuditAdapter(auditUsecase abstraction.AuditUsecase) *AuditAdapter {
+	return &AuditAdapter{auditUsecase: auditUsecase}
+}
+
+func (a *AuditAdapter) Router(r chi.Router) {
+	r.Get("/", middleware.RequireAuthentication(a.List()))
+}
+
+// @Summary List audit logs
+// @Description List the audit logs of the administrative and security actions, from the newest to the oldest. <br>
+// @Description Pass the `seq` of the last returned log as `before` to get the next page. <br>
+// @Description Require `todennus/admin:read:user.profile` scope and admin role.
+// @Tags Audit
+// @Security OAuth2Application[todennus/admin:read:user.profile]
+// @Produce json
+// @Param actor_id query string false "actor id"
+// @Param action query string false "action, e.g. user.register"
+// @Param target_type query string false "target type, e.g. user"
+// @Param target_id query string false "target id"
+// @Param from query string false "RFC3339 time, inclusive"
+// @Param to query string false "RFC3339 time, exclusive"
+// @Param before query int false "only return the logs whose seq is less than this value"
+// @Param limit query int false "maximum number of logs, up to 500 (default 50)"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.AuditListResponse] "List successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /audit_logs [get]
+func (a *AuditAdapter) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.AuditListRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.auditUsecase.List(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewAuditListResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
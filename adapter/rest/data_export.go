@@ -0,0 +1,159 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type DataExportAdapter struct {
+	dataExportUsecase abstraction.DataExportUsecase
+}
+
+func NewDataExportAdapter(dataExportUsecase abstraction.DataExportUsecase) *DataExportAdapter {
+	return &DataExportAdapter{dataExportUsecase: dataExportUsecase}
+}
+
+func (a *DataExportAdapter) Router(r chi.Router) {
+	r.Get("/", middleware.RequireAuthentication(a.Request()))
+	r.Get("/{export_id}", middleware.RequireAuthentication(a.Get()))
+	r.Get("/{export_id}/archive", middleware.RequireAuthentication(a.Download()))
+}
+
+// @Summary Export my data
+// @Description Download a JSON archive of everything this service stores about the current user. <br>
+// @Description The archive of a large account is generated in background, the request then returns `202` with the export whose status is at the `Location` header. <br>
+// @Description Require `todennus/read:user.profile` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.profile]
+// @Produce json
+// @Success 200 {string} string "The archive"
+// @Success 202 {object} response.SwaggerSuccessResponse[dto.DataExportResponse] "The archive is being generated"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/@me/export [get]
+func (a *DataExportAdapter) Request() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.DataExportRequestRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.dataExportUsecase.Request(ctx, req.To(xcontext.RequestSubjectID(ctx)))
+		if err == nil && resp.Archive != nil {
+			writeArchive(w, r, xcontext.RequestSubjectID(ctx).String(), resp.Archive)
+			return
+		}
+
+		if err == nil {
+			w.Header().Set("Location", fmt.Sprintf("%s/%s", strings.TrimSuffix(r.URL.Path, "/"), resp.Export.ID))
+		}
+
+		response.NewRESTResponseHandler(ctx, dto.NewDataExportRequestResponse(resp), err).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WithDefaultCode(http.StatusAccepted).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Get my data export
+// @Description Get the status of a data export of the current user, the archive of a `completed` export is downloaded until `expires_at`. <br>
+// @Description Require `todennus/read:user.profile` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.profile]
+// @Produce json
+// @Param export_id path string true "export_id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.DataExportResponse] "Get successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/@me/export/{export_id} [get]
+func (a *DataExportAdapter) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.DataExportGetRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.dataExportUsecase.Get(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewDataExportGetResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Download my data export
+// @Description Download the archive of a completed data export of the current user. <br>
+// @Description Require `todennus/read:user.profile` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.profile]
+// @Produce json
+// @Param export_id path string true "export_id"
+// @Success 200 {string} string "The archive"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/@me/export/{export_id}/archive [get]
+func (a *DataExportAdapter) Download() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.DataExportDownloadRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.dataExportUsecase.Download(ctx, ucreq)
+		if err != nil {
+			response.NewRESTResponseHandler(ctx, nil, err).
+				Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+				Map(http.StatusForbidden, errordef.ErrForbidden).
+				Map(http.StatusNotFound, errordef.ErrNotFound).
+				WriteHTTPResponse(ctx, w)
+			return
+		}
+
+		writeArchive(w, r, xcontext.RequestSubjectID(ctx).String(), resp.Archive)
+	}
+}
+
+func writeArchive(w http.ResponseWriter, r *http.Request, userID string, archive []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todennus-user-%s.json"`, userID))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(archive); err != nil {
+		xcontext.Logger(r.Context()).Warn("failed-to-write-data-export", "err", err)
+	}
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	DefaultAuditLogLimit = 50
+	MaximumAuditLogLimit = 500
+)
+
+type AuditListRequest struct {
+	ActorID    string `query:"actor_id"`
+	Action     string `query:"action"`
+	TargetType string `query:"target_type"`
+	TargetID   string `query:"target_id"`
+	From       string `query:"from"`
+	Until      string `query:"to"`
+	Before     int64  `query:"before"`
+	Limit      int    `query:"limit"`
+}
+
+func (req AuditListRequest) To() (*dto.AuditListRequest, error) {
+	ucreq := &dto.AuditListRequest{
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		BeforeSeq:  req.Before,
+		Limit:      req.Limit,
+	}
+
+	var err error
+	if req.ActorID != "" {
+		ucreq.ActorID, err = snowflake.ParseString(req.ActorID)
+		if err != nil {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid actor id")
+		}
+	}
+
+	if req.From != "" {
+		ucreq.From, err = time.Parse(time.RFC3339, req.From)
+		if err != nil {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "from must be a RFC3339 time")
+		}
+	}
+
+	if req.Until != "" {
+		ucreq.To, err = time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "to must be a RFC3339 time")
+		}
+	}
+
+	if ucreq.Limit == 0 {
+		ucreq.Limit = DefaultAuditLogLimit
+	}
+
+	if ucreq.Limit < 0 || ucreq.Limit > MaximumAuditLogLimit {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "limit must be in range [1, %d]", MaximumAuditLogLimit)
+	}
+
+	if ucreq.BeforeSeq < 0 {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid before")
+	}
+
+	return ucreq, nil
+}
+
+type AuditListResponse struct {
+	Logs []*resource.AuditLog `json:"logs"`
+}
+
+func NewAuditListResponse(resp *dto.AuditListResponse) *AuditListResponse {
+	if resp == nil {
+		return nil
+	}
+
+	logs := []*resource.AuditLog{}
+	for i := range resp.Logs {
+		logs = append(logs, resource.NewAuditLog(resp.Logs[i]))
+	}
+
+	return &AuditListResponse{Logs: logs}
+}
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+func parseDataExportID(s string) (snowflake.ID, error) {
+	exportID, err := snowflake.ParseString(s)
+	if err != nil {
+		return 0, xerror.Enrich(errordef.ErrRequestInvalid, "invalid export id")
+	}
+
+	return exportID, nil
+}
+
+type DataExportRequestRequest struct {
+}
+
+func (req DataExportRequestRequest) To(meID snowflake.ID) *dto.DataExportRequestRequest {
+	return &dto.DataExportRequestRequest{UserID: meID}
+}
+
+type DataExportResponse struct {
+	*resource.DataExport
+}
+
+func NewDataExportRequestResponse(resp *dto.DataExportRequestResponse) *DataExportResponse {
+	if resp == nil || resp.Export == nil {
+		return nil
+	}
+
+	return &DataExportResponse{DataExport: resource.NewDataExport(resp.Export)}
+}
+
+type DataExportGetRequest struct {
+	ExportID string `param:"export_id"`
+}
+
+func (req DataExportGetRequest) To(meID snowflake.ID) (*dto.DataExportGetRequest, error) {
+	exportID, err := parseDataExportID(req.ExportID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DataExportGetRequest{UserID: meID, ExportID: exportID}, nil
+}
+
+func NewDataExportGetResponse(resp *dto.DataExportGetResponse) *DataExportResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &DataExportResponse{DataExport: resource.NewDataExport(resp.Export)}
+}
+
+type DataExportDownloadRequest struct {
+	ExportID string `param:"export_id"`
+}
+
+func (req DataExportDownloadRequest) To(meID snowflake.ID) (*dto.DataExportDownloadRequest, error) {
+	exportID, err := parseDataExportID(req.ExportID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DataExportDownloadRequest{UserID: meID, ExportID: exportID}, nil
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+// Send
+type EmailVerificationSendRequest struct {
+	Email string `json:"email" example:"huykingsofm@example.com"`
+}
+
+func (req EmailVerificationSendRequest) To() *dto.EmailVerificationSendRequest {
+	return &dto.EmailVerificationSendRequest{Email: req.Email}
+}
+
+type EmailVerificationSendResponse struct{}
+
+func NewEmailVerificationSendResponse(resp *dto.EmailVerificationSendResponse) *EmailVerificationSendResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &EmailVerificationSendResponse{}
+}
+
+// Verify
+type EmailVerificationVerifyRequest struct {
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+func (req EmailVerificationVerifyRequest) To() *dto.EmailVerificationVerifyRequest {
+	return &dto.EmailVerificationVerifyRequest{Token: req.Token}
+}
+
+type EmailVerificationVerifyResponse struct {
+	UserID        string `json:"user_id" example:"330559330522759168"`
+	EmailVerified bool   `json:"email_verified" example:"true"`
+}
+
+func NewEmailVerificationVerifyResponse(resp *dto.EmailVerificationVerifyResponse) *EmailVerificationVerifyResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &EmailVerificationVerifyResponse{
+		UserID:        resp.UserID.String(),
+		EmailVerified: resp.EmailVerified,
+	}
+}
@@ -0,0 +1,132 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// Create
+type InvitationCreateRequest struct {
+	Role      string `json:"role" example:"user"`
+	ExpiresIn int    `json:"expires_in" example:"604800"` // in second
+}
+
+func (req InvitationCreateRequest) To() (*dto.InvitationCreateRequest, error) {
+	if req.ExpiresIn < 0 {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid expires_in")
+	}
+
+	return &dto.InvitationCreateRequest{
+		Role:       req.Role,
+		Expiration: time.Duration(req.ExpiresIn) * time.Second,
+	}, nil
+}
+
+type InvitationCreateResponse struct {
+	*resource.Invitation
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+func NewInvitationCreateResponse(resp *dto.InvitationCreateResponse) *InvitationCreateResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &InvitationCreateResponse{
+		Invitation: resource.NewInvitation(resp.Invitation),
+		Token:      resp.Token,
+	}
+}
+
+// List
+type InvitationListRequest struct {
+	Before string `query:"before"`
+	Limit  int    `query:"limit"`
+}
+
+func (req InvitationListRequest) To() (*dto.InvitationListRequest, error) {
+	ucreq := &dto.InvitationListRequest{Limit: req.Limit}
+	if req.Before != "" {
+		var err error
+		if ucreq.BeforeID, err = snowflake.ParseString(req.Before); err != nil {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid before")
+		}
+	}
+
+	return ucreq, nil
+}
+
+type InvitationListResponse struct {
+	Invitations []*resource.Invitation `json:"invitations"`
+}
+
+func NewInvitationListResponse(resp *dto.InvitationListResponse) *InvitationListResponse {
+	if resp == nil {
+		return nil
+	}
+
+	invitations := []*resource.Invitation{}
+	for _, invitation := range resp.Invitations {
+		invitations = append(invitations, resource.NewInvitation(invitation))
+	}
+
+	return &InvitationListResponse{Invitations: invitations}
+}
+
+// Revoke
+type InvitationRevokeRequest struct {
+	InvitationID string `param:"invitation_id"`
+}
+
+func (req InvitationRevokeRequest) To() (*dto.InvitationRevokeRequest, error) {
+	invitationID, err := snowflake.ParseString(req.InvitationID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid invitation id")
+	}
+
+	return &dto.InvitationRevokeRequest{InvitationID: invitationID}, nil
+}
+
+type InvitationRevokeResponse struct {
+	*resource.Invitation
+}
+
+func NewInvitationRevokeResponse(resp *dto.InvitationRevokeResponse) *InvitationRevokeResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &InvitationRevokeResponse{Invitation: resource.NewInvitation(resp.Invitation)}
+}
+
+// Redeem
+type InvitationRedeemRequest struct {
+	Token    string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Username string `json:"username" example:"huykingsofm"`
+	Password string `json:"password" example:"s3Cr3tP@ssW0rD"`
+}
+
+func (req InvitationRedeemRequest) To() *dto.InvitationRedeemRequest {
+	return &dto.InvitationRedeemRequest{
+		Token:    req.Token,
+		Username: req.Username,
+		Password: req.Password,
+	}
+}
+
+type InvitationRedeemResponse struct {
+	*resource.User
+}
+
+func NewInvitationRedeemResponse(resp *dto.InvitationRedeemResponse) *InvitationRedeemResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &InvitationRedeemResponse{User: resource.NewUser(resp.User)}
+}
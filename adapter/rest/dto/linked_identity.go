@@ -0,0 +1,160 @@
+package dto
+
+import (
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// List
+type LinkedIdentityListRequest struct {
+	UserID string `param:"user_id"`
+}
+
+func (req LinkedIdentityListRequest) To(meID snowflake.ID) (*dto.LinkedIdentityListRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.LinkedIdentityListRequest{UserID: userID}, nil
+}
+
+type LinkedIdentityListResponse struct {
+	Identities []*resource.LinkedIdentity `json:"identities"`
+}
+
+func NewLinkedIdentityListResponse(resp *dto.LinkedIdentityListResponse) *LinkedIdentityListResponse {
+	if resp == nil {
+		return nil
+	}
+
+	identities := []*resource.LinkedIdentity{}
+	for _, identity := range resp.Identities {
+		identities = append(identities, resource.NewLinkedIdentity(identity))
+	}
+
+	return &LinkedIdentityListResponse{Identities: identities}
+}
+
+// Link
+type LinkedIdentityLinkRequest struct {
+	UserID   string `json:"-" param:"user_id"`
+	Provider string `json:"provider" example:"github"`
+	Subject  string `json:"subject" example:"583231"`
+	Email    string `json:"email" example:"huykingsofm@example.com"`
+}
+
+func (req *LinkedIdentityLinkRequest) To(meID snowflake.ID) (*dto.LinkedIdentityLinkRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.LinkedIdentityLinkRequest{
+		UserID:   userID,
+		Provider: req.Provider,
+		Subject:  req.Subject,
+		Email:    req.Email,
+	}, nil
+}
+
+type LinkedIdentityLinkResponse struct {
+	*resource.LinkedIdentity
+}
+
+func NewLinkedIdentityLinkResponse(resp *dto.LinkedIdentityLinkResponse) *LinkedIdentityLinkResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &LinkedIdentityLinkResponse{LinkedIdentity: resource.NewLinkedIdentity(resp.Identity)}
+}
+
+// Unlink
+type LinkedIdentityUnlinkRequest struct {
+	UserID     string `param:"user_id"`
+	IdentityID string `param:"identity_id"`
+}
+
+func (req LinkedIdentityUnlinkRequest) To(meID snowflake.ID) (*dto.LinkedIdentityUnlinkRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	identityID, err := snowflake.ParseString(req.IdentityID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid identity id")
+	}
+
+	return &dto.LinkedIdentityUnlinkRequest{UserID: userID, IdentityID: identityID}, nil
+}
+
+type LinkedIdentityUnlinkResponse struct{}
+
+func NewLinkedIdentityUnlinkResponse(resp *dto.LinkedIdentityUnlinkResponse) *LinkedIdentityUnlinkResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &LinkedIdentityUnlinkResponse{}
+}
+
+// Lookup
+type FederationLookupRequest struct {
+	Provider string `json:"provider" example:"github"`
+	Subject  string `json:"subject" example:"583231"`
+}
+
+func (req FederationLookupRequest) To() *dto.LinkedIdentityGetUserRequest {
+	return &dto.LinkedIdentityGetUserRequest{Provider: req.Provider, Subject: req.Subject}
+}
+
+type FederationLookupResponse struct {
+	*resource.User
+}
+
+func NewFederationLookupResponse(resp *dto.LinkedIdentityGetUserResponse) *FederationLookupResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &FederationLookupResponse{User: resource.NewUser(resp.User)}
+}
+
+// Provision
+type FederationProvisionRequest struct {
+	Provider          string `json:"provider" example:"github"`
+	Subject           string `json:"subject" example:"583231"`
+	Email             string `json:"email" example:"huykingsofm@example.com"`
+	EmailVerified     bool   `json:"email_verified" example:"true"`
+	PreferredUsername string `json:"preferred_username" example:"huykingsofm"`
+	DisplayName       string `json:"display_name" example:"Huy"`
+}
+
+func (req FederationProvisionRequest) To() *dto.LinkedIdentityProvisionRequest {
+	return &dto.LinkedIdentityProvisionRequest{
+		Provider:          req.Provider,
+		Subject:           req.Subject,
+		Email:             req.Email,
+		EmailVerified:     req.EmailVerified,
+		PreferredUsername: req.PreferredUsername,
+		DisplayName:       req.DisplayName,
+	}
+}
+
+type FederationProvisionResponse struct {
+	*resource.User
+	Created bool `json:"created" example:"true"`
+}
+
+func NewFederationProvisionResponse(resp *dto.LinkedIdentityProvisionResponse) *FederationProvisionResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &FederationProvisionResponse{User: resource.NewUser(resp.User), Created: resp.Created}
+}
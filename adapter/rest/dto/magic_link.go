@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+// Send
+type MagicLinkSendRequest struct {
+	Email string `json:"email" example:"huykingsofm@example.com"`
+}
+
+func (req MagicLinkSendRequest) To() *dto.MagicLinkSendRequest {
+	return &dto.MagicLinkSendRequest{Email: req.Email}
+}
+
+type MagicLinkSendResponse struct{}
+
+func NewMagicLinkSendResponse(resp *dto.MagicLinkSendResponse) *MagicLinkSendResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &MagicLinkSendResponse{}
+}
+
+// Redeem
+type MagicLinkRedeemRequest struct {
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+func (req MagicLinkRedeemRequest) To() *dto.MagicLinkRedeemRequest {
+	return &dto.MagicLinkRedeemRequest{Token: req.Token}
+}
+
+type MagicLinkRedeemResponse struct {
+	*resource.User
+}
+
+func NewMagicLinkRedeemResponse(resp *dto.MagicLinkRedeemResponse) *MagicLinkRedeemResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &MagicLinkRedeemResponse{User: resource.NewUser(resp.User)}
+}
@@ -0,0 +1,198 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// BeginRegistration
+type PasskeyBeginRegistrationRequest struct{}
+
+func (req PasskeyBeginRegistrationRequest) To() *dto.PasskeyBeginRegistrationRequest {
+	return &dto.PasskeyBeginRegistrationRequest{}
+}
+
+// PasskeyBeginRegistrationResponse has the options of
+// navigator.credentials.create, as a PublicKeyCredentialCreationOptions JSON.
+type PasskeyBeginRegistrationResponse struct {
+	SessionID string          `json:"session_id" example:"3q2-7wYh0Hf0ZJ6Rr1x2Sg"`
+	Options   json.RawMessage `json:"options" swaggertype:"object"`
+}
+
+func NewPasskeyBeginRegistrationResponse(resp *dto.PasskeyBeginRegistrationResponse) *PasskeyBeginRegistrationResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasskeyBeginRegistrationResponse{SessionID: resp.SessionID, Options: resp.Options}
+}
+
+// FinishRegistration
+type PasskeyFinishRegistrationRequest struct {
+	SessionID             string          `json:"session_id" example:"3q2-7wYh0Hf0ZJ6Rr1x2Sg"`
+	Name                  string          `json:"name" example:"YubiKey 5"`
+	Credential            json.RawMessage `json:"credential" swaggertype:"object"`
+	ReauthenticationToken string          `json:"reauthentication_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+func (req PasskeyFinishRegistrationRequest) To() (*dto.PasskeyFinishRegistrationRequest, error) {
+	if len(req.Credential) == 0 {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require credential")
+	}
+
+	return &dto.PasskeyFinishRegistrationRequest{
+		SessionID:             req.SessionID,
+		Name:                  req.Name,
+		Credential:            req.Credential,
+		ReauthenticationToken: req.ReauthenticationToken,
+	}, nil
+}
+
+type PasskeyFinishRegistrationResponse struct {
+	*resource.Passkey
+}
+
+func NewPasskeyFinishRegistrationResponse(resp *dto.PasskeyFinishRegistrationResponse) *PasskeyFinishRegistrationResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasskeyFinishRegistrationResponse{Passkey: resource.NewPasskey(resp.Passkey)}
+}
+
+// List
+type PasskeyListRequest struct{}
+
+func (req PasskeyListRequest) To() *dto.PasskeyListRequest {
+	return &dto.PasskeyListRequest{}
+}
+
+type PasskeyListResponse struct {
+	Passkeys []*resource.Passkey `json:"passkeys"`
+}
+
+func NewPasskeyListResponse(resp *dto.PasskeyListResponse) *PasskeyListResponse {
+	if resp == nil {
+		return nil
+	}
+
+	passkeys := []*resource.Passkey{}
+	for _, passkey := range resp.Passkeys {
+		passkeys = append(passkeys, resource.NewPasskey(passkey))
+	}
+
+	return &PasskeyListResponse{Passkeys: passkeys}
+}
+
+// Rename
+type PasskeyRenameRequest struct {
+	PasskeyID string `json:"-" param:"passkey_id"`
+	Name      string `json:"name" example:"YubiKey 5"`
+}
+
+func (req PasskeyRenameRequest) To() (*dto.PasskeyRenameRequest, error) {
+	passkeyID, err := snowflake.ParseString(req.PasskeyID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid passkey id")
+	}
+
+	return &dto.PasskeyRenameRequest{PasskeyID: passkeyID, Name: req.Name}, nil
+}
+
+type PasskeyRenameResponse struct {
+	*resource.Passkey
+}
+
+func NewPasskeyRenameResponse(resp *dto.PasskeyRenameResponse) *PasskeyRenameResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasskeyRenameResponse{Passkey: resource.NewPasskey(resp.Passkey)}
+}
+
+// Delete
+type PasskeyDeleteRequest struct {
+	PasskeyID string `param:"passkey_id"`
+}
+
+func (req PasskeyDeleteRequest) To() (*dto.PasskeyDeleteRequest, error) {
+	passkeyID, err := snowflake.ParseString(req.PasskeyID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid passkey id")
+	}
+
+	return &dto.PasskeyDeleteRequest{PasskeyID: passkeyID}, nil
+}
+
+type PasskeyDeleteResponse struct{}
+
+func NewPasskeyDeleteResponse(resp *dto.PasskeyDeleteResponse) *PasskeyDeleteResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasskeyDeleteResponse{}
+}
+
+// BeginAssertion
+type PasskeyBeginAssertionRequest struct {
+	Username string `json:"username" example:"huykingsofm"`
+}
+
+func (req PasskeyBeginAssertionRequest) To() *dto.PasskeyBeginAssertionRequest {
+	return &dto.PasskeyBeginAssertionRequest{Username: req.Username}
+}
+
+// PasskeyBeginAssertionResponse has the options of navigator.credentials.get,
+// as a PublicKeyCredentialRequestOptions JSON.
+type PasskeyBeginAssertionResponse struct {
+	SessionID string          `json:"session_id" example:"3q2-7wYh0Hf0ZJ6Rr1x2Sg"`
+	Options   json.RawMessage `json:"options" swaggertype:"object"`
+}
+
+func NewPasskeyBeginAssertionResponse(resp *dto.PasskeyBeginAssertionResponse) *PasskeyBeginAssertionResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasskeyBeginAssertionResponse{SessionID: resp.SessionID, Options: resp.Options}
+}
+
+// VerifyAssertion
+type PasskeyVerifyAssertionRequest struct {
+	SessionID  string          `json:"session_id" example:"3q2-7wYh0Hf0ZJ6Rr1x2Sg"`
+	Credential json.RawMessage `json:"credential" swaggertype:"object"`
+}
+
+func (req PasskeyVerifyAssertionRequest) To() (*dto.PasskeyVerifyAssertionRequest, error) {
+	if len(req.Credential) == 0 {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require credential")
+	}
+
+	return &dto.PasskeyVerifyAssertionRequest{SessionID: req.SessionID, Credential: req.Credential}, nil
+}
+
+type PasskeyVerifyAssertionResponse struct {
+	*resource.User
+	ReauthenticationToken     string    `json:"reauthentication_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ReauthenticationExpiresAt time.Time `json:"reauthentication_expires_at" example:"2025-01-21T13:52:29Z"`
+}
+
+func NewPasskeyVerifyAssertionResponse(resp *dto.PasskeyVerifyAssertionResponse) *PasskeyVerifyAssertionResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasskeyVerifyAssertionResponse{
+		User:                      resource.NewUser(resp.User),
+		ReauthenticationToken:     resp.ReauthenticationToken,
+		ReauthenticationExpiresAt: resp.ReauthenticationExpiresAt,
+	}
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// Issue
+type PasswordResetIssueRequest struct {
+	UserID string `json:"user_id" example:"330559330522759168"`
+}
+
+func (req PasswordResetIssueRequest) To() (*dto.PasswordResetIssueRequest, error) {
+	userID, err := snowflake.ParseString(req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.PasswordResetIssueRequest{UserID: userID}, nil
+}
+
+type PasswordResetIssueResponse struct {
+	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresAt time.Time `json:"expires_at" example:"2025-01-21T13:52:29Z"`
+}
+
+func NewPasswordResetIssueResponse(resp *dto.PasswordResetIssueResponse) *PasswordResetIssueResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasswordResetIssueResponse{Token: resp.Token, ExpiresAt: resp.ExpiresAt}
+}
+
+// Reset
+type PasswordResetRequest struct {
+	Token       string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	NewPassword string `json:"new_password" example:"n3wS3cr3tP@ss"`
+}
+
+func (req PasswordResetRequest) To() *dto.PasswordResetRequest {
+	return &dto.PasswordResetRequest{Token: req.Token, NewPassword: req.NewPassword}
+}
+
+type PasswordResetResponse struct{}
+
+func NewPasswordResetResponse(resp *dto.PasswordResetResponse) *PasswordResetResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PasswordResetResponse{}
+}
@@ -0,0 +1,139 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// Create
+type PersonalAccessTokenCreateRequest struct {
+	Name      string `json:"name" example:"deploy script"`
+	Scope     string `json:"scope" example:"todennus/read:user.profile"`
+	ExpiresIn int    `json:"expires_in" example:"7776000"` // in second
+}
+
+func (req PersonalAccessTokenCreateRequest) To() (*dto.PersonalAccessTokenCreateRequest, error) {
+	if req.ExpiresIn < 0 {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid expires_in")
+	}
+
+	return &dto.PersonalAccessTokenCreateRequest{
+		Name:       req.Name,
+		Scope:      req.Scope,
+		Expiration: time.Duration(req.ExpiresIn) * time.Second,
+	}, nil
+}
+
+type PersonalAccessTokenCreateResponse struct {
+	*resource.PersonalAccessToken
+	Token string `json:"token" example:"tdnpat_6f1c2a..."`
+}
+
+func NewPersonalAccessTokenCreateResponse(resp *dto.PersonalAccessTokenCreateResponse) *PersonalAccessTokenCreateResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PersonalAccessTokenCreateResponse{
+		PersonalAccessToken: resource.NewPersonalAccessToken(resp.PersonalAccessToken),
+		Token:               resp.Token,
+	}
+}
+
+// List
+type PersonalAccessTokenListRequest struct{}
+
+func (req PersonalAccessTokenListRequest) To() *dto.PersonalAccessTokenListRequest {
+	return &dto.PersonalAccessTokenListRequest{}
+}
+
+type PersonalAccessTokenListResponse struct {
+	Tokens []*resource.PersonalAccessToken `json:"tokens"`
+}
+
+func NewPersonalAccessTokenListResponse(resp *dto.PersonalAccessTokenListResponse) *PersonalAccessTokenListResponse {
+	if resp == nil {
+		return nil
+	}
+
+	tokens := []*resource.PersonalAccessToken{}
+	for _, token := range resp.PersonalAccessTokens {
+		tokens = append(tokens, resource.NewPersonalAccessToken(token))
+	}
+
+	return &PersonalAccessTokenListResponse{Tokens: tokens}
+}
+
+// Revoke
+type PersonalAccessTokenRevokeRequest struct {
+	TokenID string `param:"token_id"`
+}
+
+func (req PersonalAccessTokenRevokeRequest) To() (*dto.PersonalAccessTokenRevokeRequest, error) {
+	tokenID, err := snowflake.ParseString(req.TokenID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid token id")
+	}
+
+	return &dto.PersonalAccessTokenRevokeRequest{TokenID: tokenID}, nil
+}
+
+type PersonalAccessTokenRevokeResponse struct {
+	*resource.PersonalAccessToken
+}
+
+func NewPersonalAccessTokenRevokeResponse(resp *dto.PersonalAccessTokenRevokeResponse) *PersonalAccessTokenRevokeResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &PersonalAccessTokenRevokeResponse{
+		PersonalAccessToken: resource.NewPersonalAccessToken(resp.PersonalAccessToken),
+	}
+}
+
+// Introspect
+type PersonalAccessTokenIntrospectRequest struct {
+	Token string `json:"token" example:"tdnpat_6f1c2a..."`
+}
+
+func (req PersonalAccessTokenIntrospectRequest) To() *dto.PersonalAccessTokenIntrospectRequest {
+	return &dto.PersonalAccessTokenIntrospectRequest{Token: req.Token}
+}
+
+// PersonalAccessTokenIntrospectResponse only has active if the token cannot be
+// used.
+type PersonalAccessTokenIntrospectResponse struct {
+	Active    bool       `json:"active" example:"true"`
+	TokenID   string     `json:"token_id,omitempty" example:"330559330522759168"`
+	UserID    string     `json:"user_id,omitempty" example:"330559330522759168"`
+	Username  string     `json:"username,omitempty" example:"huykingsofm"`
+	Scope     string     `json:"scope,omitempty" example:"todennus/read:user.profile"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-01-21T13:52:29Z"`
+}
+
+func NewPersonalAccessTokenIntrospectResponse(
+	resp *dto.PersonalAccessTokenIntrospectResponse,
+) *PersonalAccessTokenIntrospectResponse {
+	if resp == nil {
+		return nil
+	}
+
+	if !resp.Active {
+		return &PersonalAccessTokenIntrospectResponse{Active: false}
+	}
+
+	return &PersonalAccessTokenIntrospectResponse{
+		Active:    true,
+		TokenID:   resp.TokenID.String(),
+		UserID:    resp.UserID.String(),
+		Username:  resp.Username,
+		Scope:     resp.Scope,
+		ExpiresAt: &resp.ExpiresAt,
+	}
+}
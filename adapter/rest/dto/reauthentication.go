@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+type ReauthenticateRequest struct {
+	UserID   string `json:"-" param:"user_id"`
+	Password string `json:"password,omitempty" example:"s3Cr3tP@ssW0rD"`
+}
+
+func (req *ReauthenticateRequest) To(meID snowflake.ID) (*dto.ReauthenticateRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.ReauthenticateRequest{UserID: userID, Password: req.Password}, nil
+}
+
+type ReauthenticateResponse struct {
+	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresAt time.Time `json:"expires_at" example:"2025-01-21T13:52:29Z"`
+}
+
+func NewReauthenticateResponse(resp *dto.ReauthenticateResponse) *ReauthenticateResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &ReauthenticateResponse{Token: resp.Token, ExpiresAt: resp.ExpiresAt}
+}
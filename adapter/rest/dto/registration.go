@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// GetPolicy
+type RegistrationGetPolicyRequest struct{}
+
+func (req RegistrationGetPolicyRequest) To() *dto.RegistrationGetPolicyRequest {
+	return &dto.RegistrationGetPolicyRequest{}
+}
+
+type RegistrationGetPolicyResponse struct {
+	Mode                      string `json:"mode" example:"invite"`
+	EmailRequired             bool   `json:"email_required" example:"false"`
+	EmailVerificationRequired bool   `json:"email_verification_required" example:"false"`
+	InvitationRequired        bool   `json:"invitation_required" example:"true"`
+	CaptchaRequired           bool   `json:"captcha_required" example:"true"`
+}
+
+func NewRegistrationGetPolicyResponse(resp *dto.RegistrationGetPolicyResponse) *RegistrationGetPolicyResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &RegistrationGetPolicyResponse{
+		Mode:                      resp.Mode,
+		EmailRequired:             resp.EmailRequired,
+		EmailVerificationRequired: resp.EmailVerificationRequired,
+		InvitationRequired:        resp.InvitationRequired,
+		CaptchaRequired:           resp.CaptchaRequired,
+	}
+}
+
+// Register
+type RegistrationRegisterRequest struct {
+	Username        string `json:"username" example:"huykingsofm"`
+	Password        string `json:"password" example:"s3Cr3tP@ssW0rD"`
+	Email           string `json:"email" example:"huykingsofm@example.com"`
+	InvitationToken string `json:"invitation_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	CaptchaToken    string `json:"captcha_token" example:"10000000-aaaa-bbbb-cccc-000000000001"`
+}
+
+func (req RegistrationRegisterRequest) To(remoteIP string) *dto.RegistrationRegisterRequest {
+	return &dto.RegistrationRegisterRequest{
+		Username:        req.Username,
+		Password:        req.Password,
+		Email:           req.Email,
+		InvitationToken: req.InvitationToken,
+		CaptchaToken:    req.CaptchaToken,
+		RemoteIP:        remoteIP,
+	}
+}
+
+type RegistrationRegisterResponse struct {
+	*resource.User
+	Pending bool `json:"pending" example:"true"`
+}
+
+func NewRegistrationRegisterResponse(resp *dto.RegistrationRegisterResponse) *RegistrationRegisterResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &RegistrationRegisterResponse{
+		User:    resource.NewUser(resp.User),
+		Pending: resp.Pending,
+	}
+}
+
+// Approve
+type RegistrationApproveRequest struct {
+	UserID string `param:"user_id"`
+}
+
+func (req RegistrationApproveRequest) To() (*dto.RegistrationApproveRequest, error) {
+	userID, err := snowflake.ParseString(req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.RegistrationApproveRequest{UserID: userID}, nil
+}
+
+type RegistrationApproveResponse struct {
+	UserID  string `json:"user_id" example:"330559330522759168"`
+	Pending bool   `json:"pending" example:"false"`
+}
+
+func NewRegistrationApproveResponse(resp *dto.RegistrationApproveResponse) *RegistrationApproveResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &RegistrationApproveResponse{
+		UserID:  resp.UserID.String(),
+		Pending: resp.Pending,
+	}
+}
@@ -0,0 +1,44 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type AuditLog struct {
+	ID         string            `json:"id" example:"330559330522759168"`
+	Seq        int64             `json:"seq" example:"42"`
+	ActorID    string            `json:"actor_id,omitempty" example:"330559330522759168"`
+	ActorType  string            `json:"actor_type,omitempty" example:"user"`
+	Action     string            `json:"action" example:"user.register"`
+	TargetType string            `json:"target_type" example:"user"`
+	TargetID   string            `json:"target_id,omitempty" example:"330559330522759168"`
+	ClientID   string            `json:"client_id,omitempty" example:"330559330522759168"`
+	RequestID  string            `json:"request_id,omitempty" example:"6f1c2d3e-4b5a-6978-8a9b-0c1d2e3f4a5b"`
+	Metadata   map[string]string `json:"metadata"`
+	CreatedAt  time.Time         `json:"created_at" example:"2024-10-23T13:52:29.459752Z"`
+	Hash       string            `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+func NewAuditLog(log *resource.AuditLog) *AuditLog {
+	l := &AuditLog{
+		ID:         log.ID.String(),
+		Seq:        log.Seq,
+		ActorType:  log.ActorType,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		ClientID:   log.ClientID,
+		RequestID:  log.RequestID,
+		Metadata:   log.Metadata,
+		CreatedAt:  log.CreatedAt,
+		Hash:       log.Hash,
+	}
+
+	if log.ActorID != 0 {
+		l.ActorID = log.ActorID.String()
+	}
+
+	return l
+}
@@ -0,0 +1,21 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type AvatarHistory struct {
+	ID         string    `json:"id" example:"330559330522759168"`
+	AvatarURL  string    `json:"avatar_url" example:"http://files.todennus.com/123"`
+	ReplacedAt time.Time `json:"replaced_at" example:"2024-10-23T13:52:29.459752901+07:00"`
+}
+
+func NewAvatarHistory(history *resource.AvatarHistory) *AvatarHistory {
+	return &AvatarHistory{
+		ID:         history.ID.String(),
+		AvatarURL:  history.AvatarURL,
+		ReplacedAt: history.ReplacedAt,
+	}
+}
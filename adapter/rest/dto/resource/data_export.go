@@ -0,0 +1,35 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type DataExport struct {
+	ID          string     `json:"id" example:"330559330522759168"`
+	Status      string     `json:"status" example:"completed"`
+	Error       string     `json:"error,omitempty" example:"failed to generate the archive, please request a new export"`
+	CreatedAt   time.Time  `json:"created_at" example:"2024-10-23T13:52:29.459752901+07:00"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" example:"2024-10-23T13:53:29.459752901+07:00"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2024-10-30T13:53:29.459752901+07:00"`
+}
+
+func NewDataExport(export *resource.DataExport) *DataExport {
+	e := &DataExport{
+		ID:        export.ID.String(),
+		Status:    export.Status,
+		Error:     export.Error,
+		CreatedAt: export.CreatedAt,
+	}
+
+	if !export.CompletedAt.IsZero() {
+		e.CompletedAt = &export.CompletedAt
+	}
+
+	if !export.ExpiresAt.IsZero() {
+		e.ExpiresAt = &export.ExpiresAt
+	}
+
+	return e
+}
@@ -0,0 +1,44 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type Invitation struct {
+	ID         string     `json:"id" example:"330559330522759168"`
+	Role       string     `json:"role" example:"user"`
+	Status     string     `json:"status" example:"pending"`
+	CreatedBy  string     `json:"created_by" example:"330559330522759169"`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-10-23T13:52:29Z"`
+	ExpiresAt  time.Time  `json:"expires_at" example:"2024-10-30T13:52:29Z"`
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty" example:"2024-10-24T08:12:03Z"`
+	RedeemedBy string     `json:"redeemed_by,omitempty" example:"330559330522759170"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" example:"2024-10-24T08:12:03Z"`
+}
+
+func NewInvitation(invitation *resource.Invitation) *Invitation {
+	i := &Invitation{
+		ID:        invitation.ID.String(),
+		Role:      invitation.Role,
+		Status:    invitation.Status,
+		CreatedBy: invitation.CreatedBy.String(),
+		CreatedAt: invitation.CreatedAt,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+
+	if !invitation.RedeemedAt.IsZero() {
+		i.RedeemedAt = &invitation.RedeemedAt
+	}
+
+	if invitation.RedeemedBy != 0 {
+		i.RedeemedBy = invitation.RedeemedBy.String()
+	}
+
+	if !invitation.RevokedAt.IsZero() {
+		i.RevokedAt = &invitation.RevokedAt
+	}
+
+	return i
+}
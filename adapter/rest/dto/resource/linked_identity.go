@@ -0,0 +1,25 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type LinkedIdentity struct {
+	ID       string    `json:"id" example:"330559330522759168"`
+	Provider string    `json:"provider" example:"github"`
+	Subject  string    `json:"subject" example:"583231"`
+	Email    string    `json:"email,omitempty" example:"huykingsofm@example.com"`
+	LinkedAt time.Time `json:"linked_at" example:"2024-10-23T13:52:29Z"`
+}
+
+func NewLinkedIdentity(identity *resource.LinkedIdentity) *LinkedIdentity {
+	return &LinkedIdentity{
+		ID:       identity.ID.String(),
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: identity.LinkedAt,
+	}
+}
@@ -0,0 +1,37 @@
+package resource
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type Passkey struct {
+	ID             string     `json:"id" example:"330559330522759168"`
+	Name           string     `json:"name" example:"YubiKey 5"`
+	AAGUID         string     `json:"aaguid" example:"cb69481e8ff7403993ec0a2729a154a8"`
+	Transports     []string   `json:"transports" example:"usb,nfc"`
+	BackupEligible bool       `json:"backup_eligible" example:"false"`
+	BackupState    bool       `json:"backup_state" example:"false"`
+	CreatedAt      time.Time  `json:"created_at" example:"2024-10-23T13:52:29Z"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty" example:"2024-10-24T08:12:03Z"`
+}
+
+func NewPasskey(passkey *resource.Passkey) *Passkey {
+	p := &Passkey{
+		ID:             passkey.ID.String(),
+		Name:           passkey.Name,
+		AAGUID:         hex.EncodeToString(passkey.AAGUID),
+		Transports:     passkey.Transports,
+		BackupEligible: passkey.BackupEligible,
+		BackupState:    passkey.BackupState,
+		CreatedAt:      passkey.CreatedAt,
+	}
+
+	if !passkey.LastUsedAt.IsZero() {
+		p.LastUsedAt = &passkey.LastUsedAt
+	}
+
+	return p
+}
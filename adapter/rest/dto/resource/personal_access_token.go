@@ -0,0 +1,39 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type PersonalAccessToken struct {
+	ID         string     `json:"id" example:"330559330522759168"`
+	Name       string     `json:"name" example:"deploy script"`
+	Scope      string     `json:"scope" example:"todennus/read:user.profile"`
+	Status     string     `json:"status" example:"active"`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-10-23T13:52:29Z"`
+	ExpiresAt  time.Time  `json:"expires_at" example:"2025-01-21T13:52:29Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2024-10-24T08:12:03Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" example:"2024-10-24T08:12:03Z"`
+}
+
+func NewPersonalAccessToken(token *resource.PersonalAccessToken) *PersonalAccessToken {
+	t := &PersonalAccessToken{
+		ID:        token.ID.String(),
+		Name:      token.Name,
+		Scope:     token.Scope,
+		Status:    token.Status,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	}
+
+	if !token.LastUsedAt.IsZero() {
+		t.LastUsedAt = &token.LastUsedAt
+	}
+
+	if !token.RevokedAt.IsZero() {
+		t.RevokedAt = &token.RevokedAt
+	}
+
+	return t
+}
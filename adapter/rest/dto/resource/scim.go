@@ -0,0 +1,45 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type ScimMeta struct {
+	ResourceType string    `json:"resourceType" example:"User"`
+	LastModified time.Time `json:"lastModified" example:"2024-10-23T13:52:29.459752Z"`
+	Location     string    `json:"location" example:"http://localhost:8081/scim/v2/Users/330559330522759168"`
+	Version      string    `json:"version" example:"W/\"m2f1k3a8\""`
+}
+
+type ScimUser struct {
+	Schemas     []string  `json:"schemas"`
+	ID          string    `json:"id" example:"330559330522759168"`
+	UserName    string    `json:"userName" example:"huykingsofm"`
+	DisplayName string    `json:"displayName" example:"Huy Le Ngoc"`
+	Active      bool      `json:"active" example:"true"`
+	Meta        *ScimMeta `json:"meta"`
+}
+
+// NewScimUser needs the url of the users endpoint to build the location.
+func NewScimUser(user *resource.ScimUser, usersURL string) *ScimUser {
+	return &ScimUser{
+		Schemas:     []string{domain.ScimUserSchema},
+		ID:          user.ID.String(),
+		UserName:    user.UserName,
+		DisplayName: user.DisplayName,
+		Active:      user.Active,
+		Meta: &ScimMeta{
+			ResourceType: "User",
+			LastModified: user.LastModified,
+			Location:     usersURL + "/" + user.ID.String(),
+			Version:      ScimETag(user.Version),
+		},
+	}
+}
+
+func ScimETag(version string) string {
+	return `W/"` + version + `"`
+}
@@ -21,3 +21,15 @@ func NewUser(user *resource.User) *User {
 		AvatarURL:   user.AvatarURL,
 	}
 }
+
+type UsernameReason struct {
+	Code    string `json:"code" example:"taken"`
+	Message string `json:"message" example:"username huykingsofm has already existed"`
+}
+
+func NewUsernameReason(reason *resource.UsernameReason) *UsernameReason {
+	return &UsernameReason{
+		Code:    reason.Code,
+		Message: reason.Message,
+	}
+}
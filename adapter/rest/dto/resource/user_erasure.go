@@ -0,0 +1,27 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type UserErasure struct {
+	UserID     string     `json:"user_id" example:"330559330522759168"`
+	EraseAfter *time.Time `json:"erase_after,omitempty" example:"2024-11-22T13:52:29.459752Z"`
+	ErasedAt   *time.Time `json:"erased_at,omitempty" example:"2024-11-22T13:53:01.120310Z"`
+}
+
+func NewUserErasure(erasure *resource.UserErasure) *UserErasure {
+	e := &UserErasure{UserID: erasure.UserID.String()}
+
+	if !erasure.EraseAfter.IsZero() {
+		e.EraseAfter = &erasure.EraseAfter
+	}
+
+	if !erasure.ErasedAt.IsZero() {
+		e.ErasedAt = &erasure.ErasedAt
+	}
+
+	return e
+}
@@ -0,0 +1,73 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type Webhook struct {
+	ID         string    `json:"id" example:"330559330522759168"`
+	URL        string    `json:"url" example:"https://example.com/hooks/todennus"`
+	Secret     string    `json:"secret,omitempty" example:"whsec_5f2b..."`
+	EventTypes []string  `json:"event_types" example:"user.created"`
+	CreatedBy  string    `json:"created_by" example:"330559330522759168"`
+	CreatedAt  time.Time `json:"created_at" example:"2024-10-23T13:52:29.459752901+07:00"`
+}
+
+func NewWebhook(webhook *resource.Webhook) *Webhook {
+	return &Webhook{
+		ID:         webhook.ID.String(),
+		URL:        webhook.URL,
+		Secret:     webhook.Secret,
+		EventTypes: webhook.EventTypes,
+		CreatedBy:  webhook.CreatedBy.String(),
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+type WebhookDeliveryLog struct {
+	ID          string    `json:"id" example:"330559330522759168"`
+	DeliveryID  string    `json:"delivery_id" example:"330559330522759168"`
+	EventID     string    `json:"event_id" example:"330559330522759168"`
+	EventType   string    `json:"event_type" example:"user.created"`
+	Attempt     int       `json:"attempt" example:"1"`
+	StatusCode  int       `json:"status_code,omitempty" example:"200"`
+	Error       string    `json:"error,omitempty" example:"receiver responded 500 Internal Server Error"`
+	DurationMS  int64     `json:"duration_ms" example:"120"`
+	AttemptedAt time.Time `json:"attempted_at" example:"2024-10-23T13:52:29.459752901+07:00"`
+}
+
+func NewWebhookDeliveryLog(log *resource.WebhookDeliveryLog) *WebhookDeliveryLog {
+	return &WebhookDeliveryLog{
+		ID:          log.ID.String(),
+		DeliveryID:  log.DeliveryID.String(),
+		EventID:     log.EventID.String(),
+		EventType:   log.EventType,
+		Attempt:     log.Attempt,
+		StatusCode:  log.StatusCode,
+		Error:       log.Error,
+		DurationMS:  log.Duration.Milliseconds(),
+		AttemptedAt: log.AttemptedAt,
+	}
+}
+
+type WebhookDeadLetter struct {
+	ID        string    `json:"id" example:"330559330522759168"`
+	EventID   string    `json:"event_id" example:"330559330522759168"`
+	EventType string    `json:"event_type" example:"user.created"`
+	Attempts  int       `json:"attempts" example:"8"`
+	LastError string    `json:"last_error" example:"receiver responded 500 Internal Server Error"`
+	DeadAt    time.Time `json:"dead_at" example:"2024-10-23T13:52:29.459752901+07:00"`
+}
+
+func NewWebhookDeadLetter(deadLetter *resource.WebhookDeadLetter) *WebhookDeadLetter {
+	return &WebhookDeadLetter{
+		ID:        deadLetter.ID.String(),
+		EventID:   deadLetter.EventID.String(),
+		EventType: deadLetter.EventType,
+		Attempts:  deadLetter.Attempts,
+		LastError: deadLetter.LastError,
+		DeadAt:    deadLetter.DeadAt,
+	}
+}
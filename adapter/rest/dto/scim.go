@@ -0,0 +1,289 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	ScimListResponseSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimPatchOpSchema       = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimErrorSchema         = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimServiceConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+)
+
+const (
+	DefaultScimCount = 100
+	MaximumScimCount = 200
+)
+
+// parseScimVersion extracts the version from an If-Match header, * matches
+// every version.
+func parseScimVersion(ifMatch string) string {
+	version := strings.TrimPrefix(strings.TrimSpace(ifMatch), "W/")
+	version = strings.Trim(version, `"`)
+	if version == "*" {
+		return ""
+	}
+
+	return version
+}
+
+func parseScimUserID(s string) (snowflake.ID, error) {
+	userID, err := snowflake.ParseString(s)
+	if err != nil {
+		return 0, xerror.Enrich(errordef.ErrNotFound, "not found user with id %s", s)
+	}
+
+	return userID, nil
+}
+
+type ScimCreateUserRequest struct {
+	Schemas     []string `json:"schemas"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName"`
+	Password    string   `json:"password"`
+	Active      *bool    `json:"active"`
+}
+
+func (req ScimCreateUserRequest) To() *dto.ScimCreateUserRequest {
+	return &dto.ScimCreateUserRequest{
+		UserName:    req.UserName,
+		DisplayName: req.DisplayName,
+		Password:    req.Password,
+		Active:      req.Active,
+	}
+}
+
+type ScimGetUserRequest struct {
+	UserID string
+}
+
+func (req ScimGetUserRequest) To() (*dto.ScimGetUserRequest, error) {
+	userID, err := parseScimUserID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ScimGetUserRequest{UserID: userID}, nil
+}
+
+// ScimListUsersRequest keeps the pagination as strings, the missing and the
+// invalid values must be told apart.
+type ScimListUsersRequest struct {
+	Filter     string `query:"filter"`
+	StartIndex string `query:"startIndex"`
+	Count      string `query:"count"`
+}
+
+func (req ScimListUsersRequest) To() (*dto.ScimListUsersRequest, error) {
+	ucreq := &dto.ScimListUsersRequest{
+		Filter:     req.Filter,
+		StartIndex: 1,
+		Count:      DefaultScimCount,
+	}
+
+	// Out of range values are clamped as required by RFC 7644.
+	if req.StartIndex != "" {
+		startIndex, err := strconv.Atoi(req.StartIndex)
+		if err != nil {
+			return nil, xerror.Enrich(dto.ErrScimValueInvalid, "startIndex must be an integer")
+		}
+
+		ucreq.StartIndex = max(startIndex, 1)
+	}
+
+	if req.Count != "" {
+		count, err := strconv.Atoi(req.Count)
+		if err != nil {
+			return nil, xerror.Enrich(dto.ErrScimValueInvalid, "count must be an integer")
+		}
+
+		ucreq.Count = min(max(count, 0), MaximumScimCount)
+	}
+
+	return ucreq, nil
+}
+
+// ScimReplaceUserRequest gets the UserID from the path and the Version from
+// the If-Match header.
+type ScimReplaceUserRequest struct {
+	UserID      string   `json:"-"`
+	Version     string   `json:"-"`
+	Schemas     []string `json:"schemas"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName"`
+	Password    string   `json:"password"`
+	Active      *bool    `json:"active"`
+}
+
+func (req ScimReplaceUserRequest) To() (*dto.ScimReplaceUserRequest, error) {
+	userID, err := parseScimUserID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ScimReplaceUserRequest{
+		UserID:      userID,
+		Version:     parseScimVersion(req.Version),
+		UserName:    req.UserName,
+		DisplayName: req.DisplayName,
+		Password:    req.Password,
+		Active:      req.Active,
+	}, nil
+}
+
+type ScimPatchOperation struct {
+	Op    string          `json:"op" example:"replace"`
+	Path  string          `json:"path,omitempty" example:"active"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// ScimPatchUserRequest gets the UserID from the path and the Version from the
+// If-Match header.
+type ScimPatchUserRequest struct {
+	UserID     string               `json:"-"`
+	Version    string               `json:"-"`
+	Schemas    []string             `json:"schemas"`
+	Operations []ScimPatchOperation `json:"Operations"`
+}
+
+func (req ScimPatchUserRequest) To() (*dto.ScimPatchUserRequest, error) {
+	userID, err := parseScimUserID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	operations := []domain.ScimPatchOperation{}
+	for _, operation := range req.Operations {
+		operations = append(operations, domain.ScimPatchOperation{
+			Op:    operation.Op,
+			Path:  operation.Path,
+			Value: operation.Value,
+		})
+	}
+
+	return &dto.ScimPatchUserRequest{
+		UserID:     userID,
+		Version:    parseScimVersion(req.Version),
+		Operations: operations,
+	}, nil
+}
+
+type ScimListUsersResponse struct {
+	Schemas      []string             `json:"schemas"`
+	TotalResults int64                `json:"totalResults" example:"1"`
+	StartIndex   int                  `json:"startIndex" example:"1"`
+	ItemsPerPage int                  `json:"itemsPerPage" example:"1"`
+	Resources    []*resource.ScimUser `json:"Resources"`
+}
+
+func NewScimListUsersResponse(resp *dto.ScimListUsersResponse, usersURL string) *ScimListUsersResponse {
+	users := []*resource.ScimUser{}
+	for i := range resp.Users {
+		users = append(users, resource.NewScimUser(resp.Users[i], usersURL))
+	}
+
+	return &ScimListUsersResponse{
+		Schemas:      []string{ScimListResponseSchema},
+		TotalResults: resp.TotalResults,
+		StartIndex:   resp.StartIndex,
+		ItemsPerPage: len(users),
+		Resources:    users,
+	}
+}
+
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status" example:"400"`
+	ScimType string   `json:"scimType,omitempty" example:"invalidFilter"`
+	Detail   string   `json:"detail,omitempty" example:"invalid filter: unsupported attribute emails"`
+}
+
+type ScimSupported struct {
+	Supported bool `json:"supported"`
+}
+
+type ScimFilterSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type ScimAuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ScimServiceProviderConfig struct {
+	Schemas               []string                   `json:"schemas"`
+	Patch                 ScimSupported              `json:"patch"`
+	Bulk                  ScimSupported              `json:"bulk"`
+	Filter                ScimFilterSupported        `json:"filter"`
+	ChangePassword        ScimSupported              `json:"changePassword"`
+	Sort                  ScimSupported              `json:"sort"`
+	ETag                  ScimSupported              `json:"etag"`
+	AuthenticationSchemes []ScimAuthenticationScheme `json:"authenticationSchemes"`
+}
+
+// ScimErrorStatus returns the HTTP status and the scimType of an error.
+func ScimErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, dto.ErrScimFilterInvalid):
+		return http.StatusBadRequest, "invalidFilter"
+	case errors.Is(err, dto.ErrScimPathInvalid):
+		return http.StatusBadRequest, "invalidPath"
+	case errors.Is(err, dto.ErrScimMutability):
+		return http.StatusBadRequest, "mutability"
+	case errors.Is(err, dto.ErrScimValueInvalid), errors.Is(err, errordef.ErrRequestInvalid):
+		return http.StatusBadRequest, "invalidValue"
+	case errors.Is(err, dto.ErrScimVersionMismatched):
+		return http.StatusPreconditionFailed, "invalidVers"
+	case errors.Is(err, errordef.ErrDuplicated):
+		return http.StatusConflict, "uniqueness"
+	case errors.Is(err, errordef.ErrNotFound):
+		return http.StatusNotFound, ""
+	case errors.Is(err, errordef.ErrUnauthenticated):
+		return http.StatusUnauthorized, ""
+	case errors.Is(err, errordef.ErrForbidden):
+		return http.StatusForbidden, ""
+	default:
+		return http.StatusInternalServerError, ""
+	}
+}
+
+func NewScimError(status int, scimType, detail string) *ScimError {
+	return &ScimError{
+		Schemas:  []string{ScimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+func NewScimServiceProviderConfig() *ScimServiceProviderConfig {
+	return &ScimServiceProviderConfig{
+		Schemas:        []string{ScimServiceConfigSchema},
+		Patch:          ScimSupported{Supported: true},
+		Bulk:           ScimSupported{Supported: false},
+		Filter:         ScimFilterSupported{Supported: true, MaxResults: MaximumScimCount},
+		ChangePassword: ScimSupported{Supported: true},
+		Sort:           ScimSupported{Supported: false},
+		ETag:           ScimSupported{Supported: true},
+		AuthenticationSchemes: []ScimAuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: "An access token with the admin create:user and read:user.profile scopes",
+		}},
+	}
+}
@@ -1,8 +1,11 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/todennus/shared/errordef"
 	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/usecase/dto"
 	"github.com/todennus/x/xerror"
 	"github.com/xybor-x/snowflake"
@@ -124,6 +127,72 @@ func NewUserGetByUsernameResponse(resp *dto.UserGetByUsernameResponse) *UserGetB
 	}
 }
 
+// Search
+type UserSearchRequest struct {
+	Query string `query:"q"`
+	Limit int    `query:"limit"`
+}
+
+func (req UserSearchRequest) To() *dto.UserSearchRequest {
+	return &dto.UserSearchRequest{
+		Query: req.Query,
+		Limit: req.Limit,
+	}
+}
+
+type UserSearchResponse struct {
+	Users []*resource.User `json:"users"`
+}
+
+func NewUserSearchResponse(resp *dto.UserSearchResponse) *UserSearchResponse {
+	if resp == nil {
+		return nil
+	}
+
+	users := []*resource.User{}
+	for _, user := range resp.Users {
+		users = append(users, resource.NewUser(user))
+	}
+
+	return &UserSearchResponse{Users: users}
+}
+
+// CheckUsername
+type UserCheckUsernameRequest struct {
+	Username string `param:"username"`
+}
+
+func (req UserCheckUsernameRequest) To() *dto.UserCheckUsernameRequest {
+	return &dto.UserCheckUsernameRequest{
+		Username: req.Username,
+	}
+}
+
+type UserCheckUsernameResponse struct {
+	Username    string                     `json:"username" example:"huykingsofm"`
+	Available   bool                       `json:"available" example:"false"`
+	Reasons     []*resource.UsernameReason `json:"reasons"`
+	Suggestions []string                   `json:"suggestions" example:"huykingsofm42,huykingsofm_517"`
+}
+
+func NewUserCheckUsernameResponse(resp *dto.UserCheckUsernameResponse) *UserCheckUsernameResponse {
+	if resp == nil {
+		return nil
+	}
+
+	reasons := []*resource.UsernameReason{}
+	for _, reason := range resp.Reasons {
+		reasons = append(reasons, resource.NewUsernameReason(reason))
+	}
+
+	return &UserCheckUsernameResponse{
+		Username:    resp.Username,
+		Available:   resp.Available,
+		Reasons:     reasons,
+		Suggestions: resp.Suggestions,
+	}
+}
+
 // Validate
 type UserValidateRequest struct {
 	Username string `json:"username" example:"huykingsofm"`
@@ -139,6 +208,8 @@ func (req UserValidateRequest) To() *dto.UserValidateCredentialsRequest {
 
 type UserValidateResponse struct {
 	*resource.User
+	PasswordStatus    string     `json:"password_status" example:"valid"`
+	PasswordExpiresAt *time.Time `json:"password_expires_at,omitempty" example:"2025-01-21T13:52:29Z"`
 }
 
 func NewUserValidateResponse(resp *dto.UserValidateCredentialsResponse) *UserValidateResponse {
@@ -146,9 +217,52 @@ func NewUserValidateResponse(resp *dto.UserValidateCredentialsResponse) *UserVal
 		return nil
 	}
 
-	return &UserValidateResponse{
-		User: resource.NewUser(resp.User),
+	r := &UserValidateResponse{
+		User:           resource.NewUser(resp.User),
+		PasswordStatus: resp.PasswordStatus,
 	}
+
+	if !resp.PasswordExpiresAt.IsZero() {
+		r.PasswordExpiresAt = &resp.PasswordExpiresAt
+	}
+
+	return r
+}
+
+type UserChangePasswordRequest struct {
+	UserID          string `json:"-" param:"user_id"`
+	CurrentPassword string `json:"current_password" example:"s3Cr3tP@ssW0rD"`
+	NewPassword     string `json:"new_password" example:"n3wS3cr3tP@ss"`
+}
+
+func (req *UserChangePasswordRequest) To(meID snowflake.ID) (*dto.UserChangePasswordRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.UserChangePasswordRequest{
+		UserID:          userID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}, nil
+}
+
+type UserChangePasswordResponse struct {
+	PasswordExpiresAt *time.Time `json:"password_expires_at,omitempty" example:"2025-01-21T13:52:29Z"`
+}
+
+func NewUserChangePasswordResponse(resp *dto.UserChangePasswordResponse) *UserChangePasswordResponse {
+	if resp == nil {
+		return nil
+	}
+
+	r := &UserChangePasswordResponse{}
+	if !resp.PasswordExpiresAt.IsZero() {
+		r.PasswordExpiresAt = &resp.PasswordExpiresAt
+	}
+
+	return r
 }
 
 type AvatarGetUploadTokenRequest struct {
@@ -181,8 +295,9 @@ func NewAvatarGetUploadTokenResponse(resp *dto.AvatarGetUploadTokenResponse) *Av
 }
 
 type AvatarUpdateRequest struct {
-	UserID    string `json:"-" param:"user_id"`
-	FileToken string `json:"file_token"`
+	UserID      string `json:"-" param:"user_id"`
+	UploadToken string `json:"upload_token"`
+	FileToken   string `json:"file_token"`
 }
 
 func (req *AvatarUpdateRequest) To(meID snowflake.ID) (*dto.AvatarUpdateRequest, error) {
@@ -192,8 +307,9 @@ func (req *AvatarUpdateRequest) To(meID snowflake.ID) (*dto.AvatarUpdateRequest,
 	}
 
 	return &dto.AvatarUpdateRequest{
-		UserID:    userID,
-		FileToken: req.FileToken,
+		UserID:      userID,
+		UploadToken: req.UploadToken,
+		FileToken:   req.FileToken,
 	}, nil
 }
 
@@ -207,3 +323,126 @@ func NewAvatarUpdateResponse(resp *dto.AvatarUpdateResponse) *AvatarUpdateRespon
 
 	return &AvatarUpdateResponse{}
 }
+
+type AvatarRemoveRequest struct {
+	UserID string `json:"-" param:"user_id"`
+	Reason string `json:"reason" example:"inappropriate content"`
+}
+
+func (req *AvatarRemoveRequest) To(meID snowflake.ID) (*dto.AvatarRemoveRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.AvatarRemoveRequest{
+		UserID: userID,
+		Reason: req.Reason,
+	}, nil
+}
+
+type AvatarRemoveResponse struct {
+}
+
+func NewAvatarRemoveResponse(resp *dto.AvatarRemoveResponse) *AvatarRemoveResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &AvatarRemoveResponse{}
+}
+
+type AvatarGetDefaultRequest struct {
+	UserID string `param:"user_id"`
+	Format string `query:"format"`
+	Size   int    `query:"size"`
+}
+
+func (req *AvatarGetDefaultRequest) To(meID snowflake.ID) (*dto.AvatarGetDefaultRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	format := req.Format
+	if format == "" {
+		format = domain.DefaultAvatarFormatSVG
+	}
+
+	size := req.Size
+	if size == 0 {
+		size = domain.DefaultDefaultAvatarSize
+	}
+
+	return &dto.AvatarGetDefaultRequest{
+		UserID: userID,
+		Format: format,
+		Size:   size,
+	}, nil
+}
+
+type AvatarListHistoryRequest struct {
+	UserID string `param:"user_id"`
+}
+
+func (req *AvatarListHistoryRequest) To(meID snowflake.ID) (*dto.AvatarListHistoryRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.AvatarListHistoryRequest{UserID: userID}, nil
+}
+
+type AvatarListHistoryResponse struct {
+	Avatars []*resource.AvatarHistory `json:"avatars"`
+}
+
+func NewAvatarListHistoryResponse(resp *dto.AvatarListHistoryResponse) *AvatarListHistoryResponse {
+	if resp == nil {
+		return nil
+	}
+
+	avatars := []*resource.AvatarHistory{}
+	for i := range resp.Histories {
+		avatars = append(avatars, resource.NewAvatarHistory(resp.Histories[i]))
+	}
+
+	return &AvatarListHistoryResponse{Avatars: avatars}
+}
+
+type AvatarRestoreRequest struct {
+	UserID    string `json:"-" param:"user_id"`
+	HistoryID string `json:"history_id" example:"330559330522759168"`
+}
+
+func (req *AvatarRestoreRequest) To(meID snowflake.ID) (*dto.AvatarRestoreRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	var historyID snowflake.ID
+	if req.HistoryID != "" {
+		historyID, err = snowflake.ParseString(req.HistoryID)
+		if err != nil {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid history id")
+		}
+	}
+
+	return &dto.AvatarRestoreRequest{
+		UserID:    userID,
+		HistoryID: historyID,
+	}, nil
+}
+
+type AvatarRestoreResponse struct {
+}
+
+func NewAvatarRestoreResponse(resp *dto.AvatarRestoreResponse) *AvatarRestoreResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &AvatarRestoreResponse{}
+}
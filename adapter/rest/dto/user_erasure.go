@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+type UserErasureScheduleRequest struct {
+	UserID                string `json:"-" param:"user_id"`
+	ReauthenticationToken string `json:"reauthentication_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+func (req *UserErasureScheduleRequest) To(meID snowflake.ID) (*dto.UserErasureScheduleRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.UserErasureScheduleRequest{
+		UserID:                userID,
+		ReauthenticationToken: req.ReauthenticationToken,
+	}, nil
+}
+
+type UserErasureResponse struct {
+	*resource.UserErasure
+}
+
+func NewUserErasureScheduleResponse(resp *dto.UserErasureScheduleResponse) *UserErasureResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &UserErasureResponse{UserErasure: resource.NewUserErasure(resp.Erasure)}
+}
+
+type UserErasureCancelRequest struct {
+	UserID string `param:"user_id"`
+}
+
+func (req *UserErasureCancelRequest) To(meID snowflake.ID) (*dto.UserErasureCancelRequest, error) {
+	userID, err := ParseUserID(meID, req.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid user id")
+	}
+
+	return &dto.UserErasureCancelRequest{UserID: userID}, nil
+}
+
+func NewUserErasureCancelResponse(resp *dto.UserErasureCancelResponse) *UserErasureResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &UserErasureResponse{UserErasure: resource.NewUserErasure(resp.Erasure)}
+}
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"io"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type UserExportRequest struct {
+	Format         string `query:"format"`
+	IncludeHashes  bool   `query:"include_hashes"`
+	IncludeAvatars bool   `query:"include_avatars"`
+}
+
+func (req UserExportRequest) To(w io.Writer) *dto.UserExportRequest {
+	format := req.Format
+	if format == "" {
+		format = domain.UserImportFormatNDJSON
+	}
+
+	return &dto.UserExportRequest{
+		Format:         format,
+		Writer:         w,
+		IncludeHashes:  req.IncludeHashes,
+		IncludeAvatars: req.IncludeAvatars,
+		BatchSize:      dto.DefaultUserExportBatchSize,
+	}
+}
+
+// UserExportContentType returns the content type of an export format.
+func UserExportContentType(format string) string {
+	if format == domain.UserImportFormatCSV {
+		return "text/csv"
+	}
+
+	return "application/x-ndjson"
+}
@@ -0,0 +1,109 @@
+package dto
+
+import (
+	"io"
+	"mime"
+	"strconv"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+)
+
+// MaximumUserImportSize limits the body of an import request, larger files
+// should be imported by the CLI.
+const MaximumUserImportSize = 10 * 1024 * 1024
+
+// UserImportRequest is read from the query, the body is the file. The format
+// defaults to the one of the content type.
+type UserImportRequest struct {
+	ContentType string
+	Format      string
+	DryRun      string
+	BatchSize   string
+}
+
+func (req UserImportRequest) To(body io.Reader) (*dto.UserImportRequest, error) {
+	ucreq := &dto.UserImportRequest{
+		Format:    req.Format,
+		Reader:    body,
+		BatchSize: dto.DefaultUserImportBatchSize,
+	}
+
+	if ucreq.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(req.ContentType)
+		switch mediaType {
+		case "text/csv":
+			ucreq.Format = domain.UserImportFormatCSV
+		case "application/x-ndjson":
+			ucreq.Format = domain.UserImportFormatNDJSON
+		default:
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require content type text/csv or application/x-ndjson")
+		}
+	}
+
+	var err error
+	if req.DryRun != "" {
+		ucreq.DryRun, err = strconv.ParseBool(req.DryRun)
+		if err != nil {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "dry_run must be a boolean")
+		}
+	}
+
+	if req.BatchSize != "" {
+		ucreq.BatchSize, err = strconv.Atoi(req.BatchSize)
+		if err != nil || ucreq.BatchSize < 1 || ucreq.BatchSize > dto.MaximumUserImportBatchSize {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid,
+				"batch_size must be in range [1, %d]", dto.MaximumUserImportBatchSize)
+		}
+	}
+
+	return ucreq, nil
+}
+
+type UserImportResult struct {
+	Line     int    `json:"line" example:"2"`
+	Username string `json:"username" example:"huykingsofm"`
+	Status   string `json:"status" example:"created"`
+	UserID   string `json:"user_id,omitempty" example:"330559330522759168"`
+	Error    string `json:"error,omitempty" example:"invalid role: unknown role owner"`
+}
+
+type UserImportResponse struct {
+	DryRun  bool                `json:"dry_run" example:"false"`
+	Created int                 `json:"created" example:"1"`
+	Valid   int                 `json:"valid" example:"0"`
+	Failed  int                 `json:"failed" example:"0"`
+	Results []*UserImportResult `json:"results"`
+}
+
+func NewUserImportResponse(resp *dto.UserImportResponse) *UserImportResponse {
+	if resp == nil {
+		return nil
+	}
+
+	results := []*UserImportResult{}
+	for _, result := range resp.Results {
+		userID := ""
+		if result.UserID != 0 {
+			userID = result.UserID.String()
+		}
+
+		results = append(results, &UserImportResult{
+			Line:     result.Line,
+			Username: result.Username,
+			Status:   result.Status,
+			UserID:   userID,
+			Error:    result.Error,
+		})
+	}
+
+	return &UserImportResponse{
+		DryRun:  resp.DryRun,
+		Created: resp.Created,
+		Valid:   resp.Valid,
+		Failed:  resp.Failed,
+		Results: results,
+	}
+}
@@ -0,0 +1,165 @@
+package dto
+
+import (
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	DefaultWebhookDeliveryLogLimit = 50
+	MaximumWebhookDeliveryLogLimit = 200
+)
+
+func parseWebhookID(s string) (snowflake.ID, error) {
+	webhookID, err := snowflake.ParseString(s)
+	if err != nil {
+		return 0, xerror.Enrich(errordef.ErrRequestInvalid, "invalid webhook id")
+	}
+
+	return webhookID, nil
+}
+
+type WebhookCreateRequest struct {
+	URL        string   `json:"url" example:"https://example.com/hooks/todennus"`
+	EventTypes []string `json:"event_types" example:"user.created,user.avatar_changed"`
+}
+
+func (req WebhookCreateRequest) To() *dto.WebhookCreateRequest {
+	return &dto.WebhookCreateRequest{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+	}
+}
+
+type WebhookCreateResponse struct {
+	*resource.Webhook
+}
+
+func NewWebhookCreateResponse(resp *dto.WebhookCreateResponse) *WebhookCreateResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &WebhookCreateResponse{Webhook: resource.NewWebhook(resp.Webhook)}
+}
+
+type WebhookListRequest struct {
+}
+
+func (req WebhookListRequest) To() *dto.WebhookListRequest {
+	return &dto.WebhookListRequest{}
+}
+
+type WebhookListResponse struct {
+	Webhooks []*resource.Webhook `json:"webhooks"`
+}
+
+func NewWebhookListResponse(resp *dto.WebhookListResponse) *WebhookListResponse {
+	if resp == nil {
+		return nil
+	}
+
+	webhooks := []*resource.Webhook{}
+	for i := range resp.Webhooks {
+		webhooks = append(webhooks, resource.NewWebhook(resp.Webhooks[i]))
+	}
+
+	return &WebhookListResponse{Webhooks: webhooks}
+}
+
+type WebhookDeleteRequest struct {
+	WebhookID string `json:"-" param:"webhook_id"`
+}
+
+func (req WebhookDeleteRequest) To() (*dto.WebhookDeleteRequest, error) {
+	webhookID, err := parseWebhookID(req.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.WebhookDeleteRequest{WebhookID: webhookID}, nil
+}
+
+type WebhookDeleteResponse struct {
+}
+
+func NewWebhookDeleteResponse(resp *dto.WebhookDeleteResponse) *WebhookDeleteResponse {
+	if resp == nil {
+		return nil
+	}
+
+	return &WebhookDeleteResponse{}
+}
+
+type WebhookListDeliveryLogsRequest struct {
+	WebhookID string `param:"webhook_id"`
+	Limit     int    `query:"limit"`
+}
+
+func (req WebhookListDeliveryLogsRequest) To() (*dto.WebhookListDeliveryLogsRequest, error) {
+	webhookID, err := parseWebhookID(req.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultWebhookDeliveryLogLimit
+	}
+
+	if limit < 0 || limit > MaximumWebhookDeliveryLogLimit {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "limit must be in range [1, %d]", MaximumWebhookDeliveryLogLimit)
+	}
+
+	return &dto.WebhookListDeliveryLogsRequest{WebhookID: webhookID, Limit: limit}, nil
+}
+
+type WebhookListDeliveryLogsResponse struct {
+	Logs []*resource.WebhookDeliveryLog `json:"logs"`
+}
+
+func NewWebhookListDeliveryLogsResponse(resp *dto.WebhookListDeliveryLogsResponse) *WebhookListDeliveryLogsResponse {
+	if resp == nil {
+		return nil
+	}
+
+	logs := []*resource.WebhookDeliveryLog{}
+	for i := range resp.Logs {
+		logs = append(logs, resource.NewWebhookDeliveryLog(resp.Logs[i]))
+	}
+
+	return &WebhookListDeliveryLogsResponse{Logs: logs}
+}
+
+type WebhookListDeadLettersRequest struct {
+	WebhookID string `param:"webhook_id"`
+}
+
+func (req WebhookListDeadLettersRequest) To() (*dto.WebhookListDeadLettersRequest, error) {
+	webhookID, err := parseWebhookID(req.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.WebhookListDeadLettersRequest{WebhookID: webhookID}, nil
+}
+
+type WebhookListDeadLettersResponse struct {
+	DeadLetters []*resource.WebhookDeadLetter `json:"dead_letters"`
+}
+
+func NewWebhookListDeadLettersResponse(resp *dto.WebhookListDeadLettersResponse) *WebhookListDeadLettersResponse {
+	if resp == nil {
+		return nil
+	}
+
+	deadLetters := []*resource.WebhookDeadLetter{}
+	for i := range resp.DeadLetters {
+		deadLetters = append(deadLetters, resource.NewWebhookDeadLetter(resp.DeadLetters[i]))
+	}
+
+	return &WebhookListDeadLettersResponse{DeadLetters: deadLetters}
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	ucdto "github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// EmailVerificationAdapter lets the users verify their email with a link sent
+// to it. The requests are not authenticated, so the pending users can verify
+// their email.
+type EmailVerificationAdapter struct {
+	emailVerificationUsecase abstraction.EmailVerificationUsecase
+}
+
+func NewEmailVerificationAdapter(emailVerificationUsecase abstraction.EmailVerificationUsecase) *EmailVerificationAdapter {
+	return &EmailVerificationAdapter{emailVerificationUsecase: emailVerificationUsecase}
+}
+
+func (a *EmailVerificationAdapter) Router(r chi.Router) {
+	r.Post("/", a.Send())
+	r.Post("/verify", a.Verify())
+}
+
+// @Summary Send an email verification link
+// @Description Send a verification link to the email if it is the unverified email of a user. The response is the same whether a link is sent or not. <br>
+// @Description Every email receives a number of links per window, exceeding it returns `429`.
+// @Tags EmailVerification
+// @Accept json
+// @Produce json
+// @Param body body dto.EmailVerificationSendRequest true "Email"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.EmailVerificationSendResponse] "Send successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Email verification is disabled"
+// @Router /email_verifications [post]
+func (a *EmailVerificationAdapter) Send() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.EmailVerificationSendRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.emailVerificationUsecase.Send(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewEmailVerificationSendResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			Map(http.StatusTooManyRequests, ucdto.ErrTooManyRequests).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Verify an email
+// @Description Verify the email of the token sent by email. A token is rejected once the email of its user has changed.
+// @Tags EmailVerification
+// @Accept json
+// @Produce json
+// @Param body body dto.EmailVerificationVerifyRequest true "Email verification token"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.EmailVerificationVerifyResponse] "Verify successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Router /email_verifications/verify [post]
+func (a *EmailVerificationAdapter) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.EmailVerificationVerifyRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.emailVerificationUsecase.Verify(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewEmailVerificationVerifyResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			WriteHTTPResponse(ctx, w)
+	}
+}
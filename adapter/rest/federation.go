@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// FederationAdapter serves the auth server when a user signs in with an
+// external identity provider.
+type FederationAdapter struct {
+	linkedIdentityUsecase abstraction.LinkedIdentityUsecase
+}
+
+func NewFederationAdapter(linkedIdentityUsecase abstraction.LinkedIdentityUsecase) *FederationAdapter {
+	return &FederationAdapter{linkedIdentityUsecase: linkedIdentityUsecase}
+}
+
+func (a *FederationAdapter) Router(r chi.Router) {
+	r.Post("/lookup", middleware.RequireAuthentication(a.Lookup()))
+	r.Post("/provision", middleware.RequireAuthentication(a.Provision()))
+}
+
+// @Summary Get the user of an external identity
+// @Description Get the user to whom the external identity is linked, the user must be able to sign in. <br>
+// @Description Require `todennus/admin:validate:user` scope.
+// @Tags Federation
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.FederationLookupRequest true "External identity"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.FederationLookupResponse] "Get successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /federation/lookup [post]
+func (a *FederationAdapter) Lookup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.FederationLookupRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.linkedIdentityUsecase.GetUser(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewFederationLookupResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid, errordef.ErrCredentialsInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Provision the user of an external identity
+// @Description Return the user to whom the external identity is linked, or create a user without password and link the identity to them. <br>
+// @Description The username is derived from the preferred username or the email, the email is only kept if it is verified and not used by another user. <br>
+// @Description Require `todennus/admin:create:user` scope.
+// @Tags Federation
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.FederationProvisionRequest true "External identity claims"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.FederationProvisionResponse] "Already provisioned"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.FederationProvisionResponse] "Provisioned successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 409 {object} response.SwaggerDuplicatedErrorResponse "Duplicated"
+// @Router /federation/provision [post]
+func (a *FederationAdapter) Provision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.FederationProvisionRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		code := http.StatusOK
+		resp, err := a.linkedIdentityUsecase.Provision(ctx, req.To())
+		if resp != nil && resp.Created {
+			code = http.StatusCreated
+		}
+
+		response.NewRESTResponseHandler(ctx, dto.NewFederationProvisionResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid, errordef.ErrCredentialsInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusConflict, errordef.ErrDuplicated).
+			WithDefaultCode(code).
+			WriteHTTPResponse(ctx, w)
+	}
+}
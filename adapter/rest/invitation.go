@@ -0,0 +1,172 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type InvitationAdapter struct {
+	invitationUsecase abstraction.InvitationUsecase
+}
+
+func NewInvitationAdapter(invitationUsecase abstraction.InvitationUsecase) *InvitationAdapter {
+	return &InvitationAdapter{invitationUsecase: invitationUsecase}
+}
+
+func (a *InvitationAdapter) Router(r chi.Router) {
+	r.Post("/", middleware.RequireAuthentication(a.Create()))
+	r.Get("/", middleware.RequireAuthentication(a.List()))
+	r.Post("/redeem", a.Redeem())
+	r.Post("/{invitation_id}/revoke", middleware.RequireAuthentication(a.Revoke()))
+}
+
+// @Summary Create an invitation
+// @Description Create an invitation for a user of the given role, the returned `token` is sent to the invitee and cannot be read again. <br>
+// @Description The invitation expires after `expires_in` seconds, or after the default expiration if it is omitted. <br>
+// @Description Require `todennus/admin:create:user` scope and admin role.
+// @Tags Invitation
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Accept json
+// @Produce json
+// @Param invitation body dto.InvitationCreateRequest true "Invitation data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.InvitationCreateResponse] "Created successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /invitations [post]
+func (a *InvitationAdapter) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.InvitationCreateRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.invitationUsecase.Create(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewInvitationCreateResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary List invitations
+// @Description List the invitations from the newest to the oldest. <br>
+// @Description Pass the `id` of the last returned invitation as `before` to get the next page. <br>
+// @Description Require `todennus/admin:create:user` scope and admin role.
+// @Tags Invitation
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Produce json
+// @Param before query string false "only return the invitations whose id is less than this value"
+// @Param limit query int false "maximum number of invitations, up to 200 (default 50)"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.InvitationListResponse] "List successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /invitations [get]
+func (a *InvitationAdapter) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.InvitationListRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.invitationUsecase.List(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewInvitationListResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Revoke an invitation
+// @Description Prevent a pending invitation from being redeemed. <br>
+// @Description Require `todennus/admin:create:user` scope and admin role.
+// @Tags Invitation
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Produce json
+// @Param invitation_id path string true "Invitation id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.InvitationRevokeResponse] "Revoked successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /invitations/{invitation_id}/revoke [post]
+func (a *InvitationAdapter) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.InvitationRevokeRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.invitationUsecase.Revoke(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewInvitationRevokeResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Redeem an invitation
+// @Description Create my user from an invitation token, the user gets the role of the invitation. <br>
+// @Description An invitation can only be redeemed once, before it expires.
+// @Tags Invitation
+// @Accept json
+// @Produce json
+// @Param redemption body dto.InvitationRedeemRequest true "Redemption data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.InvitationRedeemResponse] "Redeemed successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Failure 409 {object} response.SwaggerDuplicatedErrorResponse "Duplicated"
+// @Router /invitations/redeem [post]
+func (a *InvitationAdapter) Redeem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.InvitationRedeemRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.invitationUsecase.Redeem(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewInvitationRedeemResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			Map(http.StatusConflict, errordef.ErrDuplicated).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
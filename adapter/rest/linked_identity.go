@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type LinkedIdentityAdapter struct {
+	linkedIdentityUsecase abstraction.LinkedIdentityUsecase
+}
+
+func NewLinkedIdentityAdapter(linkedIdentityUsecase abstraction.LinkedIdentityUsecase) *LinkedIdentityAdapter {
+	return &LinkedIdentityAdapter{linkedIdentityUsecase: linkedIdentityUsecase}
+}
+
+func (a *LinkedIdentityAdapter) Router(r chi.Router) {
+	r.Get("/", middleware.RequireAuthentication(a.List()))
+	r.Post("/", middleware.RequireAuthentication(a.Link()))
+	r.Delete("/{identity_id}", middleware.RequireAuthentication(a.Unlink()))
+}
+
+// @Summary List the linked identities
+// @Description List the external identities linked to the user. <br>
+// @Description Listing the identities of another user requires `todennus/admin:create:user` scope and the admin role.
+// @Tags User
+// @Produce json
+// @Param user_id path string true "User id or @me"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.LinkedIdentityListResponse] "List successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/identities [get]
+func (a *LinkedIdentityAdapter) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.LinkedIdentityListRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.linkedIdentityUsecase.List(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewLinkedIdentityListResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Link an external identity
+// @Description Link the identity of an external provider to the user, the auth server calls it after the user signs in with the provider. <br>
+// @Description Require `todennus/admin:create:user` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User id"
+// @Param identity body dto.LinkedIdentityLinkRequest true "Identity data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.LinkedIdentityLinkResponse] "Linked successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Failure 409 {object} response.SwaggerDuplicatedErrorResponse "Duplicated"
+// @Router /users/{user_id}/identities [post]
+func (a *LinkedIdentityAdapter) Link() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.LinkedIdentityLinkRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.linkedIdentityUsecase.Link(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewLinkedIdentityLinkResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			Map(http.StatusConflict, errordef.ErrDuplicated).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Unlink an external identity
+// @Description Unlink an external identity from the user. The last identity of a user without password nor passkey cannot be unlinked, they could not sign in anymore. <br>
+// @Description Require `todennus/update:user.identity` scope. <br>
+// @Description Unlinking the identities of another user requires `todennus/admin:create:user` scope and the admin role.
+// @Tags User
+// @Produce json
+// @Param user_id path string true "User id or @me"
+// @Param identity_id path string true "Identity id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.LinkedIdentityUnlinkResponse] "Unlinked successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/identities/{identity_id} [delete]
+func (a *LinkedIdentityAdapter) Unlink() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.LinkedIdentityUnlinkRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.linkedIdentityUsecase.Unlink(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewLinkedIdentityUnlinkResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	ucdto "github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// MagicLinkAdapter serves the auth server when a user signs in with a link
+// sent to their email.
+type MagicLinkAdapter struct {
+	magicLinkUsecase abstraction.MagicLinkUsecase
+}
+
+func NewMagicLinkAdapter(magicLinkUsecase abstraction.MagicLinkUsecase) *MagicLinkAdapter {
+	return &MagicLinkAdapter{magicLinkUsecase: magicLinkUsecase}
+}
+
+func (a *MagicLinkAdapter) Router(r chi.Router) {
+	r.Post("/", middleware.RequireAuthentication(a.Send()))
+	r.Post("/redeem", middleware.RequireAuthentication(a.Redeem()))
+}
+
+// @Summary Send a magic link
+// @Description Send a single-use sign-in link to the email if it is the verified email of a user who can sign in. The response does not tell whether a link was sent. <br>
+// @Description The links sent to an email are rate limited. <br>
+// @Description Require `todennus/admin:validate:user` scope.
+// @Tags MagicLink
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.MagicLinkSendRequest true "Email"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.MagicLinkSendResponse] "Handled successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Magic links are disabled"
+// @Router /magic_links [post]
+func (a *MagicLinkAdapter) Send() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.MagicLinkSendRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.magicLinkUsecase.Send(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewMagicLinkSendResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			Map(http.StatusTooManyRequests, ucdto.ErrTooManyRequests).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Redeem a magic link
+// @Description Validate the token of a magic link and return the user information, like the credentials validation. A token is redeemed at most once. <br>
+// @Description Require `todennus/admin:validate:user` scope.
+// @Tags MagicLink
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.MagicLinkRedeemRequest true "Magic link token"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.MagicLinkRedeemResponse] "Redeem successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /magic_links/redeem [post]
+func (a *MagicLinkAdapter) Redeem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.MagicLinkRedeemRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.magicLinkUsecase.Redeem(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewMagicLinkRedeemResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid, errordef.ErrCredentialsInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type PasskeyAdapter struct {
+	passkeyUsecase abstraction.PasskeyUsecase
+}
+
+func NewPasskeyAdapter(passkeyUsecase abstraction.PasskeyUsecase) *PasskeyAdapter {
+	return &PasskeyAdapter{passkeyUsecase: passkeyUsecase}
+}
+
+func (a *PasskeyAdapter) Router(r chi.Router) {
+	r.Post("/registration", middleware.RequireAuthentication(a.BeginRegistration()))
+	r.Post("/", middleware.RequireAuthentication(a.FinishRegistration()))
+	r.Get("/", middleware.RequireAuthentication(a.List()))
+	r.Patch("/{passkey_id}", middleware.RequireAuthentication(a.Rename()))
+	r.Delete("/{passkey_id}", middleware.RequireAuthentication(a.Delete()))
+}
+
+// @Summary Begin the registration of a passkey
+// @Description Return the `options` given to `navigator.credentials.create`, the passkeys already registered are excluded. <br>
+// @Description The registration must be finished with the returned `session_id` before the session expires. <br>
+// @Description Require `todennus/update:user.passkey` scope.
+// @Tags User
+// @Produce json
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasskeyBeginRegistrationResponse] "Begin successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/@me/passkeys/registration [post]
+func (a *PasskeyAdapter) BeginRegistration() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyBeginRegistrationRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.BeginRegistration(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyBeginRegistrationResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Register a passkey
+// @Description Verify the `credential` returned by `navigator.credentials.create` and save it as a passkey of the current user. <br>
+// @Description The passkey is named `Passkey` if the `name` is omitted. <br>
+// @Description Require `todennus/update:user.passkey` scope and a `reauthentication_token` of the current user.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param body body dto.PasskeyFinishRegistrationRequest true "Registration data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.PasskeyFinishRegistrationResponse] "Registered successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 409 {object} response.SwaggerDuplicatedErrorResponse "Duplicated"
+// @Router /users/@me/passkeys [post]
+func (a *PasskeyAdapter) FinishRegistration() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyFinishRegistrationRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.FinishRegistration(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyFinishRegistrationResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			Map(http.StatusConflict, errordef.ErrDuplicated).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary List my passkeys
+// @Description List the passkeys of the current user, from the oldest to the newest.
+// @Tags User
+// @Produce json
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasskeyListResponse] "List successfully"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/@me/passkeys [get]
+func (a *PasskeyAdapter) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyListRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.List(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyListResponse(resp), err).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Rename a passkey
+// @Description Rename a passkey of the current user. <br>
+// @Description Require `todennus/update:user.passkey` scope.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param passkey_id path string true "Passkey id"
+// @Param body body dto.PasskeyRenameRequest true "Passkey name"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasskeyRenameResponse] "Renamed successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/@me/passkeys/{passkey_id} [patch]
+func (a *PasskeyAdapter) Rename() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyRenameRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.Rename(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyRenameResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Delete a passkey
+// @Description Delete a passkey of the current user, it cannot be used to sign in anymore. <br>
+// @Description Require `todennus/update:user.passkey` scope.
+// @Tags User
+// @Produce json
+// @Param passkey_id path string true "Passkey id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasskeyDeleteResponse] "Deleted successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/@me/passkeys/{passkey_id} [delete]
+func (a *PasskeyAdapter) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyDeleteRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.Delete(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyDeleteResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// PasskeyAssertionAdapter serves the auth server when a user signs in with a
+// passkey.
+type PasskeyAssertionAdapter struct {
+	passkeyUsecase abstraction.PasskeyUsecase
+}
+
+func NewPasskeyAssertionAdapter(passkeyUsecase abstraction.PasskeyUsecase) *PasskeyAssertionAdapter {
+	return &PasskeyAssertionAdapter{passkeyUsecase: passkeyUsecase}
+}
+
+func (a *PasskeyAssertionAdapter) Router(r chi.Router) {
+	r.Post("/assertion", middleware.RequireAuthentication(a.Begin()))
+	r.Post("/assertion/verify", middleware.RequireAuthentication(a.Verify()))
+}
+
+// @Summary Begin a sign-in with a passkey
+// @Description Return the `options` given to `navigator.credentials.get`. The authenticator chooses a discoverable passkey if the `username` is omitted. <br>
+// @Description Require `todennus/admin:validate:user` scope.
+// @Tags Passkey
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.PasskeyBeginAssertionRequest true "Assertion data"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasskeyBeginAssertionResponse] "Begin successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /passkeys/assertion [post]
+func (a *PasskeyAssertionAdapter) Begin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyBeginAssertionRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.BeginAssertion(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyBeginAssertionResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Verify a passkey assertion
+// @Description Verify the `credential` returned by `navigator.credentials.get` and return the user of the passkey. <br>
+// @Description The response has a `reauthentication_token` of the user, the assertion proves that they have just signed in. <br>
+// @Description Require `todennus/admin:validate:user` scope.
+// @Tags Passkey
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.PasskeyVerifyAssertionRequest true "Assertion data"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasskeyVerifyAssertionResponse] "Verify successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /passkeys/assertion/verify [post]
+func (a *PasskeyAssertionAdapter) Verify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasskeyVerifyAssertionRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passkeyUsecase.VerifyAssertion(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPasskeyVerifyAssertionResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid, errordef.ErrCredentialsInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
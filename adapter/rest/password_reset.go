@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// PasswordResetAdapter lets the users set a new password without the current
+// one, with a token issued by an admin.
+type PasswordResetAdapter struct {
+	passwordResetUsecase abstraction.PasswordResetUsecase
+}
+
+func NewPasswordResetAdapter(passwordResetUsecase abstraction.PasswordResetUsecase) *PasswordResetAdapter {
+	return &PasswordResetAdapter{passwordResetUsecase: passwordResetUsecase}
+}
+
+func (a *PasswordResetAdapter) Router(r chi.Router) {
+	r.Post("/", middleware.RequireAuthentication(a.Issue()))
+	r.Post("/redeem", a.Reset())
+}
+
+// @Summary Issue a password reset token
+// @Description Issue a single-use token letting the user set a new password without the current one, for example after an import requiring a password reset. The admin hands the token to the user. <br>
+// @Description Require `todennus/admin:create:user` scope and the admin role.
+// @Tags PasswordReset
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.PasswordResetIssueRequest true "User"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasswordResetIssueResponse] "Issue successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /password_resets [post]
+func (a *PasswordResetAdapter) Issue() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasswordResetIssueRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passwordResetUsecase.Issue(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPasswordResetIssueResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Reset a password
+// @Description Set a new password with a password reset token, the current password is not required. A token is used at most once. The new password cannot be one of the last passwords of the user.
+// @Tags PasswordReset
+// @Accept json
+// @Produce json
+// @Param body body dto.PasswordResetRequest true "Password reset token and new password"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PasswordResetResponse] "Reset successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Router /password_resets/redeem [post]
+func (a *PasswordResetAdapter) Reset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PasswordResetRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.passwordResetUsecase.Reset(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewPasswordResetResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid, errordef.ErrCredentialsInvalid).
+			WriteHTTPResponse(ctx, w)
+	}
+}
@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type PersonalAccessTokenAdapter struct {
+	personalAccessTokenUsecase abstraction.PersonalAccessTokenUsecase
+}
+
+func NewPersonalAccessTokenAdapter(personalAccessTokenUsecase abstraction.PersonalAccessTokenUsecase) *PersonalAccessTokenAdapter {
+	return &PersonalAccessTokenAdapter{personalAccessTokenUsecase: personalAccessTokenUsecase}
+}
+
+func (a *PersonalAccessTokenAdapter) Router(r chi.Router) {
+	r.Post("/", middleware.RequireAuthentication(a.Create()))
+	r.Get("/", middleware.RequireAuthentication(a.List()))
+	r.Delete("/{token_id}", middleware.RequireAuthentication(a.Revoke()))
+}
+
+// @Summary Create a personal access token
+// @Description Create a token which scripts use instead of my password, the returned `token` cannot be read again. <br>
+// @Description The `scope` is space separated and must be granted to the current access token. The token expires after `expires_in` seconds, or after the default expiration if it is omitted. <br>
+// @Description Require `todennus/update:user.token` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/update:user.token]
+// @Accept json
+// @Produce json
+// @Param token body dto.PersonalAccessTokenCreateRequest true "Token data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.PersonalAccessTokenCreateResponse] "Created successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/@me/tokens [post]
+func (a *PersonalAccessTokenAdapter) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PersonalAccessTokenCreateRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.personalAccessTokenUsecase.Create(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPersonalAccessTokenCreateResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary List my personal access tokens
+// @Description List the personal access tokens of the current user, including the expired and revoked ones, from the newest to the oldest. <br>
+// @Description Require `todennus/update:user.token` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/update:user.token]
+// @Produce json
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PersonalAccessTokenListResponse] "List successfully"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/@me/tokens [get]
+func (a *PersonalAccessTokenAdapter) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PersonalAccessTokenListRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.personalAccessTokenUsecase.List(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewPersonalAccessTokenListResponse(resp), err).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Revoke a personal access token
+// @Description Revoke a personal access token of the current user, it cannot be used anymore. <br>
+// @Description Require `todennus/update:user.token` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/update:user.token]
+// @Produce json
+// @Param token_id path string true "Token id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PersonalAccessTokenRevokeResponse] "Revoked successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/@me/tokens/{token_id} [delete]
+func (a *PersonalAccessTokenAdapter) Revoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PersonalAccessTokenRevokeRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.personalAccessTokenUsecase.Revoke(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewPersonalAccessTokenRevokeResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// ReauthenticationAdapter issues the tokens required by the sensitive changes
+// of a user, such as the erasure.
+type ReauthenticationAdapter struct {
+	reauthenticationUsecase abstraction.ReauthenticationUsecase
+}
+
+func NewReauthenticationAdapter(reauthenticationUsecase abstraction.ReauthenticationUsecase) *ReauthenticationAdapter {
+	return &ReauthenticationAdapter{reauthenticationUsecase: reauthenticationUsecase}
+}
+
+func (a *ReauthenticationAdapter) Router(r chi.Router) {
+	r.Post("/", middleware.RequireAuthentication(a.Reauthenticate()))
+}
+
+// @Summary Re-authenticate a user
+// @Description Return a short-lived token proving that the user has just confirmed their identity. <br>
+// @Description Users confirm their password, or the password of their directory entry for the LDAP users. <br>
+// @Description The auth server issues the token without a password, with `todennus/admin:validate:user` scope, once it verified the user again with a linked identity or a magic link. The passkey assertions return their own token.
+// @Tags User
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Param body body dto.ReauthenticateRequest true "Password"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.ReauthenticateResponse] "Re-authenticate successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/reauthentication [post]
+func (a *ReauthenticationAdapter) Reauthenticate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.ReauthenticateRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.reauthenticationUsecase.Reauthenticate(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewReauthenticateResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
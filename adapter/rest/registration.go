@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	ucdto "github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type RegistrationAdapter struct {
+	registrationUsecase abstraction.RegistrationUsecase
+}
+
+func NewRegistrationAdapter(registrationUsecase abstraction.RegistrationUsecase) *RegistrationAdapter {
+	return &RegistrationAdapter{registrationUsecase: registrationUsecase}
+}
+
+func (a *RegistrationAdapter) Router(r chi.Router) {
+	r.Get("/", a.GetPolicy())
+	r.Post("/", a.Register())
+	r.Post("/{user_id}/approve", middleware.RequireAuthentication(a.Approve()))
+}
+
+// @Summary Get the registration policy
+// @Description Get the self registration mode and the fields required by the registration.
+// @Tags Registration
+// @Produce json
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.RegistrationGetPolicyResponse] "Get successfully"
+// @Router /registration [get]
+func (a *RegistrationAdapter) GetPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.RegistrationGetPolicyRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.registrationUsecase.GetPolicy(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewRegistrationGetPolicyResponse(resp), err).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Register by myself
+// @Description Register without an admin when the self registration is enabled, the user is pending until an admin approves them. <br>
+// @Description The invite mode requires an `invitation_token`, the invited user has the role of the invitation and is not pending. The domain mode requires an `email` of an allowed domain, a verification link is sent to it. <br>
+// @Description The response does not tell whether the email is already used, its owner receives an email instead. <br>
+// @Description Registrations are rate limited per ip address, exceeding the limit returns `429`.
+// @Tags Registration
+// @Accept json
+// @Produce json
+// @Param user body dto.RegistrationRegisterRequest true "Registration data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.RegistrationRegisterResponse] "Registered successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Self registration is disabled"
+// @Failure 409 {object} response.SwaggerDuplicatedErrorResponse "Duplicated"
+// @Router /registration [post]
+func (a *RegistrationAdapter) Register() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.RegistrationRegisterRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.registrationUsecase.Register(ctx, req.To(remoteIP(r)))
+		response.NewRESTResponseHandler(ctx, dto.NewRegistrationRegisterResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			Map(http.StatusConflict, errordef.ErrDuplicated).
+			Map(http.StatusTooManyRequests, ucdto.ErrTooManyRequests).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Approve a registration
+// @Description Let a user who registered by themselves sign in. In the domain mode, the email of the user must be verified first. <br>
+// @Description Require `todennus/admin:create:user` scope and admin role.
+// @Tags Registration
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Produce json
+// @Param user_id path string true "User id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.RegistrationApproveResponse] "Approved successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /registration/{user_id}/approve [post]
+func (a *RegistrationAdapter) Approve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.RegistrationApproveRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.registrationUsecase.Approve(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewRegistrationApproveResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// remoteIP returns the ip address of the client, the context middleware has
+// already replaced the remote address by the one of the proxy headers.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
@@ -0,0 +1,308 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/user-service/adapter/rest/dto/resource"
+	"github.com/todennus/x/xerror"
+)
+
+const scimContentType = "application/scim+json"
+
+// maxScimRequestSize limits the body of the SCIM requests, a user resource is
+// far smaller than this.
+const maxScimRequestSize = 64 * 1024
+
+// ScimAdapter serves the SCIM 2.0 protocol (RFC 7643, RFC 7644). It does not
+// use the common response format of this service, both the resources and the
+// errors follow the SCIM schemas.
+type ScimAdapter struct {
+	scimUsecase abstraction.ScimUsecase
+	usersURL    string
+}
+
+// NewScimAdapter needs the public url of the users endpoint to build the
+// location of the resources.
+func NewScimAdapter(scimUsecase abstraction.ScimUsecase, usersURL string) *ScimAdapter {
+	return &ScimAdapter{scimUsecase: scimUsecase, usersURL: usersURL}
+}
+
+func (a *ScimAdapter) Router(r chi.Router) {
+	r.Get("/ServiceProviderConfig", a.ServiceProviderConfig())
+
+	r.Get("/Users", middleware.RequireAuthentication(a.ListUsers()))
+	r.Post("/Users", middleware.RequireAuthentication(a.CreateUser()))
+	r.Get("/Users/{user_id}", middleware.RequireAuthentication(a.GetUser()))
+	r.Put("/Users/{user_id}", middleware.RequireAuthentication(a.ReplaceUser()))
+	r.Patch("/Users/{user_id}", middleware.RequireAuthentication(a.PatchUser()))
+}
+
+// @Summary SCIM service provider configuration
+// @Description Describe the SCIM features supported by this service.
+// @Tags SCIM
+// @Produce application/scim+json
+// @Success 200 {object} dto.ScimServiceProviderConfig "Get successfully"
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (a *ScimAdapter) ServiceProviderConfig() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeScim(r.Context(), w, http.StatusOK, dto.NewScimServiceProviderConfig())
+	}
+}
+
+// @Summary SCIM create user
+// @Description Provision a user. A random password is generated if none is given. <br>
+// @Description Require admin scope `create:user`.
+// @Tags SCIM
+// @Accept application/scim+json
+// @Produce application/scim+json
+// @Param body body dto.ScimCreateUserRequest true "User"
+// @Success 201 {object} resource.ScimUser "Create successfully"
+// @Failure 400 {object} dto.ScimError "Bad request"
+// @Failure 403 {object} dto.ScimError "Forbidden"
+// @Failure 409 {object} dto.ScimError "Duplicated"
+// @Router /scim/v2/Users [post]
+func (a *ScimAdapter) CreateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := dto.ScimCreateUserRequest{}
+		if err := decodeScimBody(r, &req); err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.scimUsecase.CreateUser(ctx, req.To())
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		user := resource.NewScimUser(resp.User, a.usersURL)
+		w.Header().Set("Location", user.Meta.Location)
+		w.Header().Set("ETag", user.Meta.Version)
+		writeScim(ctx, w, http.StatusCreated, user)
+	}
+}
+
+// @Summary SCIM get user
+// @Description Get a user. Return 304 if the `If-None-Match` header matches the current version. <br>
+// @Description Require admin scope `read:user.profile`.
+// @Tags SCIM
+// @Produce application/scim+json
+// @Param user_id path string true "User ID"
+// @Param If-None-Match header string false "ETag of the known version"
+// @Success 200 {object} resource.ScimUser "Get successfully"
+// @Success 304 "Not modified"
+// @Failure 403 {object} dto.ScimError "Forbidden"
+// @Failure 404 {object} dto.ScimError "Not found"
+// @Router /scim/v2/Users/{user_id} [get]
+func (a *ScimAdapter) GetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := dto.ScimGetUserRequest{UserID: chi.URLParam(r, "user_id")}
+		ucreq, err := req.To()
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.scimUsecase.GetUser(ctx, ucreq)
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		user := resource.NewScimUser(resp.User, a.usersURL)
+		w.Header().Set("ETag", user.Meta.Version)
+		if r.Header.Get("If-None-Match") == user.Meta.Version {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		writeScim(ctx, w, http.StatusOK, user)
+	}
+}
+
+// @Summary SCIM list users
+// @Description List the users matching the filter, ordered by id. <br>
+// @Description Support the attributes `id`, `userName`, `displayName`, `active` and `meta.lastModified`. <br>
+// @Description Require admin scope `read:user.profile`.
+// @Tags SCIM
+// @Produce application/scim+json
+// @Param filter query string false "filter, e.g. userName eq \"huykingsofm\""
+// @Param startIndex query int false "1-based index of the first result (default 1)"
+// @Param count query int false "maximum number of results, up to 200 (default 100)"
+// @Success 200 {object} dto.ScimListUsersResponse "List successfully"
+// @Failure 400 {object} dto.ScimError "Bad request"
+// @Failure 403 {object} dto.ScimError "Forbidden"
+// @Router /scim/v2/Users [get]
+func (a *ScimAdapter) ListUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		query := r.URL.Query()
+		req := dto.ScimListUsersRequest{
+			Filter:     query.Get("filter"),
+			StartIndex: query.Get("startIndex"),
+			Count:      query.Get("count"),
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.scimUsecase.ListUsers(ctx, ucreq)
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		writeScim(ctx, w, http.StatusOK, dto.NewScimListUsersResponse(resp, a.usersURL))
+	}
+}
+
+// @Summary SCIM replace user
+// @Description Replace the attributes of a user, userName cannot be changed. <br>
+// @Description Require admin scope `create:user`.
+// @Tags SCIM
+// @Accept application/scim+json
+// @Produce application/scim+json
+// @Param user_id path string true "User ID"
+// @Param If-Match header string false "ETag of the version to replace"
+// @Param body body dto.ScimReplaceUserRequest true "User"
+// @Success 200 {object} resource.ScimUser "Replace successfully"
+// @Failure 400 {object} dto.ScimError "Bad request"
+// @Failure 403 {object} dto.ScimError "Forbidden"
+// @Failure 404 {object} dto.ScimError "Not found"
+// @Failure 412 {object} dto.ScimError "Version mismatched"
+// @Router /scim/v2/Users/{user_id} [put]
+func (a *ScimAdapter) ReplaceUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := dto.ScimReplaceUserRequest{}
+		if err := decodeScimBody(r, &req); err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		req.UserID = chi.URLParam(r, "user_id")
+		req.Version = r.Header.Get("If-Match")
+
+		ucreq, err := req.To()
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.scimUsecase.ReplaceUser(ctx, ucreq)
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		user := resource.NewScimUser(resp.User, a.usersURL)
+		w.Header().Set("ETag", user.Meta.Version)
+		writeScim(ctx, w, http.StatusOK, user)
+	}
+}
+
+// @Summary SCIM patch user
+// @Description Apply the add, replace and remove operations to a user. <br>
+// @Description Deactivate a user by replacing `active` with false, users cannot be deleted. <br>
+// @Description Require admin scope `create:user`.
+// @Tags SCIM
+// @Accept application/scim+json
+// @Produce application/scim+json
+// @Param user_id path string true "User ID"
+// @Param If-Match header string false "ETag of the version to patch"
+// @Param body body dto.ScimPatchUserRequest true "Operations"
+// @Success 200 {object} resource.ScimUser "Patch successfully"
+// @Failure 400 {object} dto.ScimError "Bad request"
+// @Failure 403 {object} dto.ScimError "Forbidden"
+// @Failure 404 {object} dto.ScimError "Not found"
+// @Failure 412 {object} dto.ScimError "Version mismatched"
+// @Router /scim/v2/Users/{user_id} [patch]
+func (a *ScimAdapter) PatchUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req := dto.ScimPatchUserRequest{}
+		if err := decodeScimBody(r, &req); err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		req.UserID = chi.URLParam(r, "user_id")
+		req.Version = r.Header.Get("If-Match")
+
+		ucreq, err := req.To()
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.scimUsecase.PatchUser(ctx, ucreq)
+		if err != nil {
+			writeScimError(ctx, w, err)
+			return
+		}
+
+		user := resource.NewScimUser(resp.User, a.usersURL)
+		w.Header().Set("ETag", user.Meta.Version)
+		writeScim(ctx, w, http.StatusOK, user)
+	}
+}
+
+// decodeScimBody decodes the JSON body, the SCIM clients send it as either
+// application/scim+json or application/json.
+func decodeScimBody(r *http.Request, obj any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != scimContentType && mediaType != "application/json") {
+		return xerror.Enrich(errordef.ErrRequestInvalid, "require content type %s", scimContentType)
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxScimRequestSize))
+	if err := decoder.Decode(obj); err != nil {
+		return xerror.Enrich(errordef.ErrRequestInvalid, "invalid json body")
+	}
+
+	return nil
+}
+
+func writeScim(ctx context.Context, w http.ResponseWriter, code int, resp any) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		xcontext.Logger(ctx).Critical("failed to marshal scim response", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(code)
+	if _, err := w.Write(body); err != nil {
+		xcontext.Logger(ctx).Debug("failed to write scim response", "err", err)
+	}
+}
+
+// writeScimError writes the error in the SCIM error schema. The detail is the
+// description of the common error response, so the server errors are hidden
+// in the same way.
+func writeScimError(ctx context.Context, w http.ResponseWriter, err error) {
+	status, scimType := dto.ScimErrorStatus(err)
+	errResp := response.NewRESTErrorResponse(ctx, err)
+	writeScim(ctx, w, status, dto.NewScimError(status, scimType, errResp.ErrorDescription))
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+// TokenIntrospectionAdapter serves the auth server when a client presents a
+// personal access token.
+type TokenIntrospectionAdapter struct {
+	personalAccessTokenUsecase abstraction.PersonalAccessTokenUsecase
+}
+
+func NewTokenIntrospectionAdapter(personalAccessTokenUsecase abstraction.PersonalAccessTokenUsecase) *TokenIntrospectionAdapter {
+	return &TokenIntrospectionAdapter{personalAccessTokenUsecase: personalAccessTokenUsecase}
+}
+
+func (a *TokenIntrospectionAdapter) Router(r chi.Router) {
+	r.Post("/introspect", middleware.RequireAuthentication(a.Introspect()))
+}
+
+// @Summary Introspect a personal access token
+// @Description Resolve a personal access token into its user and scope, a token which cannot be used is returned with `active` false. <br>
+// @Description Require `todennus/admin:validate:user` scope.
+// @Tags Federation
+// @Security OAuth2Application[todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.PersonalAccessTokenIntrospectRequest true "Personal access token"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.PersonalAccessTokenIntrospectResponse] "Introspect successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /tokens/introspect [post]
+func (a *TokenIntrospectionAdapter) Introspect() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.PersonalAccessTokenIntrospectRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.personalAccessTokenUsecase.Introspect(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewPersonalAccessTokenIntrospectResponse(resp), err).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
@@ -1,6 +1,9 @@
 package rest
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -10,27 +13,57 @@ import (
 	"github.com/todennus/shared/xcontext"
 	"github.com/todennus/user-service/adapter/abstraction"
 	"github.com/todennus/user-service/adapter/rest/dto"
+	ucdto "github.com/todennus/user-service/usecase/dto"
 	"github.com/todennus/x/xhttp"
 )
 
 type UserAdapter struct {
-	userUsecase   abstraction.UserUsecase
-	avatarUsecase abstraction.AvatarUsecase
+	userUsecase       abstraction.UserUsecase
+	avatarUsecase     abstraction.AvatarUsecase
+	userImportUsecase abstraction.UserImportUsecase
+	userExportUsecase abstraction.UserExportUsecase
+
+	userErasureUsecase abstraction.UserErasureUsecase
 }
 
-func NewUserAdapter(userUsecase abstraction.UserUsecase, avatarUsecase abstraction.AvatarUsecase) *UserAdapter {
-	return &UserAdapter{userUsecase: userUsecase, avatarUsecase: avatarUsecase}
+func NewUserAdapter(
+	userUsecase abstraction.UserUsecase,
+	avatarUsecase abstraction.AvatarUsecase,
+	userImportUsecase abstraction.UserImportUsecase,
+	userExportUsecase abstraction.UserExportUsecase,
+	userErasureUsecase abstraction.UserErasureUsecase,
+) *UserAdapter {
+	return &UserAdapter{
+		userUsecase:        userUsecase,
+		avatarUsecase:      avatarUsecase,
+		userImportUsecase:  userImportUsecase,
+		userExportUsecase:  userExportUsecase,
+		userErasureUsecase: userErasureUsecase,
+	}
 }
 
 func (a *UserAdapter) Router(r chi.Router) {
 	r.Post("/", middleware.RequireAuthentication(a.Register()))
 	r.Post("/validate", middleware.RequireAuthentication(a.Validate()))
+	r.Post("/import", middleware.RequireAuthentication(a.Import()))
+	r.Get("/export", middleware.RequireAuthentication(a.Export()))
+	r.Get("/search", middleware.RequireAuthentication(a.Search()))
 
 	r.Get("/{user_id}", middleware.RequireAuthentication(a.GetByID()))
 	r.Get("/username/{username}", middleware.RequireAuthentication(a.GetByUsername()))
+	r.Get("/username/{username}/availability", middleware.RequireAuthentication(a.CheckUsername()))
 
 	r.Get("/{user_id}/avatar/upload_token", middleware.RequireAuthentication(a.GetAvatarUploadToken()))
 	r.Put("/{user_id}/avatar", middleware.RequireAuthentication(a.UpdateAvatar()))
+	r.Delete("/{user_id}/avatar", middleware.RequireAuthentication(a.RemoveAvatar()))
+	r.Get("/{user_id}/avatar/default", middleware.RequireAuthentication(a.GetDefaultAvatar()))
+	r.Post("/{user_id}/avatar/restore", middleware.RequireAuthentication(a.RestoreAvatar()))
+	r.Get("/{user_id}/avatars", middleware.RequireAuthentication(a.ListAvatarHistory()))
+
+	r.Post("/{user_id}/password", middleware.RequireAuthentication(a.ChangePassword()))
+
+	r.Post("/{user_id}/erasure", middleware.RequireAuthentication(a.ScheduleErasure()))
+	r.Delete("/{user_id}/erasure", middleware.RequireAuthentication(a.CancelErasure()))
 }
 
 // @Summary Register a new user
@@ -66,6 +99,107 @@ func (a *UserAdapter) Register() func(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary Import users
+// @Description Import users from a CSV file with a header row or from a NDJSON file, up to 10MiB. <br>
+// @Description The columns are `username`, `display_name`, `role`, `password_hash` (bcrypt or argon2id) and `force_reset`. <br>
+// @Description Every row is validated, a failed row does not prevent the other rows from being imported. <br>
+// @Description Require `todennus/admin:create:user` scope and admin role.
+// @Tags User
+// @Security OAuth2Application[todennus/admin:create:user]
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "csv or ndjson, default to the content type"
+// @Param dry_run query bool false "only validate the rows"
+// @Param batch_size query int false "number of users created per transaction, up to 1000 (default 100)"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.UserImportResponse] "Import successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/import [post]
+func (a *UserAdapter) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		query := r.URL.Query()
+		req := dto.UserImportRequest{
+			ContentType: r.Header.Get("Content-Type"),
+			Format:      query.Get("format"),
+			DryRun:      query.Get("dry_run"),
+			BatchSize:   query.Get("batch_size"),
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, dto.MaximumUserImportSize))
+		if err != nil {
+			if xhttp.AsMaxBytesError(err) != nil {
+				err = fmt.Errorf("%wtoo large request (limit %d bytes)", xhttp.ErrHTTPTooLarge, dto.MaximumUserImportSize)
+			} else {
+				err = fmt.Errorf("%wfailed to read the body", xhttp.ErrHTTPBadRequest)
+			}
+
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(bytes.NewReader(body))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.userImportUsecase.Import(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewUserImportResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Export users
+// @Description Stream every user as NDJSON or as CSV with a header row, in the format read by the import. <br>
+// @Description The password hashes and the avatar ownership ids are only exported if requested. <br>
+// @Description Require `todennus/admin:read:user.profile` scope and admin role.
+// @Tags User
+// @Security OAuth2Application[todennus/admin:read:user.profile]
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param format query string false "ndjson (default) or csv"
+// @Param include_hashes query bool false "export the password hashes"
+// @Param include_avatars query bool false "export the avatar ownership ids"
+// @Success 200 {string} string "Export successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/export [get]
+func (a *UserAdapter) Export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.UserExportRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq := req.To(nil)
+		writer := &streamWriter{w: w, contentType: dto.UserExportContentType(ucreq.Format)}
+		ucreq.Writer = writer
+
+		_, err = a.userExportUsecase.Export(ctx, ucreq)
+		if err != nil && writer.started {
+			// The status has been sent, abort the response so the client
+			// does not take a truncated export for a complete one.
+			response.NewRESTErrorResponse(ctx, err)
+			panic(http.ErrAbortHandler)
+		}
+
+		if err != nil {
+			response.NewRESTResponseHandler(ctx, nil, err).
+				Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+				Map(http.StatusForbidden, errordef.ErrForbidden).
+				WriteHTTPResponse(ctx, w)
+		}
+	}
+}
+
 // @Summary Get user by id
 // @Description Get an user information by user id.
 // @Tags User
@@ -126,8 +260,63 @@ func (a *UserAdapter) GetByUsername() http.HandlerFunc {
 	}
 }
 
+// @Summary Search users
+// @Description Search users by username prefix, display name or a fuzzy match, the best matches first. <br>
+// @Description The fields of every user are filtered like the get apis.
+// @Tags User
+// @Produce json
+// @Param q query string true "Query"
+// @Param limit query int false "Maximum number of users (default 20, at most 50)"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.UserSearchResponse] "Search successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Router /users/search [get]
+func (a *UserAdapter) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.UserSearchRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.userUsecase.Search(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewUserSearchResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Check username availability
+// @Description Check whether a username can be registered. An unavailable username comes with the reasons and a few available alternatives. <br>
+// @Description Every client is limited to a number of checks per minute, exceeding it returns `429`.
+// @Tags User
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.UserCheckUsernameResponse] "Check successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Router /users/username/{username}/availability [get]
+func (a *UserAdapter) CheckUsername() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.UserCheckUsernameRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.userUsecase.CheckUsername(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewUserCheckUsernameResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusTooManyRequests, ucdto.ErrTooManyRequests).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
 // @Summary Validate user credentials
 // @Description Validate the user credentials and returns the user information. <br>
+// @Description The `password_status` is `valid`, `expiring` or `expired`, the user must change an expired password before signing in. <br>
 // @Description Require `todennus/admin:validate:user` scope.
 // @Tags User
 // @Security OAuth2Application[todennus/admin:validate:user]
@@ -156,6 +345,46 @@ func (a *UserAdapter) Validate() http.HandlerFunc {
 	}
 }
 
+// @Summary Change the password of a user
+// @Description Change the password after confirming the current one, which may be expired. The new password cannot be one of the last passwords of the user. <br>
+// @Description Users change their own password with `todennus/update:user.password` scope. <br>
+// @Description The auth server changes the password on behalf of a user with `todennus/admin:validate:user` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.profile, todennus/admin:validate:user]
+// @Accept json
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Param body body dto.UserChangePasswordRequest true "Password change data"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.UserChangePasswordResponse] "Change successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/password [post]
+func (a *UserAdapter) ChangePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.UserChangePasswordRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.userUsecase.ChangePassword(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewUserChangePasswordResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
 // @Summary Get an avatar upload_token.
 // @Description Get the upload_token used for updating the avatar image. <br>
 // @Description Require `todennus/update:user.avatar` scope.
@@ -191,6 +420,7 @@ func (a *UserAdapter) GetAvatarUploadToken() http.HandlerFunc {
 
 // @Summary Update avatar.
 // @Description Use a temporary_file_token to update user avatar. <br>
+// @Description The optional `upload_token` is the token which the file was uploaded with, the file is then validated against the avatar policy of that upload. Without it, the current avatar policy of the user is used. <br>
 // @Description Require `todennus/update:user.avatar` scope.
 // @Tags User
 // @Security OAuth2Application[todennus/update:user.avatar]
@@ -225,3 +455,260 @@ func (a *UserAdapter) UpdateAvatar() http.HandlerFunc {
 			WriteHTTPResponse(ctx, w)
 	}
 }
+
+// @Summary Remove avatar.
+// @Description Remove the current avatar of user. <br>
+// @Description Admins can remove the avatar of other users by providing a reason. <br>
+// @Description Require `todennus/update:user.avatar` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/update:user.avatar]
+// @Accept json
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Param body body dto.AvatarRemoveRequest true "Avatar remove request"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.AvatarRemoveResponse] "Remove successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/avatar [delete]
+func (a *UserAdapter) RemoveAvatar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.AvatarRemoveRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.avatarUsecase.Remove(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewAvatarRemoveResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Get default avatar.
+// @Description Get the generated default avatar of user. <br>
+// @Description The `svg` format renders the initials of the display name, the `png` format renders an identicon. <br>
+// @Description Require an access token, so the endpoint cannot be used to find out which users exist.
+// @Tags User
+// @Produce image/svg+xml
+// @Produce image/png
+// @Param user_id path string true "user_id"
+// @Param format query string false "svg or png (default svg)"
+// @Param size query int false "image size in pixels, from 16 to 512 (default 128)"
+// @Success 200 {file} binary "The avatar image"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/avatar/default [get]
+func (a *UserAdapter) GetDefaultAvatar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.AvatarGetDefaultRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.avatarUsecase.GetDefault(ctx, ucreq)
+		if err != nil {
+			response.NewRESTResponseHandler(ctx, nil, err).
+				Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+				Map(http.StatusNotFound, errordef.ErrNotFound).
+				WriteHTTPResponse(ctx, w)
+			return
+		}
+
+		w.Header().Set("Content-Type", resp.ContentType)
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(resp.Content); err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-write-default-avatar", "err", err)
+		}
+	}
+}
+
+// @Summary List previous avatars.
+// @Description List the recent avatars which the user can restore, from the newest to the oldest. <br>
+// @Description Require `todennus/read:user.avatar` or `todennus/admin:read:user.profile` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.avatar]
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.AvatarListHistoryResponse] "List successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /users/{user_id}/avatars [get]
+func (a *UserAdapter) ListAvatarHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.AvatarListHistoryRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.avatarUsecase.ListHistory(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewAvatarListHistoryResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Restore a previous avatar.
+// @Description Restore a previous avatar from the history, the most recent one is restored if history_id is empty. <br>
+// @Description Require `todennus/update:user.avatar` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/update:user.avatar]
+// @Accept json
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Param body body dto.AvatarRestoreRequest true "Avatar restore request"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.AvatarRestoreResponse] "Restore successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/avatar/restore [post]
+func (a *UserAdapter) RestoreAvatar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.AvatarRestoreRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.avatarUsecase.Restore(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewAvatarRestoreResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Schedule the erasure of a user
+// @Description Schedule the anonymization of the user after a grace period, the user cannot be restored once erased. <br>
+// @Description Users schedule their own erasure with a re-authentication token from `POST /users/{user_id}/reauthentication`, with `todennus/read:user.profile` scope. <br>
+// @Description Admins schedule the erasure of any user, with `todennus/admin:create:user` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.profile, todennus/admin:create:user]
+// @Accept json
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Param body body dto.UserErasureScheduleRequest true "Erasure request"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.UserErasureResponse] "Schedule successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/erasure [post]
+func (a *UserAdapter) ScheduleErasure() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.UserErasureScheduleRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.userErasureUsecase.Schedule(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewUserErasureScheduleResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Cancel the erasure of a user
+// @Description Cancel the scheduled erasure of the user during the grace period. <br>
+// @Description Require `todennus/read:user.profile` scope for the own erasure, or `todennus/admin:create:user` scope.
+// @Tags User
+// @Security OAuth2Application[todennus/read:user.profile, todennus/admin:create:user]
+// @Produce json
+// @Param user_id path string true "user_id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.UserErasureResponse] "Cancel successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /users/{user_id}/erasure [delete]
+func (a *UserAdapter) CancelErasure() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.UserErasureCancelRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To(xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.userErasureUsecase.Cancel(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewUserErasureCancelResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// streamWriter sends the headers of a streamed response on the first write,
+// the handler can still write an error response until then.
+type streamWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	started     bool
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.w.Header().Set("Content-Type", w.contentType)
+		w.w.WriteHeader(http.StatusOK)
+	}
+
+	return w.w.Write(p)
+}
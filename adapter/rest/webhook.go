@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/shared/response"
+	"github.com/todennus/user-service/adapter/abstraction"
+	"github.com/todennus/user-service/adapter/rest/dto"
+	"github.com/todennus/x/xhttp"
+)
+
+type WebhookAdapter struct {
+	webhookUsecase abstraction.WebhookUsecase
+}
+
+func NewWebhookAdapter(webhookUsecase abstraction.WebhookUsecase) *WebhookAdapter {
+	return &WebhookAdapter{webhookUsecase: webhookUsecase}
+}
+
+func (a *WebhookAdapter) Router(r chi.Router) {
+	r.Post("/", middleware.RequireAuthentication(a.Create()))
+	r.Get("/", middleware.RequireAuthentication(a.List()))
+	r.Delete("/{webhook_id}", middleware.RequireAuthentication(a.Delete()))
+	r.Get("/{webhook_id}/deliveries", middleware.RequireAuthentication(a.ListDeliveryLogs()))
+	r.Get("/{webhook_id}/dead_letters", middleware.RequireAuthentication(a.ListDeadLetters()))
+}
+
+// @Summary Register a webhook
+// @Description Register an endpoint receiving the user events. The secret used to verify the `X-Todennus-Signature` header is only returned once. <br>
+// @Description Use `*` as an event type to receive every event. <br>
+// @Description Require `todennus/admin:read:user.profile` and `todennus/admin:create:user` scopes and admin role.
+// @Tags Webhook
+// @Security OAuth2Application[todennus/admin:read:user.profile, todennus/admin:create:user]
+// @Accept json
+// @Produce json
+// @Param body body dto.WebhookCreateRequest true "Webhook data"
+// @Success 201 {object} response.SwaggerSuccessResponse[dto.WebhookCreateResponse] "Register successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /webhooks [post]
+func (a *WebhookAdapter) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.WebhookCreateRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.webhookUsecase.Create(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewWebhookCreateResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WithDefaultCode(http.StatusCreated).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary List webhooks
+// @Description List the registered webhooks. <br>
+// @Description Require `todennus/admin:read:user.profile` scope and admin role.
+// @Tags Webhook
+// @Security OAuth2Application[todennus/admin:read:user.profile]
+// @Produce json
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.WebhookListResponse] "List successfully"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /webhooks [get]
+func (a *WebhookAdapter) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.WebhookListRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.webhookUsecase.List(ctx, req.To())
+		response.NewRESTResponseHandler(ctx, dto.NewWebhookListResponse(resp), err).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary Delete a webhook
+// @Description Delete a webhook with its pending deliveries, logs and dead letters. <br>
+// @Description Require `todennus/admin:read:user.profile` and `todennus/admin:create:user` scopes and admin role.
+// @Tags Webhook
+// @Security OAuth2Application[todennus/admin:read:user.profile, todennus/admin:create:user]
+// @Produce json
+// @Param webhook_id path string true "webhook_id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.WebhookDeleteResponse] "Delete successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Failure 404 {object} response.SwaggerNotFoundErrorResponse "Not found"
+// @Router /webhooks/{webhook_id} [delete]
+func (a *WebhookAdapter) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.WebhookDeleteRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.webhookUsecase.Delete(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewWebhookDeleteResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			Map(http.StatusNotFound, errordef.ErrNotFound).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary List delivery logs of a webhook
+// @Description List the delivery attempts of a webhook, from the newest to the oldest. <br>
+// @Description Require `todennus/admin:read:user.profile` scope and admin role.
+// @Tags Webhook
+// @Security OAuth2Application[todennus/admin:read:user.profile]
+// @Produce json
+// @Param webhook_id path string true "webhook_id"
+// @Param limit query int false "maximum number of logs, up to 200 (default 50)"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.WebhookListDeliveryLogsResponse] "List successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /webhooks/{webhook_id}/deliveries [get]
+func (a *WebhookAdapter) ListDeliveryLogs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.WebhookListDeliveryLogsRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.webhookUsecase.ListDeliveryLogs(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewWebhookListDeliveryLogsResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
+
+// @Summary List dead letters of a webhook
+// @Description List the deliveries which failed every attempt. They can be replayed by the `cli webhook-replay` command. <br>
+// @Description Require `todennus/admin:read:user.profile` scope and admin role.
+// @Tags Webhook
+// @Security OAuth2Application[todennus/admin:read:user.profile]
+// @Produce json
+// @Param webhook_id path string true "webhook_id"
+// @Success 200 {object} response.SwaggerSuccessResponse[dto.WebhookListDeadLettersResponse] "List successfully"
+// @Failure 400 {object} response.SwaggerBadRequestErrorResponse "Bad request"
+// @Failure 403 {object} response.SwaggerForbiddenErrorResponse "Forbidden"
+// @Router /webhooks/{webhook_id}/dead_letters [get]
+func (a *WebhookAdapter) ListDeadLetters() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		req, err := xhttp.ParseHTTPRequest[dto.WebhookListDeadLettersRequest](r)
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		ucreq, err := req.To()
+		if err != nil {
+			response.RESTWriteAndLogInvalidRequestError(ctx, w, err)
+			return
+		}
+
+		resp, err := a.webhookUsecase.ListDeadLetters(ctx, ucreq)
+		response.NewRESTResponseHandler(ctx, dto.NewWebhookListDeadLettersResponse(resp), err).
+			Map(http.StatusBadRequest, errordef.ErrRequestInvalid).
+			Map(http.StatusForbidden, errordef.ErrForbidden).
+			WriteHTTPResponse(ctx, w)
+	}
+}
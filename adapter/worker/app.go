@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/todennus/shared/config"
+	"github.com/todennus/shared/middleware"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/wiring"
+)
+
+// job processes a batch and returns the number of processed items. It runs
+// on every tick of the worker, or every interval if it is set.
+type job struct {
+	name      string
+	batchSize int
+	interval  time.Duration
+	run       func(ctx context.Context, batchSize int) (int, error)
+}
+
+// Worker relays the outbox messages to their destinations, sends the webhook
+// deliveries, generates the data exports, erases the users whose grace period
+// has passed and synchronizes the users sourced from LDAP.
+type Worker struct {
+	config   *config.Config
+	interval time.Duration
+	jobs     []job
+}
+
+func App(config *config.Config, variable *wiring.Variable, usecases *wiring.Usecases) *Worker {
+	worker := &Worker{
+		config:   config,
+		interval: time.Duration(variable.Outbox.RelayInterval) * time.Millisecond,
+		jobs: []job{
+			{
+				name:      "outbox-relay",
+				batchSize: variable.Outbox.RelayBatchSize,
+				run: func(ctx context.Context, batchSize int) (int, error) {
+					resp, err := usecases.OutboxUsecase.Relay(ctx, &dto.OutboxRelayRequest{BatchSize: batchSize})
+					if err != nil {
+						return 0, err
+					}
+
+					if resp.Delivered+resp.Failed > 0 {
+						slog.Info("Relayed outbox", "delivered", resp.Delivered, "failed", resp.Failed)
+					}
+
+					return resp.Delivered + resp.Failed, nil
+				},
+			},
+			{
+				name:      "webhook-dispatch",
+				batchSize: variable.Webhook.DispatchBatchSize,
+				run: func(ctx context.Context, batchSize int) (int, error) {
+					resp, err := usecases.WebhookUsecase.Dispatch(ctx, &dto.WebhookDispatchRequest{BatchSize: batchSize})
+					if err != nil {
+						return 0, err
+					}
+
+					if resp.Delivered+resp.Failed+resp.Dead > 0 {
+						slog.Info("Dispatched webhooks", "delivered", resp.Delivered, "failed", resp.Failed, "dead", resp.Dead)
+					}
+
+					return resp.Delivered + resp.Failed + resp.Dead, nil
+				},
+			},
+			{
+				name:      "data-export",
+				batchSize: variable.DataExport.ProcessBatchSize,
+				run: func(ctx context.Context, batchSize int) (int, error) {
+					resp, err := usecases.DataExportUsecase.Process(ctx, &dto.DataExportProcessRequest{BatchSize: batchSize})
+					if err != nil {
+						return 0, err
+					}
+
+					if resp.Completed+resp.Failed > 0 || resp.Deleted > 0 {
+						slog.Info("Processed data exports", "completed", resp.Completed, "failed", resp.Failed, "deleted", resp.Deleted)
+					}
+
+					return resp.Completed + resp.Failed, nil
+				},
+			},
+			{
+				name:      "user-erasure",
+				batchSize: variable.Erasure.ProcessBatchSize,
+				run: func(ctx context.Context, batchSize int) (int, error) {
+					resp, err := usecases.UserErasureUsecase.Process(ctx, &dto.UserErasureProcessRequest{BatchSize: batchSize})
+					if err != nil {
+						return 0, err
+					}
+
+					if resp.Erased+resp.Failed > 0 {
+						slog.Info("Erased users", "erased", resp.Erased, "failed", resp.Failed)
+					}
+
+					// The failed users are due again, they are retried on the
+					// next tick instead of immediately.
+					return resp.Erased, nil
+				},
+			},
+		},
+	}
+
+	if usecases.LDAPUsecase != nil {
+		worker.jobs = append(worker.jobs, job{
+			name:      "ldap-sync",
+			batchSize: 1,
+			interval:  time.Duration(variable.LDAP.SyncInterval) * time.Millisecond,
+			run: func(ctx context.Context, batchSize int) (int, error) {
+				resp, err := usecases.LDAPUsecase.Sync(ctx, &dto.LDAPSyncRequest{})
+				if err != nil {
+					return 0, err
+				}
+
+				if resp.Created+resp.Updated+resp.Disabled+resp.Failed > 0 {
+					slog.Info("Synchronized LDAP users", "created", resp.Created, "updated", resp.Updated,
+						"disabled", resp.Disabled, "failed", resp.Failed)
+				}
+
+				// A sync is never followed immediately by the next one.
+				return 0, nil
+			},
+		})
+	}
+
+	return worker
+}
+
+// Run runs every job until the context is canceled.
+func (worker *Worker) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, j := range worker.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.loop(ctx, j)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// loop runs the job on every tick. A full batch is followed immediately by the
+// next one instead of waiting for the ticker.
+func (worker *Worker) loop(ctx context.Context, j job) {
+	interval := worker.interval
+	if j.interval > 0 {
+		interval = j.interval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		processed, err := j.run(middleware.WithBasicContext(ctx, worker.config), j.batchSize)
+		if err != nil {
+			slog.Error("Failed to run job", "job", j.name, "err", err)
+		}
+
+		if err == nil && processed >= j.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -25,14 +25,14 @@ var Command = &cobra.Command{
 		}
 
 		address := fmt.Sprintf("%s:%d", system.Config.Variable.Server.Host, system.Config.Variable.Server.Port)
-		app := grpc.App(system.Config, system.Usecases)
+		app := grpc.App(system.Config, system.Infras.ServerTLS, system.Usecases)
 
 		listener, err := net.Listen("tcp", address)
 		if err != nil {
 			panic(err)
 		}
 
-		slog.Info("gRPC server started", "address", address)
+		slog.Info("gRPC server started", "address", address, "tls", system.Infras.ServerTLS != nil)
 		if err := app.Serve(listener); err != nil {
 			panic(err)
 		}
@@ -4,7 +4,9 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/todennus/user-service/adapter/cli"
 	"github.com/todennus/user-service/cmd/grpc"
+	"github.com/todennus/user-service/cmd/migration"
 	"github.com/todennus/user-service/cmd/rest"
+	"github.com/todennus/user-service/cmd/worker"
 )
 
 var rootCommand = &cobra.Command{
@@ -16,6 +18,8 @@ func main() {
 	rootCommand.PersistentFlags().StringArray("env", []string{".env"}, "environment file paths")
 	rootCommand.AddCommand(rest.Command)
 	rootCommand.AddCommand(grpc.Command)
+	rootCommand.AddCommand(worker.Command)
+	rootCommand.AddCommand(migration.Command)
 	rootCommand.AddCommand(cli.Command)
 
 	if err := rootCommand.Execute(); err != nil {
@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/migration/postgres"
+	"github.com/todennus/shared/config"
+	"github.com/todennus/shared/middleware"
+	userpostgres "github.com/todennus/user-service/migration/postgres"
+)
+
+var downFlag int
+
+var Command = &cobra.Command{
+	Use:   "migration",
+	Short: "Migrate the tables owned by the user service",
+	Long: "Apply the migrations in migration/postgres, which are versioned in their own table.\n" +
+		"The shared tables must be migrated by todennus/migration first.",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		config, err := config.Load(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		ctx := middleware.WithBasicContext(context.Background(), config)
+
+		gormDB, err := postgres.Initialize(ctx, config)
+		if err != nil {
+			panic(err)
+		}
+
+		db, err := gormDB.DB()
+		if err != nil {
+			panic(err)
+		}
+
+		if downFlag == 0 {
+			if err := userpostgres.Up(ctx, db); err != nil {
+				panic(err)
+			}
+		} else {
+			if err := userpostgres.Down(ctx, db, downFlag); err != nil {
+				panic(err)
+			}
+		}
+	},
+}
+
+func init() {
+	Command.Flags().IntVar(&downFlag, "down", 0, "Migrate down with the number of steps")
+}
@@ -25,7 +25,7 @@ var Command = &cobra.Command{
 		}
 
 		address := fmt.Sprintf("%s:%d", system.Config.Variable.Server.Host, system.Config.Variable.Server.Port)
-		app := rest.App(system.Config, system.Usecases)
+		app := rest.App(system.Config, system.Variable, system.Usecases)
 
 		slog.Info("Server started", "address", address)
 		if err := http.ListenAndServe(address, app); err != nil {
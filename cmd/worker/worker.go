@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/todennus/user-service/adapter/worker"
+	"github.com/todennus/user-service/wiring"
+)
+
+var Command = &cobra.Command{
+	Use:   "worker",
+	Short: "Start the worker relaying the outbox and sending webhooks",
+	Run: func(cmd *cobra.Command, args []string) {
+		envPaths, err := cmd.Flags().GetStringArray("env")
+		if err != nil {
+			panic(err)
+		}
+
+		system, err := wiring.InitializeSystem(envPaths...)
+		if err != nil {
+			panic(err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		app := worker.App(system.Config, system.Variable, system.Usecases)
+
+		slog.Info("Worker started")
+		app.Run(ctx)
+		slog.Info("Worker stopped")
+	},
+}
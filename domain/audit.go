@@ -0,0 +1,297 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	AuditUserRegister                  = "user.register"
+	AuditUserRegisterFirst             = "user.register_first"
+	AuditUserValidateCredentialsFailed = "user.validate_credentials_failed"
+	AuditUserProvision                 = "user.provision"
+	AuditUserUpdate                    = "user.update"
+	AuditUserImport                    = "user.import"
+	AuditUserExport                    = "user.export"
+	AuditUserDataExport                = "user.data_export"
+	AuditUserErasureRequest            = "user.erasure_request"
+	AuditUserErasureCancel             = "user.erasure_cancel"
+	AuditUserErase                     = "user.erase"
+	AuditUserSelfRegister              = "user.self_register"
+	AuditUserApprove                   = "user.approve"
+	AuditUserEmailVerificationSend     = "user.email_verification_send"
+	AuditUserEmailVerify               = "user.email_verify"
+	AuditUserIdentityLink              = "user.identity_link"
+	AuditUserIdentityUnlink            = "user.identity_unlink"
+	AuditUserFederatedProvision        = "user.federated_provision"
+	AuditUserLDAPProvision             = "user.ldap_provision"
+	AuditUserLDAPUpdate                = "user.ldap_update"
+	AuditUserLDAPDisable               = "user.ldap_disable"
+	AuditUserPasskeyRegister           = "user.passkey_register"
+	AuditUserPasskeyDelete             = "user.passkey_delete"
+	AuditUserPasskeyAssertionFailed    = "user.passkey_assertion_failed"
+	AuditUserMagicLinkSend             = "user.magic_link_send"
+	AuditUserMagicLinkRedeemFailed     = "user.magic_link_redeem_failed"
+	AuditUserPasswordChange            = "user.password_change"
+	AuditUserPasswordResetIssue        = "user.password_reset_issue"
+	AuditUserPasswordReset             = "user.password_reset"
+	AuditUserReauthenticate            = "user.reauthenticate"
+	AuditInvitationCreate              = "invitation.create"
+	AuditInvitationRevoke              = "invitation.revoke"
+	AuditInvitationRedeem              = "invitation.redeem"
+	AuditPersonalAccessTokenCreate     = "personal_access_token.create"
+	AuditPersonalAccessTokenRevoke     = "personal_access_token.revoke"
+	AuditWebhookCreate                 = "webhook.create"
+	AuditWebhookDelete                 = "webhook.delete"
+	AuditWebhookReplay                 = "webhook.replay"
+)
+
+const (
+	AuditTargetUser                = "user"
+	AuditTargetWebhook             = "webhook"
+	AuditTargetInvitation          = "invitation"
+	AuditTargetPersonalAccessToken = "personal_access_token"
+)
+
+// AuditLogVersion is the version of the new audit logs. Version 1 logs hash
+// their metadata with the rest of the content, version 2 logs hash a salted
+// hash of their metadata instead, so the metadata can be scrubbed without
+// breaking the chain. A scrubbed version 1 log cannot be hashed again, its
+// stored hash is trusted.
+const AuditLogVersion = 2
+
+// AuditActor is the subject which performed an audited action. Its ID is zero
+// for anonymous requests and for the commands run by an operator.
+type AuditActor struct {
+	ID        snowflake.ID
+	Type      string
+	ClientID  string
+	RequestID string
+}
+
+// AuditLog is an append-only record of an administrative or security action.
+// Every log holds the hash of the previous one, so modifying or removing a log
+// breaks the chain from that point.
+type AuditLog struct {
+	ID         snowflake.ID
+	Seq        int64
+	ActorID    snowflake.ID
+	ActorType  string
+	Action     string
+	TargetType string
+	TargetID   string
+	ClientID   string
+	RequestID  string
+	Metadata   map[string]string
+	CreatedAt  time.Time
+	PrevHash   string
+	Hash       string
+
+	Version      int
+	MetadataSalt string
+	MetadataHash string
+
+	// ScrubbedAt is set once the metadata is removed, the metadata hash of a
+	// version 2 log and the hash of a version 1 log are then trusted as is.
+	ScrubbedAt time.Time
+}
+
+// auditLogContent is the hashed content of an audit log. The field order is
+// part of the chain format, do not change it.
+type auditLogContent struct {
+	Seq        int64             `json:"seq"`
+	ID         snowflake.ID      `json:"id"`
+	ActorID    snowflake.ID      `json:"actor_id"`
+	ActorType  string            `json:"actor_type"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	ClientID   string            `json:"client_id"`
+	RequestID  string            `json:"request_id"`
+	Metadata   map[string]string `json:"metadata"`
+	CreatedAt  int64             `json:"created_at"`
+	PrevHash   string            `json:"prev_hash"`
+}
+
+// auditLogContentV2 is the hashed content of a version 2 audit log. The field
+// order is part of the chain format, do not change it.
+type auditLogContentV2 struct {
+	Version      int          `json:"version"`
+	Seq          int64        `json:"seq"`
+	ID           snowflake.ID `json:"id"`
+	ActorID      snowflake.ID `json:"actor_id"`
+	ActorType    string       `json:"actor_type"`
+	Action       string       `json:"action"`
+	TargetType   string       `json:"target_type"`
+	TargetID     string       `json:"target_id"`
+	ClientID     string       `json:"client_id"`
+	RequestID    string       `json:"request_id"`
+	MetadataHash string       `json:"metadata_hash"`
+	CreatedAt    int64        `json:"created_at"`
+	PrevHash     string       `json:"prev_hash"`
+}
+
+type AuditDomain struct {
+	Snowflake *snowflake.Node
+}
+
+func NewAuditDomain(snowflake *snowflake.Node) *AuditDomain {
+	return &AuditDomain{Snowflake: snowflake}
+}
+
+// New creates an audit log which is not chained yet. The creation time is kept
+// in UTC with microsecond precision so it is stored without loss.
+func (domain *AuditDomain) New(
+	actor AuditActor,
+	action, targetType, targetID string,
+	metadata map[string]string,
+) (*AuditLog, error) {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate metadata salt: %w", err)
+	}
+
+	log := &AuditLog{
+		ID:         domain.Snowflake.Generate(),
+		ActorID:    actor.ID,
+		ActorType:  actor.Type,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		ClientID:   actor.ClientID,
+		RequestID:  actor.RequestID,
+		Metadata:   metadata,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+
+		Version:      AuditLogVersion,
+		MetadataSalt: hex.EncodeToString(salt),
+	}
+	log.MetadataHash = domain.metadataHash(log)
+
+	return log, nil
+}
+
+// Chain appends the log after prev, which is nil for the first log.
+func (domain *AuditDomain) Chain(log *AuditLog, prev *AuditLog) {
+	log.Seq = 1
+	log.PrevHash = ""
+	if prev != nil {
+		log.Seq = prev.Seq + 1
+		log.PrevHash = prev.Hash
+	}
+
+	log.Hash = domain.hash(log)
+}
+
+// Verify checks that the logs, ordered by their sequence, continue the chain
+// after prev. prev is nil if the logs start the chain.
+func (domain *AuditDomain) Verify(logs []*AuditLog, prev *AuditLog) error {
+	for _, log := range logs {
+		expectedSeq, expectedPrevHash := int64(1), ""
+		if prev != nil {
+			expectedSeq, expectedPrevHash = prev.Seq+1, prev.Hash
+		}
+
+		if log.Seq != expectedSeq {
+			return fmt.Errorf("%w: expected log %d but got %d", ErrAuditChainBroken, expectedSeq, log.Seq)
+		}
+
+		if log.PrevHash != expectedPrevHash {
+			return fmt.Errorf("%w: log %d does not link to the previous log", ErrAuditChainBroken, log.Seq)
+		}
+
+		if log.Version >= 2 && log.ScrubbedAt.IsZero() && log.MetadataHash != domain.metadataHash(log) {
+			return fmt.Errorf("%w: the metadata of log %d was modified", ErrAuditChainBroken, log.Seq)
+		}
+
+		// The hash of a scrubbed version 1 log covered the removed metadata, only
+		// its link to the next log is verified.
+		if (log.Version >= 2 || log.ScrubbedAt.IsZero()) && log.Hash != domain.hash(log) {
+			return fmt.Errorf("%w: log %d was modified", ErrAuditChainBroken, log.Seq)
+		}
+
+		prev = log
+	}
+
+	return nil
+}
+
+// Scrub removes the metadata of the log, which may hold personal data, and
+// reports whether the log was changed. The chain stays verifiable because it
+// only holds the hash of the metadata. The chain of a scrubbed version 1 log
+// is only verified by its link, the changes of its other fields are not
+// detected anymore.
+func (domain *AuditDomain) Scrub(log *AuditLog) bool {
+	if !log.ScrubbedAt.IsZero() {
+		return false
+	}
+
+	log.Metadata = map[string]string{}
+	log.MetadataSalt = ""
+	log.ScrubbedAt = time.Now().UTC().Truncate(time.Microsecond)
+	return true
+}
+
+// metadataHash is salted so the scrubbed metadata cannot be guessed from its
+// hash.
+func (domain *AuditDomain) metadataHash(log *AuditLog) string {
+	// Marshalling a string map never fails, the keys are sorted.
+	content, _ := json.Marshal(log.Metadata)
+
+	sum := sha256.Sum256(append([]byte(log.MetadataSalt), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+func (domain *AuditDomain) hash(log *AuditLog) string {
+	if log.Version >= 2 {
+		// Marshalling strings never fails.
+		content, _ := json.Marshal(auditLogContentV2{
+			Version:      log.Version,
+			Seq:          log.Seq,
+			ID:           log.ID,
+			ActorID:      log.ActorID,
+			ActorType:    log.ActorType,
+			Action:       log.Action,
+			TargetType:   log.TargetType,
+			TargetID:     log.TargetID,
+			ClientID:     log.ClientID,
+			RequestID:    log.RequestID,
+			MetadataHash: log.MetadataHash,
+			CreatedAt:    log.CreatedAt.UnixMicro(),
+			PrevHash:     log.PrevHash,
+		})
+
+		sum := sha256.Sum256(content)
+		return hex.EncodeToString(sum[:])
+	}
+
+	// Marshalling strings and a string map never fails, the map keys are
+	// sorted so the content is canonical.
+	content, _ := json.Marshal(auditLogContent{
+		Seq:        log.Seq,
+		ID:         log.ID,
+		ActorID:    log.ActorID,
+		ActorType:  log.ActorType,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		ClientID:   log.ClientID,
+		RequestID:  log.RequestID,
+		Metadata:   log.Metadata,
+		CreatedAt:  log.CreatedAt.UnixMicro(),
+		PrevHash:   log.PrevHash,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
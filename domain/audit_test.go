@@ -0,0 +1,121 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+func newAuditChain(t *testing.T, auditDomain *domain.AuditDomain, n int) []*domain.AuditLog {
+	logs := []*domain.AuditLog{}
+	var prev *domain.AuditLog
+	for i := range n {
+		log, err := auditDomain.New(
+			domain.AuditActor{ID: 1, Type: "user", RequestID: "req"},
+			domain.AuditUserUpdate, domain.AuditTargetUser, "42",
+			map[string]string{"display_name": "Alice", "index": string(rune('a' + i))},
+		)
+		require.NoError(t, err)
+
+		auditDomain.Chain(log, prev)
+		logs = append(logs, log)
+		prev = log
+	}
+
+	return logs
+}
+
+func newAuditDomain(t *testing.T) *domain.AuditDomain {
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+
+	return domain.NewAuditDomain(node)
+}
+
+func TestAuditVerifyChain(t *testing.T) {
+	auditDomain := newAuditDomain(t)
+	logs := newAuditChain(t, auditDomain, 3)
+
+	require.Equal(t, int64(1), logs[0].Seq)
+	require.Empty(t, logs[0].PrevHash)
+	require.Equal(t, logs[1].Hash, logs[2].PrevHash)
+
+	require.NoError(t, auditDomain.Verify(logs, nil))
+	require.NoError(t, auditDomain.Verify(logs[1:], logs[0]))
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	testcases := map[string]func(logs []*domain.AuditLog) []*domain.AuditLog{
+		"modified-metadata": func(logs []*domain.AuditLog) []*domain.AuditLog {
+			logs[1].Metadata["display_name"] = "Mallory"
+			return logs
+		},
+		"modified-action": func(logs []*domain.AuditLog) []*domain.AuditLog {
+			logs[1].Action = domain.AuditUserRegister
+			return logs
+		},
+		"removed-log": func(logs []*domain.AuditLog) []*domain.AuditLog {
+			return append(logs[:1], logs[2:]...)
+		},
+		"reordered-logs": func(logs []*domain.AuditLog) []*domain.AuditLog {
+			logs[1], logs[2] = logs[2], logs[1]
+			return logs
+		},
+		"relinked-log": func(logs []*domain.AuditLog) []*domain.AuditLog {
+			logs[2].PrevHash = logs[0].Hash
+			return logs
+		},
+	}
+
+	for name, tamper := range testcases {
+		t.Run(name, func(t *testing.T) {
+			auditDomain := newAuditDomain(t)
+			logs := tamper(newAuditChain(t, auditDomain, 3))
+
+			require.ErrorIs(t, auditDomain.Verify(logs, nil), domain.ErrAuditChainBroken)
+		})
+	}
+}
+
+func TestAuditScrubKeepsChainVerifiable(t *testing.T) {
+	auditDomain := newAuditDomain(t)
+	logs := newAuditChain(t, auditDomain, 3)
+	hash := logs[1].Hash
+
+	require.True(t, auditDomain.Scrub(logs[1]))
+	require.Empty(t, logs[1].Metadata)
+	require.Empty(t, logs[1].MetadataSalt)
+	require.False(t, logs[1].ScrubbedAt.IsZero())
+	require.Equal(t, hash, logs[1].Hash)
+	require.NoError(t, auditDomain.Verify(logs, nil))
+
+	require.False(t, auditDomain.Scrub(logs[1]))
+
+	// Scrubbing does not hide the modification of the other fields.
+	logs[1].TargetID = "43"
+	require.ErrorIs(t, auditDomain.Verify(logs, nil), domain.ErrAuditChainBroken)
+}
+
+func TestAuditScrubVersion1Log(t *testing.T) {
+	auditDomain := newAuditDomain(t)
+
+	// Version 1 logs hash their metadata directly.
+	logs := newAuditChain(t, auditDomain, 3)
+	var prev *domain.AuditLog
+	for _, log := range logs {
+		log.Version, log.MetadataSalt, log.MetadataHash = 1, "", ""
+		auditDomain.Chain(log, prev)
+		prev = log
+	}
+	require.NoError(t, auditDomain.Verify(logs, nil))
+
+	require.True(t, auditDomain.Scrub(logs[1]))
+	require.Empty(t, logs[1].Metadata)
+	require.NoError(t, auditDomain.Verify(logs, nil))
+
+	// The link of the scrubbed log is still verified.
+	logs[1].PrevHash = "modified"
+	require.ErrorIs(t, auditDomain.Verify(logs, nil), domain.ErrAuditChainBroken)
+}
@@ -1,34 +1,107 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
 	"github.com/xybor-x/snowflake"
 )
 
+// AvatarPolicyRule describes which avatar images a group of users is allowed
+// to upload.
+type AvatarPolicyRule struct {
+	AllowedTypes []string
+	MaxSize      int64
+}
+
+// AvatarPolicyRules decides the rule of a user: the rule of the user role if
+// any, else the verified rule if the user verified their email, else the
+// default rule.
+type AvatarPolicyRules struct {
+	Default  AvatarPolicyRule
+	Verified *AvatarPolicyRule
+	Roles    map[enumdef.UserRole]AvatarPolicyRule
+}
+
 type AvatarPolicy struct {
 	UserID       snowflake.ID
 	AllowedTypes []string
 	MaxSize      int64
 }
 
+// AvatarHistory is a previous avatar of the user. The file ownership is kept
+// referenced while the entry exists, so the avatar can be restored later.
+type AvatarHistory struct {
+	ID          snowflake.ID
+	UserID      snowflake.ID
+	OwnershipID snowflake.ID
+	ReplacedAt  time.Time
+}
+
 type AvatarDomain struct {
-	AllowedTypes []string
-	MaxSize      int64
+	Snowflake      *snowflake.Node
+	PolicyRules    AvatarPolicyRules
+	HistorySize    int
+	DefaultBaseURL string
 }
 
 func NewAvatarDomain(
-	allowedTypes []string,
-	maxSize int64,
+	snowflake *snowflake.Node,
+	policyRules AvatarPolicyRules,
+	historySize int,
+	defaultBaseURL string,
 ) *AvatarDomain {
+	if policyRules.Roles == nil {
+		policyRules.Roles = map[enumdef.UserRole]AvatarPolicyRule{}
+	}
+
 	return &AvatarDomain{
-		AllowedTypes: allowedTypes,
-		MaxSize:      maxSize,
+		Snowflake:      snowflake,
+		PolicyRules:    policyRules,
+		HistorySize:    historySize,
+		DefaultBaseURL: strings.TrimSuffix(defaultBaseURL, "/"),
 	}
 }
 
-func (domain *AvatarDomain) GetPolicy(userID snowflake.ID) *AvatarPolicy {
+// GetPolicy returns the policy of the rule matching the user, see
+// AvatarPolicyRules.
+func (domain *AvatarDomain) GetPolicy(user *User) *AvatarPolicy {
+	rule, ok := domain.PolicyRules.Roles[user.Role]
+	if !ok {
+		rule = domain.PolicyRules.Default
+		if user.EmailVerified && domain.PolicyRules.Verified != nil {
+			rule = *domain.PolicyRules.Verified
+		}
+	}
+
 	return &AvatarPolicy{
-		UserID:       userID,
-		AllowedTypes: domain.AllowedTypes,
-		MaxSize:      domain.MaxSize,
+		UserID:       user.ID,
+		AllowedTypes: rule.AllowedTypes,
+		MaxSize:      rule.MaxSize,
+	}
+}
+
+func (domain *AvatarDomain) DefaultURL(userID snowflake.ID) string {
+	return fmt.Sprintf("%s/users/%s/avatar/default", domain.DefaultBaseURL, userID)
+}
+
+func (domain *AvatarDomain) NewHistory(userID, ownershipID snowflake.ID) *AvatarHistory {
+	return &AvatarHistory{
+		ID:          domain.Snowflake.Generate(),
+		UserID:      userID,
+		OwnershipID: ownershipID,
+		ReplacedAt:  time.Now(),
+	}
+}
+
+// EvictHistory returns the entries which are out of the retained history. The
+// histories must be sorted from the newest to the oldest.
+func (domain *AvatarDomain) EvictHistory(histories []*AvatarHistory) []*AvatarHistory {
+	if len(histories) <= domain.HistorySize {
+		return nil
 	}
+
+	return histories[domain.HistorySize:]
 }
@@ -0,0 +1,62 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/user-service/domain"
+)
+
+func TestAvatarGetPolicy(t *testing.T) {
+	defaultRule := domain.AvatarPolicyRule{AllowedTypes: []string{"image/png"}, MaxSize: 1}
+	verifiedRule := domain.AvatarPolicyRule{AllowedTypes: []string{"image/webp"}, MaxSize: 2}
+	adminRule := domain.AvatarPolicyRule{AllowedTypes: []string{"image/gif"}, MaxSize: 3}
+
+	testcases := map[string]struct {
+		rules domain.AvatarPolicyRules
+		user  *domain.User
+		rule  domain.AvatarPolicyRule
+	}{
+		"default": {
+			rules: domain.AvatarPolicyRules{Default: defaultRule, Verified: &verifiedRule},
+			user:  &domain.User{Role: enumdef.UserRoleUser},
+			rule:  defaultRule,
+		},
+		"verified": {
+			rules: domain.AvatarPolicyRules{Default: defaultRule, Verified: &verifiedRule},
+			user:  &domain.User{Role: enumdef.UserRoleUser, EmailVerified: true},
+			rule:  verifiedRule,
+		},
+		"verified without verified rule": {
+			rules: domain.AvatarPolicyRules{Default: defaultRule},
+			user:  &domain.User{Role: enumdef.UserRoleUser, EmailVerified: true},
+			rule:  defaultRule,
+		},
+		"role wins over verified": {
+			rules: domain.AvatarPolicyRules{
+				Default:  defaultRule,
+				Verified: &verifiedRule,
+				Roles:    map[enumdef.UserRole]domain.AvatarPolicyRule{enumdef.UserRoleAdmin: adminRule},
+			},
+			user: &domain.User{Role: enumdef.UserRoleAdmin, EmailVerified: true},
+			rule: adminRule,
+		},
+		"role without rule": {
+			rules: domain.AvatarPolicyRules{
+				Default: defaultRule,
+				Roles:   map[enumdef.UserRole]domain.AvatarPolicyRule{enumdef.UserRoleAdmin: adminRule},
+			},
+			user: &domain.User{Role: enumdef.UserRoleUser},
+			rule: defaultRule,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			policy := domain.NewAvatarDomain(nil, tc.rules, 0, "").GetPolicy(tc.user)
+			require.Equal(t, tc.rule.AllowedTypes, policy.AllowedTypes)
+			require.Equal(t, tc.rule.MaxSize, policy.MaxSize)
+		})
+	}
+}
@@ -1,12 +1,26 @@
 package domain
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const argon2idPrefix = "$argon2id$"
+
+// The limits of the argon2id parameters accepted from imported hashes, so a
+// crafted hash cannot make every sign in exhaust the server.
+const (
+	maximumArgon2Memory  = 256 * 1024 // KiB
+	maximumArgon2Time    = 16
+	maximumArgon2Threads = 16
+)
+
 func HashPassword(secret string) ([]byte, error) {
 	hashedSecret, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
 	if err != nil {
@@ -16,7 +30,28 @@ func HashPassword(secret string) ([]byte, error) {
 	return hashedSecret, nil
 }
 
+// ValidatePassword compares the secret with a bcrypt hash, or with an
+// argon2id hash imported from another system. An empty hash, the hash of an
+// erased user, matches no secret.
 func ValidatePassword(hashedSecret, secret string) error {
+	if hashedSecret == "" {
+		return ErrMismatchedPassword
+	}
+
+	if strings.HasPrefix(hashedSecret, argon2idPrefix) {
+		hash, err := parseArgon2idHash(hashedSecret)
+		if err != nil {
+			return fmt.Errorf("failed to parse hashed secret: %w", err)
+		}
+
+		key := argon2.IDKey([]byte(secret), hash.salt, hash.time, hash.memory, hash.threads, uint32(len(hash.key)))
+		if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+			return ErrMismatchedPassword
+		}
+
+		return nil
+	}
+
 	err := bcrypt.CompareHashAndPassword([]byte(hashedSecret), []byte(secret))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
@@ -28,3 +63,62 @@ func ValidatePassword(hashedSecret, secret string) error {
 
 	return nil
 }
+
+// ValidatePasswordHash checks that a hash created by another system can be
+// used to validate passwords.
+func ValidatePasswordHash(hashedSecret string) error {
+	if strings.HasPrefix(hashedSecret, argon2idPrefix) {
+		_, err := parseArgon2idHash(hashedSecret)
+		return err
+	}
+
+	if _, err := bcrypt.Cost([]byte(hashedSecret)); err != nil {
+		return fmt.Errorf("%w: require a bcrypt or argon2id hash", ErrPasswordHashInvalid)
+	}
+
+	return nil
+}
+
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2idHash parses a hash in the PHC string format, for example
+// $argon2id$v=19$m=65536,t=3,p=4$<base64 salt>$<base64 key>.
+func parseArgon2idHash(hashedSecret string) (*argon2idHash, error) {
+	parts := strings.Split(hashedSecret, "$")
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("%w: malformed argon2id hash", ErrPasswordHashInvalid)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported argon2id version", ErrPasswordHashInvalid)
+	}
+
+	hash := &argon2idHash{}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed argon2id parameters", ErrPasswordHashInvalid)
+	}
+
+	if hash.memory == 0 || hash.memory > maximumArgon2Memory ||
+		hash.time == 0 || hash.time > maximumArgon2Time ||
+		hash.threads == 0 || hash.threads > maximumArgon2Threads {
+		return nil, fmt.Errorf("%w: argon2id parameters out of range", ErrPasswordHashInvalid)
+	}
+
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil || len(hash.salt) == 0 {
+		return nil, fmt.Errorf("%w: malformed argon2id salt", ErrPasswordHashInvalid)
+	}
+
+	if hash.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(hash.key) < 16 {
+		return nil, fmt.Errorf("%w: malformed argon2id key", ErrPasswordHashInvalid)
+	}
+
+	return hash, nil
+}
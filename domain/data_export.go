@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	DataExportStatusPending   = "pending"
+	DataExportStatusCompleted = "completed"
+	DataExportStatusFailed    = "failed"
+)
+
+// DataExportSchemaVersion is increased on incompatible changes of the archive.
+const DataExportSchemaVersion = 1
+
+// DataExport is an archive of the data of a user, generated by the worker for
+// the accounts too large to be exported within a request. The archive is kept
+// until ExpiresAt.
+type DataExport struct {
+	ID          snowflake.ID
+	UserID      snowflake.ID
+	Status      string
+	Archive     []byte
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// UserDataArchive is everything this service stores about a user. The avatar
+// url is not stored with the archive, it is presigned again on every download.
+// The password hash is never exported.
+type UserDataArchive struct {
+	SchemaVersion int                     `json:"schema_version"`
+	GeneratedAt   time.Time               `json:"generated_at"`
+	Profile       UserDataProfile         `json:"profile"`
+	Avatar        UserDataAvatar          `json:"avatar"`
+	AvatarHistory []UserDataAvatarHistory `json:"avatar_history"`
+	Identities    []UserDataIdentity      `json:"linked_identities"`
+	AuditLogs     []UserDataAuditLog      `json:"audit_logs"`
+}
+
+type UserDataProfile struct {
+	ID                    snowflake.ID `json:"id"`
+	Username              string       `json:"username"`
+	DisplayName           string       `json:"display_name"`
+	Role                  string       `json:"role"`
+	Active                bool         `json:"active"`
+	PasswordResetRequired bool         `json:"password_reset_required"`
+	Email                 string       `json:"email,omitempty"`
+	Pending               bool         `json:"pending"`
+}
+
+// UserDataAvatar has no ownership id for the default avatar.
+type UserDataAvatar struct {
+	OwnershipID snowflake.ID `json:"ownership_id,omitempty"`
+	URL         string       `json:"url,omitempty"`
+}
+
+type UserDataAvatarHistory struct {
+	OwnershipID snowflake.ID `json:"ownership_id"`
+	ReplacedAt  time.Time    `json:"replaced_at"`
+}
+
+type UserDataIdentity struct {
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email,omitempty"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+type UserDataAuditLog struct {
+	ID         snowflake.ID      `json:"id"`
+	ActorID    snowflake.ID      `json:"actor_id,omitempty"`
+	ActorType  string            `json:"actor_type,omitempty"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id,omitempty"`
+	ClientID   string            `json:"client_id,omitempty"`
+	Metadata   map[string]string `json:"metadata"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type DataExportDomain struct {
+	Snowflake *snowflake.Node
+
+	// Expiration is the duration during which a generated archive can be
+	// downloaded.
+	Expiration time.Duration
+}
+
+func NewDataExportDomain(snowflake *snowflake.Node, expiration time.Duration) *DataExportDomain {
+	return &DataExportDomain{Snowflake: snowflake, Expiration: expiration}
+}
+
+func (domain *DataExportDomain) New(userID snowflake.ID) *DataExport {
+	return &DataExport{
+		ID:        domain.Snowflake.Generate(),
+		UserID:    userID,
+		Status:    DataExportStatusPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (domain *DataExportDomain) NewArchive(
+	user *User,
+	histories []*AvatarHistory,
+	identities []*LinkedIdentity,
+	logs []*AuditLog,
+) *UserDataArchive {
+	archive := &UserDataArchive{
+		SchemaVersion: DataExportSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Profile: UserDataProfile{
+			ID:                    user.ID,
+			Username:              user.Username,
+			DisplayName:           user.DisplayName,
+			Role:                  user.Role.String(),
+			Active:                user.Active,
+			PasswordResetRequired: user.PasswordResetRequired,
+			Email:                 user.Email,
+			Pending:               user.Pending,
+		},
+		Avatar:        UserDataAvatar{OwnershipID: user.Avatar},
+		AvatarHistory: []UserDataAvatarHistory{},
+		Identities:    []UserDataIdentity{},
+		AuditLogs:     []UserDataAuditLog{},
+	}
+
+	for _, history := range histories {
+		archive.AvatarHistory = append(archive.AvatarHistory, UserDataAvatarHistory{
+			OwnershipID: history.OwnershipID,
+			ReplacedAt:  history.ReplacedAt.UTC(),
+		})
+	}
+
+	for _, identity := range identities {
+		archive.Identities = append(archive.Identities, UserDataIdentity{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+			LinkedAt: identity.LinkedAt.UTC(),
+		})
+	}
+
+	for _, log := range logs {
+		archive.AuditLogs = append(archive.AuditLogs, UserDataAuditLog{
+			ID:         log.ID,
+			ActorID:    log.ActorID,
+			ActorType:  log.ActorType,
+			Action:     log.Action,
+			TargetType: log.TargetType,
+			TargetID:   log.TargetID,
+			ClientID:   log.ClientID,
+			Metadata:   log.Metadata,
+			CreatedAt:  log.CreatedAt.UTC(),
+		})
+	}
+
+	return archive
+}
+
+// Complete stores the archive in the export, it can be downloaded until the
+// export expires.
+func (domain *DataExportDomain) Complete(export *DataExport, archive *UserDataArchive) error {
+	content, err := domain.EncodeArchive(archive)
+	if err != nil {
+		return err
+	}
+
+	export.Status = DataExportStatusCompleted
+	export.Archive = content
+	export.CompletedAt = time.Now()
+	export.ExpiresAt = export.CompletedAt.Add(domain.Expiration)
+	return nil
+}
+
+// Fail records the reason of a failed export, which is shown to the user. The
+// user can request a new export.
+func (domain *DataExportDomain) Fail(export *DataExport, reason string) {
+	export.Status = DataExportStatusFailed
+	export.Error = reason
+	export.CompletedAt = time.Now()
+	export.ExpiresAt = export.CompletedAt.Add(domain.Expiration)
+}
+
+func (domain *DataExportDomain) IsExpired(export *DataExport) bool {
+	return !export.ExpiresAt.IsZero() && time.Now().After(export.ExpiresAt)
+}
+
+func (domain *DataExportDomain) EncodeArchive(archive *UserDataArchive) ([]byte, error) {
+	content, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal archive: %w", err)
+	}
+
+	return content, nil
+}
+
+func (domain *DataExportDomain) DecodeArchive(export *DataExport) (*UserDataArchive, error) {
+	if export.Status != DataExportStatusCompleted {
+		return nil, fmt.Errorf("%w: the export is %s", ErrDataExportNotCompleted, export.Status)
+	}
+
+	archive := &UserDataArchive{}
+	if err := json.Unmarshal(export.Archive, archive); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal archive: %w", err)
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/todennus/x/mime"
+)
+
+const (
+	DefaultAvatarFormatSVG = "svg"
+	DefaultAvatarFormatPNG = "png"
+
+	MinimumDefaultAvatarSize = 16
+	MaximumDefaultAvatarSize = 512
+	DefaultDefaultAvatarSize = 128
+
+	identiconGrid = 5
+)
+
+const imageSVG = "image/svg+xml"
+
+var defaultAvatarForeground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+// DefaultAvatar is a placeholder avatar generated for users who have not
+// uploaded their own one. It is derived only from the user id and display
+// name, so the same user always gets the same image.
+type DefaultAvatar struct {
+	Initials   string
+	Background color.RGBA
+	Pattern    [identiconGrid][identiconGrid]bool
+}
+
+// Render returns the content type and the encoded image in the given format.
+// SVG renders the initials, PNG renders an identicon because it doesn't need
+// any font.
+func (avatar *DefaultAvatar) Render(format string, size int) (string, []byte, error) {
+	if size < MinimumDefaultAvatarSize || size > MaximumDefaultAvatarSize {
+		return "", nil, fmt.Errorf("%w: require size in range [%d, %d]",
+			ErrDefaultAvatarInvalid, MinimumDefaultAvatarSize, MaximumDefaultAvatarSize)
+	}
+
+	switch format {
+	case DefaultAvatarFormatSVG:
+		return imageSVG, avatar.svg(size), nil
+	case DefaultAvatarFormatPNG:
+		content, err := avatar.png(size)
+		return mime.ImagePNG, content, err
+	default:
+		return "", nil, fmt.Errorf("%w: not support format %s", ErrDefaultAvatarInvalid, format)
+	}
+}
+
+func (avatar *DefaultAvatar) svg(size int) []byte {
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 100 100">`+
+			`<rect width="100" height="100" fill="%s"/>`+
+			`<text x="50" y="50" dy=".35em" text-anchor="middle" font-family="sans-serif" font-size="42" fill="%s">%s</text>`+
+			`</svg>`,
+		size, size, hexColor(avatar.Background), hexColor(defaultAvatarForeground), html.EscapeString(avatar.Initials),
+	))
+}
+
+func (avatar *DefaultAvatar) png(size int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	// Keep a half-cell padding around the grid.
+	cell := float64(size) / (identiconGrid + 1)
+	padding := cell / 2
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			col := int(math.Floor((float64(x) - padding) / cell))
+			row := int(math.Floor((float64(y) - padding) / cell))
+
+			inGrid := col >= 0 && col < identiconGrid && row >= 0 && row < identiconGrid
+			if inGrid && avatar.Pattern[row][col] {
+				img.SetRGBA(x, y, defaultAvatarForeground)
+			} else {
+				img.SetRGBA(x, y, avatar.Background)
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (domain *AvatarDomain) NewDefault(user *User) *DefaultAvatar {
+	digest := sha256.Sum256(binary.BigEndian.AppendUint64(nil, uint64(user.ID.Int64())))
+
+	avatar := &DefaultAvatar{
+		Initials:   initials(user.DisplayName),
+		Background: hslColor(float64(binary.BigEndian.Uint16(digest[:2])%360), 0.55, 0.45),
+	}
+
+	// The identicon is horizontally symmetric, so only the left half (and the
+	// middle column) is taken from the digest.
+	bit := 0
+	for col := 0; col < (identiconGrid+1)/2; col++ {
+		for row := 0; row < identiconGrid; row++ {
+			on := digest[2+bit/8]&(1<<(bit%8)) != 0
+			avatar.Pattern[row][col] = on
+			avatar.Pattern[row][identiconGrid-1-col] = on
+			bit++
+		}
+	}
+
+	return avatar
+}
+
+func initials(displayName string) string {
+	words := strings.FieldsFunc(displayName, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '_'
+	})
+
+	switch len(words) {
+	case 0:
+		return "?"
+	case 1:
+		return strings.ToUpper(string([]rune(words[0])[:1]))
+	default:
+		first := []rune(words[0])[:1]
+		last := []rune(words[len(words)-1])[:1]
+		return strings.ToUpper(string(first) + string(last))
+	}
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func hslColor(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,82 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+func newDefaultAvatar(id snowflake.ID, displayName string) *domain.DefaultAvatar {
+	avatarDomain := domain.NewAvatarDomain(nil, domain.AvatarPolicyRules{}, 0, "http://localhost")
+	return avatarDomain.NewDefault(&domain.User{ID: id, DisplayName: displayName})
+}
+
+func TestDefaultAvatarInitials(t *testing.T) {
+	testcases := map[string]struct {
+		displayName string
+		initials    string
+	}{
+		"empty":            {displayName: "", initials: "?"},
+		"only spaces":      {displayName: "   ", initials: "?"},
+		"one word":         {displayName: "alice", initials: "A"},
+		"two words":        {displayName: "alice liddell", initials: "AL"},
+		"first and last":   {displayName: "Alice Pleasance Liddell", initials: "AL"},
+		"underscores":      {displayName: "alice_liddell", initials: "AL"},
+		"extra whitespace": {displayName: "  alice \t liddell  ", initials: "AL"},
+		"non latin":        {displayName: "élise ünal", initials: "ÉÜ"},
+		"multibyte":        {displayName: "山田 太郎", initials: "山太"},
+		"markup":           {displayName: "<b> &amp", initials: "<&"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.initials, newDefaultAvatar(1, tc.displayName).Initials)
+		})
+	}
+}
+
+func TestDefaultAvatarBackground(t *testing.T) {
+	testcases := map[string]struct {
+		id         snowflake.ID
+		background string
+	}{
+		"first id":     {id: 1, background: "#b2348c"},
+		"second id":    {id: 2, background: "#9034b2"},
+		"snowflake id": {id: 336379750404866048, background: "#8434b2"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// The colour is derived from the id only, renaming the user keeps it.
+			for _, displayName := range []string{"", "alice", "bob marley"} {
+				avatar := newDefaultAvatar(tc.id, displayName)
+				_, content, err := avatar.Render(domain.DefaultAvatarFormatSVG, domain.DefaultDefaultAvatarSize)
+				require.NoError(t, err)
+				require.Contains(t, string(content), `fill="`+tc.background+`"`)
+			}
+		})
+	}
+}
+
+func TestDefaultAvatarRender(t *testing.T) {
+	avatar := newDefaultAvatar(1, "<b> &amp")
+
+	contentType, content, err := avatar.Render(domain.DefaultAvatarFormatSVG, 64)
+	require.NoError(t, err)
+	require.Equal(t, "image/svg+xml", contentType)
+	require.Contains(t, string(content), `width="64"`)
+	require.Contains(t, string(content), ">&lt;&amp;</text>")
+
+	contentType, content, err = avatar.Render(domain.DefaultAvatarFormatPNG, 64)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", contentType)
+	require.Equal(t, "\x89PNG", string(content[:4]))
+
+	_, _, err = avatar.Render("gif", 64)
+	require.ErrorIs(t, err, domain.ErrDefaultAvatarInvalid)
+
+	_, _, err = avatar.Render(domain.DefaultAvatarFormatSVG, domain.MaximumDefaultAvatarSize+1)
+	require.ErrorIs(t, err, domain.ErrDefaultAvatarInvalid)
+}
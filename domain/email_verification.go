@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/todennus/x/token"
+	"github.com/xybor-x/snowflake"
+)
+
+// EmailVerificationTokenType distinguishes the email verification tokens from
+// the other tokens signed by the same engine.
+const EmailVerificationTokenType = "email_verification"
+
+// EmailVerificationToken are the claims of the token sent to the email of the
+// user, it proves that the user receives the emails sent to Email. The user is
+// not the sub claim, so the token is never accepted as an access token.
+type EmailVerificationToken struct {
+	ID        string `json:"jti"`
+	Type      string `json:"typ"`
+	UserID    string `json:"uid"`
+	Email     string `json:"email"`
+	ExpiresAt int    `json:"exp"`
+}
+
+func (claims *EmailVerificationToken) Valid() error {
+	if claims.Type != EmailVerificationTokenType {
+		return fmt.Errorf("%w: not an email verification token", token.ErrTokenInvalidFormat)
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("%w: invalid jti", token.ErrTokenInvalidFormat)
+	}
+
+	if _, err := snowflake.ParseString(claims.UserID); err != nil {
+		return fmt.Errorf("%w: invalid uid", token.ErrTokenInvalidFormat)
+	}
+
+	if time.Unix(int64(claims.ExpiresAt), 0).Before(time.Now()) {
+		return token.ErrTokenExpired
+	}
+
+	return nil
+}
+
+type EmailVerificationDomain struct {
+	Snowflake  *snowflake.Node
+	Expiration time.Duration
+
+	// URL is the page verifying the email, the token is appended to its
+	// query.
+	URL string
+}
+
+func NewEmailVerificationDomain(snowflake *snowflake.Node, expiration time.Duration, url string) *EmailVerificationDomain {
+	return &EmailVerificationDomain{Snowflake: snowflake, Expiration: expiration, URL: url}
+}
+
+// NewToken creates the claims verifying the current email of the user, who
+// must have an email.
+func (domain *EmailVerificationDomain) NewToken(user *User) *EmailVerificationToken {
+	return &EmailVerificationToken{
+		ID:        domain.Snowflake.Generate().String(),
+		Type:      EmailVerificationTokenType,
+		UserID:    user.ID.String(),
+		Email:     CanonicalEmail(user.Email),
+		ExpiresAt: int(time.Now().Add(domain.Expiration).Unix()),
+	}
+}
+
+// NewMail creates the email holding the verification link of the signed
+// token.
+func (domain *EmailVerificationDomain) NewMail(user *User, signedToken string) (*Mail, error) {
+	link, err := url.Parse(domain.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email verification url: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", signedToken)
+	link.RawQuery = query.Encode()
+
+	return &Mail{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Follow this link to verify the email of %s. It expires in %d minutes.\n\n"+
+			"%s\n\n"+
+			"If you did not register, you can ignore this email.\n",
+			user.DisplayName, user.Username, int(domain.Expiration.Minutes()), link.String()),
+	}, nil
+}
+
+// NewTakenMail creates the email telling the owner of an email that someone
+// tried to register with it. The registration does not tell that the email is
+// taken, so the email cannot be enumerated.
+func (domain *EmailVerificationDomain) NewTakenMail(user *User) *Mail {
+	return &Mail{
+		To:      user.Email,
+		Subject: "Someone tried to register with your email",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone tried to register a new user with this email, which is already used by %s. "+
+			"No user was created.\n\n"+
+			"If it was you, sign in as %s instead. Otherwise, you can ignore this email.\n",
+			user.DisplayName, user.Username, user.Username),
+	}
+}
+
+// CheckToken checks that the token verifies the current email of the user.
+func (domain *EmailVerificationDomain) CheckToken(user *User, claims *EmailVerificationToken) error {
+	if user.ID.String() != claims.UserID {
+		return fmt.Errorf("%w: the token belongs to another user", ErrEmailVerificationTokenUnusable)
+	}
+
+	if user.Email == "" || CanonicalEmail(user.Email) != claims.Email {
+		return fmt.Errorf("%w: the email has changed", ErrEmailVerificationTokenUnusable)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the user as verified. It reports whether the
+// user was changed.
+func (domain *UserDomain) VerifyEmail(user *User) bool {
+	if user.EmailVerified {
+		return false
+	}
+
+	user.EmailVerified = true
+	user.UpdatedAt = domain.now()
+	return true
+}
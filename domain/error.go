@@ -11,4 +11,59 @@ var (
 	ErrDisplayNameInvalid = fmt.Errorf("%winvalid display name", errordef.ErrDomainKnown)
 	ErrPasswordInvalid    = fmt.Errorf("%winvalid password", errordef.ErrDomainKnown)
 	ErrMismatchedPassword = fmt.Errorf("%wmismatched password", errordef.ErrDomainKnown)
+	ErrPasswordReused     = fmt.Errorf("%wpassword reused", errordef.ErrDomainKnown)
+	ErrRoleInvalid        = fmt.Errorf("%winvalid role", errordef.ErrDomainKnown)
+	ErrUserErased         = fmt.Errorf("%wuser erased", errordef.ErrDomainKnown)
+	ErrEmailInvalid       = fmt.Errorf("%winvalid email", errordef.ErrDomainKnown)
+
+	ErrSearchQueryInvalid = fmt.Errorf("%winvalid search query", errordef.ErrDomainKnown)
+
+	ErrPasswordPolicyInvalid = fmt.Errorf("%winvalid password policy", errordef.ErrDomainKnown)
+
+	ErrPasswordHashInvalid = fmt.Errorf("%winvalid password hash", errordef.ErrDomainKnown)
+	ErrImportFormatInvalid = fmt.Errorf("%winvalid import format", errordef.ErrDomainKnown)
+	ErrImportRowInvalid    = fmt.Errorf("%winvalid import row", errordef.ErrDomainKnown)
+
+	ErrDefaultAvatarInvalid = fmt.Errorf("%winvalid default avatar", errordef.ErrDomainKnown)
+
+	ErrOutboxPayloadInvalid = fmt.Errorf("%winvalid outbox payload", errordef.ErrDomainKnown)
+
+	ErrWebhookURLInvalid       = fmt.Errorf("%winvalid webhook url", errordef.ErrDomainKnown)
+	ErrWebhookEventTypeInvalid = fmt.Errorf("%winvalid webhook event type", errordef.ErrDomainKnown)
+
+	ErrAuditChainBroken = fmt.Errorf("%wbroken audit log chain", errordef.ErrDomainKnown)
+
+	ErrScimFilterInvalid = fmt.Errorf("%winvalid filter", errordef.ErrDomainKnown)
+	ErrScimPathInvalid   = fmt.Errorf("%winvalid path", errordef.ErrDomainKnown)
+	ErrScimValueInvalid  = fmt.Errorf("%winvalid value", errordef.ErrDomainKnown)
+	ErrScimMutability    = fmt.Errorf("%wimmutable attribute", errordef.ErrDomainKnown)
+
+	ErrRegistrationModeInvalid = fmt.Errorf("%winvalid registration mode", errordef.ErrDomainKnown)
+	ErrRegistrationClosed      = fmt.Errorf("%wregistration closed", errordef.ErrDomainKnown)
+	ErrInvitationRequired      = fmt.Errorf("%winvitation required", errordef.ErrDomainKnown)
+	ErrEmailDomainNotAllowed   = fmt.Errorf("%wemail domain not allowed", errordef.ErrDomainKnown)
+
+	ErrEmailVerificationTokenUnusable = fmt.Errorf("%wunusable email verification token", errordef.ErrDomainKnown)
+
+	ErrInvitationExpirationInvalid = fmt.Errorf("%winvalid invitation expiration", errordef.ErrDomainKnown)
+	ErrInvitationUnavailable       = fmt.Errorf("%winvitation unavailable", errordef.ErrDomainKnown)
+
+	ErrPersonalAccessTokenNameInvalid       = fmt.Errorf("%winvalid personal access token name", errordef.ErrDomainKnown)
+	ErrPersonalAccessTokenScopeInvalid      = fmt.Errorf("%winvalid personal access token scope", errordef.ErrDomainKnown)
+	ErrPersonalAccessTokenExpirationInvalid = fmt.Errorf("%winvalid personal access token expiration", errordef.ErrDomainKnown)
+
+	ErrIdentityProviderInvalid = fmt.Errorf("%winvalid identity provider", errordef.ErrDomainKnown)
+	ErrExternalSubjectInvalid  = fmt.Errorf("%winvalid external subject", errordef.ErrDomainKnown)
+	ErrLastLoginMethod         = fmt.Errorf("%wlast login method", errordef.ErrDomainKnown)
+
+	ErrLDAPEntryInvalid = fmt.Errorf("%winvalid ldap entry", errordef.ErrDomainKnown)
+
+	ErrPasswordResetTokenUnusable = fmt.Errorf("%wunusable password reset token", errordef.ErrDomainKnown)
+	ErrReauthenticationRequired   = fmt.Errorf("%wre-authentication required", errordef.ErrDomainKnown)
+
+	ErrPasskeyNameInvalid    = fmt.Errorf("%winvalid passkey name", errordef.ErrDomainKnown)
+	ErrPasskeySessionInvalid = fmt.Errorf("%winvalid passkey session", errordef.ErrDomainKnown)
+	ErrPasskeyCloned         = fmt.Errorf("%wpasskey cloned", errordef.ErrDomainKnown)
+
+	ErrDataExportNotCompleted = fmt.Errorf("%wdata export not completed", errordef.ErrDomainKnown)
 )
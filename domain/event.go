@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	EventUserCreated       = "user.created"
+	EventUserAvatarChanged = "user.avatar_changed"
+	EventUserUpdated       = "user.updated"
+	EventUserErased        = "user.erased"
+)
+
+// EventTypes lists every event type this service publishes.
+var EventTypes = []string{
+	EventUserCreated,
+	EventUserAvatarChanged,
+	EventUserUpdated,
+	EventUserErased,
+}
+
+// EventVersion is increased whenever the data of an event changes in an
+// incompatible way.
+const EventVersion = 1
+
+// Event is the envelope of every domain event published by this service.
+// Consumers should deduplicate events by ID because the delivery is
+// at-least-once.
+type Event struct {
+	ID         snowflake.ID    `json:"id"`
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	UserID     snowflake.ID    `json:"user_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type UserCreatedData struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role"`
+}
+
+// UserUpdatedData holds the profile after the update.
+type UserUpdatedData struct {
+	DisplayName string `json:"display_name"`
+	Active      bool   `json:"active"`
+}
+
+// UserErasedData holds the pseudonyms which replaced the personal data of the
+// user, consumers should erase their own copies of that data.
+type UserErasedData struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+// UserAvatarChangedData holds zero ownership ids for the default avatar.
+type UserAvatarChangedData struct {
+	OwnershipID         snowflake.ID `json:"ownership_id"`
+	PreviousOwnershipID snowflake.ID `json:"previous_ownership_id"`
+}
+
+type EventDomain struct {
+	Snowflake *snowflake.Node
+}
+
+func NewEventDomain(snowflake *snowflake.Node) *EventDomain {
+	return &EventDomain{Snowflake: snowflake}
+}
+
+func (domain *EventDomain) New(eventType string, userID snowflake.ID, data any) (*Event, error) {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	return &Event{
+		ID:         domain.Snowflake.Generate(),
+		Type:       eventType,
+		Version:    EventVersion,
+		UserID:     userID,
+		OccurredAt: time.Now(),
+		Data:       content,
+	}, nil
+}
+
+func (domain *EventDomain) NewUserCreated(user *User) (*Event, error) {
+	return domain.New(EventUserCreated, user.ID, &UserCreatedData{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Role:        user.Role.String(),
+	})
+}
+
+func (domain *EventDomain) NewUserAvatarChanged(userID, previousOwnershipID, ownershipID snowflake.ID) (*Event, error) {
+	return domain.New(EventUserAvatarChanged, userID, &UserAvatarChangedData{
+		OwnershipID:         ownershipID,
+		PreviousOwnershipID: previousOwnershipID,
+	})
+}
+
+func (domain *EventDomain) NewUserUpdated(user *User) (*Event, error) {
+	return domain.New(EventUserUpdated, user.ID, &UserUpdatedData{
+		DisplayName: user.DisplayName,
+		Active:      user.Active,
+	})
+}
+
+func (domain *EventDomain) NewUserErased(user *User) (*Event, error) {
+	return domain.New(EventUserErased, user.ID, &UserErasedData{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+	})
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/x/token"
+	"github.com/xybor-x/enum"
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusRedeemed = "redeemed"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+// InvitationTokenType distinguishes the invitation tokens from the other
+// tokens signed by the same engine.
+const InvitationTokenType = "invitation"
+
+// Invitation lets someone create a user with the given role until ExpiresAt.
+// It is redeemed at most once.
+type Invitation struct {
+	ID         snowflake.ID
+	Role       enumdef.UserRole
+	CreatedBy  snowflake.ID
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	RedeemedAt time.Time
+	RedeemedBy snowflake.ID
+	RevokedAt  time.Time
+}
+
+// InvitationToken are the claims of the token given to the invitee, it only
+// refers to the invitation.
+type InvitationToken struct {
+	ID        string `json:"jti"`
+	Type      string `json:"typ"`
+	ExpiresAt int    `json:"exp"`
+}
+
+func (claims *InvitationToken) Valid() error {
+	if claims.Type != InvitationTokenType {
+		return fmt.Errorf("%w: not an invitation token", token.ErrTokenInvalidFormat)
+	}
+
+	if _, err := snowflake.ParseString(claims.ID); err != nil {
+		return fmt.Errorf("%w: invalid jti", token.ErrTokenInvalidFormat)
+	}
+
+	if time.Unix(int64(claims.ExpiresAt), 0).Before(time.Now()) {
+		return token.ErrTokenExpired
+	}
+
+	return nil
+}
+
+type InvitationDomain struct {
+	Snowflake *snowflake.Node
+
+	// MaxExpiration is the longest duration an invitation can be valid for.
+	MaxExpiration time.Duration
+}
+
+func NewInvitationDomain(snowflake *snowflake.Node, maxExpiration time.Duration) *InvitationDomain {
+	return &InvitationDomain{Snowflake: snowflake, MaxExpiration: maxExpiration}
+}
+
+// New creates an invitation for a user of the role, which expires after the
+// given duration.
+func (domain *InvitationDomain) New(createdBy snowflake.ID, role string, expiration time.Duration) (*Invitation, error) {
+	userRole, ok := enum.FromString[enumdef.UserRole](role)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown role %s", ErrRoleInvalid, role)
+	}
+
+	if expiration <= 0 || expiration > domain.MaxExpiration {
+		return nil, fmt.Errorf("%w: the expiration must be in range (0, %s]",
+			ErrInvitationExpirationInvalid, domain.MaxExpiration)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	return &Invitation{
+		ID:        domain.Snowflake.Generate(),
+		Role:      userRole,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiration),
+	}, nil
+}
+
+func (domain *InvitationDomain) NewToken(invitation *Invitation) *InvitationToken {
+	return &InvitationToken{
+		ID:        invitation.ID.String(),
+		Type:      InvitationTokenType,
+		ExpiresAt: int(invitation.ExpiresAt.Unix()),
+	}
+}
+
+func (domain *InvitationDomain) Status(invitation *Invitation) string {
+	switch {
+	case !invitation.RedeemedAt.IsZero():
+		return InvitationStatusRedeemed
+	case !invitation.RevokedAt.IsZero():
+		return InvitationStatusRevoked
+	case !time.Now().Before(invitation.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
+
+// Redeem marks the invitation as used by the user.
+func (domain *InvitationDomain) Redeem(invitation *Invitation, userID snowflake.ID) error {
+	if status := domain.Status(invitation); status != InvitationStatusPending {
+		return fmt.Errorf("%w: the invitation is %s", ErrInvitationUnavailable, status)
+	}
+
+	invitation.RedeemedAt = time.Now().UTC()
+	invitation.RedeemedBy = userID
+	return nil
+}
+
+// Revoke prevents the invitation from being redeemed.
+func (domain *InvitationDomain) Revoke(invitation *Invitation) error {
+	if status := domain.Status(invitation); status != InvitationStatusPending {
+		return fmt.Errorf("%w: the invitation is %s", ErrInvitationUnavailable, status)
+	}
+
+	invitation.RevokedAt = time.Now().UTC()
+	return nil
+}
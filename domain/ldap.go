@@ -0,0 +1,100 @@
+package domain
+
+import "fmt"
+
+// MaximumLDAPIDLength is the longest value of the id attribute of an entry.
+const MaximumLDAPIDLength = 255
+
+// LDAPEntry is a user of the LDAP directory. ID is the value of a stable
+// attribute of the entry (entryUUID, objectGUID...), it survives the renames
+// of the entry.
+type LDAPEntry struct {
+	ID          string
+	Username    string
+	DisplayName string
+	Email       string
+}
+
+// NewFromLDAP creates a user from an entry of the directory. The user has no
+// local password, the directory validates it. Like imported users, the user
+// may take a reserved username.
+func (domain *UserDomain) NewFromLDAP(entry *LDAPEntry) (*User, error) {
+	if err := validateLDAPID(entry.ID); err != nil {
+		return nil, err
+	}
+
+	if err := domain.validateUsername(entry.Username); err != nil {
+		return nil, err
+	}
+
+	hashedPass, err := domain.randomHashedPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	user := domain.newUser(entry.Username, hashedPass)
+	user.HasPassword = false
+	user.LDAPID = entry.ID
+	domain.copyLDAPProfile(user, entry)
+
+	return user, nil
+}
+
+// SyncLDAP updates the user sourced from LDAP with the entry. It reports
+// whether the user was changed. The user is only activated when created, a
+// user deactivated locally, or disabled when their entry left the subtree,
+// stays inactive until an admin activates them again.
+func (domain *UserDomain) SyncLDAP(user *User, entry *LDAPEntry) (bool, error) {
+	if user.Username != entry.Username {
+		if err := domain.validateUsername(entry.Username); err != nil {
+			return false, err
+		}
+	}
+
+	before := *user
+	user.Username = entry.Username
+	domain.copyLDAPProfile(user, entry)
+
+	if *user == before {
+		return false, nil
+	}
+
+	user.UpdatedAt = domain.now()
+	return true, nil
+}
+
+// DisableLDAP deactivates the user sourced from LDAP whose entry left the
+// synchronized subtree. It reports whether the user was changed.
+func (domain *UserDomain) DisableLDAP(user *User) bool {
+	if !user.Active {
+		return false
+	}
+
+	domain.SetActive(user, false)
+	return true
+}
+
+// copyLDAPProfile copies the display name and the email of the entry, the
+// invalid ones are replaced by the username and dropped respectively.
+func (domain *UserDomain) copyLDAPProfile(user *User, entry *LDAPEntry) {
+	user.DisplayName = user.Username
+	if domain.validateDisplayName(entry.DisplayName) == nil {
+		user.DisplayName = entry.DisplayName
+	}
+
+	user.Email = ""
+	if email, err := normalizeEmail(entry.Email); err == nil {
+		user.Email = email
+	}
+
+	// The directory is trusted with the emails of its entries.
+	user.EmailVerified = user.Email != ""
+}
+
+func validateLDAPID(id string) error {
+	if id == "" || len(id) > MaximumLDAPIDLength {
+		return fmt.Errorf("%w: require between 1 and %d characters", ErrLDAPEntryInvalid, MaximumLDAPIDLength)
+	}
+
+	return nil
+}
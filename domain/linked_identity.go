@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const MaximumExternalSubjectLength = 255
+
+// federatedUsernameFallback is the base of the username of a federated user
+// whose claims give no usable username.
+const federatedUsernameFallback = "user"
+
+// LinkedIdentity maps the subject of an external identity provider to a user,
+// so the user can sign in through the provider.
+type LinkedIdentity struct {
+	ID       snowflake.ID
+	UserID   snowflake.ID
+	Provider string
+	Subject  string
+	Email    string
+	LinkedAt time.Time
+}
+
+// ExternalClaims are the claims of an external identity, as verified by the
+// auth server.
+type ExternalClaims struct {
+	Provider          string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	DisplayName       string
+}
+
+type LinkedIdentityDomain struct {
+	Snowflake *snowflake.Node
+
+	// Providers is the set of the lowercase names of the identity providers
+	// which can be linked.
+	Providers map[string]bool
+}
+
+func NewLinkedIdentityDomain(snowflake *snowflake.Node, providers []string) *LinkedIdentityDomain {
+	allowed := map[string]bool{}
+	for _, provider := range providers {
+		if provider != "" {
+			allowed[strings.ToLower(provider)] = true
+		}
+	}
+
+	return &LinkedIdentityDomain{Snowflake: snowflake, Providers: allowed}
+}
+
+// New links the external identity to the user. The email of the identity is
+// only informative, it is dropped if it is not an email address.
+func (domain *LinkedIdentityDomain) New(userID snowflake.ID, claims *ExternalClaims) (*LinkedIdentity, error) {
+	provider := strings.ToLower(claims.Provider)
+	if !domain.Providers[provider] {
+		return nil, fmt.Errorf("%w: unknown provider %s", ErrIdentityProviderInvalid, claims.Provider)
+	}
+
+	if claims.Subject == "" || len(claims.Subject) > MaximumExternalSubjectLength {
+		return nil, fmt.Errorf("%w: require a subject of at most %d characters",
+			ErrExternalSubjectInvalid, MaximumExternalSubjectLength)
+	}
+
+	identity := &LinkedIdentity{
+		ID:       domain.Snowflake.Generate(),
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		LinkedAt: time.Now().UTC(),
+	}
+
+	if email, err := normalizeEmail(claims.Email); err == nil {
+		identity.Email = email
+	}
+
+	return identity, nil
+}
+
+// NormalizeProvider returns the stored form of the provider name.
+func (domain *LinkedIdentityDomain) NormalizeProvider(provider string) string {
+	return strings.ToLower(provider)
+}
+
+// CheckUnlink rejects removing the last way the user can sign in, the
+// identities and the passkeys are all those of the user. The users sourced
+// from LDAP sign in with the password of the directory.
+func (domain *LinkedIdentityDomain) CheckUnlink(user *User, identities []*LinkedIdentity, passkeys []*Passkey) error {
+	if !user.HasPassword && user.LDAPID == "" && len(identities) <= 1 && len(passkeys) == 0 {
+		return fmt.Errorf("%w: set a password, link another identity or register a passkey first", ErrLastLoginMethod)
+	}
+
+	return nil
+}
+
+// NewFederated creates a user who signs in through an external identity
+// provider only, until they set a password. Reserved usernames are rejected.
+func (domain *UserDomain) NewFederated(username, displayName string) (*User, error) {
+	if domain.IsReservedUsername(username) {
+		return nil, fmt.Errorf("%w: the username %s is reserved", ErrUsernameInvalid, username)
+	}
+
+	if err := domain.validateUsername(username); err != nil {
+		return nil, err
+	}
+
+	hashedPass, err := domain.randomHashedPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	user := domain.newUser(username, hashedPass)
+	user.HasPassword = false
+	if domain.validateDisplayName(displayName) == nil {
+		user.DisplayName = displayName
+	}
+
+	return user, nil
+}
+
+// FederatedUsernames returns up to n acceptable usernames for a user created
+// from the claims, the preferred one first. They are not checked against the
+// existing users.
+func (domain *UserDomain) FederatedUsernames(claims *ExternalClaims, n int) []string {
+	base := claims.PreferredUsername
+	if i := strings.LastIndex(claims.Email, "@"); base == "" && i > 0 {
+		base = claims.Email[:i]
+	}
+
+	candidates := []string{}
+	if len(domain.CheckUsername(base)) == 0 {
+		candidates = append(candidates, base)
+	}
+
+	candidates = append(candidates, domain.SuggestUsernames(base, n-len(candidates))...)
+	if len(candidates) == 0 {
+		candidates = domain.SuggestUsernames(federatedUsernameFallback, n)
+	}
+
+	return candidates
+}
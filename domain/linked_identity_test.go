@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+func TestCheckUnlinkKeepsOneLoginMethod(t *testing.T) {
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+
+	linkedIdentityDomain := domain.NewLinkedIdentityDomain(node, []string{"github", "google"})
+	github := &domain.LinkedIdentity{ID: 1, Provider: "github"}
+	google := &domain.LinkedIdentity{ID: 2, Provider: "google"}
+	passkey := &domain.Passkey{ID: 3}
+
+	testcases := map[string]struct {
+		user       *domain.User
+		identities []*domain.LinkedIdentity
+		passkeys   []*domain.Passkey
+		allowed    bool
+	}{
+		"last-identity":           {&domain.User{}, []*domain.LinkedIdentity{github}, nil, false},
+		"another-identity":        {&domain.User{}, []*domain.LinkedIdentity{github, google}, nil, true},
+		"passkey":                 {&domain.User{}, []*domain.LinkedIdentity{github}, []*domain.Passkey{passkey}, true},
+		"password":                {&domain.User{HasPassword: true}, []*domain.LinkedIdentity{github}, nil, true},
+		"ldap-directory-password": {&domain.User{LDAPID: "uid=alice"}, []*domain.LinkedIdentity{github}, nil, true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			err := linkedIdentityDomain.CheckUnlink(tc.user, tc.identities, tc.passkeys)
+			if tc.allowed {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, domain.ErrLastLoginMethod)
+			}
+		})
+	}
+}
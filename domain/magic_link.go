@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/todennus/x/token"
+	"github.com/xybor-x/snowflake"
+)
+
+// MagicLinkTokenType distinguishes the magic link tokens from the other tokens
+// signed by the same engine.
+const MagicLinkTokenType = "magic_link"
+
+// MagicLinkToken are the claims of the token sent by email, it signs in the
+// user whose email is Email until ExpiresAt. Its ID lets the token be redeemed
+// at most once. The user is not the sub claim, so the token is never accepted
+// as an access token signed by the same engine.
+type MagicLinkToken struct {
+	ID        string `json:"jti"`
+	Type      string `json:"typ"`
+	UserID    string `json:"uid"`
+	Email     string `json:"email"`
+	ExpiresAt int    `json:"exp"`
+}
+
+func (claims *MagicLinkToken) Valid() error {
+	if claims.Type != MagicLinkTokenType {
+		return fmt.Errorf("%w: not a magic link token", token.ErrTokenInvalidFormat)
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("%w: invalid jti", token.ErrTokenInvalidFormat)
+	}
+
+	if _, err := snowflake.ParseString(claims.UserID); err != nil {
+		return fmt.Errorf("%w: invalid uid", token.ErrTokenInvalidFormat)
+	}
+
+	if time.Unix(int64(claims.ExpiresAt), 0).Before(time.Now()) {
+		return token.ErrTokenExpired
+	}
+
+	return nil
+}
+
+type MagicLinkDomain struct {
+	Snowflake  *snowflake.Node
+	Expiration time.Duration
+
+	// URL is the page of the auth server redeeming the links, the token is
+	// appended to its query.
+	URL string
+}
+
+func NewMagicLinkDomain(snowflake *snowflake.Node, expiration time.Duration, url string) *MagicLinkDomain {
+	return &MagicLinkDomain{Snowflake: snowflake, Expiration: expiration, URL: url}
+}
+
+// NewToken creates the claims of a magic link for the user, who must have an
+// email.
+func (domain *MagicLinkDomain) NewToken(user *User) *MagicLinkToken {
+	return &MagicLinkToken{
+		ID:        domain.Snowflake.Generate().String(),
+		Type:      MagicLinkTokenType,
+		UserID:    user.ID.String(),
+		Email:     CanonicalEmail(user.Email),
+		ExpiresAt: int(time.Now().Add(domain.Expiration).Unix()),
+	}
+}
+
+// NewMail creates the email holding the link of the signed token.
+func (domain *MagicLinkDomain) NewMail(user *User, signedToken string) (*Mail, error) {
+	link, err := url.Parse(domain.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid magic link url: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", signedToken)
+	link.RawQuery = query.Encode()
+
+	return &Mail{
+		To:      user.Email,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Follow this link to sign in as %s. It expires in %d minutes and works only once.\n\n"+
+			"%s\n\n"+
+			"If you did not ask for it, you can ignore this email.\n",
+			user.DisplayName, user.Username, int(domain.Expiration.Minutes()), link.String()),
+	}, nil
+}
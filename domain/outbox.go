@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	// OutboxTopicFileRefcount messages change the refcount of file ownerships
+	// in the file service.
+	OutboxTopicFileRefcount = "file.refcount"
+
+	// OutboxTopicEvent messages are domain events published to other
+	// services.
+	OutboxTopicEvent = "event"
+
+	// OutboxTopicWebhookEvent messages are domain events fanned out to the
+	// webhooks subscribing to them.
+	OutboxTopicWebhookEvent = "webhook.event"
+
+	// OutboxTopicAuditLog messages are audit logs appended to the chain by the
+	// relay, so the request recording them does not wait for the chain lock.
+	OutboxTopicAuditLog = "audit.log"
+)
+
+// OutboxMessage is a side effect which is recorded in the same transaction
+// as the change causing it, then delivered by the relay.
+type OutboxMessage struct {
+	ID            snowflake.ID
+	Topic         string
+	Payload       []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	DeliveredAt   time.Time
+}
+
+type RefcountChange struct {
+	IncOwnershipIDs []snowflake.ID `json:"inc"`
+	DecOwnershipIDs []snowflake.ID `json:"dec"`
+}
+
+type OutboxDomain struct {
+	Snowflake *snowflake.Node
+
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	ClaimLease      time.Duration
+}
+
+func NewOutboxDomain(
+	snowflake *snowflake.Node,
+	maxAttempts int,
+	retryBackoff time.Duration,
+	maxRetryBackoff time.Duration,
+	claimLease time.Duration,
+) *OutboxDomain {
+	return &OutboxDomain{
+		Snowflake:       snowflake,
+		MaxAttempts:     maxAttempts,
+		RetryBackoff:    retryBackoff,
+		MaxRetryBackoff: maxRetryBackoff,
+		ClaimLease:      claimLease,
+	}
+}
+
+func (domain *OutboxDomain) New(topic string, payload any) (*OutboxMessage, error) {
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	now := time.Now()
+	return &OutboxMessage{
+		ID:            domain.Snowflake.Generate(),
+		Topic:         topic,
+		Payload:       content,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+func (domain *OutboxDomain) NewRefcountChange(inc, dec []snowflake.ID) (*OutboxMessage, error) {
+	return domain.New(OutboxTopicFileRefcount, &RefcountChange{IncOwnershipIDs: inc, DecOwnershipIDs: dec})
+}
+
+func (domain *OutboxDomain) ParseRefcountChange(msg *OutboxMessage) (*RefcountChange, error) {
+	change := &RefcountChange{}
+	if err := json.Unmarshal(msg.Payload, change); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOutboxPayloadInvalid, err.Error())
+	}
+
+	return change, nil
+}
+
+func (domain *OutboxDomain) NewEvent(event *Event) (*OutboxMessage, error) {
+	return domain.New(OutboxTopicEvent, event)
+}
+
+func (domain *OutboxDomain) NewWebhookEvent(event *Event) (*OutboxMessage, error) {
+	return domain.New(OutboxTopicWebhookEvent, event)
+}
+
+func (domain *OutboxDomain) ParseEvent(msg *OutboxMessage) (*Event, error) {
+	event := &Event{}
+	if err := json.Unmarshal(msg.Payload, event); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOutboxPayloadInvalid, err.Error())
+	}
+
+	return event, nil
+}
+
+func (domain *OutboxDomain) NewAuditLog(log *AuditLog) (*OutboxMessage, error) {
+	return domain.New(OutboxTopicAuditLog, log)
+}
+
+func (domain *OutboxDomain) ParseAuditLog(msg *OutboxMessage) (*AuditLog, error) {
+	log := &AuditLog{}
+	if err := json.Unmarshal(msg.Payload, log); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOutboxPayloadInvalid, err.Error())
+	}
+
+	return log, nil
+}
+
+// Lease postpones the next attempt of a claimed message until the end of the
+// lease, so other relays do not claim it while it is being delivered.
+func (domain *OutboxDomain) Lease(msg *OutboxMessage) {
+	msg.NextAttemptAt = time.Now().Add(domain.ClaimLease)
+}
+
+func (domain *OutboxDomain) SetDelivered(msg *OutboxMessage) {
+	msg.Attempts++
+	msg.LastError = ""
+	msg.DeliveredAt = time.Now()
+}
+
+// SetFailed schedules the next attempt with an exponential backoff.
+func (domain *OutboxDomain) SetFailed(msg *OutboxMessage, err error) {
+	backoff := domain.RetryBackoff << min(msg.Attempts, 30)
+	if backoff <= 0 || backoff > domain.MaxRetryBackoff {
+		backoff = domain.MaxRetryBackoff
+	}
+
+	msg.Attempts++
+	msg.LastError = err.Error()
+	msg.NextAttemptAt = time.Now().Add(backoff)
+}
+
+func (domain *OutboxDomain) MaxDeliveryAttempts() int {
+	return domain.MaxAttempts
+}
+
+// Revive allows the relay to deliver a dead message again.
+func (domain *OutboxDomain) Revive(msg *OutboxMessage) {
+	msg.Attempts = 0
+	msg.NextAttemptAt = time.Now()
+}
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xybor-x/snowflake"
+)
+
+// The ceremonies of the WebAuthn relying party.
+const (
+	PasskeyCeremonyRegistration = "registration"
+	PasskeyCeremonyAssertion    = "assertion"
+)
+
+const MaximumPasskeyNameLength = 64
+
+// defaultPasskeyName is the name of a passkey registered without a name.
+const defaultPasskeyName = "Passkey"
+
+// Passkey is a WebAuthn credential of a user, it lets the user sign in without
+// a password. SignCount is the last signature counter of the authenticator,
+// zero if the authenticator does not implement it.
+type Passkey struct {
+	ID              snowflake.ID
+	UserID          snowflake.ID
+	Name            string
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	BackupEligible  bool
+	BackupState     bool
+	CreatedAt       time.Time
+	LastUsedAt      time.Time
+
+	// AttestationObject is kept to verify the authenticator model later, for
+	// example against the FIDO metadata service.
+	AttestationObject []byte
+}
+
+// PasskeyCredential is a credential verified by the relying party at the end
+// of a ceremony.
+type PasskeyCredential struct {
+	ID                []byte
+	PublicKey         []byte
+	AttestationType   string
+	AttestationObject []byte
+	AAGUID            []byte
+	SignCount         uint32
+	Transports        []string
+	BackupEligible    bool
+	BackupState       bool
+
+	// CloneWarning is set when the signature counter did not increase, the
+	// private key may have been copied.
+	CloneWarning bool
+}
+
+// PasskeySession keeps the state of a ceremony between its beginning and its
+// end, it is used at most once. UserID is zero for an assertion which lets the
+// authenticator choose the user. Data is only read by the relying party.
+type PasskeySession struct {
+	ID        string
+	Ceremony  string
+	UserID    snowflake.ID
+	Data      []byte
+	ExpiresAt time.Time
+}
+
+// PasskeyUserHandle is the WebAuthn user handle of the user, the
+// authenticators return it in the assertions of discoverable credentials.
+func PasskeyUserHandle(userID snowflake.ID) []byte {
+	return []byte(userID.String())
+}
+
+type PasskeyDomain struct {
+	Snowflake *snowflake.Node
+
+	// SessionTimeout is the time given to the user to finish a ceremony.
+	SessionTimeout time.Duration
+}
+
+func NewPasskeyDomain(snowflake *snowflake.Node, sessionTimeout time.Duration) *PasskeyDomain {
+	return &PasskeyDomain{Snowflake: snowflake, SessionTimeout: sessionTimeout}
+}
+
+// NewSession creates the session of a ceremony, the data is the state given by
+// the relying party.
+func (domain *PasskeyDomain) NewSession(ceremony string, userID snowflake.ID, data []byte) (*PasskeySession, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate session id: %w", err)
+	}
+
+	return &PasskeySession{
+		ID:        base64.RawURLEncoding.EncodeToString(id),
+		Ceremony:  ceremony,
+		UserID:    userID,
+		Data:      data,
+		ExpiresAt: time.Now().UTC().Add(domain.SessionTimeout),
+	}, nil
+}
+
+// CheckSession rejects a session of another ceremony or an expired session.
+func (domain *PasskeyDomain) CheckSession(session *PasskeySession, ceremony string) error {
+	if session.Ceremony != ceremony {
+		return fmt.Errorf("%w: not a %s session", ErrPasskeySessionInvalid, ceremony)
+	}
+
+	if !time.Now().Before(session.ExpiresAt) {
+		return fmt.Errorf("%w: the session has expired", ErrPasskeySessionInvalid)
+	}
+
+	return nil
+}
+
+// New creates the passkey of the user from the credential verified at the end
+// of the registration.
+func (domain *PasskeyDomain) New(userID snowflake.ID, name string, credential *PasskeyCredential) (*Passkey, error) {
+	name, err := normalizePasskeyName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Passkey{
+		ID:              domain.Snowflake.Generate(),
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.AAGUID,
+		SignCount:       credential.SignCount,
+		Transports:      credential.Transports,
+		BackupEligible:  credential.BackupEligible,
+		BackupState:     credential.BackupState,
+		CreatedAt:       time.Now().UTC(),
+
+		AttestationObject: credential.AttestationObject,
+	}, nil
+}
+
+func (domain *PasskeyDomain) Rename(passkey *Passkey, name string) error {
+	name, err := normalizePasskeyName(name)
+	if err != nil {
+		return err
+	}
+
+	passkey.Name = name
+	return nil
+}
+
+// Use records a successful assertion with the passkey. An assertion whose
+// signature counter did not increase is rejected, the passkey may be cloned.
+func (domain *PasskeyDomain) Use(passkey *Passkey, credential *PasskeyCredential) error {
+	if credential.CloneWarning {
+		return fmt.Errorf("%w: the signature counter did not increase", ErrPasskeyCloned)
+	}
+
+	passkey.SignCount = credential.SignCount
+	passkey.BackupState = credential.BackupState
+	passkey.LastUsedAt = time.Now().UTC()
+	return nil
+}
+
+func normalizePasskeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultPasskeyName, nil
+	}
+
+	if utf8.RuneCountInString(name) > MaximumPasskeyNameLength {
+		return "", fmt.Errorf("%w: require at most %d characters", ErrPasskeyNameInvalid, MaximumPasskeyNameLength)
+	}
+
+	return name, nil
+}
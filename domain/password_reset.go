@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/todennus/x/token"
+	"github.com/xybor-x/snowflake"
+)
+
+// PasswordResetTokenType distinguishes the password reset tokens from the
+// other tokens signed by the same engine.
+const PasswordResetTokenType = "password_reset"
+
+// PasswordResetToken are the claims of the token letting the user set a new
+// password without the current one, for example after an import forcing the
+// reset. It holds the time the password was last changed, so it is rejected
+// once the password has changed and is used at most once. The user is not the
+// sub claim, so the token is never accepted as an access token.
+type PasswordResetToken struct {
+	ID                string `json:"jti"`
+	Type              string `json:"typ"`
+	UserID            string `json:"uid"`
+	PasswordChangedAt int64  `json:"pca"` // in microsecond
+	ExpiresAt         int    `json:"exp"`
+}
+
+func (claims *PasswordResetToken) Valid() error {
+	if claims.Type != PasswordResetTokenType {
+		return fmt.Errorf("%w: not a password reset token", token.ErrTokenInvalidFormat)
+	}
+
+	if _, err := snowflake.ParseString(claims.ID); err != nil {
+		return fmt.Errorf("%w: invalid jti", token.ErrTokenInvalidFormat)
+	}
+
+	if _, err := snowflake.ParseString(claims.UserID); err != nil {
+		return fmt.Errorf("%w: invalid uid", token.ErrTokenInvalidFormat)
+	}
+
+	if time.Unix(int64(claims.ExpiresAt), 0).Before(time.Now()) {
+		return token.ErrTokenExpired
+	}
+
+	return nil
+}
+
+type PasswordResetDomain struct {
+	Snowflake  *snowflake.Node
+	Expiration time.Duration
+}
+
+func NewPasswordResetDomain(snowflake *snowflake.Node, expiration time.Duration) *PasswordResetDomain {
+	return &PasswordResetDomain{Snowflake: snowflake, Expiration: expiration}
+}
+
+func (domain *PasswordResetDomain) NewToken(user *User) *PasswordResetToken {
+	return &PasswordResetToken{
+		ID:                domain.Snowflake.Generate().String(),
+		Type:              PasswordResetTokenType,
+		UserID:            user.ID.String(),
+		PasswordChangedAt: user.PasswordChangedAt.UnixMicro(),
+		ExpiresAt:         int(time.Now().Add(domain.Expiration).Unix()),
+	}
+}
+
+// CheckToken checks that the token can still reset the password of the user.
+func (domain *PasswordResetDomain) CheckToken(user *User, claims *PasswordResetToken) error {
+	if user.ID.String() != claims.UserID {
+		return fmt.Errorf("%w: the token belongs to another user", ErrPasswordResetTokenUnusable)
+	}
+
+	if user.LDAPID != "" {
+		return fmt.Errorf("%w: the password is managed by the directory", ErrPasswordResetTokenUnusable)
+	}
+
+	if user.PasswordChangedAt.UnixMicro() != claims.PasswordChangedAt {
+		return fmt.Errorf("%w: the password has changed since the token was issued", ErrPasswordResetTokenUnusable)
+	}
+
+	return nil
+}
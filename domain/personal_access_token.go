@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	PersonalAccessTokenStatusActive  = "active"
+	PersonalAccessTokenStatusExpired = "expired"
+	PersonalAccessTokenStatusRevoked = "revoked"
+)
+
+// PersonalAccessTokenPrefix makes the personal access tokens recognizable, for
+// example by secret scanners.
+const PersonalAccessTokenPrefix = "tdnpat_"
+
+const MaximumPersonalAccessTokenNameLength = 64
+
+// personalAccessTokenUseInterval is the precision of LastUsedAt, a token used
+// several times within it is only recorded once.
+const personalAccessTokenUseInterval = time.Minute
+
+// PersonalAccessToken lets scripts act as its user with the given scopes,
+// without the password of the user. Only the hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         snowflake.ID
+	UserID     snowflake.ID
+	Name       string
+	Scope      string
+	TokenHash  string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+type PersonalAccessTokenDomain struct {
+	Snowflake *snowflake.Node
+
+	// MaxExpiration is the longest duration a token can be valid for.
+	MaxExpiration time.Duration
+}
+
+func NewPersonalAccessTokenDomain(snowflake *snowflake.Node, maxExpiration time.Duration) *PersonalAccessTokenDomain {
+	return &PersonalAccessTokenDomain{Snowflake: snowflake, MaxExpiration: maxExpiration}
+}
+
+// New creates a token of the user and returns it with its plain value, which
+// cannot be recovered afterward. The scope must have been checked against the
+// scope of the user.
+func (domain *PersonalAccessTokenDomain) New(
+	userID snowflake.ID,
+	name, scope string,
+	expiration time.Duration,
+) (*PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaximumPersonalAccessTokenNameLength {
+		return nil, "", fmt.Errorf("%w: require a name of at most %d characters",
+			ErrPersonalAccessTokenNameInvalid, MaximumPersonalAccessTokenNameLength)
+	}
+
+	if scope == "" {
+		return nil, "", fmt.Errorf("%w: require at least one scope", ErrPersonalAccessTokenScopeInvalid)
+	}
+
+	if expiration <= 0 || expiration > domain.MaxExpiration {
+		return nil, "", fmt.Errorf("%w: the expiration must be in range (0, %s]",
+			ErrPersonalAccessTokenExpirationInvalid, domain.MaxExpiration)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate personal access token: %w", err)
+	}
+
+	token := PersonalAccessTokenPrefix + hex.EncodeToString(secret)
+	now := time.Now().UTC().Truncate(time.Second)
+	return &PersonalAccessToken{
+		ID:        domain.Snowflake.Generate(),
+		UserID:    userID,
+		Name:      name,
+		Scope:     scope,
+		TokenHash: domain.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(expiration),
+	}, token, nil
+}
+
+// HashToken returns the stored form of the token. The token is random enough
+// for a plain SHA-256 hash, which also allows looking it up.
+func (domain *PersonalAccessTokenDomain) HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsToken reports whether the value looks like a personal access token.
+func (domain *PersonalAccessTokenDomain) IsToken(value string) bool {
+	return strings.HasPrefix(value, PersonalAccessTokenPrefix)
+}
+
+func (domain *PersonalAccessTokenDomain) Status(token *PersonalAccessToken) string {
+	switch {
+	case !token.RevokedAt.IsZero():
+		return PersonalAccessTokenStatusRevoked
+	case !time.Now().Before(token.ExpiresAt):
+		return PersonalAccessTokenStatusExpired
+	default:
+		return PersonalAccessTokenStatusActive
+	}
+}
+
+// Revoke prevents the token from being used, revoking a token which is
+// already revoked keeps the first revocation time.
+func (domain *PersonalAccessTokenDomain) Revoke(token *PersonalAccessToken) {
+	if token.RevokedAt.IsZero() {
+		token.RevokedAt = time.Now().UTC()
+	}
+}
+
+// Use records that the token is being used. It returns false if the previous
+// use is recent enough, so the token does not need to be saved.
+func (domain *PersonalAccessTokenDomain) Use(token *PersonalAccessToken) bool {
+	now := time.Now().UTC()
+	if now.Sub(token.LastUsedAt) < personalAccessTokenUseInterval {
+		return false
+	}
+
+	token.LastUsedAt = now
+	return true
+}
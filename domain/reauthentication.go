@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/todennus/x/token"
+	"github.com/xybor-x/snowflake"
+)
+
+// ReauthenticationTokenType distinguishes the re-authentication tokens from
+// the other tokens signed by the same engine.
+const ReauthenticationTokenType = "reauthentication"
+
+// The methods a user re-authenticated with.
+const (
+	// ReauthenticationMethodPassword is the local password of the user.
+	ReauthenticationMethodPassword = "password"
+
+	// ReauthenticationMethodLDAP is the password of the directory entry of
+	// the user.
+	ReauthenticationMethodLDAP = "ldap"
+
+	// ReauthenticationMethodPasskey is a passkey assertion verified by this
+	// service.
+	ReauthenticationMethodPasskey = "passkey"
+
+	// ReauthenticationMethodAuthServer is any other sign-in verified again by
+	// the auth server, such as a linked identity or a magic link.
+	ReauthenticationMethodAuthServer = "auth_server"
+)
+
+// ReauthenticationToken are the claims of the short-lived token proving that
+// the user has just confirmed their identity, it is required by the sensitive
+// changes such as the erasure. The user is not the sub claim, so the token is
+// never accepted as an access token.
+type ReauthenticationToken struct {
+	ID        string `json:"jti"`
+	Type      string `json:"typ"`
+	UserID    string `json:"uid"`
+	Method    string `json:"mth"`
+	ExpiresAt int    `json:"exp"`
+}
+
+func (claims *ReauthenticationToken) Valid() error {
+	if claims.Type != ReauthenticationTokenType {
+		return fmt.Errorf("%w: not a re-authentication token", token.ErrTokenInvalidFormat)
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("%w: invalid jti", token.ErrTokenInvalidFormat)
+	}
+
+	if _, err := snowflake.ParseString(claims.UserID); err != nil {
+		return fmt.Errorf("%w: invalid uid", token.ErrTokenInvalidFormat)
+	}
+
+	if time.Unix(int64(claims.ExpiresAt), 0).Before(time.Now()) {
+		return token.ErrTokenExpired
+	}
+
+	return nil
+}
+
+type ReauthenticationDomain struct {
+	Snowflake  *snowflake.Node
+	Expiration time.Duration
+}
+
+func NewReauthenticationDomain(snowflake *snowflake.Node, expiration time.Duration) *ReauthenticationDomain {
+	return &ReauthenticationDomain{Snowflake: snowflake, Expiration: expiration}
+}
+
+func (domain *ReauthenticationDomain) NewToken(user *User, method string) *ReauthenticationToken {
+	return &ReauthenticationToken{
+		ID:        domain.Snowflake.Generate().String(),
+		Type:      ReauthenticationTokenType,
+		UserID:    user.ID.String(),
+		Method:    method,
+		ExpiresAt: int(time.Now().Add(domain.Expiration).Unix()),
+	}
+}
+
+// CheckToken checks that the token was issued to the user.
+func (domain *ReauthenticationDomain) CheckToken(user *User, claims *ReauthenticationToken) error {
+	if user.ID.String() != claims.UserID {
+		return fmt.Errorf("%w: the token belongs to another user", ErrReauthenticationRequired)
+	}
+
+	return nil
+}
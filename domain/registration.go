@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The modes of the self registration.
+const (
+	// RegistrationModeOff only lets admins create users.
+	RegistrationModeOff = "off"
+
+	// RegistrationModeOpen lets anyone register.
+	RegistrationModeOpen = "open"
+
+	// RegistrationModeInvite requires an invitation created by an admin.
+	RegistrationModeInvite = "invite"
+
+	// RegistrationModeDomain requires a verified email of an allowed domain.
+	RegistrationModeDomain = "domain"
+)
+
+var registrationModes = []string{
+	RegistrationModeOff,
+	RegistrationModeOpen,
+	RegistrationModeInvite,
+	RegistrationModeDomain,
+}
+
+// RegistrationDomain decides who can register without an admin. The users
+// registering by themselves are pending until an admin approves them.
+type RegistrationDomain struct {
+	Mode string
+
+	// AllowedDomains is the set of the lowercase email domains allowed in the
+	// domain mode.
+	AllowedDomains map[string]bool
+}
+
+func NewRegistrationDomain(mode string, allowedDomains []string) (*RegistrationDomain, error) {
+	if !slices.Contains(registrationModes, mode) {
+		return nil, fmt.Errorf("%w: unknown mode %s", ErrRegistrationModeInvalid, mode)
+	}
+
+	domains := map[string]bool{}
+	for _, domain := range allowedDomains {
+		domains[strings.ToLower(strings.TrimPrefix(domain, "@"))] = true
+	}
+
+	if mode == RegistrationModeDomain && len(domains) == 0 {
+		return nil, fmt.Errorf("%w: the domain mode requires allowed domains", ErrRegistrationModeInvalid)
+	}
+
+	return &RegistrationDomain{Mode: mode, AllowedDomains: domains}, nil
+}
+
+// RegistrationPolicy tells the sign-up forms which fields are required.
+type RegistrationPolicy struct {
+	Mode                      string
+	EmailRequired             bool
+	EmailVerificationRequired bool
+	InvitationRequired        bool
+}
+
+func (domain *RegistrationDomain) Policy() RegistrationPolicy {
+	return RegistrationPolicy{
+		Mode:                      domain.Mode,
+		EmailRequired:             domain.Mode == RegistrationModeDomain,
+		EmailVerificationRequired: domain.RequiresEmailVerification(),
+		InvitationRequired:        domain.Mode == RegistrationModeInvite,
+	}
+}
+
+func (domain *RegistrationDomain) IsOpen() bool {
+	return domain.Mode != RegistrationModeOff
+}
+
+// RequiresEmailVerification reports whether the pending users must verify
+// their email before being approved. The domain mode trusts the domain of the
+// email, which is only proven by receiving an email.
+func (domain *RegistrationDomain) RequiresEmailVerification() bool {
+	return domain.Mode == RegistrationModeDomain
+}
+
+// Authorize checks the registration of a user against the mode, the email is
+// the normalized email of the user. The invitation is nil if none was given,
+// it must have been checked by the caller.
+func (domain *RegistrationDomain) Authorize(email string, invitation *Invitation) error {
+	switch domain.Mode {
+	case RegistrationModeOpen:
+		return nil
+
+	case RegistrationModeInvite:
+		if invitation == nil {
+			return fmt.Errorf("%w: require an invitation token", ErrInvitationRequired)
+		}
+
+		return nil
+
+	case RegistrationModeDomain:
+		if email == "" {
+			return fmt.Errorf("%w: require an email", ErrEmailDomainNotAllowed)
+		}
+
+		if !domain.AllowedDomains[EmailDomain(email)] {
+			return fmt.Errorf("%w: %s", ErrEmailDomainNotAllowed, EmailDomain(email))
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("%w: self registration is disabled", ErrRegistrationClosed)
+	}
+}
+
+// NewSelfRegistered creates a user who registered without an admin, the user
+// is pending until an admin approves them. Their email is not verified.
+func (domain *UserDomain) NewSelfRegistered(username, password, email string) (*User, error) {
+	user, err := domain.New(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if email != "" {
+		if user.Email, err = domain.NormalizeEmail(email); err != nil {
+			return nil, err
+		}
+	}
+
+	user.Pending = true
+	return user, nil
+}
@@ -0,0 +1,459 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const ScimUserSchema = "urn:ietf:params:scim:schemas:core:2.0:User"
+
+// The user attributes which can be filtered.
+const (
+	ScimAttrID           = "id"
+	ScimAttrUserName     = "userName"
+	ScimAttrDisplayName  = "displayName"
+	ScimAttrActive       = "active"
+	ScimAttrLastModified = "meta.lastModified"
+
+	scimAttrPassword = "password"
+)
+
+const (
+	ScimOpAnd = "and"
+	ScimOpOr  = "or"
+	ScimOpNot = "not"
+
+	ScimOpEqual          = "eq"
+	ScimOpNotEqual       = "ne"
+	ScimOpContains       = "co"
+	ScimOpStartsWith     = "sw"
+	ScimOpEndsWith       = "ew"
+	ScimOpPresent        = "pr"
+	ScimOpGreater        = "gt"
+	ScimOpGreaterOrEqual = "ge"
+	ScimOpLess           = "lt"
+	ScimOpLessOrEqual    = "le"
+)
+
+// scimAttrOps lists the operators supported by each attribute.
+var scimAttrOps = map[string][]string{
+	ScimAttrID: {ScimOpEqual, ScimOpNotEqual, ScimOpPresent},
+	ScimAttrUserName: {ScimOpEqual, ScimOpNotEqual, ScimOpContains, ScimOpStartsWith, ScimOpEndsWith,
+		ScimOpPresent, ScimOpGreater, ScimOpGreaterOrEqual, ScimOpLess, ScimOpLessOrEqual},
+	ScimAttrDisplayName: {ScimOpEqual, ScimOpNotEqual, ScimOpContains, ScimOpStartsWith, ScimOpEndsWith,
+		ScimOpPresent, ScimOpGreater, ScimOpGreaterOrEqual, ScimOpLess, ScimOpLessOrEqual},
+	ScimAttrActive: {ScimOpEqual, ScimOpNotEqual, ScimOpPresent},
+	ScimAttrLastModified: {ScimOpEqual, ScimOpNotEqual, ScimOpPresent,
+		ScimOpGreater, ScimOpGreaterOrEqual, ScimOpLess, ScimOpLessOrEqual},
+}
+
+// ScimFilter is a parsed SCIM filter expression. Logical filters (and, or,
+// not) hold their operands in Filters, the others compare Attr with Value.
+// Value is a snowflake.ID for id, a bool for active, a time.Time for
+// meta.lastModified and a string for the other attributes. It is nil for the
+// pr operator.
+type ScimFilter struct {
+	Op      string
+	Attr    string
+	Value   any
+	Filters []*ScimFilter
+}
+
+// ScimPatchOperation is an operation of a SCIM PATCH request.
+type ScimPatchOperation struct {
+	Op    string
+	Path  string
+	Value json.RawMessage
+}
+
+// ScimUserPatch is the result of the PATCH operations, nil fields are left
+// unchanged. An empty display name resets it to the username.
+type ScimUserPatch struct {
+	DisplayName *string
+	Password    *string
+	Active      *bool
+}
+
+type ScimDomain struct{}
+
+func NewScimDomain() *ScimDomain {
+	return &ScimDomain{}
+}
+
+// ParseFilter parses a filter expression as defined in RFC 7644 section
+// 3.4.2.2. Complex attribute filters (value paths) are not supported.
+func (domain *ScimDomain) ParseFilter(filter string) (*ScimFilter, error) {
+	tokens, err := tokenizeScimFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &scimFilterParser{tokens: tokens}
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.done() {
+		return nil, fmt.Errorf("%w: unexpected %s", ErrScimFilterInvalid, parser.peek().value)
+	}
+
+	return result, nil
+}
+
+// ParsePatch merges the PATCH operations into a patch of the user. The
+// username cannot be changed.
+func (domain *ScimDomain) ParsePatch(operations []ScimPatchOperation) (*ScimUserPatch, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%w: require at least an operation", ErrScimValueInvalid)
+	}
+
+	patch := &ScimUserPatch{}
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return nil, fmt.Errorf("%w: unknown operation %s", ErrScimValueInvalid, operation.Op)
+		}
+
+		if operation.Path == "" {
+			if op == "remove" {
+				return nil, fmt.Errorf("%w: remove operation requires a path", ErrScimPathInvalid)
+			}
+
+			values := map[string]json.RawMessage{}
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return nil, fmt.Errorf("%w: operation without path requires an object value", ErrScimValueInvalid)
+			}
+
+			// Attributes which are not managed by this service are ignored,
+			// the identity providers often send their whole user.
+			for attr, value := range values {
+				if err := patch.set(normalizeScimAttr(attr), value, true); err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		attr := normalizeScimAttr(operation.Path)
+		if op == "remove" {
+			if attr != ScimAttrDisplayName {
+				return nil, fmt.Errorf("%w: %s cannot be removed", ErrScimMutability, operation.Path)
+			}
+
+			patch.DisplayName = new(string)
+			continue
+		}
+
+		if err := patch.set(attr, operation.Value, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return patch, nil
+}
+
+func (patch *ScimUserPatch) set(attr string, value json.RawMessage, ignoreUnknown bool) error {
+	switch attr {
+	case ScimAttrDisplayName:
+		displayName := ""
+		if err := json.Unmarshal(value, &displayName); err != nil {
+			return fmt.Errorf("%w: displayName must be a string", ErrScimValueInvalid)
+		}
+
+		patch.DisplayName = &displayName
+	case scimAttrPassword:
+		password := ""
+		if err := json.Unmarshal(value, &password); err != nil {
+			return fmt.Errorf("%w: password must be a string", ErrScimValueInvalid)
+		}
+
+		patch.Password = &password
+	case ScimAttrActive:
+		active, err := parseScimBool(value)
+		if err != nil {
+			return err
+		}
+
+		patch.Active = &active
+	case ScimAttrUserName:
+		return fmt.Errorf("%w: userName cannot be changed", ErrScimMutability)
+	default:
+		if !ignoreUnknown {
+			return fmt.Errorf("%w: unsupported path %s", ErrScimPathInvalid, attr)
+		}
+	}
+
+	return nil
+}
+
+// parseScimBool also accepts the "True" and "False" strings sent by some
+// identity providers.
+func parseScimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+
+	return false, fmt.Errorf("%w: active must be a boolean", ErrScimValueInvalid)
+}
+
+// normalizeScimAttr removes the schema prefix and fixes the case of the known
+// attributes, the attribute names are case-insensitive.
+func normalizeScimAttr(attr string) string {
+	if len(attr) > len(ScimUserSchema) && strings.EqualFold(attr[:len(ScimUserSchema)+1], ScimUserSchema+":") {
+		attr = attr[len(ScimUserSchema)+1:]
+	}
+
+	for _, known := range []string{ScimAttrID, ScimAttrUserName, ScimAttrDisplayName, ScimAttrActive, ScimAttrLastModified, scimAttrPassword} {
+		if strings.EqualFold(attr, known) {
+			return known
+		}
+	}
+
+	return attr
+}
+
+type scimTokenKind int
+
+const (
+	scimTokenWord scimTokenKind = iota
+	scimTokenString
+	scimTokenOpenParen
+	scimTokenCloseParen
+)
+
+type scimToken struct {
+	kind  scimTokenKind
+	value string
+}
+
+func tokenizeScimFilter(filter string) ([]scimToken, error) {
+	tokens := []scimToken{}
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, scimToken{kind: scimTokenOpenParen, value: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, scimToken{kind: scimTokenCloseParen, value: ")"})
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+
+			if j >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string", ErrScimFilterInvalid)
+			}
+
+			value := ""
+			if err := json.Unmarshal([]byte(string(runes[i:j+1])), &value); err != nil {
+				return nil, fmt.Errorf("%w: invalid string %s", ErrScimFilterInvalid, string(runes[i:j+1]))
+			}
+
+			tokens = append(tokens, scimToken{kind: scimTokenString, value: value})
+			i = j + 1
+		case c == '[':
+			return nil, fmt.Errorf("%w: complex attribute filters are not supported", ErrScimFilterInvalid)
+		default:
+			j := i
+			for ; j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune(`()"[`, runes[j]); j++ {
+			}
+
+			tokens = append(tokens, scimToken{kind: scimTokenWord, value: string(runes[i:j])})
+			i = j
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty filter", ErrScimFilterInvalid)
+	}
+
+	return tokens, nil
+}
+
+// scimFilterParser is a recursive descent parser, not binds tighter than and,
+// which binds tighter than or.
+type scimFilterParser struct {
+	tokens []scimToken
+	pos    int
+}
+
+func (p *scimFilterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *scimFilterParser) peek() scimToken {
+	return p.tokens[p.pos]
+}
+
+func (p *scimFilterParser) next() (scimToken, error) {
+	if p.done() {
+		return scimToken{}, fmt.Errorf("%w: unexpected end of filter", ErrScimFilterInvalid)
+	}
+
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *scimFilterParser) nextIsWord(word string) bool {
+	return !p.done() && p.peek().kind == scimTokenWord && strings.EqualFold(p.peek().value, word)
+}
+
+func (p *scimFilterParser) parseOr() (*ScimFilter, error) {
+	return p.parseLogical(ScimOpOr, p.parseAnd)
+}
+
+func (p *scimFilterParser) parseAnd() (*ScimFilter, error) {
+	return p.parseLogical(ScimOpAnd, p.parseUnary)
+}
+
+func (p *scimFilterParser) parseLogical(op string, operand func() (*ScimFilter, error)) (*ScimFilter, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.nextIsWord(op) {
+		p.pos++
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &ScimFilter{Op: op, Filters: []*ScimFilter{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *scimFilterParser) parseUnary() (*ScimFilter, error) {
+	if p.nextIsWord(ScimOpNot) {
+		p.pos++
+		if p.done() || p.peek().kind != scimTokenOpenParen {
+			return nil, fmt.Errorf("%w: not requires a parenthesized filter", ErrScimFilterInvalid)
+		}
+
+		filter, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ScimFilter{Op: ScimOpNot, Filters: []*ScimFilter{filter}}, nil
+	}
+
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token.kind {
+	case scimTokenOpenParen:
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if token, err := p.next(); err != nil || token.kind != scimTokenCloseParen {
+			return nil, fmt.Errorf("%w: missing closing parenthesis", ErrScimFilterInvalid)
+		}
+
+		return filter, nil
+	case scimTokenWord:
+		return p.parseComparison(token.value)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %s", ErrScimFilterInvalid, token.value)
+	}
+}
+
+func (p *scimFilterParser) parseComparison(rawAttr string) (*ScimFilter, error) {
+	attr := normalizeScimAttr(rawAttr)
+	ops, ok := scimAttrOps[attr]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported attribute %s", ErrScimFilterInvalid, rawAttr)
+	}
+
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	op := strings.ToLower(token.value)
+	if token.kind != scimTokenWord || !slices.Contains(ops, op) {
+		return nil, fmt.Errorf("%w: unsupported operator %s for %s", ErrScimFilterInvalid, token.value, rawAttr)
+	}
+
+	if op == ScimOpPresent {
+		return &ScimFilter{Op: op, Attr: attr}, nil
+	}
+
+	token, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parseScimFilterValue(attr, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScimFilter{Op: op, Attr: attr, Value: value}, nil
+}
+
+func parseScimFilterValue(attr string, token scimToken) (any, error) {
+	switch attr {
+	case ScimAttrActive:
+		if token.kind == scimTokenWord {
+			if b, err := strconv.ParseBool(strings.ToLower(token.value)); err == nil {
+				return b, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: %s requires a boolean", ErrScimFilterInvalid, attr)
+	case ScimAttrID:
+		if token.kind == scimTokenString {
+			if id, err := snowflake.ParseString(token.value); err == nil {
+				return id, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: %s requires a user id string", ErrScimFilterInvalid, attr)
+	case ScimAttrLastModified:
+		if token.kind == scimTokenString {
+			if t, err := time.Parse(time.RFC3339, token.value); err == nil {
+				return t, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: %s requires a RFC3339 time string", ErrScimFilterInvalid, attr)
+	default:
+		if token.kind == scimTokenString {
+			return token.value, nil
+		}
+
+		return nil, fmt.Errorf("%w: %s requires a string", ErrScimFilterInvalid, attr)
+	}
+}
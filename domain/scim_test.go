@@ -0,0 +1,136 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+func TestScimParseFilter(t *testing.T) {
+	testcases := map[string]struct {
+		filter string
+		want   *domain.ScimFilter
+	}{
+		"equal": {
+			filter: `userName eq "Alice"`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpEqual, Attr: domain.ScimAttrUserName, Value: "Alice"},
+		},
+		"case-insensitive-attribute-and-operator": {
+			filter: `USERNAME SW "al"`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpStartsWith, Attr: domain.ScimAttrUserName, Value: "al"},
+		},
+		"schema-prefix": {
+			filter: `urn:ietf:params:scim:schemas:core:2.0:User:displayName co "li"`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpContains, Attr: domain.ScimAttrDisplayName, Value: "li"},
+		},
+		"escaped-string": {
+			filter: `displayName eq "say \"hi\""`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpEqual, Attr: domain.ScimAttrDisplayName, Value: `say "hi"`},
+		},
+		"present": {
+			filter: `displayName pr`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpPresent, Attr: domain.ScimAttrDisplayName},
+		},
+		"boolean": {
+			filter: `active eq False`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpEqual, Attr: domain.ScimAttrActive, Value: false},
+		},
+		"id": {
+			filter: `id eq "336379742305665024"`,
+			want:   &domain.ScimFilter{Op: domain.ScimOpEqual, Attr: domain.ScimAttrID, Value: snowflake.ID(336379742305665024)},
+		},
+		"time": {
+			filter: `meta.lastModified gt "2024-11-02T10:00:00Z"`,
+			want: &domain.ScimFilter{Op: domain.ScimOpGreater, Attr: domain.ScimAttrLastModified,
+				Value: time.Date(2024, 11, 2, 10, 0, 0, 0, time.UTC)},
+		},
+		"and-binds-tighter-than-or": {
+			filter: `userName eq "a" or userName eq "b" and active eq true`,
+			want: &domain.ScimFilter{Op: domain.ScimOpOr, Filters: []*domain.ScimFilter{
+				{Op: domain.ScimOpEqual, Attr: domain.ScimAttrUserName, Value: "a"},
+				{Op: domain.ScimOpAnd, Filters: []*domain.ScimFilter{
+					{Op: domain.ScimOpEqual, Attr: domain.ScimAttrUserName, Value: "b"},
+					{Op: domain.ScimOpEqual, Attr: domain.ScimAttrActive, Value: true},
+				}},
+			}},
+		},
+		"parentheses-and-not": {
+			filter: `not (userName eq "a" or userName eq "b") and active pr`,
+			want: &domain.ScimFilter{Op: domain.ScimOpAnd, Filters: []*domain.ScimFilter{
+				{Op: domain.ScimOpNot, Filters: []*domain.ScimFilter{
+					{Op: domain.ScimOpOr, Filters: []*domain.ScimFilter{
+						{Op: domain.ScimOpEqual, Attr: domain.ScimAttrUserName, Value: "a"},
+						{Op: domain.ScimOpEqual, Attr: domain.ScimAttrUserName, Value: "b"},
+					}},
+				}},
+				{Op: domain.ScimOpPresent, Attr: domain.ScimAttrActive},
+			}},
+		},
+	}
+
+	scimDomain := domain.NewScimDomain()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			filter, err := scimDomain.ParseFilter(tc.filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, filter)
+		})
+	}
+}
+
+func TestScimParseFilterInvalid(t *testing.T) {
+	testcases := map[string]string{
+		"empty":                  ``,
+		"unterminated-string":    `userName eq "alice`,
+		"unknown-attribute":      `emails eq "a@example.com"`,
+		"unsupported-operator":   `active co "t"`,
+		"missing-value":          `userName eq`,
+		"value-not-a-string":     `userName eq alice`,
+		"value-not-a-boolean":    `active eq "true"`,
+		"invalid-id":             `id eq "alice"`,
+		"invalid-time":           `meta.lastModified gt "yesterday"`,
+		"missing-parenthesis":    `(userName eq "a"`,
+		"not-without-parens":     `not userName eq "a"`,
+		"trailing-tokens":        `userName eq "a" active`,
+		"dangling-logical":       `userName eq "a" and`,
+		"complex-attribute":      `emails[type eq "work"]`,
+		"operator-as-value-kind": `userName "a" eq`,
+	}
+
+	scimDomain := domain.NewScimDomain()
+	for name, filter := range testcases {
+		t.Run(name, func(t *testing.T) {
+			_, err := scimDomain.ParseFilter(filter)
+			require.ErrorIs(t, err, domain.ErrScimFilterInvalid)
+		})
+	}
+}
+
+func TestScimParsePatch(t *testing.T) {
+	scimDomain := domain.NewScimDomain()
+
+	patch, err := scimDomain.ParsePatch([]domain.ScimPatchOperation{
+		{Op: "Replace", Path: "displayName", Value: []byte(`"Alice"`)},
+		{Op: "replace", Value: []byte(`{"active": "False", "emails": []}`)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Alice", *patch.DisplayName)
+	require.False(t, *patch.Active)
+	require.Nil(t, patch.Password)
+
+	patch, err = scimDomain.ParsePatch([]domain.ScimPatchOperation{{Op: "remove", Path: "displayName"}})
+	require.NoError(t, err)
+	require.Empty(t, *patch.DisplayName)
+
+	_, err = scimDomain.ParsePatch([]domain.ScimPatchOperation{{Op: "remove", Path: "active"}})
+	require.ErrorIs(t, err, domain.ErrScimMutability)
+
+	_, err = scimDomain.ParsePatch([]domain.ScimPatchOperation{{Op: "replace", Path: "userName", Value: []byte(`"bob"`)}})
+	require.Error(t, err)
+
+	_, err = scimDomain.ParsePatch(nil)
+	require.ErrorIs(t, err, domain.ErrScimValueInvalid)
+}
@@ -1,11 +1,14 @@
 package domain
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/todennus/shared/enumdef"
 	"github.com/todennus/x/xstring"
+	"github.com/xybor-x/enum"
 	"github.com/xybor-x/snowflake"
 )
 
@@ -27,18 +30,89 @@ type User struct {
 	HashedPass  string
 	Role        enumdef.UserRole
 	Avatar      snowflake.ID
+	Active      bool
 	UpdatedAt   time.Time
+
+	// PasswordResetRequired is set for the imported users whose password
+	// must be changed before they can sign in.
+	PasswordResetRequired bool
+
+	// HasPassword is unset for the users who were given a random password,
+	// they only sign in through a linked identity until they set a password.
+	HasPassword bool
+
+	// PasswordChangedAt is the time the password was last set, the password
+	// expires once it is older than the maximum age of the password policy.
+	PasswordChangedAt time.Time
+
+	// LDAPID is the id of the directory entry of the users sourced from LDAP,
+	// whose password is validated by the directory.
+	LDAPID string
+
+	// Email is optional, it is given by the users registering by themselves
+	// or taken from their directory entry or external identity.
+	// EmailVerified is set once the user proved that they receive the emails
+	// sent to it, the emails of the directory and of the verified external
+	// identities are trusted. Pending is set for the users registering by
+	// themselves until an admin approves them.
+	Email         string
+	EmailVerified bool
+	Pending       bool
+
+	// EraseAfter is set while the erasure of the user is scheduled, ErasedAt
+	// once the user is erased.
+	EraseAfter time.Time
+	ErasedAt   time.Time
 }
 
 type UserDomain struct {
 	Snowflake *snowflake.Node
+
+	// ErasureGracePeriod is the duration during which a scheduled erasure can
+	// be canceled.
+	ErasureGracePeriod time.Duration
+
+	// ReservedUsernames is the set of the canonical usernames which users
+	// cannot register.
+	ReservedUsernames map[string]bool
+
+	PasswordPolicy PasswordPolicy
 }
 
-func NewUserDomain(snowflake *snowflake.Node) (*UserDomain, error) {
-	return &UserDomain{Snowflake: snowflake}, nil
+func NewUserDomain(
+	snowflake *snowflake.Node,
+	erasureGracePeriod time.Duration,
+	reservedUsernames []string,
+	passwordPolicy PasswordPolicy,
+) (*UserDomain, error) {
+	if passwordPolicy.MaxAge < 0 || passwordPolicy.WarningWindow < 0 || passwordPolicy.HistorySize < 0 {
+		return nil, fmt.Errorf("%w: negative password policy", ErrPasswordPolicyInvalid)
+	}
+
+	reserved := map[string]bool{}
+	for _, username := range reservedUsernames {
+		reserved[CanonicalUsername(username)] = true
+	}
+
+	return &UserDomain{
+		Snowflake:          snowflake,
+		ErasureGracePeriod: erasureGracePeriod,
+		ReservedUsernames:  reserved,
+		PasswordPolicy:     passwordPolicy,
+	}, nil
 }
 
+// New creates a user registering with a password, reserved usernames are
+// rejected.
 func (domain *UserDomain) New(username, password string) (*User, error) {
+	if domain.IsReservedUsername(username) {
+		return nil, fmt.Errorf("%w: the username %s is reserved", ErrUsernameInvalid, username)
+	}
+
+	return domain.newWithPassword(username, password)
+}
+
+func (domain *UserDomain) newWithPassword(username, password string) (*User, error) {
 	if err := domain.validateUsername(username); err != nil {
 		return nil, err
 	}
@@ -52,17 +126,91 @@ func (domain *UserDomain) New(username, password string) (*User, error) {
 		return nil, err
 	}
 
-	return &User{
-		ID:          domain.Snowflake.Generate(),
-		DisplayName: username,
-		Username:    username,
-		HashedPass:  string(hashedPass),
-		Role:        enumdef.UserRoleUser,
-	}, nil
+	return domain.newUser(username, hashedPass), nil
+}
+
+// NewProvisioned creates a user on behalf of an identity provider. If the
+// password is empty, the user gets a random one and can only sign in through
+// the identity provider.
+func (domain *UserDomain) NewProvisioned(username, password string) (*User, error) {
+	if password != "" {
+		return domain.newWithPassword(username, password)
+	}
+
+	if err := domain.validateUsername(username); err != nil {
+		return nil, err
+	}
+
+	hashedPass, err := domain.randomHashedPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	user := domain.newUser(username, hashedPass)
+	user.HasPassword = false
+	return user, nil
 }
 
+// NewImported creates a user from a row imported from another system. The
+// row either has a bcrypt or argon2id hash of the password, or forces the user
+// to reset the password, or both.
+func (domain *UserDomain) NewImported(row *UserImportRow) (*User, error) {
+	if row.SchemaVersion != 0 && row.SchemaVersion != UserExportSchemaVersion {
+		return nil, fmt.Errorf("%w: unsupported schema version %d", ErrImportRowInvalid, row.SchemaVersion)
+	}
+
+	if err := domain.validateUsername(row.Username); err != nil {
+		return nil, err
+	}
+
+	role := enumdef.UserRoleUser
+	if row.Role != "" {
+		var ok bool
+		if role, ok = enum.FromString[enumdef.UserRole](row.Role); !ok {
+			return nil, fmt.Errorf("%w: unknown role %s", ErrRoleInvalid, row.Role)
+		}
+	}
+
+	var hashedPass []byte
+	switch {
+	case row.PasswordHash != "":
+		if err := ValidatePasswordHash(row.PasswordHash); err != nil {
+			return nil, err
+		}
+
+		hashedPass = []byte(row.PasswordHash)
+	case row.ForceReset:
+		var err error
+		if hashedPass, err = domain.randomHashedPassword(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: require a password hash or force_reset", ErrPasswordHashInvalid)
+	}
+
+	user := domain.newUser(row.Username, hashedPass)
+	user.Role = role
+	user.PasswordResetRequired = row.ForceReset
+	if row.ID != 0 {
+		user.ID = row.ID
+	}
+
+	if row.Active != nil {
+		user.Active = *row.Active
+	}
+
+	if row.DisplayName != "" {
+		if err := domain.SetDisplayName(user, row.DisplayName); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// NewFirst creates the first admin, who may take a reserved username.
 func (domain *UserDomain) NewFirst(username, password string) (*User, error) {
-	user, err := domain.New(username, password)
+	user, err := domain.newWithPassword(username, password)
 	if err != nil {
 		return nil, err
 	}
@@ -81,9 +229,73 @@ func (domain *UserDomain) SetDisplayName(user *User, displayname string) error {
 	}
 
 	user.DisplayName = displayname
+	user.UpdatedAt = domain.now()
+	return nil
+}
+
+func (domain *UserDomain) SetPassword(user *User, password string) error {
+	if err := domain.validatePassword(password); err != nil {
+		return err
+	}
+
+	hashedPass, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.HashedPass = string(hashedPass)
+	user.PasswordResetRequired = false
+	user.HasPassword = true
+	user.UpdatedAt = domain.now()
+	user.PasswordChangedAt = user.UpdatedAt
 	return nil
 }
 
+// Approve lets a pending user sign in.
+func (domain *UserDomain) Approve(user *User) {
+	user.Pending = false
+	user.UpdatedAt = domain.now()
+}
+
+// SetActive activates or deactivates the user, a deactivated user cannot sign
+// in.
+func (domain *UserDomain) SetActive(user *User, active bool) {
+	user.Active = active
+	user.UpdatedAt = domain.now()
+}
+
+func (domain *UserDomain) newUser(username string, hashedPass []byte) *User {
+	now := domain.now()
+	return &User{
+		ID:                domain.Snowflake.Generate(),
+		DisplayName:       username,
+		Username:          username,
+		HashedPass:        string(hashedPass),
+		Role:              enumdef.UserRoleUser,
+		Active:            true,
+		HasPassword:       true,
+		UpdatedAt:         now,
+		PasswordChangedAt: now,
+	}
+}
+
+// randomHashedPassword hashes a random password which nobody knows, the user
+// cannot sign in with a password until it is set.
+func (domain *UserDomain) randomHashedPassword() ([]byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	return HashPassword(hex.EncodeToString(secret)[:MaximumPassowrdLength])
+}
+
+// now is truncated to the precision of the database, so the update time read
+// back from the database is unchanged.
+func (domain *UserDomain) now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
 func (domain *UserDomain) validateDisplayName(displayname string) error {
 	if len(displayname) > MaximumDisplayNameLength {
 		return fmt.Errorf("%w: require at most %d characters", ErrDisplayNameInvalid, MaximumDisplayNameLength)
@@ -103,18 +315,8 @@ func (domain *UserDomain) validateDisplayName(displayname string) error {
 }
 
 func (domain *UserDomain) validateUsername(username string) error {
-	if len(username) > MaximumUsernameLength {
-		return fmt.Errorf("%w: require at most %d characters", ErrUsernameInvalid, MaximumUsernameLength)
-	}
-
-	if len(username) < MinimumUsernameLength {
-		return fmt.Errorf("%w: require at least %d characters", ErrUsernameInvalid, MinimumUsernameLength)
-	}
-
-	for _, c := range username {
-		if !xstring.IsNumber(c) && !xstring.IsLetter(c) && !xstring.IsUnderscore(c) {
-			return fmt.Errorf("%w: got an invalid character %c", ErrUsernameInvalid, c)
-		}
+	if reasons := checkUsernameFormat(username); len(reasons) > 0 {
+		return fmt.Errorf("%w: %s", ErrUsernameInvalid, reasons[0].Message)
 	}
 
 	return nil
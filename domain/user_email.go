@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+const MaximumEmailLength = 254
+
+// CanonicalEmail is the form in which two emails are compared.
+func CanonicalEmail(email string) string {
+	return strings.ToLower(email)
+}
+
+// EmailDomain returns the part of the email after the last @.
+func EmailDomain(email string) string {
+	return email[strings.LastIndex(email, "@")+1:]
+}
+
+// NormalizeEmail returns the canonical form of a bare email address, a name
+// or angle brackets are rejected.
+func (domain *UserDomain) NormalizeEmail(email string) (string, error) {
+	return normalizeEmail(email)
+}
+
+func normalizeEmail(email string) (string, error) {
+	if len(email) > MaximumEmailLength {
+		return "", fmt.Errorf("%w: require at most %d characters", ErrEmailInvalid, MaximumEmailLength)
+	}
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return "", fmt.Errorf("%w: %s is not an email address", ErrEmailInvalid, email)
+	}
+
+	return CanonicalEmail(email), nil
+}
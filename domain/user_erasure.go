@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/xybor-x/snowflake"
+)
+
+// ErasedDisplayName is the display name of every erased user.
+const ErasedDisplayName = "Erased user"
+
+const erasedUsernamePrefix = "erased_"
+
+// ErasedUsername is the pseudonym of an erased user. It only depends on the
+// user id, so it is stable and unique, and it is a valid username of at most
+// 20 characters.
+func ErasedUsername(userID snowflake.ID) string {
+	return erasedUsernamePrefix + strconv.FormatInt(userID.Int64(), 36)
+}
+
+func (domain *UserDomain) IsErased(user *User) bool {
+	return !user.ErasedAt.IsZero()
+}
+
+// ScheduleErasure schedules the erasure of the user after the grace period. An
+// erasure which is already scheduled keeps its time.
+func (domain *UserDomain) ScheduleErasure(user *User) error {
+	if domain.IsErased(user) {
+		return fmt.Errorf("%w: the user was erased at %s", ErrUserErased, user.ErasedAt.Format(time.RFC3339))
+	}
+
+	if !user.EraseAfter.IsZero() {
+		return nil
+	}
+
+	user.UpdatedAt = domain.now()
+	user.EraseAfter = user.UpdatedAt.Add(domain.ErasureGracePeriod)
+	return nil
+}
+
+// CancelErasure cancels the scheduled erasure of the user, an erased user
+// cannot be restored.
+func (domain *UserDomain) CancelErasure(user *User) error {
+	if domain.IsErased(user) {
+		return fmt.Errorf("%w: the user was erased at %s", ErrUserErased, user.ErasedAt.Format(time.RFC3339))
+	}
+
+	user.EraseAfter = time.Time{}
+	user.UpdatedAt = domain.now()
+	return nil
+}
+
+// Erase replaces the personal data of the user with a pseudonym and destroys
+// the password hash and the admin role, so the user cannot sign in anymore.
+// The avatar must be released by the caller. Erasing an erased user does
+// nothing.
+func (domain *UserDomain) Erase(user *User) {
+	if domain.IsErased(user) {
+		return
+	}
+
+	user.Username = ErasedUsername(user.ID)
+	user.DisplayName = ErasedDisplayName
+	user.HashedPass = ""
+	user.Avatar = 0
+	user.Role = enumdef.UserRoleUser
+	user.Active = false
+	user.PasswordResetRequired = false
+	user.HasPassword = false
+	user.LDAPID = ""
+	user.Email = ""
+	user.EmailVerified = false
+	user.Pending = false
+	user.UpdatedAt = domain.now()
+	user.ErasedAt = user.UpdatedAt
+}
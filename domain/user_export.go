@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// UserExportSchemaVersion is increased on incompatible changes of the export
+// fields, the import rejects the files of an unknown version.
+const UserExportSchemaVersion = 1
+
+// The columns of a CSV export, in order. They are also accepted by the import,
+// so an export can be imported into another deployment.
+const (
+	userExportColumnSchemaVersion = "schema_version"
+	userExportColumnID            = "id"
+	userExportColumnActive        = "active"
+	userExportColumnAvatar        = "avatar"
+)
+
+var userExportColumns = []string{
+	userExportColumnSchemaVersion,
+	userExportColumnID,
+	userImportColumnUsername,
+	userImportColumnDisplayName,
+	userImportColumnRole,
+	userExportColumnActive,
+	userImportColumnPasswordHash,
+	userImportColumnForceReset,
+	userExportColumnAvatar,
+}
+
+// UserExportOptions chooses the sensitive fields of an export. Without the
+// hashes, force_reset is still the one of the user, the rows without a hash
+// are rejected by the import unless force_reset is set on purpose.
+type UserExportOptions struct {
+	IncludeHashes  bool
+	IncludeAvatars bool
+}
+
+type userExportRecord struct {
+	SchemaVersion int    `json:"schema_version"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	DisplayName   string `json:"display_name"`
+	Role          string `json:"role"`
+	Active        bool   `json:"active"`
+	PasswordHash  string `json:"password_hash,omitempty"`
+	ForceReset    bool   `json:"force_reset"`
+	Avatar        string `json:"avatar,omitempty"`
+}
+
+// UserExportEncoder writes the exported users one by one, Close must be called
+// to flush the buffered users.
+type UserExportEncoder interface {
+	Encode(user *User) error
+	Close() error
+}
+
+// NewExportEncoder creates an encoder writing a CSV file with a header row,
+// or a newline delimited JSON file.
+func (domain *UserDomain) NewExportEncoder(format string, w io.Writer, options UserExportOptions) (UserExportEncoder, error) {
+	switch format {
+	case UserImportFormatCSV:
+		return newUserExportCSVEncoder(w, options)
+	case UserImportFormatNDJSON:
+		return newUserExportNDJSONEncoder(w, options), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %s", ErrImportFormatInvalid, format)
+	}
+}
+
+func newUserExportRecord(user *User, options UserExportOptions) *userExportRecord {
+	record := &userExportRecord{
+		SchemaVersion: UserExportSchemaVersion,
+		ID:            user.ID.String(),
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Role:          user.Role.String(),
+		Active:        user.Active,
+		ForceReset:    user.PasswordResetRequired,
+	}
+
+	if options.IncludeHashes {
+		record.PasswordHash = user.HashedPass
+	}
+
+	if options.IncludeAvatars && user.Avatar != 0 {
+		record.Avatar = user.Avatar.String()
+	}
+
+	return record
+}
+
+type userExportCSVEncoder struct {
+	writer  *csv.Writer
+	options UserExportOptions
+}
+
+func newUserExportCSVEncoder(w io.Writer, options UserExportOptions) (*userExportCSVEncoder, error) {
+	encoder := &userExportCSVEncoder{writer: csv.NewWriter(w), options: options}
+	if err := encoder.writer.Write(userExportColumns); err != nil {
+		return nil, err
+	}
+
+	return encoder, nil
+}
+
+func (encoder *userExportCSVEncoder) Encode(user *User) error {
+	record := newUserExportRecord(user, encoder.options)
+	return encoder.writer.Write([]string{
+		strconv.Itoa(record.SchemaVersion),
+		record.ID,
+		record.Username,
+		record.DisplayName,
+		record.Role,
+		strconv.FormatBool(record.Active),
+		record.PasswordHash,
+		strconv.FormatBool(record.ForceReset),
+		record.Avatar,
+	})
+}
+
+func (encoder *userExportCSVEncoder) Close() error {
+	encoder.writer.Flush()
+	return encoder.writer.Error()
+}
+
+type userExportNDJSONEncoder struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	options UserExportOptions
+}
+
+func newUserExportNDJSONEncoder(w io.Writer, options UserExportOptions) *userExportNDJSONEncoder {
+	buffer := bufio.NewWriter(w)
+	return &userExportNDJSONEncoder{buffer: buffer, encoder: json.NewEncoder(buffer), options: options}
+}
+
+func (encoder *userExportNDJSONEncoder) Encode(user *User) error {
+	return encoder.encoder.Encode(newUserExportRecord(user, encoder.options))
+}
+
+func (encoder *userExportNDJSONEncoder) Close() error {
+	return encoder.buffer.Flush()
+}
@@ -0,0 +1,75 @@
+package domain_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+func newExportedUser(t *testing.T, forceReset bool) (*domain.UserDomain, *domain.User) {
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	user, err := userDomain.New("exported", "s3Cr3tP@ssW0rD")
+	require.NoError(t, err)
+
+	user.PasswordResetRequired = forceReset
+	return userDomain, user
+}
+
+func exportNDJSON(t *testing.T, userDomain *domain.UserDomain, user *domain.User, options domain.UserExportOptions) map[string]any {
+	buffer := &bytes.Buffer{}
+	encoder, err := userDomain.NewExportEncoder(domain.UserImportFormatNDJSON, buffer, options)
+	require.NoError(t, err)
+	require.NoError(t, encoder.Encode(user))
+	require.NoError(t, encoder.Close())
+
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	return record
+}
+
+func TestExportWithoutHashesKeepsForceReset(t *testing.T) {
+	userDomain, user := newExportedUser(t, false)
+
+	record := exportNDJSON(t, userDomain, user, domain.UserExportOptions{})
+	require.Equal(t, false, record["force_reset"])
+	require.NotContains(t, record, "password_hash")
+
+	user.PasswordResetRequired = true
+	record = exportNDJSON(t, userDomain, user, domain.UserExportOptions{})
+	require.Equal(t, true, record["force_reset"])
+}
+
+func TestExportWithHashesRoundTrips(t *testing.T) {
+	userDomain, user := newExportedUser(t, false)
+
+	record := exportNDJSON(t, userDomain, user, domain.UserExportOptions{IncludeHashes: true})
+	require.Equal(t, user.HashedPass, record["password_hash"])
+	require.Equal(t, false, record["force_reset"])
+
+	row := &domain.UserImportRow{Username: "imported", PasswordHash: record["password_hash"].(string)}
+	imported, err := userDomain.NewImported(row)
+	require.NoError(t, err)
+	require.False(t, imported.PasswordResetRequired)
+	require.NoError(t, userDomain.Validate(imported.HashedPass, "s3Cr3tP@ssW0rD"))
+}
+
+func TestImportRejectsRowWithoutHashOrForceReset(t *testing.T) {
+	userDomain, _ := newExportedUser(t, false)
+
+	_, err := userDomain.NewImported(&domain.UserImportRow{Username: "imported"})
+	require.ErrorIs(t, err, domain.ErrPasswordHashInvalid)
+
+	imported, err := userDomain.NewImported(&domain.UserImportRow{Username: "imported", ForceReset: true})
+	require.NoError(t, err)
+	require.True(t, imported.PasswordResetRequired)
+}
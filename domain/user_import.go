@@ -0,0 +1,212 @@
+package domain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	UserImportFormatCSV    = "csv"
+	UserImportFormatNDJSON = "ndjson"
+)
+
+// The columns of a CSV import, the header row names them in any order. The
+// columns of an export are accepted as well.
+const (
+	userImportColumnUsername     = "username"
+	userImportColumnDisplayName  = "display_name"
+	userImportColumnRole         = "role"
+	userImportColumnPasswordHash = "password_hash"
+	userImportColumnForceReset   = "force_reset"
+)
+
+// maximumImportLineSize limits an NDJSON line, a user is far smaller.
+const maximumImportLineSize = 64 * 1024
+
+// UserImportRow is a user read from an import file. Line is the line of the
+// row in the file. A row which cannot be decoded keeps the error in Err, the
+// other rows are still imported.
+//
+// SchemaVersion, ID, Active and Avatar come from an export. The user keeps its
+// id if given, the avatar is not imported because it is owned by the file
+// service of the exporting deployment.
+type UserImportRow struct {
+	Line          int
+	SchemaVersion int          `json:"schema_version"`
+	ID            snowflake.ID `json:"id"`
+	Username      string       `json:"username"`
+	DisplayName   string       `json:"display_name"`
+	Role          string       `json:"role"`
+	Active        *bool        `json:"active"`
+	PasswordHash  string       `json:"password_hash"`
+	ForceReset    bool         `json:"force_reset"`
+	Avatar        string       `json:"avatar"`
+	Err           error        `json:"-"`
+}
+
+// DecodeImport reads the rows of a CSV file with a header row, or of a
+// newline delimited JSON file.
+func (domain *UserDomain) DecodeImport(format string, r io.Reader) ([]*UserImportRow, error) {
+	switch format {
+	case UserImportFormatCSV:
+		return decodeUserImportCSV(r)
+	case UserImportFormatNDJSON:
+		return decodeUserImportNDJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %s", ErrImportFormatInvalid, format)
+	}
+}
+
+func decodeUserImportCSV(r io.Reader) ([]*UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: require a header row", ErrImportFormatInvalid)
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrImportFormatInvalid, err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(userExportColumns, name) {
+			return nil, fmt.Errorf("%w: unknown column %s", ErrImportFormatInvalid, name)
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: duplicated column %s", ErrImportFormatInvalid, name)
+		}
+
+		columns[name] = i
+	}
+
+	if _, ok := columns[userImportColumnUsername]; !ok {
+		return nil, fmt.Errorf("%w: require the %s column", ErrImportFormatInvalid, userImportColumnUsername)
+	}
+
+	rows := []*UserImportRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read csv: %w", err)
+			}
+
+			rows = append(rows, &UserImportRow{
+				Line: parseErr.StartLine,
+				Err:  fmt.Errorf("%w: %s", ErrImportRowInvalid, parseErr.Err.Error()),
+			})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &UserImportRow{Line: line}
+		rows = append(rows, row)
+
+		if len(record) != len(header) {
+			row.Err = fmt.Errorf("%w: require %d fields but got %d", ErrImportRowInvalid, len(header), len(record))
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		row.Username = field(userImportColumnUsername)
+		row.DisplayName = field(userImportColumnDisplayName)
+		row.Role = field(userImportColumnRole)
+		row.PasswordHash = field(userImportColumnPasswordHash)
+
+		row.Err = decodeUserImportCSVFields(row, field)
+	}
+}
+
+func decodeUserImportCSVFields(row *UserImportRow, field func(string) string) error {
+	var err error
+	if forceReset := field(userImportColumnForceReset); forceReset != "" {
+		if row.ForceReset, err = strconv.ParseBool(forceReset); err != nil {
+			return fmt.Errorf("%w: force_reset requires a boolean", ErrImportRowInvalid)
+		}
+	}
+
+	if schemaVersion := field(userExportColumnSchemaVersion); schemaVersion != "" {
+		if row.SchemaVersion, err = strconv.Atoi(schemaVersion); err != nil {
+			return fmt.Errorf("%w: schema_version requires an integer", ErrImportRowInvalid)
+		}
+	}
+
+	if id := field(userExportColumnID); id != "" {
+		if row.ID, err = snowflake.ParseString(id); err != nil {
+			return fmt.Errorf("%w: invalid id %s", ErrImportRowInvalid, id)
+		}
+	}
+
+	if active := field(userExportColumnActive); active != "" {
+		value, err := strconv.ParseBool(active)
+		if err != nil {
+			return fmt.Errorf("%w: active requires a boolean", ErrImportRowInvalid)
+		}
+
+		row.Active = &value
+	}
+
+	row.Avatar = field(userExportColumnAvatar)
+	return nil
+}
+
+func decodeUserImportNDJSON(r io.Reader) ([]*UserImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maximumImportLineSize)
+
+	rows := []*UserImportRow{}
+	for line := 1; scanner.Scan(); line++ {
+		content := bytes.TrimSpace(scanner.Bytes())
+		if len(content) == 0 {
+			continue
+		}
+
+		row := &UserImportRow{}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(row); err != nil {
+			row = &UserImportRow{Err: fmt.Errorf("%w: %s", ErrImportRowInvalid, err.Error())}
+		}
+
+		row.Line = line
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("%w: a line exceeds %d bytes", ErrImportFormatInvalid, maximumImportLineSize)
+		}
+
+		return nil, fmt.Errorf("failed to read ndjson: %w", err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+// The statuses of the password of a user, returned when the credentials are
+// validated. An expired password must be changed before the user signs in.
+const (
+	PasswordStatusValid    = "valid"
+	PasswordStatusExpiring = "expiring"
+	PasswordStatusExpired  = "expired"
+)
+
+// PasswordPolicy defines the rotation of the passwords. The zero policy never
+// expires the passwords and allows reusing them.
+type PasswordPolicy struct {
+	// MaxAge is the age after which a password expires, zero for never. The
+	// password is expiring during the WarningWindow before.
+	MaxAge        time.Duration
+	WarningWindow time.Duration
+
+	// HistorySize is the number of the last passwords of a user, including
+	// the current one, which cannot be reused.
+	HistorySize int
+}
+
+// PasswordHistory is the hash of a previous password of a user.
+type PasswordHistory struct {
+	ID         snowflake.ID
+	UserID     snowflake.ID
+	HashedPass string
+	ReplacedAt time.Time
+}
+
+// PasswordStatus returns the status of the password of the user and the time
+// it expires, which is zero if it never expires. The passwords of the users
+// without a local password or sourced from LDAP never expire here.
+func (domain *UserDomain) PasswordStatus(user *User) (string, time.Time) {
+	policy := domain.PasswordPolicy
+	if policy.MaxAge == 0 || !user.HasPassword || user.LDAPID != "" {
+		return PasswordStatusValid, time.Time{}
+	}
+
+	expiresAt := user.PasswordChangedAt.Add(policy.MaxAge)
+	now := domain.now()
+	switch {
+	case !now.Before(expiresAt):
+		return PasswordStatusExpired, expiresAt
+	case !now.Before(expiresAt.Add(-policy.WarningWindow)):
+		return PasswordStatusExpiring, expiresAt
+	default:
+		return PasswordStatusValid, expiresAt
+	}
+}
+
+// ChangePassword replaces the password of the user, which must differ from the
+// last passwords retained by the policy. The histories must be sorted from the
+// newest to the oldest. It returns the history of the replaced password, or
+// nil if there is nothing to retain.
+func (domain *UserDomain) ChangePassword(user *User, password string, histories []*PasswordHistory) (*PasswordHistory, error) {
+	if err := domain.validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	size := domain.PasswordPolicy.HistorySize
+	if size > 0 {
+		previous := []string{}
+		if user.HasPassword {
+			previous = append(previous, user.HashedPass)
+		}
+
+		for _, history := range histories {
+			previous = append(previous, history.HashedPass)
+		}
+
+		if len(previous) > size {
+			previous = previous[:size]
+		}
+
+		for _, hashedPass := range previous {
+			if ValidatePassword(hashedPass, password) == nil {
+				return nil, fmt.Errorf("%w: the password is one of the last %d passwords", ErrPasswordReused, size)
+			}
+		}
+	}
+
+	var replaced *PasswordHistory
+	if size > 0 && user.HasPassword {
+		replaced = &PasswordHistory{
+			ID:         domain.Snowflake.Generate(),
+			UserID:     user.ID,
+			HashedPass: user.HashedPass,
+			ReplacedAt: domain.now(),
+		}
+	}
+
+	if err := domain.SetPassword(user, password); err != nil {
+		return nil, err
+	}
+
+	return replaced, nil
+}
+
+// EvictPasswordHistory returns the histories which are no longer needed to
+// forbid the reuse, the current password counts as one of the retained
+// passwords. The histories must be sorted from the newest to the oldest.
+func (domain *UserDomain) EvictPasswordHistory(histories []*PasswordHistory) []*PasswordHistory {
+	keep := max(domain.PasswordPolicy.HistorySize-1, 0)
+	if len(histories) <= keep {
+		return nil
+	}
+
+	return histories[keep:]
+}
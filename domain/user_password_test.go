@@ -0,0 +1,112 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+func newPasswordUserDomain(t *testing.T, policy domain.PasswordPolicy) *domain.UserDomain {
+	node, err := snowflake.NewNode(1)
+	require.NoError(t, err)
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, policy)
+	require.NoError(t, err)
+	return userDomain
+}
+
+func TestPasswordStatus(t *testing.T) {
+	userDomain := newPasswordUserDomain(t, domain.PasswordPolicy{
+		MaxAge:        30 * 24 * time.Hour,
+		WarningWindow: 7 * 24 * time.Hour,
+	})
+
+	user, err := userDomain.New("alice", "P@ssw0rd-0")
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name   string
+		age    time.Duration
+		status string
+	}{
+		{name: "new", age: 0, status: domain.PasswordStatusValid},
+		{name: "before the warning", age: 22 * 24 * time.Hour, status: domain.PasswordStatusValid},
+		{name: "in the warning", age: 24 * 24 * time.Hour, status: domain.PasswordStatusExpiring},
+		{name: "expired", age: 31 * 24 * time.Hour, status: domain.PasswordStatusExpired},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			user.PasswordChangedAt = time.Now().Add(-tc.age)
+
+			status, expiresAt := userDomain.PasswordStatus(user)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, user.PasswordChangedAt.Add(30*24*time.Hour), expiresAt)
+		})
+	}
+
+	user.PasswordChangedAt = time.Now().Add(-365 * 24 * time.Hour)
+
+	ldapUser := *user
+	ldapUser.LDAPID = "alice-id"
+	status, expiresAt := userDomain.PasswordStatus(&ldapUser)
+	require.Equal(t, domain.PasswordStatusValid, status)
+	require.True(t, expiresAt.IsZero())
+
+	status, _ = newPasswordUserDomain(t, domain.PasswordPolicy{}).PasswordStatus(user)
+	require.Equal(t, domain.PasswordStatusValid, status)
+}
+
+func TestChangePasswordRefusesRecentPasswords(t *testing.T) {
+	userDomain := newPasswordUserDomain(t, domain.PasswordPolicy{HistorySize: 3})
+
+	user, err := userDomain.New("alice", "P@ssw0rd-0")
+	require.NoError(t, err)
+
+	histories := []*domain.PasswordHistory{}
+	change := func(password string) error {
+		replaced, err := userDomain.ChangePassword(user, password, histories)
+		if err != nil {
+			return err
+		}
+
+		histories = append([]*domain.PasswordHistory{replaced}, histories...)
+		for _, evicted := range userDomain.EvictPasswordHistory(histories) {
+			histories = histories[:len(histories)-1]
+			require.Equal(t, user.ID, evicted.UserID)
+		}
+
+		return nil
+	}
+
+	require.ErrorIs(t, change("P@ssw0rd-0"), domain.ErrPasswordReused)
+
+	require.NoError(t, change("P@ssw0rd-1"))
+	require.NoError(t, change("P@ssw0rd-2"))
+	require.Len(t, histories, 2)
+
+	require.ErrorIs(t, change("P@ssw0rd-2"), domain.ErrPasswordReused)
+	require.ErrorIs(t, change("P@ssw0rd-1"), domain.ErrPasswordReused)
+	require.ErrorIs(t, change("P@ssw0rd-0"), domain.ErrPasswordReused)
+	require.NoError(t, domain.ValidatePassword(user.HashedPass, "P@ssw0rd-2"))
+
+	// The oldest password is evicted once it is not one of the last three.
+	require.NoError(t, change("P@ssw0rd-3"))
+	require.Len(t, histories, 2)
+	require.NoError(t, change("P@ssw0rd-0"))
+}
+
+func TestChangePasswordWithoutHistory(t *testing.T) {
+	userDomain := newPasswordUserDomain(t, domain.PasswordPolicy{})
+
+	user, err := userDomain.New("alice", "P@ssw0rd-0")
+	require.NoError(t, err)
+
+	replaced, err := userDomain.ChangePassword(user, "P@ssw0rd-0", nil)
+	require.NoError(t, err)
+	require.Nil(t, replaced)
+	require.Empty(t, userDomain.EvictPasswordHistory(nil))
+}
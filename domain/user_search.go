@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/todennus/x/xstring"
+)
+
+// UserSearchSimilarityThreshold is the minimum trigram similarity of a fuzzy
+// match, it is the default threshold of the pg_trgm % operator.
+const UserSearchSimilarityThreshold = 0.3
+
+// The scores of the search matches, a fuzzy match scores half of its trigram
+// similarity so it is always ranked after the other matches.
+const (
+	userSearchScoreUsername          = 1.0
+	userSearchScoreUsernamePrefix    = 0.9
+	userSearchScoreDisplayName       = 0.85
+	userSearchScoreDisplayNamePrefix = 0.8
+	userSearchScoreSubstring         = 0.6
+	userSearchScoreFuzzyWeight       = 0.5
+)
+
+// NormalizeSearchQuery lowercases the query and collapses its spaces. A query
+// has the characters of a display name.
+func (domain *UserDomain) NormalizeSearchQuery(query string) (string, error) {
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if query == "" {
+		return "", fmt.Errorf("%w: require a query", ErrSearchQueryInvalid)
+	}
+
+	if len(query) > MaximumDisplayNameLength {
+		return "", fmt.Errorf("%w: require at most %d characters", ErrSearchQueryInvalid, MaximumDisplayNameLength)
+	}
+
+	for _, c := range query {
+		if !xstring.IsNumber(c) && !xstring.IsLetter(c) && !xstring.IsUnderscore(c) && !xstring.IsSpace(c) {
+			return "", fmt.Errorf("%w: got an invalid character %c", ErrSearchQueryInvalid, c)
+		}
+	}
+
+	return query, nil
+}
+
+// RankSearch orders the users by their score for the normalized query and
+// keeps the first limit users. Users which do not match are removed.
+func (domain *UserDomain) RankSearch(users []*User, query string, limit int) []*User {
+	return RankUserSearch(users, query, limit)
+}
+
+// RankUserSearch is RankSearch for the repositories which cannot rank the
+// results themselves. Ties are ordered by username.
+func RankUserSearch(users []*User, query string, limit int) []*User {
+	type scoredUser struct {
+		user  *User
+		score float64
+	}
+
+	scored := []scoredUser{}
+	for _, user := range users {
+		if score := UserSearchScore(user, query); score > 0 {
+			scored = append(scored, scoredUser{user: user, score: score})
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+
+		return scored[i].user.Username < scored[j].user.Username
+	})
+
+	ranked := []*User{}
+	for i := 0; i < len(scored) && i < limit; i++ {
+		ranked = append(ranked, scored[i].user)
+	}
+
+	return ranked
+}
+
+// UserSearchScore scores the user for the normalized query, from 0 (no match)
+// to 1 (the exact username).
+func UserSearchScore(user *User, query string) float64 {
+	username := strings.ToLower(user.Username)
+	displayName := strings.ToLower(user.DisplayName)
+
+	switch {
+	case username == query:
+		return userSearchScoreUsername
+	case strings.HasPrefix(username, query):
+		return userSearchScoreUsernamePrefix
+	case displayName == query:
+		return userSearchScoreDisplayName
+	case hasWordPrefix(displayName, query):
+		return userSearchScoreDisplayNamePrefix
+	case strings.Contains(username, query) || strings.Contains(displayName, query):
+		return userSearchScoreSubstring
+	}
+
+	similarity := max(TrigramSimilarity(username, query), TrigramSimilarity(displayName, query))
+	if similarity < UserSearchSimilarityThreshold {
+		return 0
+	}
+
+	return similarity * userSearchScoreFuzzyWeight
+}
+
+// TrigramSimilarity is the similarity of pg_trgm: the number of trigrams
+// shared by both strings divided by the number of distinct trigrams. Words are
+// split on the characters other than letters and digits, and padded with two
+// spaces before and one space after.
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(c rune) bool {
+		return !xstring.IsLetter(c) && !xstring.IsNumber(c)
+	})
+
+	result := map[string]bool{}
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = true
+		}
+	}
+
+	return result
+}
+
+// hasWordPrefix reports whether s or one of its words starts with prefix.
+func hasWordPrefix(s, prefix string) bool {
+	if strings.HasPrefix(s, prefix) {
+		return true
+	}
+
+	for _, word := range strings.Fields(s) {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/todennus/x/xstring"
+)
+
+// The codes of the reasons why a username is unavailable.
+const (
+	UsernameReasonTooShort         = "too_short"
+	UsernameReasonTooLong          = "too_long"
+	UsernameReasonInvalidCharacter = "invalid_character"
+	UsernameReasonReserved         = "reserved"
+	UsernameReasonTaken            = "taken"
+)
+
+// UsernameReason explains why a username cannot be registered.
+type UsernameReason struct {
+	Code    string
+	Message string
+}
+
+// UsernameTakenReason is the reason of a username taken by another user.
+func UsernameTakenReason(username string) UsernameReason {
+	return UsernameReason{
+		Code:    UsernameReasonTaken,
+		Message: fmt.Sprintf("username %s has already existed", username),
+	}
+}
+
+// CanonicalUsername is the form in which two usernames are compared, usernames
+// differing only by their case belong to the same user.
+func CanonicalUsername(username string) string {
+	return strings.ToLower(username)
+}
+
+// CheckUsername returns every reason why the username cannot be registered,
+// regardless of the existing users. It is empty for an acceptable username.
+func (domain *UserDomain) CheckUsername(username string) []UsernameReason {
+	reasons := checkUsernameFormat(username)
+	if domain.IsReservedUsername(username) {
+		reasons = append(reasons, UsernameReason{
+			Code:    UsernameReasonReserved,
+			Message: fmt.Sprintf("the username %s is reserved", username),
+		})
+	}
+
+	return reasons
+}
+
+// IsReservedUsername reports whether the username is reserved for the system,
+// it cannot be registered by users but can still be provisioned or imported.
+func (domain *UserDomain) IsReservedUsername(username string) bool {
+	return domain.ReservedUsernames[CanonicalUsername(username)]
+}
+
+// SuggestUsernames returns up to n acceptable usernames derived from the given
+// one by appending a number. They are not checked against the existing users.
+func (domain *UserDomain) SuggestUsernames(username string, n int) []string {
+	base := strings.Map(func(c rune) rune {
+		if xstring.IsNumber(c) || xstring.IsLetter(c) || xstring.IsUnderscore(c) {
+			return c
+		}
+
+		return -1
+	}, username)
+
+	for strings.HasPrefix(CanonicalUsername(base), erasedUsernamePrefix) {
+		base = base[len(erasedUsernamePrefix):]
+	}
+
+	base = strings.TrimRight(base, "_")
+	if base == "" {
+		return []string{}
+	}
+
+	seen := map[string]bool{CanonicalUsername(username): true}
+	suggestions := []string{}
+	for attempt := 0; len(suggestions) < n && attempt < 10*n; attempt++ {
+		suffix := strconv.Itoa(10 + rand.IntN(990))
+		if attempt%2 == 1 {
+			suffix = "_" + suffix
+		}
+
+		prefix := base
+		if len(prefix)+len(suffix) > MaximumUsernameLength {
+			prefix = prefix[:MaximumUsernameLength-len(suffix)]
+		}
+
+		candidate := prefix + suffix
+		if seen[CanonicalUsername(candidate)] || len(domain.CheckUsername(candidate)) > 0 {
+			continue
+		}
+
+		seen[CanonicalUsername(candidate)] = true
+		suggestions = append(suggestions, candidate)
+	}
+
+	return suggestions
+}
+
+func checkUsernameFormat(username string) []UsernameReason {
+	reasons := []UsernameReason{}
+	if len(username) > MaximumUsernameLength {
+		reasons = append(reasons, UsernameReason{
+			Code:    UsernameReasonTooLong,
+			Message: fmt.Sprintf("require at most %d characters", MaximumUsernameLength),
+		})
+	}
+
+	if len(username) < MinimumUsernameLength {
+		reasons = append(reasons, UsernameReason{
+			Code:    UsernameReasonTooShort,
+			Message: fmt.Sprintf("require at least %d characters", MinimumUsernameLength),
+		})
+	}
+
+	for _, c := range username {
+		if !xstring.IsNumber(c) && !xstring.IsLetter(c) && !xstring.IsUnderscore(c) {
+			reasons = append(reasons, UsernameReason{
+				Code:    UsernameReasonInvalidCharacter,
+				Message: fmt.Sprintf("got an invalid character %c", c),
+			})
+			break
+		}
+	}
+
+	if strings.HasPrefix(CanonicalUsername(username), erasedUsernamePrefix) {
+		reasons = append(reasons, UsernameReason{
+			Code:    UsernameReasonReserved,
+			Message: fmt.Sprintf("the prefix %s is reserved", erasedUsernamePrefix),
+		})
+	}
+
+	return reasons
+}
@@ -0,0 +1,248 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+// WebhookAllEvents subscribes a webhook to every event type, including the ones
+// added later.
+const WebhookAllEvents = "*"
+
+const webhookSecretPrefix = "whsec_"
+
+type Webhook struct {
+	ID         snowflake.ID
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedBy  snowflake.ID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// WebhookDelivery is an event waiting to be delivered to a webhook.
+type WebhookDelivery struct {
+	ID            snowflake.ID
+	WebhookID     snowflake.ID
+	EventID       snowflake.ID
+	EventType     string
+	Payload       []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	DeliveredAt   time.Time
+}
+
+// WebhookDeliveryLog records a single attempt of a delivery.
+type WebhookDeliveryLog struct {
+	ID          snowflake.ID
+	DeliveryID  snowflake.ID
+	WebhookID   snowflake.ID
+	EventID     snowflake.ID
+	EventType   string
+	Attempt     int
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+// WebhookDeadLetter is a delivery which failed every attempt. It is kept until
+// being replayed.
+type WebhookDeadLetter struct {
+	ID        snowflake.ID
+	WebhookID snowflake.ID
+	EventID   snowflake.ID
+	EventType string
+	Payload   []byte
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+	DeadAt    time.Time
+}
+
+type WebhookDomain struct {
+	Snowflake *snowflake.Node
+
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	ClaimLease      time.Duration
+}
+
+func NewWebhookDomain(
+	snowflake *snowflake.Node,
+	maxAttempts int,
+	retryBackoff time.Duration,
+	maxRetryBackoff time.Duration,
+	claimLease time.Duration,
+) *WebhookDomain {
+	return &WebhookDomain{
+		Snowflake:       snowflake,
+		MaxAttempts:     maxAttempts,
+		RetryBackoff:    retryBackoff,
+		MaxRetryBackoff: maxRetryBackoff,
+		ClaimLease:      claimLease,
+	}
+}
+
+func (domain *WebhookDomain) New(rawURL string, eventTypes []string, createdBy snowflake.ID) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: require an absolute http or https url", ErrWebhookURLInvalid)
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: require at least one event type", ErrWebhookEventTypeInvalid)
+	}
+
+	for _, eventType := range eventTypes {
+		if eventType != WebhookAllEvents && !slices.Contains(EventTypes, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %s", ErrWebhookEventTypeInvalid, eventType)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	now := time.Now()
+	return &Webhook{
+		ID:         domain.Snowflake.Generate(),
+		URL:        u.String(),
+		Secret:     webhookSecretPrefix + hex.EncodeToString(secret),
+		EventTypes: eventTypes,
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+func (domain *WebhookDomain) Subscribes(webhook *Webhook, eventType string) bool {
+	return slices.Contains(webhook.EventTypes, WebhookAllEvents) || slices.Contains(webhook.EventTypes, eventType)
+}
+
+func (domain *WebhookDomain) NewDelivery(webhook *Webhook, event *Event) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:            domain.Snowflake.Generate(),
+		WebhookID:     webhook.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Sign returns the value of the signature header. The receiver computes the
+// HMAC-SHA256 of "<t>.<body>" with the webhook secret and compares it with v1,
+// then rejects timestamps which are too old to prevent replay attacks.
+func (domain *WebhookDomain) Sign(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func (domain *WebhookDomain) NewDeliveryLog(
+	delivery *WebhookDelivery,
+	attemptedAt time.Time,
+	statusCode int,
+	err error,
+) *WebhookDeliveryLog {
+	log := &WebhookDeliveryLog{
+		ID:          domain.Snowflake.Generate(),
+		DeliveryID:  delivery.ID,
+		WebhookID:   delivery.WebhookID,
+		EventID:     delivery.EventID,
+		EventType:   delivery.EventType,
+		Attempt:     delivery.Attempts + 1,
+		StatusCode:  statusCode,
+		Duration:    time.Since(attemptedAt),
+		AttemptedAt: attemptedAt,
+	}
+
+	if err != nil {
+		log.Error = err.Error()
+	}
+
+	return log
+}
+
+// Lease postpones the next attempt of a claimed delivery until the end of the
+// lease, so other dispatchers do not claim it while it is being sent.
+func (domain *WebhookDomain) Lease(delivery *WebhookDelivery) {
+	delivery.NextAttemptAt = time.Now().Add(domain.ClaimLease)
+}
+
+func (domain *WebhookDomain) SetDelivered(delivery *WebhookDelivery) {
+	delivery.Attempts++
+	delivery.LastError = ""
+	delivery.DeliveredAt = time.Now()
+}
+
+// SetFailed schedules the next attempt with an exponential backoff. It
+// returns a dead letter if the delivery has used up all of its attempts.
+func (domain *WebhookDomain) SetFailed(delivery *WebhookDelivery, err error) *WebhookDeadLetter {
+	backoff := domain.RetryBackoff << min(delivery.Attempts, 30)
+	if backoff <= 0 || backoff > domain.MaxRetryBackoff {
+		backoff = domain.MaxRetryBackoff
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	delivery.NextAttemptAt = time.Now().Add(backoff)
+
+	if delivery.Attempts < domain.MaxAttempts {
+		return nil
+	}
+
+	return &WebhookDeadLetter{
+		ID:        delivery.ID,
+		WebhookID: delivery.WebhookID,
+		EventID:   delivery.EventID,
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+		Attempts:  delivery.Attempts,
+		LastError: delivery.LastError,
+		CreatedAt: delivery.CreatedAt,
+		DeadAt:    time.Now(),
+	}
+}
+
+// Replay creates a new delivery of the dead letter with fresh attempts.
+func (domain *WebhookDomain) Replay(deadLetter *WebhookDeadLetter) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:            domain.Snowflake.Generate(),
+		WebhookID:     deadLetter.WebhookID,
+		EventID:       deadLetter.EventID,
+		EventType:     deadLetter.EventType,
+		Payload:       deadLetter.Payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
@@ -0,0 +1,154 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader keeps a key pair and a CA pool loaded from disk. The files are
+// checked again at most once per interval, during a handshake, and loaded
+// again if they have been modified. A failed reload keeps the previous
+// certificates so a half-written file doesn't break new connections.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func NewReloader(certFile, keyFile, caFile string, interval time.Duration) (*Reloader, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("require both certificate and key file")
+	}
+
+	reloader := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		interval: interval,
+	}
+
+	modTime, err := reloader.lastModified()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := reloader.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// Certificate returns the current key pair, nil if no key pair is configured.
+func (reloader *Reloader) Certificate() *tls.Certificate {
+	reloader.reloadIfModified()
+
+	reloader.mu.RLock()
+	defer reloader.mu.RUnlock()
+	return reloader.cert
+}
+
+// Pool returns the current CA pool, nil if no CA file is configured.
+func (reloader *Reloader) Pool() *x509.CertPool {
+	reloader.reloadIfModified()
+
+	reloader.mu.RLock()
+	defer reloader.mu.RUnlock()
+	return reloader.pool
+}
+
+func (reloader *Reloader) reloadIfModified() {
+	reloader.mu.RLock()
+	due := reloader.interval > 0 && time.Since(reloader.checkedAt) >= reloader.interval
+	current := reloader.modTime
+	reloader.mu.RUnlock()
+
+	if !due {
+		return
+	}
+
+	modTime, err := reloader.lastModified()
+	if err == nil && modTime.After(current) {
+		err = reloader.load(modTime)
+		if err == nil {
+			slog.Info("Reloaded certificates", "cert", reloader.certFile, "ca", reloader.caFile)
+		}
+	}
+
+	if err != nil {
+		slog.Warn("Failed to reload certificates, keep the previous ones", "err", err)
+	}
+
+	reloader.mu.Lock()
+	reloader.checkedAt = time.Now()
+	reloader.mu.Unlock()
+}
+
+func (reloader *Reloader) load(modTime time.Time) error {
+	var cert *tls.Certificate
+	if reloader.certFile != "" {
+		pair, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair: %w", err)
+		}
+
+		cert = &pair
+	}
+
+	var pool *x509.CertPool
+	if reloader.caFile != "" {
+		content, err := os.ReadFile(reloader.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read ca file: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return fmt.Errorf("not found any certificate in ca file %s", reloader.caFile)
+		}
+	}
+
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	reloader.cert = cert
+	reloader.pool = pool
+	reloader.modTime = modTime
+	reloader.checkedAt = time.Now()
+
+	return nil
+}
+
+// lastModified returns the latest modification time among the files.
+func (reloader *Reloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{reloader.certFile, reloader.keyFile, reloader.caFile} {
+		if path == "" {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,137 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+type ServerConfig struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile is used to verify the client certificates, ClientAuth
+	// decides whether a client certificate is required (mTLS).
+	ClientCAFile string
+	ClientAuth   string
+
+	ReloadInterval time.Duration
+}
+
+// NewServerTLSConfig returns a tls config whose certificate and client CA pool
+// follow the files on disk.
+func NewServerTLSConfig(config ServerConfig) (*tls.Config, error) {
+	if config.CertFile == "" {
+		return nil, errors.New("require certificate file for tls server")
+	}
+
+	var clientAuth tls.ClientAuthType
+	switch config.ClientAuth {
+	case "", ClientAuthNone:
+		clientAuth = tls.NoClientCert
+	case ClientAuthRequest:
+		clientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid client auth %s", config.ClientAuth)
+	}
+
+	if clientAuth != tls.NoClientCert && config.ClientCAFile == "" {
+		return nil, errors.New("require client ca file to verify client certificates")
+	}
+
+	reloader, err := NewReloader(config.CertFile, config.KeyFile, config.ClientCAFile, config.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*reloader.Certificate()},
+				ClientAuth:   clientAuth,
+				ClientCAs:    reloader.Pool(),
+			}, nil
+		},
+	}, nil
+}
+
+type ClientConfig struct {
+	// CAFile is used to verify the server certificate, the system pool is used
+	// if it is empty.
+	CAFile string
+
+	// CertFile and KeyFile are presented to the server for mTLS.
+	CertFile string
+	KeyFile  string
+
+	// ServerName is the name the server certificate is verified against. It is
+	// required with CAFile, the name of the dialed address is not used.
+	ServerName     string
+	ReloadInterval time.Duration
+}
+
+// NewClientTLSConfig returns a tls config whose client certificate and CA pool
+// follow the files on disk.
+func NewClientTLSConfig(config ClientConfig) (*tls.Config, error) {
+	if config.CAFile != "" && config.ServerName == "" {
+		return nil, errors.New("require server name to verify the server certificate")
+	}
+
+	reloader, err := NewReloader(config.CertFile, config.KeyFile, config.CAFile, config.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.ServerName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := reloader.Certificate(); cert != nil {
+				return cert, nil
+			}
+
+			return &tls.Certificate{}, nil
+		},
+	}
+
+	if config.CAFile == "" {
+		return tlsConfig, nil
+	}
+
+	// RootCAs is read once per config, so the server certificate is verified
+	// manually against the current pool to pick up a rotated CA. It is verified
+	// against the configured name, the name in the connection state is empty
+	// when an IP address is dialed, which would skip the hostname check.
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server didn't present any certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			DNSName:       config.ServerName,
+			Roots:         reloader.Pool(),
+			Intermediates: intermediates,
+		})
+		return err
+	}
+
+	return tlsConfig, nil
+}
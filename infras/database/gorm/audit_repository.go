@@ -0,0 +1,165 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey is the key of the transaction-level advisory lock held
+// while appending to the audit log chain.
+const auditChainLockKey = 0x61756469746c6f67 // "auditlog"
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// LockChain must be called inside a transaction, the lock is released when the
+// transaction ends.
+func (repo *AuditLogRepository) LockChain(ctx context.Context) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error,
+	)
+}
+
+func (repo *AuditLogRepository) GetLast(ctx context.Context) (*domain.AuditLog, error) {
+	model := model.AuditLogModel{}
+	if err := xcontext.DB(ctx, repo.db).Order("seq DESC").Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To()
+}
+
+func (repo *AuditLogRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	model, err := model.NewAuditLog(log)
+	if err != nil {
+		return err
+	}
+
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+// Scrub saves the scrubbed metadata of the log, the only change allowed by the
+// append-only trigger.
+func (repo *AuditLogRepository) Scrub(ctx context.Context, log *domain.AuditLog) error {
+	m, err := model.NewAuditLog(log)
+	if err != nil {
+		return err
+	}
+
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(&model.AuditLogModel{}).
+			Where("seq=?", log.Seq).
+			Updates(map[string]any{
+				"metadata":      m.Metadata,
+				"metadata_salt": m.MetadataSalt,
+				"scrubbed_at":   m.ScrubbedAt,
+			}).Error,
+	)
+}
+
+// Find returns the matched logs from the newest to the oldest.
+func (repo *AuditLogRepository) Find(
+	ctx context.Context,
+	filter *abstraction.AuditLogFilter,
+	limit int,
+) ([]*domain.AuditLog, error) {
+	db := xcontext.DB(ctx, repo.db)
+	if filter.ActorID != 0 {
+		db = db.Where("actor_id=?", filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		db = db.Where("action=?", filter.Action)
+	}
+
+	if filter.TargetType != "" {
+		db = db.Where("target_type=?", filter.TargetType)
+	}
+
+	if filter.TargetID != "" {
+		db = db.Where("target_id=?", filter.TargetID)
+	}
+
+	if !filter.From.IsZero() {
+		db = db.Where("created_at>=?", filter.From.UTC())
+	}
+
+	if !filter.To.IsZero() {
+		db = db.Where("created_at<?", filter.To.UTC())
+	}
+
+	if filter.BeforeSeq != 0 {
+		db = db.Where("seq<?", filter.BeforeSeq)
+	}
+
+	return repo.find(db.Order("seq DESC").Limit(limit))
+}
+
+// GetAfter returns the logs whose sequence is greater than afterSeq, from the
+// oldest to the newest.
+func (repo *AuditLogRepository) GetAfter(ctx context.Context, afterSeq int64, limit int) ([]*domain.AuditLog, error) {
+	return repo.find(xcontext.DB(ctx, repo.db).Where("seq>?", afterSeq).Order("seq").Limit(limit))
+}
+
+// GetByUser returns the logs whose actor or target is the user and whose
+// sequence is greater than afterSeq, from the oldest to the newest.
+func (repo *AuditLogRepository) GetByUser(
+	ctx context.Context,
+	userID snowflake.ID,
+	afterSeq int64,
+	limit int,
+) ([]*domain.AuditLog, error) {
+	return repo.find(xcontext.DB(ctx, repo.db).
+		Where("seq>?", afterSeq).
+		Where("actor_id=? OR (target_type=? AND target_id=?)", userID, domain.AuditTargetUser, userID.String()).
+		Order("seq").
+		Limit(limit))
+}
+
+// GetByUnknownUsername returns the failed validations of the username made
+// while no user had it, whose sequence is greater than afterSeq, from the
+// oldest to the newest. The username is matched case-insensitively.
+func (repo *AuditLogRepository) GetByUnknownUsername(
+	ctx context.Context,
+	username string,
+	afterSeq int64,
+	limit int,
+) ([]*domain.AuditLog, error) {
+	return repo.find(xcontext.DB(ctx, repo.db).
+		Where("seq>?", afterSeq).
+		Where("action=? AND target_id=''", domain.AuditUserValidateCredentialsFailed).
+		Where("LOWER(metadata::jsonb ->> 'username')=LOWER(?)", username).
+		Order("seq").
+		Limit(limit))
+}
+
+func (repo *AuditLogRepository) find(db *gorm.DB) ([]*domain.AuditLog, error) {
+	models := []model.AuditLogModel{}
+	if err := db.Find(&models).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	logs := []*domain.AuditLog{}
+	for i := range models {
+		log, err := models[i].To()
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, log)
+	}
+
+	return logs, nil
+}
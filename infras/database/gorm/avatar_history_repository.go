@@ -0,0 +1,53 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+type AvatarHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewAvatarHistoryRepository(db *gorm.DB) *AvatarHistoryRepository {
+	return &AvatarHistoryRepository{db: db}
+}
+
+func (repo *AvatarHistoryRepository) Create(ctx context.Context, history *domain.AvatarHistory) error {
+	model := model.NewAvatarHistory(history)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *AvatarHistoryRepository) GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.AvatarHistory, error) {
+	models := []model.AvatarHistoryModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("user_id=?", userID).
+		Order("replaced_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	histories := []*domain.AvatarHistory{}
+	for i := range models {
+		histories = append(histories, models[i].To())
+	}
+
+	return histories, nil
+}
+
+func (repo *AvatarHistoryRepository) Delete(ctx context.Context, historyIDs ...snowflake.ID) error {
+	if len(historyIDs) == 0 {
+		return nil
+	}
+
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("id IN ?", historyIDs).Delete(&model.AvatarHistoryModel{}).Error,
+	)
+}
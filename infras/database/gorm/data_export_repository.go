@@ -0,0 +1,98 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DataExportRepository struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) *DataExportRepository {
+	return &DataExportRepository{db: db}
+}
+
+func (repo *DataExportRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	model := model.NewDataExport(export)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *DataExportRepository) GetByID(ctx context.Context, exportID snowflake.ID) (*domain.DataExport, error) {
+	model := model.DataExportModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("id=?", exportID).Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+// GetPendingByUserID returns the export of the user which is not generated yet.
+func (repo *DataExportRepository) GetPendingByUserID(ctx context.Context, userID snowflake.ID) (*domain.DataExport, error) {
+	model := model.DataExportModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Omit("archive").
+		Where("user_id=? AND status=?", userID, domain.DataExportStatusPending).
+		Take(&model).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+// ClaimPending locks the exports which are not generated yet, skipping the
+// ones locked by another worker. It must be called inside a transaction.
+func (repo *DataExportRepository) ClaimPending(ctx context.Context, limit int) ([]*domain.DataExport, error) {
+	models := []model.DataExportModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status=?", domain.DataExportStatusPending).
+		Order("id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	exports := []*domain.DataExport{}
+	for i := range models {
+		exports = append(exports, models[i].To())
+	}
+
+	return exports, nil
+}
+
+func (repo *DataExportRepository) Update(ctx context.Context, export *domain.DataExport) error {
+	model := model.NewDataExport(export)
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(model).
+			Select("status", "archive", "error", "completed_at", "expires_at").
+			Updates(model).Error,
+	)
+}
+
+// DeleteExpired deletes the exports expired before the given time and returns
+// the number of deleted exports.
+func (repo *DataExportRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := xcontext.DB(ctx, repo.db).Where("expires_at<?", before).Delete(&model.DataExportModel{})
+	if result.Error != nil {
+		return 0, errordef.ConvertGormError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (repo *DataExportRepository) DeleteByUserID(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Delete(&model.DataExportModel{}).Error,
+	)
+}
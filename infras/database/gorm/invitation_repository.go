@@ -0,0 +1,82 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+func (repo *InvitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	model := model.NewInvitation(invitation)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *InvitationRepository) GetByID(ctx context.Context, invitationID snowflake.ID) (*domain.Invitation, error) {
+	model := model.InvitationModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("id=?", invitationID).Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+// GetBeforeID returns the invitations whose id is less than beforeID, the
+// latest first. A zero beforeID starts from the latest invitation.
+func (repo *InvitationRepository) GetBeforeID(
+	ctx context.Context,
+	beforeID snowflake.ID,
+	limit int,
+) ([]*domain.Invitation, error) {
+	db := xcontext.DB(ctx, repo.db)
+	if beforeID != 0 {
+		db = db.Where("id<?", beforeID)
+	}
+
+	models := []model.InvitationModel{}
+	if err := db.Order("id DESC").Limit(limit).Find(&models).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	invitations := []*domain.Invitation{}
+	for i := range models {
+		invitations = append(invitations, models[i].To())
+	}
+
+	return invitations, nil
+}
+
+// Update saves the redemption or the revocation of the invitation if it was
+// neither redeemed nor revoked, so an invitation is redeemed at most once.
+func (repo *InvitationRepository) Update(ctx context.Context, invitation *domain.Invitation) error {
+	m := model.NewInvitation(invitation)
+	result := xcontext.DB(ctx, repo.db).Model(&model.InvitationModel{}).
+		Where("id=? AND redeemed_at IS NULL AND revoked_at IS NULL", invitation.ID).
+		Updates(map[string]any{
+			"redeemed_at": m.RedeemedAt,
+			"redeemed_by": m.RedeemedBy,
+			"revoked_at":  m.RevokedAt,
+		})
+	if result.Error != nil {
+		return errordef.ConvertGormError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return abstraction.ErrStaleVersion
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+type LinkedIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkedIdentityRepository(db *gorm.DB) *LinkedIdentityRepository {
+	return &LinkedIdentityRepository{db: db}
+}
+
+// Create returns ErrDuplicated if the external identity is already linked, or
+// if the user already has an identity of the provider.
+func (repo *LinkedIdentityRepository) Create(ctx context.Context, identity *domain.LinkedIdentity) error {
+	model := model.NewLinkedIdentity(identity)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *LinkedIdentityRepository) GetByExternalIdentity(
+	ctx context.Context,
+	provider, subject string,
+) (*domain.LinkedIdentity, error) {
+	model := model.LinkedIdentityModel{}
+	err := xcontext.DB(ctx, repo.db).Where("provider=? AND subject=?", provider, subject).Take(&model).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+func (repo *LinkedIdentityRepository) GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.LinkedIdentity, error) {
+	models := []model.LinkedIdentityModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Order("id").Find(&models).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	identities := []*domain.LinkedIdentity{}
+	for i := range models {
+		identities = append(identities, models[i].To())
+	}
+
+	return identities, nil
+}
+
+func (repo *LinkedIdentityRepository) Delete(ctx context.Context, identityID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("id=?", identityID).Delete(&model.LinkedIdentityModel{}).Error,
+	)
+}
+
+func (repo *LinkedIdentityRepository) DeleteByUserID(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Delete(&model.LinkedIdentityModel{}).Error,
+	)
+}
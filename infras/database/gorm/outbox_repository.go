@@ -0,0 +1,82 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (repo *OutboxRepository) Create(ctx context.Context, msg *domain.OutboxMessage) error {
+	model := model.NewOutboxMessage(msg)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+// ClaimPending locks the messages which are ready to be delivered. Rows
+// locked by another relay are skipped, so the same message is never claimed
+// concurrently. It must be called inside a transaction which leases the
+// messages before committing.
+func (repo *OutboxRepository) ClaimPending(
+	ctx context.Context,
+	topics []string,
+	maxAttempts int,
+	limit int,
+) ([]*domain.OutboxMessage, error) {
+	models := []model.OutboxMessageModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("topic IN ?", topics).
+		Where("delivered_at IS NULL AND attempts<? AND next_attempt_at<=?", maxAttempts, time.Now()).
+		Order("id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return toOutboxMessages(models), nil
+}
+
+func (repo *OutboxRepository) GetDead(ctx context.Context, topic string, maxAttempts int) ([]*domain.OutboxMessage, error) {
+	models := []model.OutboxMessageModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("topic=? AND delivered_at IS NULL AND attempts>=?", topic, maxAttempts).
+		Order("id").
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return toOutboxMessages(models), nil
+}
+
+func (repo *OutboxRepository) Update(ctx context.Context, msg *domain.OutboxMessage) error {
+	model := model.NewOutboxMessage(msg)
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(model).
+			Select("attempts", "next_attempt_at", "last_error", "delivered_at").
+			Updates(model).Error,
+	)
+}
+
+func toOutboxMessages(models []model.OutboxMessageModel) []*domain.OutboxMessage {
+	msgs := []*domain.OutboxMessage{}
+	for i := range models {
+		msgs = append(msgs, models[i].To())
+	}
+
+	return msgs
+}
@@ -0,0 +1,94 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+type PasskeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPasskeyRepository(db *gorm.DB) *PasskeyRepository {
+	return &PasskeyRepository{db: db}
+}
+
+func (repo *PasskeyRepository) Create(ctx context.Context, passkey *domain.Passkey) error {
+	model := model.NewPasskey(passkey)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *PasskeyRepository) GetByID(ctx context.Context, passkeyID snowflake.ID) (*domain.Passkey, error) {
+	model := model.PasskeyModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("id=?", passkeyID).Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+func (repo *PasskeyRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*domain.Passkey, error) {
+	model := model.PasskeyModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("credential_id=?", credentialID).Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+// GetByUserID returns the passkeys of the user, the oldest first.
+func (repo *PasskeyRepository) GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.Passkey, error) {
+	models := []model.PasskeyModel{}
+	err := xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Order("id").Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	passkeys := []*domain.Passkey{}
+	for i := range models {
+		passkeys = append(passkeys, models[i].To())
+	}
+
+	return passkeys, nil
+}
+
+func (repo *PasskeyRepository) UpdateName(ctx context.Context, passkey *domain.Passkey) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(&model.PasskeyModel{}).
+			Where("id=?", passkey.ID).
+			Update("name", passkey.Name).Error,
+	)
+}
+
+// UpdateUsage saves the last assertion with the passkey unless an assertion
+// with a greater signature counter has already been saved.
+func (repo *PasskeyRepository) UpdateUsage(ctx context.Context, passkey *domain.Passkey) error {
+	m := model.NewPasskey(passkey)
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(&model.PasskeyModel{}).
+			Where("id=? AND sign_count<=?", passkey.ID, m.SignCount).
+			Updates(map[string]any{
+				"sign_count":   m.SignCount,
+				"backup_state": m.BackupState,
+				"last_used_at": m.LastUsedAt,
+			}).Error,
+	)
+}
+
+func (repo *PasskeyRepository) Delete(ctx context.Context, passkeyID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("id=?", passkeyID).Delete(&model.PasskeyModel{}).Error,
+	)
+}
+
+func (repo *PasskeyRepository) DeleteByUserID(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Delete(&model.PasskeyModel{}).Error,
+	)
+}
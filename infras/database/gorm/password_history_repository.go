@@ -0,0 +1,59 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) *PasswordHistoryRepository {
+	return &PasswordHistoryRepository{db: db}
+}
+
+func (repo *PasswordHistoryRepository) Create(ctx context.Context, history *domain.PasswordHistory) error {
+	model := model.NewPasswordHistory(history)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *PasswordHistoryRepository) GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.PasswordHistory, error) {
+	models := []model.PasswordHistoryModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("user_id=?", userID).
+		Order("replaced_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	histories := []*domain.PasswordHistory{}
+	for i := range models {
+		histories = append(histories, models[i].To())
+	}
+
+	return histories, nil
+}
+
+func (repo *PasswordHistoryRepository) Delete(ctx context.Context, historyIDs ...snowflake.ID) error {
+	if len(historyIDs) == 0 {
+		return nil
+	}
+
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("id IN ?", historyIDs).Delete(&model.PasswordHistoryModel{}).Error,
+	)
+}
+
+func (repo *PasswordHistoryRepository) DeleteByUserID(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Delete(&model.PasswordHistoryModel{}).Error,
+	)
+}
@@ -0,0 +1,107 @@
+package gorm
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+)
+
+type PersonalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db}
+}
+
+func (repo *PersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	model := model.NewPersonalAccessToken(token)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *PersonalAccessTokenRepository) GetByID(ctx context.Context, tokenID snowflake.ID) (*domain.PersonalAccessToken, error) {
+	model := model.PersonalAccessTokenModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("id=?", tokenID).Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+func (repo *PersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	model := model.PersonalAccessTokenModel{}
+	if err := xcontext.DB(ctx, repo.db).Where("token_hash=?", tokenHash).Take(&model).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+// GetByUserID returns the tokens of the user, the latest first.
+func (repo *PersonalAccessTokenRepository) GetByUserID(
+	ctx context.Context,
+	userID snowflake.ID,
+) ([]*domain.PersonalAccessToken, error) {
+	models := []model.PersonalAccessTokenModel{}
+	err := xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Order("id DESC").Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	tokens := []*domain.PersonalAccessToken{}
+	for i := range models {
+		tokens = append(tokens, models[i].To())
+	}
+
+	return tokens, nil
+}
+
+// LockUser locks the row of the user, so the tokens of the user are counted
+// and created by one transaction at a time. It must be called inside a
+// transaction, the lock is released when the transaction ends.
+func (repo *PersonalAccessTokenRepository) LockUser(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Exec("SELECT id FROM users WHERE id=? FOR UPDATE", userID).Error,
+	)
+}
+
+func (repo *PersonalAccessTokenRepository) CountActiveByUserID(ctx context.Context, userID snowflake.ID) (int, error) {
+	var n int64
+	err := xcontext.DB(ctx, repo.db).Model(&model.PersonalAccessTokenModel{}).
+		Where("user_id=? AND revoked_at IS NULL AND expires_at>NOW()", userID).
+		Count(&n).Error
+	if err != nil {
+		return 0, errordef.ConvertGormError(err)
+	}
+
+	return int(n), nil
+}
+
+func (repo *PersonalAccessTokenRepository) Revoke(ctx context.Context, token *domain.PersonalAccessToken) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(&model.PersonalAccessTokenModel{}).
+			Where("id=? AND revoked_at IS NULL", token.ID).
+			Update("revoked_at", token.RevokedAt).Error,
+	)
+}
+
+// UpdateLastUsedAt saves the last use of the token unless a later use has
+// already been saved.
+func (repo *PersonalAccessTokenRepository) UpdateLastUsedAt(ctx context.Context, token *domain.PersonalAccessToken) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(&model.PersonalAccessTokenModel{}).
+			Where("id=? AND (last_used_at IS NULL OR last_used_at<?)", token.ID, token.LastUsedAt).
+			Update("last_used_at", token.LastUsedAt).Error,
+	)
+}
+
+func (repo *PersonalAccessTokenRepository) DeleteByUserID(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("user_id=?", userID).Delete(&model.PersonalAccessTokenModel{}).Error,
+	)
+}
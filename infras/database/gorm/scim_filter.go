@@ -0,0 +1,97 @@
+package gorm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/todennus/user-service/domain"
+)
+
+// scimColumns maps the filterable attributes to their columns. The string
+// attributes are case-insensitive in SCIM, so they are compared in lower case.
+var scimColumns = map[string]string{
+	domain.ScimAttrID:           "id",
+	domain.ScimAttrUserName:     "LOWER(username)",
+	domain.ScimAttrDisplayName:  "LOWER(display_name)",
+	domain.ScimAttrActive:       "active",
+	domain.ScimAttrLastModified: "updated_at",
+}
+
+var scimComparisons = map[string]string{
+	domain.ScimOpEqual:          "=",
+	domain.ScimOpNotEqual:       "<>",
+	domain.ScimOpGreater:        ">",
+	domain.ScimOpGreaterOrEqual: ">=",
+	domain.ScimOpLess:           "<",
+	domain.ScimOpLessOrEqual:    "<=",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// scimFilterSQL builds the WHERE condition of a parsed SCIM filter.
+func scimFilterSQL(filter *domain.ScimFilter) (string, []any, error) {
+	switch filter.Op {
+	case domain.ScimOpAnd, domain.ScimOpOr:
+		conditions := []string{}
+		args := []any{}
+		for _, operand := range filter.Filters {
+			condition, operandArgs, err := scimFilterSQL(operand)
+			if err != nil {
+				return "", nil, err
+			}
+
+			conditions = append(conditions, "("+condition+")")
+			args = append(args, operandArgs...)
+		}
+
+		return strings.Join(conditions, " "+strings.ToUpper(filter.Op)+" "), args, nil
+
+	case domain.ScimOpNot:
+		condition, args, err := scimFilterSQL(filter.Filters[0])
+		if err != nil {
+			return "", nil, err
+		}
+
+		return "NOT (" + condition + ")", args, nil
+	}
+
+	column, ok := scimColumns[filter.Attr]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported scim attribute %s", filter.Attr)
+	}
+
+	value := filter.Value
+	if s, ok := value.(string); ok {
+		value = strings.ToLower(s)
+	}
+
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC()
+	}
+
+	switch filter.Op {
+	case domain.ScimOpPresent:
+		if filter.Attr == domain.ScimAttrUserName || filter.Attr == domain.ScimAttrDisplayName {
+			return column + " IS NOT NULL AND " + column + "<>''", nil, nil
+		}
+
+		return column + " IS NOT NULL", nil, nil
+
+	case domain.ScimOpContains:
+		return column + ` LIKE ? ESCAPE '\'`, []any{"%" + likeEscaper.Replace(value.(string)) + "%"}, nil
+
+	case domain.ScimOpStartsWith:
+		return column + ` LIKE ? ESCAPE '\'`, []any{likeEscaper.Replace(value.(string)) + "%"}, nil
+
+	case domain.ScimOpEndsWith:
+		return column + ` LIKE ? ESCAPE '\'`, []any{"%" + likeEscaper.Replace(value.(string))}, nil
+	}
+
+	comparison, ok := scimComparisons[filter.Op]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported scim operator %s", filter.Op)
+	}
+
+	return column + comparison + "?", []any{value}, nil
+}
@@ -0,0 +1,49 @@
+package gorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+)
+
+func TestScimFilterSQL(t *testing.T) {
+	testcases := map[string]struct {
+		filter    string
+		condition string
+		args      []any
+	}{
+		"equal-is-case-insensitive": {
+			filter:    `userName eq "Alice"`,
+			condition: "LOWER(username)=?",
+			args:      []any{"alice"},
+		},
+		"contains-escapes-wildcards": {
+			filter:    `displayName co "50%_off\\"`,
+			condition: `LOWER(display_name) LIKE ? ESCAPE '\'`,
+			args:      []any{`%50\%\_off\\%`},
+		},
+		"present-string": {
+			filter:    `displayName pr`,
+			condition: "LOWER(display_name) IS NOT NULL AND LOWER(display_name)<>''",
+		},
+		"logical": {
+			filter:    `not (active eq true) or userName sw "a"`,
+			condition: `(NOT (active=?)) OR (LOWER(username) LIKE ? ESCAPE '\')`,
+			args:      []any{true, "a%"},
+		},
+	}
+
+	scimDomain := domain.NewScimDomain()
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			filter, err := scimDomain.ParseFilter(tc.filter)
+			require.NoError(t, err)
+
+			condition, args, err := scimFilterSQL(filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.condition, condition)
+			require.Equal(t, tc.args, args)
+		})
+	}
+}
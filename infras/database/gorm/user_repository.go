@@ -2,18 +2,32 @@ package gorm
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/todennus/shared/enumdef"
 	"github.com/todennus/shared/errordef"
 	"github.com/todennus/shared/xcontext"
 	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/infras/database/model"
+	"github.com/todennus/user-service/usecase/abstraction"
 	"github.com/xybor-x/snowflake"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// userSearchScanBatchSize is the number of users scored at once by the search
+// fallback.
+const userSearchScanBatchSize = 500
+
 type UserRepository struct {
 	db *gorm.DB
+
+	// trigram is set once the pg_trgm extension is found, the search falls
+	// back to scoring every user otherwise.
+	trigramMu      sync.Mutex
+	trigramChecked bool
+	trigram        bool
 }
 
 func NewUserRepository(db *gorm.DB) *UserRepository {
@@ -25,6 +39,69 @@ func (repo *UserRepository) Create(ctx context.Context, user *domain.User) error
 	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
 }
 
+// CreateMany inserts the users in a single statement, either every user is
+// created or none of them.
+func (repo *UserRepository) CreateMany(ctx context.Context, users []*domain.User) error {
+	models := []*model.UserModel{}
+	for _, user := range users {
+		models = append(models, model.NewUser(user))
+	}
+
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&models).Error)
+}
+
+// FindExistingUsernames returns the given usernames which are already taken.
+func (repo *UserRepository) FindExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	existing := []string{}
+	err := xcontext.DB(ctx, repo.db).Model(&model.UserModel{}).
+		Where("username IN ?", usernames).
+		Pluck("username", &existing).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return existing, nil
+}
+
+// FindExistingCanonicalUsernames returns the canonical form of the given
+// usernames which are already taken, whatever the case of the existing
+// username.
+func (repo *UserRepository) FindExistingCanonicalUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	canonicals := []string{}
+	for _, username := range usernames {
+		canonicals = append(canonicals, domain.CanonicalUsername(username))
+	}
+
+	existing := []string{}
+	err := xcontext.DB(ctx, repo.db).Model(&model.UserModel{}).
+		Where("lower(username) IN ?", canonicals).
+		Distinct().
+		Pluck("lower(username)", &existing).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return existing, nil
+}
+
+// FindExistingIDs returns the given ids which are already taken.
+func (repo *UserRepository) FindExistingIDs(ctx context.Context, userIDs []snowflake.ID) ([]snowflake.ID, error) {
+	existing := []int64{}
+	err := xcontext.DB(ctx, repo.db).Model(&model.UserModel{}).
+		Where("id IN ?", userIDs).
+		Pluck("id", &existing).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	ids := []snowflake.ID{}
+	for _, id := range existing {
+		ids = append(ids, snowflake.ParseInt64(id))
+	}
+
+	return ids, nil
+}
+
 func (repo *UserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
 	model := model.UserModel{}
 	if err := xcontext.DB(ctx, repo.db).Take(&model, "username=?", username).Error; err != nil {
@@ -34,6 +111,16 @@ func (repo *UserRepository) GetByUsername(ctx context.Context, username string)
 	return model.To()
 }
 
+// GetByEmail returns the user having the email, whatever its case.
+func (repo *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	model := model.UserModel{}
+	if err := xcontext.DB(ctx, repo.db).Take(&model, "lower(email)=?", domain.CanonicalEmail(email)).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To()
+}
+
 func (repo *UserRepository) GetByID(ctx context.Context, userID snowflake.ID) (*domain.User, error) {
 	model := model.UserModel{}
 	if err := xcontext.DB(ctx, repo.db).Take(&model, "id=?", userID).Error; err != nil {
@@ -52,6 +139,58 @@ func (repo *UserRepository) GetAvatarByID(ctx context.Context, userID snowflake.
 	return snowflake.ParseInt64(model.Avatar), nil
 }
 
+// GetAfterID returns the users whose id is greater than afterID, ordered by
+// id.
+func (repo *UserRepository) GetAfterID(ctx context.Context, afterID snowflake.ID, limit int) ([]*domain.User, error) {
+	models := []model.UserModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("id>?", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	users := []*domain.User{}
+	for i := range models {
+		user, err := models[i].To()
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// GetWithAvatar returns users having an avatar whose id is greater than
+// afterID, ordered by id.
+func (repo *UserRepository) GetWithAvatar(ctx context.Context, afterID snowflake.ID, limit int) ([]*domain.User, error) {
+	models := []model.UserModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("id>? AND avatar IS NOT NULL AND avatar<>0", afterID).
+		Order("id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	users := []*domain.User{}
+	for i := range models {
+		user, err := models[i].To()
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
 func (repo *UserRepository) UpdateAvatarByID(ctx context.Context, userID, avatar snowflake.ID) error {
 	return errordef.ConvertGormError(
 		xcontext.DB(ctx, repo.db).Model(&model.UserModel{}).
@@ -60,6 +199,14 @@ func (repo *UserRepository) UpdateAvatarByID(ctx context.Context, userID, avatar
 	)
 }
 
+func (repo *UserRepository) RemoveAvatarByID(ctx context.Context, userID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(&model.UserModel{}).
+			Where("id=?", userID).
+			Update("avatar", nil).Error,
+	)
+}
+
 func (repo *UserRepository) CountByRole(ctx context.Context, role enumdef.UserRole) (int64, error) {
 	var n int64
 	err := xcontext.DB(ctx, repo.db).
@@ -68,3 +215,244 @@ func (repo *UserRepository) CountByRole(ctx context.Context, role enumdef.UserRo
 		Count(&n).Error
 	return n, errordef.ConvertGormError(err)
 }
+
+// GetDueErasure returns the users whose erasure is scheduled before the given
+// time and who are not erased yet.
+func (repo *UserRepository) GetDueErasure(ctx context.Context, before time.Time, limit int) ([]*domain.User, error) {
+	models := []model.UserModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("erase_after<=? AND erased_at IS NULL", before).
+		Order("erase_after").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	users := []*domain.User{}
+	for i := range models {
+		user, err := models[i].To()
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// GetLDAPSourced returns the users sourced from LDAP who are not erased.
+func (repo *UserRepository) GetLDAPSourced(ctx context.Context) ([]*domain.User, error) {
+	models := []model.UserModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("ldap_id IS NOT NULL AND erased_at IS NULL").
+		Order("id").
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	users := []*domain.User{}
+	for i := range models {
+		user, err := models[i].To()
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// Update saves the profile of the user if it was not updated since version,
+// the update time of the user when it was read.
+func (repo *UserRepository) Update(ctx context.Context, user *domain.User, version time.Time) error {
+	m := model.NewUser(user)
+	result := xcontext.DB(ctx, repo.db).Model(&model.UserModel{}).
+		Where("id=? AND updated_at=?", user.ID, version).
+		Updates(map[string]any{
+			"username":     m.Username,
+			"display_name": m.DisplayName,
+			"hashed_pass":  m.HashedPass,
+			"role":         m.Role,
+			"active":       m.Active,
+			"updated_at":   m.UpdatedAt,
+
+			"password_reset_required": m.PasswordResetRequired,
+			"has_password":            m.HasPassword,
+
+			"password_changed_at": m.PasswordChangedAt,
+
+			"ldap_id": m.LDAPID,
+
+			"email":   m.Email,
+			"pending": m.Pending,
+
+			"erase_after": m.EraseAfter,
+			"erased_at":   m.ErasedAt,
+		})
+	if result.Error != nil {
+		return errordef.ConvertGormError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return abstraction.ErrStaleVersion
+	}
+
+	return nil
+}
+
+// Search returns the users which are not erased and match the normalized
+// query, ranked by relevance. The matching and the ranking use the pg_trgm
+// indexes if the extension is available.
+func (repo *UserRepository) Search(ctx context.Context, query string, limit int) ([]*domain.User, error) {
+	if !repo.hasTrigram(ctx) {
+		return repo.searchByScan(ctx, query, limit)
+	}
+
+	like := likeEscaper.Replace(query)
+	models := []model.UserModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("erased_at IS NULL").
+		Where(
+			`(lower(username) LIKE ? ESCAPE '\' OR lower(display_name) LIKE ? ESCAPE '\' `+
+				`OR lower(username) % ? OR lower(display_name) % ?)`,
+			like+"%", "%"+like+"%", query, query,
+		).
+		Order(clause.Expr{
+			SQL: `lower(username)=? DESC, lower(username) LIKE ? ESCAPE '\' DESC, ` +
+				`GREATEST(similarity(lower(username), ?), similarity(lower(display_name), ?)) DESC, username`,
+			Vars: []any{query, like + "%", query, query},
+		}).
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	users := []*domain.User{}
+	for i := range models {
+		user, err := models[i].To()
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// searchByScan scores every user which is not erased, it is only meant for the
+// small databases without pg_trgm.
+func (repo *UserRepository) searchByScan(ctx context.Context, query string, limit int) ([]*domain.User, error) {
+	matched := []*domain.User{}
+	afterID := int64(0)
+	for {
+		models := []model.UserModel{}
+		err := xcontext.DB(ctx, repo.db).
+			Where("id>? AND erased_at IS NULL", afterID).
+			Order("id").
+			Limit(userSearchScanBatchSize).
+			Find(&models).Error
+		if err != nil {
+			return nil, errordef.ConvertGormError(err)
+		}
+
+		for i := range models {
+			user, err := models[i].To()
+			if err != nil {
+				return nil, err
+			}
+
+			if domain.UserSearchScore(user, query) > 0 {
+				matched = append(matched, user)
+			}
+		}
+
+		// Only the best matches are kept, so the memory is bounded.
+		matched = domain.RankUserSearch(matched, query, limit)
+
+		if len(models) < userSearchScanBatchSize {
+			return matched, nil
+		}
+
+		afterID = models[len(models)-1].ID
+	}
+}
+
+func (repo *UserRepository) hasTrigram(ctx context.Context) bool {
+	repo.trigramMu.Lock()
+	defer repo.trigramMu.Unlock()
+
+	if repo.trigramChecked {
+		return repo.trigram
+	}
+
+	if repo.db.Dialector.Name() != "postgres" {
+		repo.trigramChecked = true
+		return false
+	}
+
+	var installed bool
+	err := repo.db.WithContext(ctx).
+		Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname='pg_trgm')").
+		Scan(&installed).Error
+	if err != nil {
+		// Checked again on the next search.
+		xcontext.Logger(ctx).Warn("failed-to-check-pg-trgm", "err", err)
+		return false
+	}
+
+	repo.trigramChecked = true
+	repo.trigram = installed
+	return installed
+}
+
+// FindByScimFilter returns a page of the users matched by the filter, ordered
+// by id, and the total number of matched users. A nil filter matches every
+// user.
+func (repo *UserRepository) FindByScimFilter(
+	ctx context.Context,
+	filter *domain.ScimFilter,
+	offset, limit int,
+) ([]*domain.User, int64, error) {
+	db := xcontext.DB(ctx, repo.db).Model(&model.UserModel{})
+	if filter != nil {
+		condition, args, err := scimFilterSQL(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		db = db.Where(condition, args...)
+	}
+
+	// The conditions are shared by the count and the page queries.
+	db = db.Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errordef.ConvertGormError(err)
+	}
+
+	models := []model.UserModel{}
+	if limit > 0 {
+		if err := db.Order("id").Offset(offset).Limit(limit).Find(&models).Error; err != nil {
+			return nil, 0, errordef.ConvertGormError(err)
+		}
+	}
+
+	users := []*domain.User{}
+	for i := range models {
+		user, err := models[i].To()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, total, nil
+}
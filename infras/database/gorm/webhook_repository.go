@@ -0,0 +1,199 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+	"github.com/xybor-x/snowflake"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (repo *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	model := model.NewWebhook(webhook)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *WebhookRepository) GetByID(ctx context.Context, webhookID snowflake.ID) (*domain.Webhook, error) {
+	model := model.WebhookModel{}
+	if err := xcontext.DB(ctx, repo.db).Take(&model, "id=?", webhookID).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+func (repo *WebhookRepository) GetAll(ctx context.Context) ([]*domain.Webhook, error) {
+	models := []model.WebhookModel{}
+	if err := xcontext.DB(ctx, repo.db).Order("id").Find(&models).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	webhooks := []*domain.Webhook{}
+	for i := range models {
+		webhooks = append(webhooks, models[i].To())
+	}
+
+	return webhooks, nil
+}
+
+func (repo *WebhookRepository) Delete(ctx context.Context, webhookID snowflake.ID) error {
+	result := xcontext.DB(ctx, repo.db).Where("id=?", webhookID).Delete(&model.WebhookModel{})
+	if result.Error != nil {
+		return errordef.ConvertGormError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return errordef.ErrNotFound
+	}
+
+	return nil
+}
+
+type WebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+func (repo *WebhookDeliveryRepository) Create(ctx context.Context, deliveries ...*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	models := []*model.WebhookDeliveryModel{}
+	for i := range deliveries {
+		models = append(models, model.NewWebhookDelivery(deliveries[i]))
+	}
+
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&models).Error)
+}
+
+// ClaimPending locks the deliveries which are ready to be sent, skipping the
+// ones locked by another worker. It must be called inside a transaction.
+func (repo *WebhookDeliveryRepository) ClaimPending(ctx context.Context, limit int) ([]*domain.WebhookDelivery, error) {
+	models := []model.WebhookDeliveryModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("delivered_at IS NULL AND next_attempt_at<=?", time.Now()).
+		Order("id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	deliveries := []*domain.WebhookDelivery{}
+	for i := range models {
+		deliveries = append(deliveries, models[i].To())
+	}
+
+	return deliveries, nil
+}
+
+func (repo *WebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	model := model.NewWebhookDelivery(delivery)
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Model(model).
+			Select("attempts", "next_attempt_at", "last_error", "delivered_at").
+			Updates(model).Error,
+	)
+}
+
+func (repo *WebhookDeliveryRepository) Delete(ctx context.Context, deliveryID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("id=?", deliveryID).Delete(&model.WebhookDeliveryModel{}).Error,
+	)
+}
+
+func (repo *WebhookDeliveryRepository) CreateLog(ctx context.Context, log *domain.WebhookDeliveryLog) error {
+	model := model.NewWebhookDeliveryLog(log)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *WebhookDeliveryRepository) GetLogsByWebhookID(
+	ctx context.Context,
+	webhookID snowflake.ID,
+	limit int,
+) ([]*domain.WebhookDeliveryLog, error) {
+	models := []model.WebhookDeliveryLogModel{}
+	err := xcontext.DB(ctx, repo.db).
+		Where("webhook_id=?", webhookID).
+		Order("attempted_at DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	logs := []*domain.WebhookDeliveryLog{}
+	for i := range models {
+		logs = append(logs, models[i].To())
+	}
+
+	return logs, nil
+}
+
+type WebhookDeadLetterRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeadLetterRepository(db *gorm.DB) *WebhookDeadLetterRepository {
+	return &WebhookDeadLetterRepository{db: db}
+}
+
+func (repo *WebhookDeadLetterRepository) Create(ctx context.Context, deadLetter *domain.WebhookDeadLetter) error {
+	model := model.NewWebhookDeadLetter(deadLetter)
+	return errordef.ConvertGormError(xcontext.DB(ctx, repo.db).Create(&model).Error)
+}
+
+func (repo *WebhookDeadLetterRepository) GetByID(ctx context.Context, deadLetterID snowflake.ID) (*domain.WebhookDeadLetter, error) {
+	model := model.WebhookDeadLetterModel{}
+	if err := xcontext.DB(ctx, repo.db).Take(&model, "id=?", deadLetterID).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	return model.To(), nil
+}
+
+func (repo *WebhookDeadLetterRepository) GetByWebhookID(ctx context.Context, webhookID snowflake.ID) ([]*domain.WebhookDeadLetter, error) {
+	return repo.find(xcontext.DB(ctx, repo.db).Where("webhook_id=?", webhookID))
+}
+
+func (repo *WebhookDeadLetterRepository) GetAll(ctx context.Context) ([]*domain.WebhookDeadLetter, error) {
+	return repo.find(xcontext.DB(ctx, repo.db))
+}
+
+func (repo *WebhookDeadLetterRepository) Delete(ctx context.Context, deadLetterID snowflake.ID) error {
+	return errordef.ConvertGormError(
+		xcontext.DB(ctx, repo.db).Where("id=?", deadLetterID).Delete(&model.WebhookDeadLetterModel{}).Error,
+	)
+}
+
+func (repo *WebhookDeadLetterRepository) find(db *gorm.DB) ([]*domain.WebhookDeadLetter, error) {
+	models := []model.WebhookDeadLetterModel{}
+	if err := db.Order("id").Find(&models).Error; err != nil {
+		return nil, errordef.ConvertGormError(err)
+	}
+
+	deadLetters := []*domain.WebhookDeadLetter{}
+	for i := range models {
+		deadLetters = append(deadLetters, models[i].To())
+	}
+
+	return deadLetters, nil
+}
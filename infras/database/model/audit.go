@@ -0,0 +1,100 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type AuditLogModel struct {
+	Seq        int64     `gorm:"column:seq"`
+	ID         int64     `gorm:"column:id"`
+	ActorID    int64     `gorm:"column:actor_id"`
+	ActorType  string    `gorm:"column:actor_type"`
+	Action     string    `gorm:"column:action"`
+	TargetType string    `gorm:"column:target_type"`
+	TargetID   string    `gorm:"column:target_id"`
+	ClientID   string    `gorm:"column:client_id"`
+	RequestID  string    `gorm:"column:request_id"`
+	Metadata   string    `gorm:"column:metadata"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	PrevHash   string    `gorm:"column:prev_hash"`
+	Hash       string    `gorm:"column:hash"`
+
+	Version      int        `gorm:"column:version"`
+	MetadataSalt string     `gorm:"column:metadata_salt"`
+	MetadataHash string     `gorm:"column:metadata_hash"`
+	ScrubbedAt   *time.Time `gorm:"column:scrubbed_at"`
+}
+
+func (AuditLogModel) TableName() string {
+	return "audit_logs"
+}
+
+func NewAuditLog(d *domain.AuditLog) (*AuditLogModel, error) {
+	metadata, err := json.Marshal(d.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &AuditLogModel{
+		Seq:        d.Seq,
+		ID:         d.ID.Int64(),
+		ActorID:    d.ActorID.Int64(),
+		ActorType:  d.ActorType,
+		Action:     d.Action,
+		TargetType: d.TargetType,
+		TargetID:   d.TargetID,
+		ClientID:   d.ClientID,
+		RequestID:  d.RequestID,
+		Metadata:   string(metadata),
+		CreatedAt:  d.CreatedAt,
+		PrevHash:   d.PrevHash,
+		Hash:       d.Hash,
+
+		Version:      d.Version,
+		MetadataSalt: d.MetadataSalt,
+		MetadataHash: d.MetadataHash,
+	}
+
+	if !d.ScrubbedAt.IsZero() {
+		m.ScrubbedAt = &d.ScrubbedAt
+	}
+
+	return m, nil
+}
+
+func (m AuditLogModel) To() (*domain.AuditLog, error) {
+	metadata := map[string]string{}
+	if err := json.Unmarshal([]byte(m.Metadata), &metadata); err != nil {
+		return nil, err
+	}
+
+	d := &domain.AuditLog{
+		Seq:        m.Seq,
+		ID:         snowflake.ID(m.ID),
+		ActorID:    snowflake.ID(m.ActorID),
+		ActorType:  m.ActorType,
+		Action:     m.Action,
+		TargetType: m.TargetType,
+		TargetID:   m.TargetID,
+		ClientID:   m.ClientID,
+		RequestID:  m.RequestID,
+		Metadata:   metadata,
+		CreatedAt:  m.CreatedAt.UTC(),
+		PrevHash:   m.PrevHash,
+		Hash:       m.Hash,
+
+		Version:      m.Version,
+		MetadataSalt: m.MetadataSalt,
+		MetadataHash: m.MetadataHash,
+	}
+
+	if m.ScrubbedAt != nil {
+		d.ScrubbedAt = m.ScrubbedAt.UTC()
+	}
+
+	return d, nil
+}
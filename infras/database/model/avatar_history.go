@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type AvatarHistoryModel struct {
+	ID          int64     `gorm:"column:id"`
+	UserID      int64     `gorm:"column:user_id"`
+	OwnershipID int64     `gorm:"column:ownership_id"`
+	ReplacedAt  time.Time `gorm:"column:replaced_at"`
+}
+
+func (AvatarHistoryModel) TableName() string {
+	return "avatar_histories"
+}
+
+func NewAvatarHistory(d *domain.AvatarHistory) *AvatarHistoryModel {
+	return &AvatarHistoryModel{
+		ID:          d.ID.Int64(),
+		UserID:      d.UserID.Int64(),
+		OwnershipID: d.OwnershipID.Int64(),
+		ReplacedAt:  d.ReplacedAt,
+	}
+}
+
+func (m AvatarHistoryModel) To() *domain.AvatarHistory {
+	return &domain.AvatarHistory{
+		ID:          snowflake.ID(m.ID),
+		UserID:      snowflake.ID(m.UserID),
+		OwnershipID: snowflake.ID(m.OwnershipID),
+		ReplacedAt:  m.ReplacedAt,
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type AvatarPolicyModel struct {
+	UserID       int64    `json:"uid"`
+	AllowedTypes []string `json:"typ"`
+	MaxSize      int64    `json:"sze"`
+}
+
+func NewAvatarPolicy(d *domain.AvatarPolicy) *AvatarPolicyModel {
+	return &AvatarPolicyModel{
+		UserID:       d.UserID.Int64(),
+		AllowedTypes: d.AllowedTypes,
+		MaxSize:      d.MaxSize,
+	}
+}
+
+func (m AvatarPolicyModel) To() *domain.AvatarPolicy {
+	return &domain.AvatarPolicy{
+		UserID:       snowflake.ID(m.UserID),
+		AllowedTypes: m.AllowedTypes,
+		MaxSize:      m.MaxSize,
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type DataExportModel struct {
+	ID          int64      `gorm:"column:id"`
+	UserID      int64      `gorm:"column:user_id"`
+	Status      string     `gorm:"column:status"`
+	Archive     []byte     `gorm:"column:archive"`
+	Error       string     `gorm:"column:error"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at"`
+}
+
+func (DataExportModel) TableName() string {
+	return "data_exports"
+}
+
+func NewDataExport(d *domain.DataExport) *DataExportModel {
+	m := &DataExportModel{
+		ID:        d.ID.Int64(),
+		UserID:    d.UserID.Int64(),
+		Status:    d.Status,
+		Archive:   d.Archive,
+		Error:     d.Error,
+		CreatedAt: d.CreatedAt,
+	}
+
+	if !d.CompletedAt.IsZero() {
+		m.CompletedAt = &d.CompletedAt
+	}
+
+	if !d.ExpiresAt.IsZero() {
+		m.ExpiresAt = &d.ExpiresAt
+	}
+
+	return m
+}
+
+func (m DataExportModel) To() *domain.DataExport {
+	d := &domain.DataExport{
+		ID:        snowflake.ID(m.ID),
+		UserID:    snowflake.ID(m.UserID),
+		Status:    m.Status,
+		Archive:   m.Archive,
+		Error:     m.Error,
+		CreatedAt: m.CreatedAt,
+	}
+
+	if m.CompletedAt != nil {
+		d.CompletedAt = *m.CompletedAt
+	}
+
+	if m.ExpiresAt != nil {
+		d.ExpiresAt = *m.ExpiresAt
+	}
+
+	return d
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type InvitationModel struct {
+	ID         int64            `gorm:"column:id"`
+	Role       enumdef.UserRole `gorm:"column:role"`
+	CreatedBy  int64            `gorm:"column:created_by"`
+	CreatedAt  time.Time        `gorm:"column:created_at"`
+	ExpiresAt  time.Time        `gorm:"column:expires_at"`
+	RedeemedAt *time.Time       `gorm:"column:redeemed_at"`
+	RedeemedBy *int64           `gorm:"column:redeemed_by"`
+	RevokedAt  *time.Time       `gorm:"column:revoked_at"`
+}
+
+func (InvitationModel) TableName() string {
+	return "invitations"
+}
+
+func NewInvitation(d *domain.Invitation) *InvitationModel {
+	m := &InvitationModel{
+		ID:        d.ID.Int64(),
+		Role:      d.Role,
+		CreatedBy: d.CreatedBy.Int64(),
+		CreatedAt: d.CreatedAt,
+		ExpiresAt: d.ExpiresAt,
+	}
+
+	if !d.RedeemedAt.IsZero() {
+		m.RedeemedAt = &d.RedeemedAt
+	}
+
+	if d.RedeemedBy != 0 {
+		redeemedBy := d.RedeemedBy.Int64()
+		m.RedeemedBy = &redeemedBy
+	}
+
+	if !d.RevokedAt.IsZero() {
+		m.RevokedAt = &d.RevokedAt
+	}
+
+	return m
+}
+
+func (m InvitationModel) To() *domain.Invitation {
+	d := &domain.Invitation{
+		ID:        snowflake.ID(m.ID),
+		Role:      m.Role,
+		CreatedBy: snowflake.ID(m.CreatedBy),
+		CreatedAt: m.CreatedAt,
+		ExpiresAt: m.ExpiresAt,
+	}
+
+	if m.RedeemedAt != nil {
+		d.RedeemedAt = *m.RedeemedAt
+	}
+
+	if m.RedeemedBy != nil {
+		d.RedeemedBy = snowflake.ID(*m.RedeemedBy)
+	}
+
+	if m.RevokedAt != nil {
+		d.RevokedAt = *m.RevokedAt
+	}
+
+	return d
+}
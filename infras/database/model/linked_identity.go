@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type LinkedIdentityModel struct {
+	ID       int64     `gorm:"column:id"`
+	UserID   int64     `gorm:"column:user_id"`
+	Provider string    `gorm:"column:provider"`
+	Subject  string    `gorm:"column:subject"`
+	Email    *string   `gorm:"column:email"`
+	LinkedAt time.Time `gorm:"column:linked_at"`
+}
+
+func (LinkedIdentityModel) TableName() string {
+	return "linked_identities"
+}
+
+func NewLinkedIdentity(d *domain.LinkedIdentity) *LinkedIdentityModel {
+	m := &LinkedIdentityModel{
+		ID:       d.ID.Int64(),
+		UserID:   d.UserID.Int64(),
+		Provider: d.Provider,
+		Subject:  d.Subject,
+		LinkedAt: d.LinkedAt,
+	}
+
+	if d.Email != "" {
+		m.Email = &d.Email
+	}
+
+	return m
+}
+
+func (m LinkedIdentityModel) To() *domain.LinkedIdentity {
+	d := &domain.LinkedIdentity{
+		ID:       snowflake.ID(m.ID),
+		UserID:   snowflake.ID(m.UserID),
+		Provider: m.Provider,
+		Subject:  m.Subject,
+		LinkedAt: m.LinkedAt,
+	}
+
+	if m.Email != nil {
+		d.Email = *m.Email
+	}
+
+	return d
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type OutboxMessageModel struct {
+	ID            int64      `gorm:"column:id"`
+	Topic         string     `gorm:"column:topic"`
+	Payload       string     `gorm:"column:payload"`
+	Attempts      int        `gorm:"column:attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at"`
+	LastError     string     `gorm:"column:last_error"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at"`
+}
+
+func (OutboxMessageModel) TableName() string {
+	return "outbox_messages"
+}
+
+func NewOutboxMessage(d *domain.OutboxMessage) *OutboxMessageModel {
+	m := &OutboxMessageModel{
+		ID:            d.ID.Int64(),
+		Topic:         d.Topic,
+		Payload:       string(d.Payload),
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+	}
+
+	if !d.DeliveredAt.IsZero() {
+		m.DeliveredAt = &d.DeliveredAt
+	}
+
+	return m
+}
+
+func (m OutboxMessageModel) To() *domain.OutboxMessage {
+	d := &domain.OutboxMessage{
+		ID:            snowflake.ID(m.ID),
+		Topic:         m.Topic,
+		Payload:       []byte(m.Payload),
+		Attempts:      m.Attempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+		CreatedAt:     m.CreatedAt,
+	}
+
+	if m.DeliveredAt != nil {
+		d.DeliveredAt = *m.DeliveredAt
+	}
+
+	return d
+}
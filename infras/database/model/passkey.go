@@ -0,0 +1,110 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type PasskeyModel struct {
+	ID                int64      `gorm:"column:id"`
+	UserID            int64      `gorm:"column:user_id"`
+	Name              string     `gorm:"column:name"`
+	CredentialID      []byte     `gorm:"column:credential_id"`
+	PublicKey         []byte     `gorm:"column:public_key"`
+	AttestationType   string     `gorm:"column:attestation_type"`
+	AttestationObject []byte     `gorm:"column:attestation_object"`
+	AAGUID            []byte     `gorm:"column:aaguid"`
+	SignCount         int64      `gorm:"column:sign_count"`
+	Transports        string     `gorm:"column:transports"`
+	BackupEligible    bool       `gorm:"column:backup_eligible"`
+	BackupState       bool       `gorm:"column:backup_state"`
+	CreatedAt         time.Time  `gorm:"column:created_at"`
+	LastUsedAt        *time.Time `gorm:"column:last_used_at"`
+}
+
+func (PasskeyModel) TableName() string {
+	return "passkeys"
+}
+
+func NewPasskey(d *domain.Passkey) *PasskeyModel {
+	m := &PasskeyModel{
+		ID:                d.ID.Int64(),
+		UserID:            d.UserID.Int64(),
+		Name:              d.Name,
+		CredentialID:      d.CredentialID,
+		PublicKey:         d.PublicKey,
+		AttestationType:   d.AttestationType,
+		AttestationObject: d.AttestationObject,
+		AAGUID:            d.AAGUID,
+		SignCount:         int64(d.SignCount),
+		Transports:        strings.Join(d.Transports, ","),
+		BackupEligible:    d.BackupEligible,
+		BackupState:       d.BackupState,
+		CreatedAt:         d.CreatedAt,
+	}
+
+	if !d.LastUsedAt.IsZero() {
+		m.LastUsedAt = &d.LastUsedAt
+	}
+
+	return m
+}
+
+func (m PasskeyModel) To() *domain.Passkey {
+	d := &domain.Passkey{
+		ID:                snowflake.ID(m.ID),
+		UserID:            snowflake.ID(m.UserID),
+		Name:              m.Name,
+		CredentialID:      m.CredentialID,
+		PublicKey:         m.PublicKey,
+		AttestationType:   m.AttestationType,
+		AttestationObject: m.AttestationObject,
+		AAGUID:            m.AAGUID,
+		SignCount:         uint32(m.SignCount),
+		Transports:        []string{},
+		BackupEligible:    m.BackupEligible,
+		BackupState:       m.BackupState,
+		CreatedAt:         m.CreatedAt,
+	}
+
+	if m.Transports != "" {
+		d.Transports = strings.Split(m.Transports, ",")
+	}
+
+	if m.LastUsedAt != nil {
+		d.LastUsedAt = *m.LastUsedAt
+	}
+
+	return d
+}
+
+type PasskeySessionModel struct {
+	ID        string    `json:"id"`
+	Ceremony  string    `json:"cer"`
+	UserID    int64     `json:"uid"`
+	Data      []byte    `json:"dat"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+func NewPasskeySession(d *domain.PasskeySession) *PasskeySessionModel {
+	return &PasskeySessionModel{
+		ID:        d.ID,
+		Ceremony:  d.Ceremony,
+		UserID:    d.UserID.Int64(),
+		Data:      d.Data,
+		ExpiresAt: d.ExpiresAt,
+	}
+}
+
+func (m PasskeySessionModel) To() *domain.PasskeySession {
+	return &domain.PasskeySession{
+		ID:        m.ID,
+		Ceremony:  m.Ceremony,
+		UserID:    snowflake.ID(m.UserID),
+		Data:      m.Data,
+		ExpiresAt: m.ExpiresAt,
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type PasswordHistoryModel struct {
+	ID         int64     `gorm:"column:id"`
+	UserID     int64     `gorm:"column:user_id"`
+	HashedPass string    `gorm:"column:hashed_pass"`
+	ReplacedAt time.Time `gorm:"column:replaced_at"`
+}
+
+func (PasswordHistoryModel) TableName() string {
+	return "password_histories"
+}
+
+func NewPasswordHistory(d *domain.PasswordHistory) *PasswordHistoryModel {
+	return &PasswordHistoryModel{
+		ID:         d.ID.Int64(),
+		UserID:     d.UserID.Int64(),
+		HashedPass: d.HashedPass,
+		ReplacedAt: d.ReplacedAt,
+	}
+}
+
+func (m PasswordHistoryModel) To() *domain.PasswordHistory {
+	return &domain.PasswordHistory{
+		ID:         snowflake.ID(m.ID),
+		UserID:     snowflake.ID(m.UserID),
+		HashedPass: m.HashedPass,
+		ReplacedAt: m.ReplacedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type PersonalAccessTokenModel struct {
+	ID         int64      `gorm:"column:id"`
+	UserID     int64      `gorm:"column:user_id"`
+	Name       string     `gorm:"column:name"`
+	Scope      string     `gorm:"column:scope"`
+	TokenHash  string     `gorm:"column:token_hash"`
+	CreatedAt  time.Time  `gorm:"column:created_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
+
+func (PersonalAccessTokenModel) TableName() string {
+	return "personal_access_tokens"
+}
+
+func NewPersonalAccessToken(d *domain.PersonalAccessToken) *PersonalAccessTokenModel {
+	m := &PersonalAccessTokenModel{
+		ID:        d.ID.Int64(),
+		UserID:    d.UserID.Int64(),
+		Name:      d.Name,
+		Scope:     d.Scope,
+		TokenHash: d.TokenHash,
+		CreatedAt: d.CreatedAt,
+		ExpiresAt: d.ExpiresAt,
+	}
+
+	if !d.LastUsedAt.IsZero() {
+		m.LastUsedAt = &d.LastUsedAt
+	}
+
+	if !d.RevokedAt.IsZero() {
+		m.RevokedAt = &d.RevokedAt
+	}
+
+	return m
+}
+
+func (m PersonalAccessTokenModel) To() *domain.PersonalAccessToken {
+	d := &domain.PersonalAccessToken{
+		ID:        snowflake.ID(m.ID),
+		UserID:    snowflake.ID(m.UserID),
+		Name:      m.Name,
+		Scope:     m.Scope,
+		TokenHash: m.TokenHash,
+		CreatedAt: m.CreatedAt,
+		ExpiresAt: m.ExpiresAt,
+	}
+
+	if m.LastUsedAt != nil {
+		d.LastUsedAt = *m.LastUsedAt
+	}
+
+	if m.RevokedAt != nil {
+		d.RevokedAt = *m.RevokedAt
+	}
+
+	return d
+}
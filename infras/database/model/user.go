@@ -15,7 +15,22 @@ type UserModel struct {
 	HashedPass  string           `gorm:"column:hashed_pass"`
 	Role        enumdef.UserRole `gorm:"column:role"`
 	Avatar      int64            `gorm:"column:avatar"`
+	Active      bool             `gorm:"column:active"`
 	UpdatedAt   time.Time        `gorm:"column:updated_at"`
+
+	PasswordResetRequired bool `gorm:"column:password_reset_required"`
+	HasPassword           bool `gorm:"column:has_password"`
+
+	PasswordChangedAt time.Time `gorm:"column:password_changed_at"`
+
+	LDAPID *string `gorm:"column:ldap_id"`
+
+	Email         *string `gorm:"column:email"`
+	EmailVerified bool    `gorm:"column:email_verified"`
+	Pending       bool    `gorm:"column:pending"`
+
+	EraseAfter *time.Time `gorm:"column:erase_after"`
+	ErasedAt   *time.Time `gorm:"column:erased_at"`
 }
 
 func (UserModel) TableName() string {
@@ -23,25 +38,79 @@ func (UserModel) TableName() string {
 }
 
 func NewUser(d *domain.User) *UserModel {
-	return &UserModel{
+	m := &UserModel{
 		ID:          d.ID.Int64(),
 		DisplayName: d.DisplayName,
 		Username:    d.Username,
 		HashedPass:  d.HashedPass,
 		Avatar:      d.Avatar.Int64(),
 		Role:        d.Role,
+		Active:      d.Active,
 		UpdatedAt:   d.UpdatedAt,
+
+		PasswordResetRequired: d.PasswordResetRequired,
+		HasPassword:           d.HasPassword,
+
+		PasswordChangedAt: d.PasswordChangedAt,
+
+		EmailVerified: d.EmailVerified,
+		Pending:       d.Pending,
+	}
+
+	if d.LDAPID != "" {
+		m.LDAPID = &d.LDAPID
+	}
+
+	if d.Email != "" {
+		m.Email = &d.Email
+	}
+
+	if !d.EraseAfter.IsZero() {
+		m.EraseAfter = &d.EraseAfter
+	}
+
+	if !d.ErasedAt.IsZero() {
+		m.ErasedAt = &d.ErasedAt
 	}
+
+	return m
 }
 
 func (u UserModel) To() (*domain.User, error) {
-	return &domain.User{
+	d := &domain.User{
 		ID:          snowflake.ID(u.ID),
 		DisplayName: u.DisplayName,
 		Username:    u.Username,
 		HashedPass:  u.HashedPass,
 		Role:        u.Role,
 		Avatar:      snowflake.ParseInt64(u.Avatar),
+		Active:      u.Active,
 		UpdatedAt:   u.UpdatedAt,
-	}, nil
+
+		PasswordResetRequired: u.PasswordResetRequired,
+		HasPassword:           u.HasPassword,
+
+		PasswordChangedAt: u.PasswordChangedAt,
+
+		EmailVerified: u.EmailVerified,
+		Pending:       u.Pending,
+	}
+
+	if u.LDAPID != nil {
+		d.LDAPID = *u.LDAPID
+	}
+
+	if u.Email != nil {
+		d.Email = *u.Email
+	}
+
+	if u.EraseAfter != nil {
+		d.EraseAfter = *u.EraseAfter
+	}
+
+	if u.ErasedAt != nil {
+		d.ErasedAt = *u.ErasedAt
+	}
+
+	return d, nil
 }
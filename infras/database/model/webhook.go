@@ -0,0 +1,195 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type WebhookModel struct {
+	ID         int64     `gorm:"column:id"`
+	URL        string    `gorm:"column:url"`
+	Secret     string    `gorm:"column:secret"`
+	EventTypes string    `gorm:"column:event_types"`
+	CreatedBy  int64     `gorm:"column:created_by"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+}
+
+func (WebhookModel) TableName() string {
+	return "webhooks"
+}
+
+func NewWebhook(d *domain.Webhook) *WebhookModel {
+	return &WebhookModel{
+		ID:         d.ID.Int64(),
+		URL:        d.URL,
+		Secret:     d.Secret,
+		EventTypes: strings.Join(d.EventTypes, ","),
+		CreatedBy:  d.CreatedBy.Int64(),
+		CreatedAt:  d.CreatedAt,
+		UpdatedAt:  d.UpdatedAt,
+	}
+}
+
+func (m WebhookModel) To() *domain.Webhook {
+	return &domain.Webhook{
+		ID:         snowflake.ID(m.ID),
+		URL:        m.URL,
+		Secret:     m.Secret,
+		EventTypes: strings.Split(m.EventTypes, ","),
+		CreatedBy:  snowflake.ID(m.CreatedBy),
+		CreatedAt:  m.CreatedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryModel struct {
+	ID            int64      `gorm:"column:id"`
+	WebhookID     int64      `gorm:"column:webhook_id"`
+	EventID       int64      `gorm:"column:event_id"`
+	EventType     string     `gorm:"column:event_type"`
+	Payload       string     `gorm:"column:payload"`
+	Attempts      int        `gorm:"column:attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at"`
+	LastError     string     `gorm:"column:last_error"`
+	CreatedAt     time.Time  `gorm:"column:created_at"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at"`
+}
+
+func (WebhookDeliveryModel) TableName() string {
+	return "webhook_deliveries"
+}
+
+func NewWebhookDelivery(d *domain.WebhookDelivery) *WebhookDeliveryModel {
+	m := &WebhookDeliveryModel{
+		ID:            d.ID.Int64(),
+		WebhookID:     d.WebhookID.Int64(),
+		EventID:       d.EventID.Int64(),
+		EventType:     d.EventType,
+		Payload:       string(d.Payload),
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+	}
+
+	if !d.DeliveredAt.IsZero() {
+		m.DeliveredAt = &d.DeliveredAt
+	}
+
+	return m
+}
+
+func (m WebhookDeliveryModel) To() *domain.WebhookDelivery {
+	d := &domain.WebhookDelivery{
+		ID:            snowflake.ID(m.ID),
+		WebhookID:     snowflake.ID(m.WebhookID),
+		EventID:       snowflake.ID(m.EventID),
+		EventType:     m.EventType,
+		Payload:       []byte(m.Payload),
+		Attempts:      m.Attempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+		CreatedAt:     m.CreatedAt,
+	}
+
+	if m.DeliveredAt != nil {
+		d.DeliveredAt = *m.DeliveredAt
+	}
+
+	return d
+}
+
+type WebhookDeliveryLogModel struct {
+	ID          int64     `gorm:"column:id"`
+	DeliveryID  int64     `gorm:"column:delivery_id"`
+	WebhookID   int64     `gorm:"column:webhook_id"`
+	EventID     int64     `gorm:"column:event_id"`
+	EventType   string    `gorm:"column:event_type"`
+	Attempt     int       `gorm:"column:attempt"`
+	StatusCode  int       `gorm:"column:status_code"`
+	Error       string    `gorm:"column:error"`
+	DurationMS  int64     `gorm:"column:duration_ms"`
+	AttemptedAt time.Time `gorm:"column:attempted_at"`
+}
+
+func (WebhookDeliveryLogModel) TableName() string {
+	return "webhook_delivery_logs"
+}
+
+func NewWebhookDeliveryLog(d *domain.WebhookDeliveryLog) *WebhookDeliveryLogModel {
+	return &WebhookDeliveryLogModel{
+		ID:          d.ID.Int64(),
+		DeliveryID:  d.DeliveryID.Int64(),
+		WebhookID:   d.WebhookID.Int64(),
+		EventID:     d.EventID.Int64(),
+		EventType:   d.EventType,
+		Attempt:     d.Attempt,
+		StatusCode:  d.StatusCode,
+		Error:       d.Error,
+		DurationMS:  d.Duration.Milliseconds(),
+		AttemptedAt: d.AttemptedAt,
+	}
+}
+
+func (m WebhookDeliveryLogModel) To() *domain.WebhookDeliveryLog {
+	return &domain.WebhookDeliveryLog{
+		ID:          snowflake.ID(m.ID),
+		DeliveryID:  snowflake.ID(m.DeliveryID),
+		WebhookID:   snowflake.ID(m.WebhookID),
+		EventID:     snowflake.ID(m.EventID),
+		EventType:   m.EventType,
+		Attempt:     m.Attempt,
+		StatusCode:  m.StatusCode,
+		Error:       m.Error,
+		Duration:    time.Duration(m.DurationMS) * time.Millisecond,
+		AttemptedAt: m.AttemptedAt,
+	}
+}
+
+type WebhookDeadLetterModel struct {
+	ID        int64     `gorm:"column:id"`
+	WebhookID int64     `gorm:"column:webhook_id"`
+	EventID   int64     `gorm:"column:event_id"`
+	EventType string    `gorm:"column:event_type"`
+	Payload   string    `gorm:"column:payload"`
+	Attempts  int       `gorm:"column:attempts"`
+	LastError string    `gorm:"column:last_error"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	DeadAt    time.Time `gorm:"column:dead_at"`
+}
+
+func (WebhookDeadLetterModel) TableName() string {
+	return "webhook_dead_letters"
+}
+
+func NewWebhookDeadLetter(d *domain.WebhookDeadLetter) *WebhookDeadLetterModel {
+	return &WebhookDeadLetterModel{
+		ID:        d.ID.Int64(),
+		WebhookID: d.WebhookID.Int64(),
+		EventID:   d.EventID.Int64(),
+		EventType: d.EventType,
+		Payload:   string(d.Payload),
+		Attempts:  d.Attempts,
+		LastError: d.LastError,
+		CreatedAt: d.CreatedAt,
+		DeadAt:    d.DeadAt,
+	}
+}
+
+func (m WebhookDeadLetterModel) To() *domain.WebhookDeadLetter {
+	return &domain.WebhookDeadLetter{
+		ID:        snowflake.ID(m.ID),
+		WebhookID: snowflake.ID(m.WebhookID),
+		EventID:   snowflake.ID(m.EventID),
+		EventType: m.EventType,
+		Payload:   []byte(m.Payload),
+		Attempts:  m.Attempts,
+		LastError: m.LastError,
+		CreatedAt: m.CreatedAt,
+		DeadAt:    m.DeadAt,
+	}
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+)
+
+// avatarPolicyKey identifies the policy by the digest of the upload token, so
+// the token itself is not stored and each upload keeps its own policy.
+func avatarPolicyKey(uploadToken string) string {
+	digest := sha256.Sum256([]byte(uploadToken))
+	return fmt.Sprintf("avatar-policy:%s", hex.EncodeToString(digest[:]))
+}
+
+type AvatarPolicyRepository struct {
+	client *redis.Client
+}
+
+func NewAvatarPolicyRepository(client *redis.Client) *AvatarPolicyRepository {
+	return &AvatarPolicyRepository{client: client}
+}
+
+func (repo *AvatarPolicyRepository) Save(
+	ctx context.Context,
+	uploadToken string,
+	policy *domain.AvatarPolicy,
+	expiration time.Duration,
+) error {
+	value, err := json.Marshal(model.NewAvatarPolicy(policy))
+	if err != nil {
+		return err
+	}
+
+	return repo.client.Set(ctx, avatarPolicyKey(uploadToken), value, expiration).Err()
+}
+
+func (repo *AvatarPolicyRepository) Get(ctx context.Context, uploadToken string) (*domain.AvatarPolicy, error) {
+	value, err := repo.client.Get(ctx, avatarPolicyKey(uploadToken)).Bytes()
+	if err != nil {
+		return nil, errordef.ConvertRedisError(err)
+	}
+
+	m := model.AvatarPolicyModel{}
+	if err := json.Unmarshal(value, &m); err != nil {
+		return nil, err
+	}
+
+	return m.To(), nil
+}
+
+func (repo *AvatarPolicyRepository) Delete(ctx context.Context, uploadToken string) error {
+	return repo.client.Del(ctx, avatarPolicyKey(uploadToken)).Err()
+}
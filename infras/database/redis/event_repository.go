@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/todennus/user-service/domain"
+)
+
+// EventRepository appends the domain events to a Redis Stream. Entries use the
+// ids generated by Redis, so consumer groups read them in the publishing
+// order. The event type is duplicated in a field to let consumers skip events
+// without decoding the payload.
+type EventRepository struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+func NewEventRepository(client *redis.Client, stream string, maxLen int64) *EventRepository {
+	return &EventRepository{client: client, stream: stream, maxLen: maxLen}
+}
+
+func (repo *EventRepository) Publish(ctx context.Context, event *domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return repo.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: repo.stream,
+		MaxLen: repo.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":      event.ID.String(),
+			"type":    event.Type,
+			"payload": payload,
+		},
+	}).Err()
+}
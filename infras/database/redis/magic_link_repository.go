@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func magicLinkKey(tokenID string) string {
+	return fmt.Sprintf("magic-link:%s", tokenID)
+}
+
+type MagicLinkRepository struct {
+	client *redis.Client
+}
+
+func NewMagicLinkRepository(client *redis.Client) *MagicLinkRepository {
+	return &MagicLinkRepository{client: client}
+}
+
+// MarkRedeemed keeps the id of the token until the token expires, a replayed
+// token is then rejected even by another instance of the service.
+func (repo *MagicLinkRepository) MarkRedeemed(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	return repo.client.SetNX(ctx, magicLinkKey(tokenID), 1, ttl).Result()
+}
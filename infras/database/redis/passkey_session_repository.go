@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/database/model"
+)
+
+func passkeySessionKey(sessionID string) string {
+	return fmt.Sprintf("passkey-session:%s", sessionID)
+}
+
+// PasskeySessionRepository keeps the sessions of the WebAuthn ceremonies until
+// they expire, a session is taken at most once.
+type PasskeySessionRepository struct {
+	client *redis.Client
+}
+
+func NewPasskeySessionRepository(client *redis.Client) *PasskeySessionRepository {
+	return &PasskeySessionRepository{client: client}
+}
+
+func (repo *PasskeySessionRepository) Save(ctx context.Context, session *domain.PasskeySession) error {
+	value, err := json.Marshal(model.NewPasskeySession(session))
+	if err != nil {
+		return err
+	}
+
+	return repo.client.Set(ctx, passkeySessionKey(session.ID), value, time.Until(session.ExpiresAt)).Err()
+}
+
+// Take returns the session and deletes it, so the ceremony cannot be replayed.
+func (repo *PasskeySessionRepository) Take(ctx context.Context, sessionID string) (*domain.PasskeySession, error) {
+	value, err := repo.client.GetDel(ctx, passkeySessionKey(sessionID)).Bytes()
+	if err != nil {
+		return nil, errordef.ConvertRedisError(err)
+	}
+
+	m := model.PasskeySessionModel{}
+	if err := json.Unmarshal(value, &m); err != nil {
+		return nil, err
+	}
+
+	return m.To(), nil
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitScript counts a hit in a fixed window which starts at the first hit,
+// then returns the count and the remaining time of the window in millisecond.
+var rateLimitScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+func rateLimitKey(key string) string {
+	return fmt.Sprintf("rate-limit:%s", key)
+}
+
+// RateLimitRepository limits the number of hits per key in a fixed window,
+// shared by every instance of the service.
+type RateLimitRepository struct {
+	client *redis.Client
+}
+
+func NewRateLimitRepository(client *redis.Client) *RateLimitRepository {
+	return &RateLimitRepository{client: client}
+}
+
+// Allow counts a hit of the key and reports whether it is within the limit. If
+// not, it also returns the duration until the window resets.
+func (repo *RateLimitRepository) Allow(
+	ctx context.Context,
+	key string,
+	limit int,
+	window time.Duration,
+) (bool, time.Duration, error) {
+	result, err := rateLimitScript.Run(ctx, repo.client, []string{rateLimitKey(key)}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit result %v", result)
+	}
+
+	if result[0] <= int64(limit) {
+		return true, 0, nil
+	}
+
+	return false, time.Duration(result[1]) * time.Millisecond, nil
+}
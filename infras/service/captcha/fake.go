@@ -0,0 +1,20 @@
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+)
+
+// FakeVerifier accepts a single known token, it stands in for a captcha
+// provider in local and test deployments.
+type FakeVerifier struct {
+	token string
+}
+
+func NewFakeVerifier(token string) *FakeVerifier {
+	return &FakeVerifier{token: token}
+}
+
+func (verifier *FakeVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(verifier.token)) == 1, nil
+}
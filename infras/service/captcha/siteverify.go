@@ -0,0 +1,67 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SiteVerifyVerifier verifies the tokens with the siteverify api shared by
+// reCAPTCHA, hCaptcha and Turnstile.
+type SiteVerifyVerifier struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+func NewSiteVerifyVerifier(url, secret string, timeout time.Duration) *SiteVerifyVerifier {
+	return &SiteVerifyVerifier{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+		secret: secret,
+	}
+}
+
+type siteVerifyResponse struct {
+	Success bool `json:"success"`
+}
+
+func (verifier *SiteVerifyVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	form := url.Values{"secret": {verifier.secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, verifier.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := verifier.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	result := siteVerifyResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Success, nil
+}
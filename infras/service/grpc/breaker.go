@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops calling a remote service after a number of consecutive
+// failures. Once the cooldown is over, a single probe call is let through;
+// the breaker closes again if it succeeds, otherwise it keeps open.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	threshold int
+	cooldown  time.Duration
+
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call can be made now. A threshold less than or
+// equal to zero disables the breaker.
+func (breaker *circuitBreaker) allow() bool {
+	if breaker.threshold <= 0 {
+		return true
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	switch breaker.state {
+	case breakerOpen:
+		if time.Since(breaker.openedAt) < breaker.cooldown {
+			return false
+		}
+
+		breaker.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// The probe call is still in flight.
+		return false
+	default:
+		return true
+	}
+}
+
+func (breaker *circuitBreaker) success() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.state = breakerClosed
+	breaker.failures = 0
+}
+
+func (breaker *circuitBreaker) failure() {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.failures++
+	if breaker.state == breakerHalfOpen || breaker.failures >= breaker.threshold {
+		breaker.state = breakerOpen
+		breaker.openedAt = time.Now()
+	}
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/todennus/proto/gen/service"
@@ -9,19 +10,47 @@ import (
 	"github.com/todennus/shared/authentication"
 	"github.com/todennus/shared/errordef"
 	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
 	"github.com/xybor-x/snowflake"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+type FileRepositoryConfig struct {
+	// Deadlines of each method, zero means no deadline other than the one of
+	// the incoming request.
+	RegisterUploadTimeout     time.Duration
+	CreatePresignedURLTimeout time.Duration
+	ChangeRefcountTimeout     time.Duration
+
+	// Only idempotent calls are retried. RegisterUpload and ChangeRefcount
+	// are never retried, a refcount change is retried by the outbox relay.
+	RetryAttempts int
+	RetryBackoff  time.Duration
+
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
 type FileRepository struct {
 	auth       *authentication.GrpcAuthorization
 	fileClient service.FileClient
+
+	config  FileRepositoryConfig
+	breaker *circuitBreaker
 }
 
-func NewFileRepository(client *grpc.ClientConn, auth *authentication.GrpcAuthorization) *FileRepository {
+func NewFileRepository(
+	client *grpc.ClientConn,
+	auth *authentication.GrpcAuthorization,
+	config FileRepositoryConfig,
+) *FileRepository {
 	return &FileRepository{
 		fileClient: service.NewFileClient(client),
 		auth:       auth,
+		config:     config,
+		breaker:    newCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
 	}
 }
 
@@ -31,9 +60,14 @@ func (repo *FileRepository) RegisterUpload(ctx context.Context, policy *domain.A
 		AllowedTypes: policy.AllowedTypes,
 		MaxSize:      policy.MaxSize,
 	}
-	resp, err := repo.fileClient.RegisterUpload(repo.auth.Context(ctx), req)
+
+	var resp *dto.FileRegisterUploadResponse
+	err := repo.call(ctx, repo.config.RegisterUploadTimeout, 1, func(ctx context.Context) (err error) {
+		resp, err = repo.fileClient.RegisterUpload(repo.auth.Context(ctx), req)
+		return err
+	})
 	if err != nil {
-		return "", errordef.ConvertGRPCError(err)
+		return "", err
 	}
 
 	return resp.UploadToken, nil
@@ -44,14 +78,21 @@ func (repo *FileRepository) CreatePresignedURL(ctx context.Context, ownershipID
 		OwnershipId: ownershipID.Int64(),
 		Expiration:  int64(expiration / time.Second),
 	}
-	resp, err := repo.fileClient.CreatePresignedURL(repo.auth.Context(ctx), req)
+
+	var resp *dto.FileCreatePresignedURLResponse
+	err := repo.call(ctx, repo.config.CreatePresignedURLTimeout, repo.config.RetryAttempts, func(ctx context.Context) (err error) {
+		resp, err = repo.fileClient.CreatePresignedURL(repo.auth.Context(ctx), req)
+		return err
+	})
 	if err != nil {
-		return "", errordef.ConvertGRPCError(err)
+		return "", err
 	}
 
 	return resp.PresignedUrl, nil
 }
 
+// ChangeRefcount is called once, the file service applies the change again if
+// it is called again, even if the response of the first call was lost.
 func (repo *FileRepository) ChangeRefcount(ctx context.Context, inc, dec []snowflake.ID) error {
 	incOwnershipID := []int64{}
 	for i := range inc {
@@ -68,9 +109,80 @@ func (repo *FileRepository) ChangeRefcount(ctx context.Context, inc, dec []snowf
 		DecOwnershipId: decOwnershipID,
 	}
 
-	if _, err := repo.fileClient.ChangeRefcount(repo.auth.Context(ctx), req); err != nil {
-		return errordef.ConvertGRPCError(err)
+	return repo.call(ctx, repo.config.ChangeRefcountTimeout, 1, func(ctx context.Context) error {
+		_, err := repo.fileClient.ChangeRefcount(repo.auth.Context(ctx), req)
+		return err
+	})
+}
+
+// call invokes fn through the circuit breaker, retrying up to attempts times
+// while the file service is unavailable. Errors which make the file service
+// look unavailable are wrapped with abstraction.ErrServiceUnavailable, the
+// others are converted to the shared errors.
+func (repo *FileRepository) call(
+	ctx context.Context,
+	timeout time.Duration,
+	attempts int,
+	fn func(ctx context.Context) error,
+) error {
+	backoff := repo.config.RetryBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if !repo.breaker.allow() {
+			return fmt.Errorf("%w: circuit breaker of file service is open", abstraction.ErrServiceUnavailable)
+		}
+
+		err = repo.invoke(ctx, timeout, fn)
+		if !isUnavailable(err) {
+			repo.breaker.success()
+			break
+		}
+
+		repo.breaker.failure()
+		if attempt >= attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", abstraction.ErrServiceUnavailable, err.Error())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
 	}
 
-	return nil
+	if err == nil {
+		return nil
+	}
+
+	if isUnavailable(err) {
+		return fmt.Errorf("%w: %s", abstraction.ErrServiceUnavailable, err.Error())
+	}
+
+	return errordef.ConvertGRPCError(err)
+}
+
+func (repo *FileRepository) invoke(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return fn(ctx)
+}
+
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
 }
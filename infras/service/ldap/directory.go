@@ -0,0 +1,209 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/todennus/user-service/domain"
+)
+
+// searchPageSize keeps each response under the size limit of the servers,
+// Active Directory returns at most 1000 entries per page by default.
+const searchPageSize = 500
+
+// binaryAttributes are the id attributes whose values are bytes, they are
+// hex encoded.
+var binaryAttributes = map[string]bool{
+	"objectguid": true,
+	"objectsid":  true,
+}
+
+type DirectoryConfig struct {
+	// URL is an ldap:// or ldaps:// url, StartTLS upgrades an ldap://
+	// connection.
+	URL      string
+	StartTLS bool
+
+	// The service account searching the directory, the search is anonymous
+	// if BindDN is empty.
+	BindDN       string
+	BindPassword string
+
+	// Only the entries of the BaseDN subtree matching both UserFilter and
+	// GroupFilter are users, GroupFilter is optional.
+	BaseDN      string
+	UserFilter  string
+	GroupFilter string
+
+	IDAttribute          string
+	UsernameAttribute    string
+	DisplayNameAttribute string
+	EmailAttribute       string
+
+	Timeout time.Duration
+}
+
+// Directory reads the users of an LDAP or Active Directory server, it opens a
+// connection per operation.
+type Directory struct {
+	config DirectoryConfig
+}
+
+func NewDirectory(config DirectoryConfig) *Directory {
+	return &Directory{config: config}
+}
+
+func (directory *Directory) Authenticate(ctx context.Context, id, password string) (bool, error) {
+	// An empty password makes an unauthenticated bind, which always succeeds.
+	if password == "" {
+		return false, nil
+	}
+
+	// An id which is not hex encoded matches no binary id.
+	idFilter, err := directory.idFilter(id)
+	if err != nil {
+		return false, nil
+	}
+
+	conn, err := directory.connect()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	result, err := conn.Search(directory.searchRequest(directory.filter(idFilter), []string{"dn"}))
+	if err != nil {
+		return false, fmt.Errorf("failed to search entry: %w", err)
+	}
+
+	if len(result.Entries) != 1 {
+		return false, nil
+	}
+
+	if err := conn.Bind(result.Entries[0].DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to bind entry: %w", err)
+	}
+
+	return true, nil
+}
+
+func (directory *Directory) Search(ctx context.Context) ([]*domain.LDAPEntry, error) {
+	conn, err := directory.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	attributes := []string{
+		directory.config.IDAttribute,
+		directory.config.UsernameAttribute,
+		directory.config.DisplayNameAttribute,
+		directory.config.EmailAttribute,
+	}
+
+	result, err := conn.SearchWithPaging(directory.searchRequest(directory.filter(""), attributes), searchPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search entries: %w", err)
+	}
+
+	entries := []*domain.LDAPEntry{}
+	for _, entry := range result.Entries {
+		entries = append(entries, &domain.LDAPEntry{
+			ID:          directory.id(entry),
+			Username:    entry.GetEqualFoldAttributeValue(directory.config.UsernameAttribute),
+			DisplayName: entry.GetEqualFoldAttributeValue(directory.config.DisplayNameAttribute),
+			Email:       entry.GetEqualFoldAttributeValue(directory.config.EmailAttribute),
+		})
+	}
+
+	return entries, nil
+}
+
+// connect opens a connection bound as the service account.
+func (directory *Directory) connect() (*goldap.Conn, error) {
+	conn, err := goldap.DialURL(directory.config.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: directory.config.Timeout}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %w", err)
+	}
+
+	conn.SetTimeout(directory.config.Timeout)
+
+	if directory.config.StartTLS {
+		serverURL, err := url.Parse(directory.config.URL)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("invalid url: %w", err)
+		}
+
+		if err := conn.StartTLS(&tls.Config{ServerName: serverURL.Hostname()}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if directory.config.BindDN != "" {
+		if err := conn.Bind(directory.config.BindDN, directory.config.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to bind service account: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (directory *Directory) searchRequest(filter string, attributes []string) *goldap.SearchRequest {
+	return goldap.NewSearchRequest(
+		directory.config.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		0,
+		int(directory.config.Timeout.Seconds()),
+		false,
+		filter,
+		attributes,
+		nil,
+	)
+}
+
+// filter restricts the user filter to the group filter and the extra filter.
+func (directory *Directory) filter(extra string) string {
+	return "(&" + directory.config.UserFilter + directory.config.GroupFilter + extra + ")"
+}
+
+func (directory *Directory) idFilter(id string) (string, error) {
+	value := id
+	if directory.isBinaryID() {
+		raw, err := hex.DecodeString(id)
+		if err != nil {
+			return "", err
+		}
+
+		value = string(raw)
+	}
+
+	return "(" + directory.config.IDAttribute + "=" + goldap.EscapeFilter(value) + ")", nil
+}
+
+func (directory *Directory) id(entry *goldap.Entry) string {
+	if directory.isBinaryID() {
+		return hex.EncodeToString(entry.GetEqualFoldRawAttributeValue(directory.config.IDAttribute))
+	}
+
+	return entry.GetEqualFoldAttributeValue(directory.config.IDAttribute)
+}
+
+func (directory *Directory) isBinaryID() bool {
+	return binaryAttributes[strings.ToLower(directory.config.IDAttribute)]
+}
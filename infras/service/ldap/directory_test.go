@@ -0,0 +1,53 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryAuthenticateRejectsEmptyPassword(t *testing.T) {
+	// Nothing listens on the port, the empty password is rejected before
+	// connecting.
+	directory := NewDirectory(DirectoryConfig{URL: "ldap://127.0.0.1:1", Timeout: time.Second})
+
+	ok, err := directory.Authenticate(context.Background(), "alice-id", "")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestDirectoryFilterEscapesID(t *testing.T) {
+	testcases := map[string]struct {
+		attribute string
+		id        string
+		filter    string
+	}{
+		"plain":    {"entryUUID", "5b2f4c9e", "(&(objectClass=person)(memberOf=cn=staff)(entryUUID=5b2f4c9e))"},
+		"wildcard": {"entryUUID", "*", `(&(objectClass=person)(memberOf=cn=staff)(entryUUID=\2a))`},
+		"injected": {"uid", "alice)(uid=*", `(&(objectClass=person)(memberOf=cn=staff)(uid=alice\29\28uid=\2a))`},
+		"binary":   {"objectGUID", "2a2900ff", `(&(objectClass=person)(memberOf=cn=staff)(objectGUID=\2a\29\00\ff))`},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			directory := NewDirectory(DirectoryConfig{
+				UserFilter:  "(objectClass=person)",
+				GroupFilter: "(memberOf=cn=staff)",
+				IDAttribute: tc.attribute,
+			})
+
+			idFilter, err := directory.idFilter(tc.id)
+			require.NoError(t, err)
+			require.Equal(t, tc.filter, directory.filter(idFilter))
+		})
+	}
+}
+
+func TestDirectoryBinaryIDMustBeHex(t *testing.T) {
+	directory := NewDirectory(DirectoryConfig{IDAttribute: "objectGUID"})
+
+	_, err := directory.idFilter("*")
+	require.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package ldap
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/todennus/user-service/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// MemoryEntry is an entry of the memory directory with its password.
+type MemoryEntry struct {
+	ID          string `yaml:"id"`
+	Username    string `yaml:"username"`
+	DisplayName string `yaml:"display_name"`
+	Email       string `yaml:"email"`
+	Password    string `yaml:"password"`
+}
+
+// memoryFile is the structure of the memory directory file, for example:
+//
+//	entries:
+//	  - id: 5b2f4c9e-0d6a-4c1e-9a57-3c8e2b1f7d40
+//	    username: alice
+//	    display_name: Alice
+//	    email: alice@example.com
+//	    password: alice-password
+type memoryFile struct {
+	Entries []MemoryEntry `yaml:"entries"`
+}
+
+// MemoryDirectory holds the entries in process, it stands in for an LDAP
+// server in local and test deployments. Its entries are the users of the
+// synchronized subtree, it applies no filter.
+type MemoryDirectory struct {
+	mu      sync.RWMutex
+	entries map[string]MemoryEntry
+	order   []string
+}
+
+func NewMemoryDirectory(entries []MemoryEntry) *MemoryDirectory {
+	directory := &MemoryDirectory{entries: map[string]MemoryEntry{}}
+	for _, entry := range entries {
+		directory.Put(entry)
+	}
+
+	return directory
+}
+
+// LoadMemoryDirectory creates a memory directory with the entries of a yaml
+// file.
+func LoadMemoryDirectory(path string) (*MemoryDirectory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ldap memory file: %w", err)
+	}
+
+	file := memoryFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ldap memory file: %w", err)
+	}
+
+	return NewMemoryDirectory(file.Entries), nil
+}
+
+// Put adds the entry or replaces the entry with the same id.
+func (directory *MemoryDirectory) Put(entry MemoryEntry) {
+	directory.mu.Lock()
+	defer directory.mu.Unlock()
+
+	if _, ok := directory.entries[entry.ID]; !ok {
+		directory.order = append(directory.order, entry.ID)
+	}
+
+	directory.entries[entry.ID] = entry
+}
+
+// Remove removes the entry with the id, as if it left the subtree.
+func (directory *MemoryDirectory) Remove(id string) {
+	directory.mu.Lock()
+	defer directory.mu.Unlock()
+
+	if _, ok := directory.entries[id]; !ok {
+		return
+	}
+
+	delete(directory.entries, id)
+	for i := range directory.order {
+		if directory.order[i] == id {
+			directory.order = append(directory.order[:i], directory.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (directory *MemoryDirectory) Authenticate(ctx context.Context, id, password string) (bool, error) {
+	directory.mu.RLock()
+	defer directory.mu.RUnlock()
+
+	entry, ok := directory.entries[id]
+	if !ok || password == "" {
+		return false, nil
+	}
+
+	return subtle.ConstantTimeCompare([]byte(entry.Password), []byte(password)) == 1, nil
+}
+
+func (directory *MemoryDirectory) Search(ctx context.Context) ([]*domain.LDAPEntry, error) {
+	directory.mu.RLock()
+	defer directory.mu.RUnlock()
+
+	entries := []*domain.LDAPEntry{}
+	for _, id := range directory.order {
+		entry := directory.entries[id]
+		entries = append(entries, &domain.LDAPEntry{
+			ID:          entry.ID,
+			Username:    entry.Username,
+			DisplayName: entry.DisplayName,
+			Email:       entry.Email,
+		})
+	}
+
+	return entries, nil
+}
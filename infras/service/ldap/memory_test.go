@@ -0,0 +1,52 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDirectoryAuthenticate(t *testing.T) {
+	directory := NewMemoryDirectory([]MemoryEntry{
+		{ID: "alice-id", Username: "alice", Password: "alice-password"},
+		{ID: "bob-id", Username: "bobby"},
+	})
+
+	testcases := map[string]struct {
+		id       string
+		password string
+		ok       bool
+	}{
+		"password":          {"alice-id", "alice-password", true},
+		"wrong-password":    {"alice-id", "bob-password", false},
+		"empty-password":    {"alice-id", "", false},
+		"entry-no-password": {"bob-id", "", false},
+		"unknown-id":        {"carol-id", "alice-password", false},
+		"wildcard-id":       {"*", "alice-password", false},
+		"injected-id":       {"alice-id)(uid=*", "alice-password", false},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ok, err := directory.Authenticate(context.Background(), tc.id, tc.password)
+			require.NoError(t, err)
+			require.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestMemoryDirectorySearch(t *testing.T) {
+	directory := NewMemoryDirectory([]MemoryEntry{
+		{ID: "alice-id", Username: "alice"},
+		{ID: "bob-id", Username: "bobby"},
+	})
+
+	directory.Put(MemoryEntry{ID: "alice-id", Username: "alice2"})
+	directory.Remove("bob-id")
+
+	entries, err := directory.Search(context.Background())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "alice2", entries[0].Username)
+}
@@ -0,0 +1,123 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/todennus/user-service/domain"
+)
+
+type SMTPSenderConfig struct {
+	// Addr is the host:port of the server, the connection is upgraded with
+	// STARTTLS if the server supports it. The sender authenticates with PLAIN
+	// if Username is set.
+	Addr     string
+	Username string
+	Password string
+
+	From    string
+	Timeout time.Duration
+}
+
+type SMTPSender struct {
+	config SMTPSenderConfig
+}
+
+func NewSMTPSender(config SMTPSenderConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+func (sender *SMTPSender) Send(ctx context.Context, mail *domain.Mail) error {
+	host, _, err := net.SplitHostPort(sender.config.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	dialer := net.Dialer{Timeout: sender.config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", sender.config.Addr)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(sender.config.Timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if sender.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", sender.config.Username, sender.config.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(sender.config.From); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(mail.To); err != nil {
+		return err
+	}
+
+	message, err := sender.message(mail)
+	if err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (sender *SMTPSender) message(mail *domain.Mail) ([]byte, error) {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "From: %s\r\n", sender.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(mail.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
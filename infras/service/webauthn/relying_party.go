@@ -0,0 +1,242 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	gowebauthn "github.com/go-webauthn/webauthn/webauthn"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+)
+
+type RelyingPartyConfig struct {
+	// ID is the domain of the relying party, the passkeys are bound to it.
+	ID          string
+	DisplayName string
+
+	// Origins are the origins of the pages allowed to run the ceremonies, for
+	// example the sign-in page of the auth server.
+	Origins []string
+
+	// UserVerification and ResidentKey are required, preferred or
+	// discouraged.
+	UserVerification string
+	ResidentKey      string
+}
+
+// RelyingParty verifies the ceremonies with go-webauthn, every attestation
+// format is accepted.
+type RelyingParty struct {
+	webauthn *gowebauthn.WebAuthn
+}
+
+func NewRelyingParty(config RelyingPartyConfig) (*RelyingParty, error) {
+	webauthn, err := gowebauthn.New(&gowebauthn.Config{
+		RPID:          config.ID,
+		RPDisplayName: config.DisplayName,
+		RPOrigins:     config.Origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirement(config.ResidentKey),
+			UserVerification: protocol.UserVerificationRequirement(config.UserVerification),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid webauthn config: %w", err)
+	}
+
+	return &RelyingParty{webauthn: webauthn}, nil
+}
+
+func (rp *RelyingParty) BeginRegistration(user *domain.User, passkeys []*domain.Passkey) ([]byte, []byte, error) {
+	rpUser := newUser(user, passkeys)
+
+	exclusions := []protocol.CredentialDescriptor{}
+	for _, credential := range rpUser.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := rp.webauthn.BeginRegistration(rpUser, gowebauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return marshalCeremony(creation, session)
+}
+
+func (rp *RelyingParty) FinishRegistration(user *domain.User, state, response []byte) (*domain.PasskeyCredential, error) {
+	session := gowebauthn.SessionData{}
+	if err := json.Unmarshal(state, &session); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, ceremonyError(err)
+	}
+
+	credential, err := rp.webauthn.CreateCredential(newUser(user, nil), session, parsed)
+	if err != nil {
+		return nil, ceremonyError(err)
+	}
+
+	return newPasskeyCredential(credential), nil
+}
+
+func (rp *RelyingParty) BeginAssertion(user *domain.User, passkeys []*domain.Passkey) ([]byte, []byte, error) {
+	if user == nil {
+		assertion, session, err := rp.webauthn.BeginDiscoverableLogin()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return marshalCeremony(assertion, session)
+	}
+
+	assertion, session, err := rp.webauthn.BeginLogin(newUser(user, passkeys))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return marshalCeremony(assertion, session)
+}
+
+func (rp *RelyingParty) FinishAssertion(
+	user *domain.User,
+	passkeys []*domain.Passkey,
+	state, response []byte,
+) (*domain.PasskeyCredential, error) {
+	session := gowebauthn.SessionData{}
+	if err := json.Unmarshal(state, &session); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, ceremonyError(err)
+	}
+
+	rpUser := newUser(user, passkeys)
+
+	var credential *gowebauthn.Credential
+	if len(session.UserID) == 0 {
+		credential, err = rp.webauthn.ValidateDiscoverableLogin(
+			func(rawID, userHandle []byte) (gowebauthn.User, error) {
+				if !bytes.Equal(userHandle, rpUser.id) {
+					return nil, errors.New("the user handle does not match the credential")
+				}
+
+				return rpUser, nil
+			}, session, parsed)
+	} else {
+		credential, err = rp.webauthn.ValidateLogin(rpUser, session, parsed)
+	}
+
+	if err != nil {
+		return nil, ceremonyError(err)
+	}
+
+	return newPasskeyCredential(credential), nil
+}
+
+func (rp *RelyingParty) AssertionCredentialID(response []byte) ([]byte, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, ceremonyError(err)
+	}
+
+	return parsed.RawID, nil
+}
+
+// user is the user of the relying party with their passkeys.
+type user struct {
+	id          []byte
+	name        string
+	displayName string
+	credentials []gowebauthn.Credential
+}
+
+func newUser(d *domain.User, passkeys []*domain.Passkey) *user {
+	u := &user{
+		id:          domain.PasskeyUserHandle(d.ID),
+		name:        d.Username,
+		displayName: d.DisplayName,
+		credentials: []gowebauthn.Credential{},
+	}
+
+	for _, passkey := range passkeys {
+		transports := []protocol.AuthenticatorTransport{}
+		for _, transport := range passkey.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		u.credentials = append(u.credentials, gowebauthn.Credential{
+			ID:              passkey.CredentialID,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Transport:       transports,
+			Flags: gowebauthn.CredentialFlags{
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: gowebauthn.Authenticator{
+				AAGUID:    passkey.AAGUID,
+				SignCount: passkey.SignCount,
+			},
+		})
+	}
+
+	return u
+}
+
+func (u *user) WebAuthnID() []byte                           { return u.id }
+func (u *user) WebAuthnName() string                         { return u.name }
+func (u *user) WebAuthnDisplayName() string                  { return u.displayName }
+func (u *user) WebAuthnCredentials() []gowebauthn.Credential { return u.credentials }
+
+func newPasskeyCredential(credential *gowebauthn.Credential) *domain.PasskeyCredential {
+	transports := []string{}
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return &domain.PasskeyCredential{
+		ID:                credential.ID,
+		PublicKey:         credential.PublicKey,
+		AttestationType:   credential.AttestationType,
+		AttestationObject: credential.Attestation.Object,
+		AAGUID:            credential.Authenticator.AAGUID,
+		SignCount:         credential.Authenticator.SignCount,
+		Transports:        transports,
+		BackupEligible:    credential.Flags.BackupEligible,
+		BackupState:       credential.Flags.BackupState,
+		CloneWarning:      credential.Authenticator.CloneWarning,
+	}
+}
+
+func marshalCeremony(options any, session *gowebauthn.SessionData) ([]byte, []byte, error) {
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := json.Marshal(session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return optionsJSON, state, nil
+}
+
+// ceremonyError keeps the details of the errors caused by the response, they
+// are hidden from the client anyway.
+func ceremonyError(err error) error {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) {
+		return fmt.Errorf("%w: %s: %s", abstraction.ErrCeremonyFailed, protocolErr.Details, protocolErr.DevInfo)
+	}
+
+	return fmt.Errorf("%w: %s", abstraction.ErrCeremonyFailed, err)
+}
@@ -0,0 +1,149 @@
+package webauthn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/service/webauthn/webauthntest"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://auth.example.com"
+)
+
+func newTestRelyingParty(t *testing.T) *RelyingParty {
+	rp, err := NewRelyingParty(RelyingPartyConfig{
+		ID:               testRPID,
+		DisplayName:      "Todennus",
+		Origins:          []string{testOrigin},
+		UserVerification: "preferred",
+		ResidentKey:      "preferred",
+	})
+	require.NoError(t, err)
+	return rp
+}
+
+func newTestUser() *domain.User {
+	return &domain.User{ID: snowflake.ID(1), Username: "alice", DisplayName: "Alice"}
+}
+
+// register runs a registration with the authenticator and returns the
+// resulting passkey.
+func register(t *testing.T, rp *RelyingParty, user *domain.User, authenticator *webauthntest.Authenticator) (*domain.Passkey, error) {
+	options, state, err := rp.BeginRegistration(user, nil)
+	require.NoError(t, err)
+
+	response, err := authenticator.Create(options)
+	require.NoError(t, err)
+
+	credential, err := rp.FinishRegistration(user, state, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Passkey{
+		UserID:          user.ID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.AAGUID,
+		SignCount:       credential.SignCount,
+	}, nil
+}
+
+// assert runs an assertion with the authenticator, a nil user lets the
+// authenticator choose a discoverable passkey.
+func assert(
+	t *testing.T,
+	rp *RelyingParty,
+	user *domain.User,
+	passkey *domain.Passkey,
+	authenticator *webauthntest.Authenticator,
+) (*domain.PasskeyCredential, error) {
+	var options, state []byte
+	var err error
+	if user == nil {
+		options, state, err = rp.BeginAssertion(nil, nil)
+	} else {
+		options, state, err = rp.BeginAssertion(user, []*domain.Passkey{passkey})
+	}
+	require.NoError(t, err)
+
+	response, err := authenticator.Get(options)
+	require.NoError(t, err)
+
+	credentialID, err := rp.AssertionCredentialID(response)
+	require.NoError(t, err)
+	require.Equal(t, passkey.CredentialID, credentialID)
+
+	return rp.FinishAssertion(newTestUser(), []*domain.Passkey{passkey}, state, response)
+}
+
+func TestRelyingPartyRegistrationAndAssertion(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	user := newTestUser()
+	authenticator := webauthntest.New(testRPID, testOrigin)
+
+	passkey, err := register(t, rp, user, authenticator)
+	require.NoError(t, err)
+	require.Equal(t, "none", passkey.AttestationType)
+	require.Zero(t, passkey.SignCount)
+
+	credential, err := assert(t, rp, user, passkey, authenticator)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), credential.SignCount)
+	require.False(t, credential.CloneWarning)
+
+	passkey.SignCount = credential.SignCount
+	credential, err = assert(t, rp, nil, passkey, authenticator)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), credential.SignCount)
+	require.False(t, credential.CloneWarning)
+}
+
+func TestRelyingPartySignCountRegression(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	user := newTestUser()
+	authenticator := webauthntest.New(testRPID, testOrigin)
+
+	passkey, err := register(t, rp, user, authenticator)
+	require.NoError(t, err)
+
+	credential, err := assert(t, rp, user, passkey, authenticator)
+	require.NoError(t, err)
+	passkey.SignCount = credential.SignCount
+
+	// A clone of the authenticator sends the counter it had when copied.
+	authenticator.SignCount = 0
+
+	credential, err = assert(t, rp, user, passkey, authenticator)
+	require.NoError(t, err)
+	require.True(t, credential.CloneWarning)
+}
+
+func TestRelyingPartyRejectsWrongRPID(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	user := newTestUser()
+
+	_, err := register(t, rp, user, webauthntest.New("evil.example.net", testOrigin))
+	require.ErrorIs(t, err, abstraction.ErrCeremonyFailed)
+
+	authenticator := webauthntest.New(testRPID, testOrigin)
+	passkey, err := register(t, rp, user, authenticator)
+	require.NoError(t, err)
+
+	authenticator.RPID = "evil.example.net"
+	_, err = assert(t, rp, user, passkey, authenticator)
+	require.ErrorIs(t, err, abstraction.ErrCeremonyFailed)
+}
+
+func TestRelyingPartyRejectsWrongOrigin(t *testing.T) {
+	rp := newTestRelyingParty(t)
+
+	_, err := register(t, rp, newTestUser(), webauthntest.New(testRPID, "https://evil.example.net"))
+	require.ErrorIs(t, err, abstraction.ErrCeremonyFailed)
+}
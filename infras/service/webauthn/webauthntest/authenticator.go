@@ -0,0 +1,191 @@
+// Package webauthntest provides a software authenticator, it runs the
+// ceremonies of the relying party without a browser.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// The flags of the authenticator data.
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+)
+
+// Authenticator holds a single ES256 credential, created by Create. Its
+// attestations use the none format.
+type Authenticator struct {
+	// RPID is the relying party the authenticator believes it talks to, its
+	// hash is part of the authenticator data.
+	RPID string
+
+	// Origin is the origin of the page running the ceremonies.
+	Origin string
+
+	// SignCount is the signature counter of the last assertion, every
+	// assertion increments it first.
+	SignCount uint32
+
+	credentialID []byte
+	userHandle   []byte
+	key          *ecdsa.PrivateKey
+}
+
+func New(rpID, origin string) *Authenticator {
+	return &Authenticator{RPID: rpID, Origin: origin}
+}
+
+// Create answers the options of navigator.credentials.create with a new
+// credential, as the JSON sent back by the browser.
+func (a *Authenticator) Create(options []byte) ([]byte, error) {
+	creation := struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			User      struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}{}
+	if err := json.Unmarshal(options, &creation); err != nil {
+		return nil, fmt.Errorf("invalid creation options: %w", err)
+	}
+
+	userHandle, err := base64.RawURLEncoding.DecodeString(creation.PublicKey.User.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		return nil, err
+	}
+
+	a.key, a.credentialID, a.userHandle, a.SignCount = key, credentialID, userHandle, 0
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: key.X.FillBytes(make([]byte, 32)),
+		YCoord: key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authData(flagUserPresent | flagUserVerified | flagAttestedCredData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(credentialID)))
+	authData = append(authData, credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.credential(map[string]string{
+		"clientDataJSON":    a.clientData("webauthn.create", creation.PublicKey.Challenge),
+		"attestationObject": encode(attestationObject),
+	})
+}
+
+// Get answers the options of navigator.credentials.get with an assertion of
+// the credential, as the JSON sent back by the browser.
+func (a *Authenticator) Get(options []byte) ([]byte, error) {
+	if a.key == nil {
+		return nil, errors.New("no credential, call Create first")
+	}
+
+	request := struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}{}
+	if err := json.Unmarshal(options, &request); err != nil {
+		return nil, fmt.Errorf("invalid request options: %w", err)
+	}
+
+	a.SignCount++
+
+	authData := a.authData(flagUserPresent | flagUserVerified)
+	clientData := a.clientData("webauthn.get", request.PublicKey.Challenge)
+
+	clientDataJSON, err := base64.RawURLEncoding.DecodeString(clientData)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return a.credential(map[string]string{
+		"clientDataJSON":    clientData,
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+// authData returns the authenticator data without the attested credential.
+func (a *Authenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, flags)
+	return binary.BigEndian.AppendUint32(authData, a.SignCount)
+}
+
+// clientData returns the encoded client data of the ceremony, the challenge is
+// already encoded in the options.
+func (a *Authenticator) clientData(ceremony, challenge string) string {
+	clientDataJSON, _ := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+
+	return encode(clientDataJSON)
+}
+
+func (a *Authenticator) credential(response map[string]string) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
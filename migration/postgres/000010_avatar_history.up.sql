@@ -0,0 +1,8 @@
+CREATE TABLE avatar_histories (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT REFERENCES users(id),
+    ownership_id BIGINT REFERENCES file_ownerships(id),
+    replaced_at TIMESTAMP
+);
+
+CREATE INDEX avatar_histories_user_id_idx ON avatar_histories (user_id, replaced_at DESC);
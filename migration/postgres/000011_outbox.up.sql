@@ -0,0 +1,13 @@
+CREATE TABLE outbox_messages (
+    id BIGINT PRIMARY KEY,
+    topic VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error VARCHAR,
+    created_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX outbox_messages_pending_idx ON outbox_messages (topic, next_attempt_at)
+    WHERE delivered_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE webhook_dead_letters;
+DROP TABLE webhook_delivery_logs;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
@@ -0,0 +1,54 @@
+CREATE TABLE webhooks (
+    id BIGINT PRIMARY KEY,
+    url VARCHAR NOT NULL,
+    secret VARCHAR NOT NULL,
+    event_types VARCHAR NOT NULL,
+    created_by BIGINT REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id BIGINT PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error VARCHAR,
+    created_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at)
+    WHERE delivered_at IS NULL;
+
+CREATE TABLE webhook_delivery_logs (
+    id BIGINT PRIMARY KEY,
+    delivery_id BIGINT NOT NULL,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT NOT NULL,
+    error VARCHAR,
+    duration_ms BIGINT NOT NULL,
+    attempted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_delivery_logs_webhook_id_idx ON webhook_delivery_logs (webhook_id, attempted_at DESC);
+
+CREATE TABLE webhook_dead_letters (
+    id BIGINT PRIMARY KEY,
+    webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL,
+    last_error VARCHAR,
+    created_at TIMESTAMP NOT NULL,
+    dead_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webhook_dead_letters_webhook_id_idx ON webhook_dead_letters (webhook_id);
@@ -0,0 +1,36 @@
+CREATE TABLE audit_logs (
+    seq BIGINT PRIMARY KEY,
+    id BIGINT NOT NULL UNIQUE,
+    actor_id BIGINT NOT NULL,
+    actor_type VARCHAR NOT NULL,
+    action VARCHAR NOT NULL,
+    target_type VARCHAR NOT NULL,
+    target_id VARCHAR NOT NULL,
+    client_id VARCHAR NOT NULL,
+    request_id VARCHAR NOT NULL,
+    metadata TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    prev_hash VARCHAR NOT NULL,
+    hash VARCHAR NOT NULL
+);
+
+CREATE INDEX audit_logs_actor_id_idx ON audit_logs (actor_id, seq);
+CREATE INDEX audit_logs_action_idx ON audit_logs (action, seq);
+CREATE INDEX audit_logs_target_idx ON audit_logs (target_type, target_id, seq);
+CREATE INDEX audit_logs_created_at_idx ON audit_logs (created_at);
+
+-- The audit logs are append-only, the hash chain detects the changes made by
+-- anyone who can bypass these triggers.
+CREATE FUNCTION audit_logs_forbid_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_forbid_update_delete
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_forbid_change();
+
+CREATE TRIGGER audit_logs_forbid_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_forbid_change();
@@ -0,0 +1,14 @@
+CREATE TABLE data_exports (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR NOT NULL,
+    archive BYTEA,
+    error VARCHAR,
+    created_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id, id DESC);
+CREATE INDEX data_exports_pending_idx ON data_exports (id) WHERE status = 'pending';
+CREATE INDEX data_exports_expires_at_idx ON data_exports (expires_at);
@@ -0,0 +1,16 @@
+-- The version 2 logs cannot be verified anymore once their metadata hash is
+-- dropped.
+CREATE OR REPLACE FUNCTION audit_logs_forbid_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE audit_logs DROP COLUMN scrubbed_at;
+ALTER TABLE audit_logs DROP COLUMN metadata_hash;
+ALTER TABLE audit_logs DROP COLUMN metadata_salt;
+ALTER TABLE audit_logs DROP COLUMN version;
+
+DROP INDEX users_erase_after_idx;
+ALTER TABLE users DROP COLUMN erased_at;
+ALTER TABLE users DROP COLUMN erase_after;
@@ -0,0 +1,36 @@
+ALTER TABLE users ADD COLUMN erase_after TIMESTAMP;
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP;
+
+CREATE INDEX users_erase_after_idx ON users (erase_after) WHERE erase_after IS NOT NULL AND erased_at IS NULL;
+
+-- The existing logs are version 1, their metadata cannot be scrubbed.
+ALTER TABLE audit_logs ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE audit_logs ADD COLUMN metadata_salt VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE audit_logs ADD COLUMN metadata_hash VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE audit_logs ADD COLUMN scrubbed_at TIMESTAMP;
+
+-- The only allowed change is scrubbing the metadata of a version 2 log once,
+-- the chain holds the hash of the metadata so it stays verifiable.
+CREATE OR REPLACE FUNCTION audit_logs_forbid_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.version >= 2
+            AND OLD.scrubbed_at IS NULL
+            AND NEW.scrubbed_at IS NOT NULL
+            AND NEW.metadata = '{}'
+            AND NEW.metadata_salt = ''
+            AND (NEW.seq, NEW.id, NEW.actor_id, NEW.actor_type, NEW.action,
+                 NEW.target_type, NEW.target_id, NEW.client_id, NEW.request_id,
+                 NEW.created_at, NEW.prev_hash, NEW.hash, NEW.version, NEW.metadata_hash)
+                IS NOT DISTINCT FROM
+                (OLD.seq, OLD.id, OLD.actor_id, OLD.actor_type, OLD.action,
+                 OLD.target_type, OLD.target_id, OLD.client_id, OLD.request_id,
+                 OLD.created_at, OLD.prev_hash, OLD.hash, OLD.version, OLD.metadata_hash)
+        THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+-- The extension is kept, it may be used outside of this service.
+DROP INDEX IF EXISTS users_display_name_trgm_idx;
+DROP INDEX IF EXISTS users_username_trgm_idx;
@@ -0,0 +1,12 @@
+-- The trigram indexes speed up the user search. Without the pg_trgm
+-- extension, for example when it is not installed or the user cannot create
+-- it, the search scores every user instead.
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    CREATE INDEX users_username_trgm_idx ON users USING GIN (lower(username) gin_trgm_ops);
+    CREATE INDEX users_display_name_trgm_idx ON users USING GIN (lower(display_name) gin_trgm_ops);
+EXCEPTION WHEN insufficient_privilege OR undefined_file THEN
+    RAISE NOTICE 'pg_trgm is not available, the user search scans every user';
+END;
+$$;
@@ -0,0 +1 @@
+CREATE INDEX users_username_lower_idx ON users (lower(username));
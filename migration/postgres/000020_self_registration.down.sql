@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS users_email_lower_idx;
+
+ALTER TABLE users DROP COLUMN pending;
+ALTER TABLE users DROP COLUMN email;
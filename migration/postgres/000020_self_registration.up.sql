@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email VARCHAR;
+ALTER TABLE users ADD COLUMN pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX users_email_lower_idx ON users (lower(email)) WHERE email IS NOT NULL;
@@ -0,0 +1,10 @@
+CREATE TABLE invitations (
+    id BIGINT PRIMARY KEY,
+    role VARCHAR NOT NULL,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    redeemed_at TIMESTAMP,
+    redeemed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP TABLE linked_identities;
+
+ALTER TABLE users DROP COLUMN has_password;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_ldap_id_idx;
+
+ALTER TABLE users DROP COLUMN ldap_id;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN ldap_id VARCHAR;
+
+CREATE UNIQUE INDEX users_ldap_id_idx ON users (ldap_id) WHERE ldap_id IS NOT NULL;
@@ -0,0 +1,18 @@
+CREATE TABLE passkeys (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR NOT NULL,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR NOT NULL,
+    attestation_object BYTEA,
+    aaguid BYTEA,
+    sign_count BIGINT NOT NULL,
+    transports VARCHAR NOT NULL,
+    backup_eligible BOOLEAN NOT NULL,
+    backup_state BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX passkeys_user_id_idx ON passkeys (user_id);
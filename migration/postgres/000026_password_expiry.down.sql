@@ -0,0 +1,3 @@
+DROP TABLE password_histories;
+
+ALTER TABLE users DROP COLUMN password_changed_at;
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE TABLE password_histories (
+    id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hashed_pass VARCHAR NOT NULL,
+    replaced_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX password_histories_user_id_idx ON password_histories (user_id, replaced_at DESC);
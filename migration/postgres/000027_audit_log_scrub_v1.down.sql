@@ -0,0 +1,25 @@
+DROP INDEX audit_logs_unknown_username_idx;
+
+CREATE OR REPLACE FUNCTION audit_logs_forbid_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.version >= 2
+            AND OLD.scrubbed_at IS NULL
+            AND NEW.scrubbed_at IS NOT NULL
+            AND NEW.metadata = '{}'
+            AND NEW.metadata_salt = ''
+            AND (NEW.seq, NEW.id, NEW.actor_id, NEW.actor_type, NEW.action,
+                 NEW.target_type, NEW.target_id, NEW.client_id, NEW.request_id,
+                 NEW.created_at, NEW.prev_hash, NEW.hash, NEW.version, NEW.metadata_hash)
+                IS NOT DISTINCT FROM
+                (OLD.seq, OLD.id, OLD.actor_id, OLD.actor_type, OLD.action,
+                 OLD.target_type, OLD.target_id, OLD.client_id, OLD.request_id,
+                 OLD.created_at, OLD.prev_hash, OLD.hash, OLD.version, OLD.metadata_hash)
+        THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,30 @@
+-- The metadata of a log is scrubbed once, whatever its version. The hash of a
+-- scrubbed version 1 log covered the removed metadata, it is trusted as is.
+CREATE OR REPLACE FUNCTION audit_logs_forbid_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        IF OLD.scrubbed_at IS NULL
+            AND NEW.scrubbed_at IS NOT NULL
+            AND NEW.metadata = '{}'
+            AND NEW.metadata_salt = ''
+            AND (NEW.seq, NEW.id, NEW.actor_id, NEW.actor_type, NEW.action,
+                 NEW.target_type, NEW.target_id, NEW.client_id, NEW.request_id,
+                 NEW.created_at, NEW.prev_hash, NEW.hash, NEW.version, NEW.metadata_hash)
+                IS NOT DISTINCT FROM
+                (OLD.seq, OLD.id, OLD.actor_id, OLD.actor_type, OLD.action,
+                 OLD.target_type, OLD.target_id, OLD.client_id, OLD.request_id,
+                 OLD.created_at, OLD.prev_hash, OLD.hash, OLD.version, OLD.metadata_hash)
+        THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- The failed validations of an unknown username have no target, they are
+-- found by the username in their metadata when a user with that username is
+-- erased.
+CREATE INDEX audit_logs_unknown_username_idx ON audit_logs (LOWER(metadata::jsonb ->> 'username'), seq)
+    WHERE action = 'user.validate_credentials_failed' AND target_id = '';
@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The emails of the directory are trusted, the federated users only got the
+-- verified email of their external identity. The emails given at the
+-- registration are not verified.
+UPDATE users SET email_verified = TRUE
+    WHERE email IS NOT NULL AND (ldap_id IS NOT NULL OR NOT has_password);
@@ -0,0 +1,66 @@
+// Package postgres migrates the tables owned by the user service. The tables
+// shared by the todennus services are migrated by todennus/migration, which
+// must be applied first.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/todennus/shared/xcontext"
+)
+
+// MigrationsTable records the version of the user service migrations. It is
+// not the table of todennus/migration, so both sets of migrations are
+// versioned independently.
+const MigrationsTable = "user_service_schema_migrations"
+
+//go:embed *.sql
+var files embed.FS
+
+func Up(ctx context.Context, db *sql.DB) error {
+	m, err := getMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	xcontext.Logger(ctx).Info("migrate up user service postgres successfully")
+	return nil
+}
+
+func Down(ctx context.Context, db *sql.DB, n int) error {
+	m, err := getMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Steps(-n); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	xcontext.Logger(ctx).Info("migrate down user service postgres successfully")
+	return nil
+}
+
+func getMigrator(db *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{MigrationsTable: MigrationsTable})
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewWithInstance("iofs", source, "postgres", driver)
+}
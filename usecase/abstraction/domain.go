@@ -1,6 +1,9 @@
 package abstraction
 
 import (
+	"io"
+	"time"
+
 	"github.com/todennus/user-service/domain"
 	"github.com/xybor-x/snowflake"
 )
@@ -8,9 +11,161 @@ import (
 type UserDomain interface {
 	New(username, password string) (*domain.User, error)
 	NewFirst(username, password string) (*domain.User, error)
+	NewProvisioned(username, password string) (*domain.User, error)
+	NewSelfRegistered(username, password, email string) (*domain.User, error)
+	NewFederated(username, displayName string) (*domain.User, error)
+	FederatedUsernames(claims *domain.ExternalClaims, n int) []string
+	NewFromLDAP(entry *domain.LDAPEntry) (*domain.User, error)
+	SyncLDAP(user *domain.User, entry *domain.LDAPEntry) (bool, error)
+	DisableLDAP(user *domain.User) bool
+	NewImported(row *domain.UserImportRow) (*domain.User, error)
+	DecodeImport(format string, r io.Reader) ([]*domain.UserImportRow, error)
+	NewExportEncoder(format string, w io.Writer, options domain.UserExportOptions) (domain.UserExportEncoder, error)
 	Validate(hashedPassword, password string) error
+	SetDisplayName(user *domain.User, displayName string) error
+	SetPassword(user *domain.User, password string) error
+	PasswordStatus(user *domain.User) (string, time.Time)
+	ChangePassword(user *domain.User, password string, histories []*domain.PasswordHistory) (*domain.PasswordHistory, error)
+	EvictPasswordHistory(histories []*domain.PasswordHistory) []*domain.PasswordHistory
+	SetActive(user *domain.User, active bool)
+	Approve(user *domain.User)
+	VerifyEmail(user *domain.User) bool
+	IsErased(user *domain.User) bool
+	ScheduleErasure(user *domain.User) error
+	CancelErasure(user *domain.User) error
+	Erase(user *domain.User)
+	CheckUsername(username string) []domain.UsernameReason
+	SuggestUsernames(username string, n int) []string
+	NormalizeEmail(email string) (string, error)
+	NormalizeSearchQuery(query string) (string, error)
+	RankSearch(users []*domain.User, query string, limit int) []*domain.User
+}
+
+type RegistrationDomain interface {
+	Policy() domain.RegistrationPolicy
+	IsOpen() bool
+	RequiresEmailVerification() bool
+	Authorize(email string, invitation *domain.Invitation) error
+}
+
+type InvitationDomain interface {
+	New(createdBy snowflake.ID, role string, expiration time.Duration) (*domain.Invitation, error)
+	NewToken(invitation *domain.Invitation) *domain.InvitationToken
+	Status(invitation *domain.Invitation) string
+	Redeem(invitation *domain.Invitation, userID snowflake.ID) error
+	Revoke(invitation *domain.Invitation) error
+}
+
+type PersonalAccessTokenDomain interface {
+	New(userID snowflake.ID, name, scope string, expiration time.Duration) (*domain.PersonalAccessToken, string, error)
+	HashToken(token string) string
+	IsToken(value string) bool
+	Status(token *domain.PersonalAccessToken) string
+	Revoke(token *domain.PersonalAccessToken)
+	Use(token *domain.PersonalAccessToken) bool
+}
+
+type LinkedIdentityDomain interface {
+	New(userID snowflake.ID, claims *domain.ExternalClaims) (*domain.LinkedIdentity, error)
+	NormalizeProvider(provider string) string
+	CheckUnlink(user *domain.User, identities []*domain.LinkedIdentity, passkeys []*domain.Passkey) error
+}
+
+type PasskeyDomain interface {
+	NewSession(ceremony string, userID snowflake.ID, data []byte) (*domain.PasskeySession, error)
+	CheckSession(session *domain.PasskeySession, ceremony string) error
+	New(userID snowflake.ID, name string, credential *domain.PasskeyCredential) (*domain.Passkey, error)
+	Rename(passkey *domain.Passkey, name string) error
+	Use(passkey *domain.Passkey, credential *domain.PasskeyCredential) error
+}
+
+type MagicLinkDomain interface {
+	NewToken(user *domain.User) *domain.MagicLinkToken
+	NewMail(user *domain.User, signedToken string) (*domain.Mail, error)
+}
+
+type PasswordResetDomain interface {
+	NewToken(user *domain.User) *domain.PasswordResetToken
+	CheckToken(user *domain.User, claims *domain.PasswordResetToken) error
+}
+
+type EmailVerificationDomain interface {
+	NewToken(user *domain.User) *domain.EmailVerificationToken
+	NewMail(user *domain.User, signedToken string) (*domain.Mail, error)
+	NewTakenMail(user *domain.User) *domain.Mail
+	CheckToken(user *domain.User, claims *domain.EmailVerificationToken) error
+}
+
+type ReauthenticationDomain interface {
+	NewToken(user *domain.User, method string) *domain.ReauthenticationToken
+	CheckToken(user *domain.User, claims *domain.ReauthenticationToken) error
 }
 
 type AvatarDomain interface {
-	GetPolicy(userID snowflake.ID) *domain.AvatarPolicy
+	GetPolicy(user *domain.User) *domain.AvatarPolicy
+	NewDefault(user *domain.User) *domain.DefaultAvatar
+	DefaultURL(userID snowflake.ID) string
+	NewHistory(userID, ownershipID snowflake.ID) *domain.AvatarHistory
+	EvictHistory(histories []*domain.AvatarHistory) []*domain.AvatarHistory
+}
+
+type OutboxDomain interface {
+	NewRefcountChange(inc, dec []snowflake.ID) (*domain.OutboxMessage, error)
+	ParseRefcountChange(msg *domain.OutboxMessage) (*domain.RefcountChange, error)
+	NewEvent(event *domain.Event) (*domain.OutboxMessage, error)
+	NewWebhookEvent(event *domain.Event) (*domain.OutboxMessage, error)
+	ParseEvent(msg *domain.OutboxMessage) (*domain.Event, error)
+	NewAuditLog(log *domain.AuditLog) (*domain.OutboxMessage, error)
+	ParseAuditLog(msg *domain.OutboxMessage) (*domain.AuditLog, error)
+	Lease(msg *domain.OutboxMessage)
+	SetDelivered(msg *domain.OutboxMessage)
+	SetFailed(msg *domain.OutboxMessage, err error)
+	MaxDeliveryAttempts() int
+	Revive(msg *domain.OutboxMessage)
+}
+
+type EventDomain interface {
+	NewUserCreated(user *domain.User) (*domain.Event, error)
+	NewUserUpdated(user *domain.User) (*domain.Event, error)
+	NewUserErased(user *domain.User) (*domain.Event, error)
+	NewUserAvatarChanged(userID, previousOwnershipID, ownershipID snowflake.ID) (*domain.Event, error)
+}
+
+type WebhookDomain interface {
+	New(url string, eventTypes []string, createdBy snowflake.ID) (*domain.Webhook, error)
+	Subscribes(webhook *domain.Webhook, eventType string) bool
+	NewDelivery(webhook *domain.Webhook, event *domain.Event) (*domain.WebhookDelivery, error)
+	Sign(secret string, timestamp time.Time, payload []byte) string
+	NewDeliveryLog(delivery *domain.WebhookDelivery, attemptedAt time.Time, statusCode int, err error) *domain.WebhookDeliveryLog
+	Lease(delivery *domain.WebhookDelivery)
+	SetDelivered(delivery *domain.WebhookDelivery)
+	SetFailed(delivery *domain.WebhookDelivery, err error) *domain.WebhookDeadLetter
+	Replay(deadLetter *domain.WebhookDeadLetter) *domain.WebhookDelivery
+}
+
+type AuditDomain interface {
+	New(actor domain.AuditActor, action, targetType, targetID string, metadata map[string]string) (*domain.AuditLog, error)
+	Chain(log *domain.AuditLog, prev *domain.AuditLog)
+	Verify(logs []*domain.AuditLog, prev *domain.AuditLog) error
+	Scrub(log *domain.AuditLog) bool
+}
+
+type ScimDomain interface {
+	ParseFilter(filter string) (*domain.ScimFilter, error)
+	ParsePatch(operations []domain.ScimPatchOperation) (*domain.ScimUserPatch, error)
+}
+
+type DataExportDomain interface {
+	New(userID snowflake.ID) *domain.DataExport
+	NewArchive(
+		user *domain.User,
+		histories []*domain.AvatarHistory,
+		identities []*domain.LinkedIdentity,
+		logs []*domain.AuditLog,
+	) *domain.UserDataArchive
+	Complete(export *domain.DataExport, archive *domain.UserDataArchive) error
+	Fail(export *domain.DataExport, reason string)
+	IsExpired(export *domain.DataExport) bool
+	EncodeArchive(archive *domain.UserDataArchive) ([]byte, error)
+	DecodeArchive(export *domain.DataExport) (*domain.UserDataArchive, error)
 }
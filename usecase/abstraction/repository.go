@@ -2,6 +2,7 @@ package abstraction
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/todennus/shared/enumdef"
@@ -11,18 +12,228 @@ import (
 
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
+	CreateMany(ctx context.Context, users []*domain.User) error
 
 	GetByID(ctx context.Context, userID snowflake.ID) (*domain.User, error)
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	FindExistingUsernames(ctx context.Context, usernames []string) ([]string, error)
+	FindExistingCanonicalUsernames(ctx context.Context, usernames []string) ([]string, error)
+	FindExistingIDs(ctx context.Context, userIDs []snowflake.ID) ([]snowflake.ID, error)
 
+	GetAfterID(ctx context.Context, afterID snowflake.ID, limit int) ([]*domain.User, error)
 	GetAvatarByID(ctx context.Context, userID snowflake.ID) (snowflake.ID, error)
+	GetWithAvatar(ctx context.Context, afterID snowflake.ID, limit int) ([]*domain.User, error)
 	UpdateAvatarByID(ctx context.Context, userID, ownershipID snowflake.ID) error
+	RemoveAvatarByID(ctx context.Context, userID snowflake.ID) error
 
 	CountByRole(ctx context.Context, role enumdef.UserRole) (int64, error)
+
+	Update(ctx context.Context, user *domain.User, version time.Time) error
+	GetDueErasure(ctx context.Context, before time.Time, limit int) ([]*domain.User, error)
+	GetLDAPSourced(ctx context.Context) ([]*domain.User, error)
+	Search(ctx context.Context, query string, limit int) ([]*domain.User, error)
+	FindByScimFilter(ctx context.Context, filter *domain.ScimFilter, offset, limit int) ([]*domain.User, int64, error)
 }
 
+// ErrStaleVersion is returned when updating an entity which was changed since
+// it was read.
+var ErrStaleVersion = errors.New("stale version")
+
+// ErrServiceUnavailable is returned by repositories backed by a remote service
+// when that service cannot be reached.
+var ErrServiceUnavailable = errors.New("service unavailable")
+
 type FileRepository interface {
 	RegisterUpload(ctx context.Context, policy *domain.AvatarPolicy) (string, error)
 	CreatePresignedURL(ctx context.Context, ownershipID snowflake.ID, expiration time.Duration) (string, error)
 	ChangeRefcount(ctx context.Context, incOwnershipID, decOwnershipID []snowflake.ID) error
 }
+
+type AvatarPolicyRepository interface {
+	Save(ctx context.Context, uploadToken string, policy *domain.AvatarPolicy, expiration time.Duration) error
+	Get(ctx context.Context, uploadToken string) (*domain.AvatarPolicy, error)
+	Delete(ctx context.Context, uploadToken string) error
+}
+
+type AvatarHistoryRepository interface {
+	Create(ctx context.Context, history *domain.AvatarHistory) error
+	GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.AvatarHistory, error)
+	Delete(ctx context.Context, historyIDs ...snowflake.ID) error
+}
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, history *domain.PasswordHistory) error
+	GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.PasswordHistory, error)
+	Delete(ctx context.Context, historyIDs ...snowflake.ID) error
+	DeleteByUserID(ctx context.Context, userID snowflake.ID) error
+}
+
+type OutboxRepository interface {
+	Create(ctx context.Context, msg *domain.OutboxMessage) error
+	ClaimPending(ctx context.Context, topics []string, maxAttempts int, limit int) ([]*domain.OutboxMessage, error)
+	GetDead(ctx context.Context, topic string, maxAttempts int) ([]*domain.OutboxMessage, error)
+	Update(ctx context.Context, msg *domain.OutboxMessage) error
+}
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *domain.Invitation) error
+	GetByID(ctx context.Context, invitationID snowflake.ID) (*domain.Invitation, error)
+	GetBeforeID(ctx context.Context, beforeID snowflake.ID, limit int) ([]*domain.Invitation, error)
+	Update(ctx context.Context, invitation *domain.Invitation) error
+}
+
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *domain.PersonalAccessToken) error
+	GetByID(ctx context.Context, tokenID snowflake.ID) (*domain.PersonalAccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error)
+	GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.PersonalAccessToken, error)
+	LockUser(ctx context.Context, userID snowflake.ID) error
+	CountActiveByUserID(ctx context.Context, userID snowflake.ID) (int, error)
+	Revoke(ctx context.Context, token *domain.PersonalAccessToken) error
+	UpdateLastUsedAt(ctx context.Context, token *domain.PersonalAccessToken) error
+	DeleteByUserID(ctx context.Context, userID snowflake.ID) error
+}
+
+type LinkedIdentityRepository interface {
+	Create(ctx context.Context, identity *domain.LinkedIdentity) error
+	GetByExternalIdentity(ctx context.Context, provider, subject string) (*domain.LinkedIdentity, error)
+	GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.LinkedIdentity, error)
+	Delete(ctx context.Context, identityID snowflake.ID) error
+	DeleteByUserID(ctx context.Context, userID snowflake.ID) error
+}
+
+type PasskeyRepository interface {
+	Create(ctx context.Context, passkey *domain.Passkey) error
+	GetByID(ctx context.Context, passkeyID snowflake.ID) (*domain.Passkey, error)
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*domain.Passkey, error)
+	GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.Passkey, error)
+	UpdateName(ctx context.Context, passkey *domain.Passkey) error
+	UpdateUsage(ctx context.Context, passkey *domain.Passkey) error
+	Delete(ctx context.Context, passkeyID snowflake.ID) error
+	DeleteByUserID(ctx context.Context, userID snowflake.ID) error
+}
+
+type PasskeySessionRepository interface {
+	Save(ctx context.Context, session *domain.PasskeySession) error
+	Take(ctx context.Context, sessionID string) (*domain.PasskeySession, error)
+}
+
+// MagicLinkRepository remembers the redeemed magic links until they expire.
+type MagicLinkRepository interface {
+	// MarkRedeemed reports whether the token has not been redeemed before.
+	MarkRedeemed(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+}
+
+type RateLimitRepository interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+type MailSender interface {
+	Send(ctx context.Context, mail *domain.Mail) error
+}
+
+// LDAPDirectory reads the users of the synchronized subtree of the directory,
+// the entries outside of it or not matching the group filter do not exist.
+type LDAPDirectory interface {
+	// Authenticate reports whether the password is the one of the entry with
+	// the given id.
+	Authenticate(ctx context.Context, id, password string) (bool, error)
+	Search(ctx context.Context) ([]*domain.LDAPEntry, error)
+}
+
+// ErrCeremonyFailed is returned by the relying party when the response of the
+// authenticator is invalid.
+var ErrCeremonyFailed = errors.New("ceremony failed")
+
+// WebAuthnRelyingParty verifies the WebAuthn ceremonies. The options are given
+// as is to the browser, the state is kept in the session of the ceremony and
+// the responses are the PublicKeyCredential returned by the browser.
+type WebAuthnRelyingParty interface {
+	// BeginRegistration excludes the passkeys already registered by the user.
+	BeginRegistration(user *domain.User, passkeys []*domain.Passkey) (options, state []byte, err error)
+	FinishRegistration(user *domain.User, state, response []byte) (*domain.PasskeyCredential, error)
+
+	// BeginAssertion lets the authenticator choose a discoverable credential
+	// if the user is nil.
+	BeginAssertion(user *domain.User, passkeys []*domain.Passkey) (options, state []byte, err error)
+	FinishAssertion(user *domain.User, passkeys []*domain.Passkey, state, response []byte) (*domain.PasskeyCredential, error)
+
+	// AssertionCredentialID returns the id of the credential used by the
+	// assertion, before it is verified.
+	AssertionCredentialID(response []byte) ([]byte, error)
+}
+
+type EventRepository interface {
+	Publish(ctx context.Context, event *domain.Event) error
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *domain.Webhook) error
+	GetByID(ctx context.Context, webhookID snowflake.ID) (*domain.Webhook, error)
+	GetAll(ctx context.Context) ([]*domain.Webhook, error)
+	Delete(ctx context.Context, webhookID snowflake.ID) error
+}
+
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, deliveries ...*domain.WebhookDelivery) error
+	ClaimPending(ctx context.Context, limit int) ([]*domain.WebhookDelivery, error)
+	Update(ctx context.Context, delivery *domain.WebhookDelivery) error
+	Delete(ctx context.Context, deliveryID snowflake.ID) error
+
+	CreateLog(ctx context.Context, log *domain.WebhookDeliveryLog) error
+	GetLogsByWebhookID(ctx context.Context, webhookID snowflake.ID, limit int) ([]*domain.WebhookDeliveryLog, error)
+}
+
+type WebhookDeadLetterRepository interface {
+	Create(ctx context.Context, deadLetter *domain.WebhookDeadLetter) error
+	GetByID(ctx context.Context, deadLetterID snowflake.ID) (*domain.WebhookDeadLetter, error)
+	GetByWebhookID(ctx context.Context, webhookID snowflake.ID) ([]*domain.WebhookDeadLetter, error)
+	GetAll(ctx context.Context) ([]*domain.WebhookDeadLetter, error)
+	Delete(ctx context.Context, deadLetterID snowflake.ID) error
+}
+
+type WebhookSender interface {
+	// Send posts the payload to the webhook and returns the response status
+	// code. A response other than 2xx is returned as an error.
+	Send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery, signature string) (int, error)
+}
+
+// AuditLogFilter selects the audit logs, zero fields are ignored.
+type AuditLogFilter struct {
+	ActorID    snowflake.ID
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	BeforeSeq  int64
+}
+
+type AuditLogRepository interface {
+	// LockChain serializes the writers of the chain until the end of the
+	// current transaction.
+	LockChain(ctx context.Context) error
+	GetLast(ctx context.Context) (*domain.AuditLog, error)
+	Create(ctx context.Context, log *domain.AuditLog) error
+	Scrub(ctx context.Context, log *domain.AuditLog) error
+
+	Find(ctx context.Context, filter *AuditLogFilter, limit int) ([]*domain.AuditLog, error)
+	GetAfter(ctx context.Context, afterSeq int64, limit int) ([]*domain.AuditLog, error)
+	GetByUser(ctx context.Context, userID snowflake.ID, afterSeq int64, limit int) ([]*domain.AuditLog, error)
+	GetByUnknownUsername(ctx context.Context, username string, afterSeq int64, limit int) ([]*domain.AuditLog, error)
+}
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *domain.DataExport) error
+	GetByID(ctx context.Context, exportID snowflake.ID) (*domain.DataExport, error)
+	GetPendingByUserID(ctx context.Context, userID snowflake.ID) (*domain.DataExport, error)
+	ClaimPending(ctx context.Context, limit int) ([]*domain.DataExport, error)
+	Update(ctx context.Context, export *domain.DataExport) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+	DeleteByUserID(ctx context.Context, userID snowflake.ID) error
+}
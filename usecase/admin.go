@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/x/xerror"
+)
+
+// requireAdminRole allows only the requests made by an admin user. It is
+// checked besides an admin scope, which does not tell whether the user is
+// still an admin.
+func requireAdminRole(ctx context.Context, userRepo abstraction.UserRepository) error {
+	requesterID := xcontext.RequestSubjectID(ctx)
+	if requesterID == 0 {
+		return xerror.Enrich(errordef.ErrForbidden, "permission denied")
+	}
+
+	requester, err := userRepo.GetByID(ctx, requesterID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return xerror.Enrich(errordef.ErrForbidden, "permission denied")
+		}
+
+		return errordef.ErrServer.Hide(err, "failed-to-get-requester", "uid", requesterID)
+	}
+
+	if requester.Role != enumdef.UserRoleAdmin {
+		return xerror.Enrich(errordef.ErrForbidden, "permission denied")
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+)
+
+type AuditUsecase struct {
+	auditDomain abstraction.AuditDomain
+
+	userRepo  abstraction.UserRepository
+	auditRepo abstraction.AuditLogRepository
+}
+
+func NewAuditUsecase(
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *AuditUsecase {
+	return &AuditUsecase{
+		auditDomain: auditDomain,
+		userRepo:    userRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+func (usecase *AuditUsecase) List(
+	ctx context.Context,
+	req *dto.AuditListRequest,
+) (*dto.AuditListResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminReadUserProfile).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if err := requireAdminRole(ctx, usecase.userRepo); err != nil {
+		return nil, err
+	}
+
+	logs, err := usecase.auditRepo.Find(ctx, &abstraction.AuditLogFilter{
+		ActorID:    req.ActorID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		From:       req.From,
+		To:         req.To,
+		BeforeSeq:  req.BeforeSeq,
+	}, req.Limit)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-find-audit-logs")
+	}
+
+	return dto.NewAuditListResponse(logs), nil
+}
+
+// Verify walks the whole chain from the first log and stops at the first log
+// which was modified, removed or inserted out of the chain.
+func (usecase *AuditUsecase) Verify(
+	ctx context.Context,
+	req *dto.AuditVerifyRequest,
+) (*dto.AuditVerifyResponse, error) {
+	var prev *domain.AuditLog
+	var verified int64
+	for {
+		afterSeq := int64(0)
+		if prev != nil {
+			afterSeq = prev.Seq
+		}
+
+		logs, err := usecase.auditRepo.GetAfter(ctx, afterSeq, req.BatchSize)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-audit-logs", "after_seq", afterSeq)
+		}
+
+		if err := usecase.auditDomain.Verify(logs, prev); err != nil {
+			return nil, errordef.DomainWrapper.Event(err, "audit-chain-broken").
+				Enrich(errordef.ErrRequestInvalid).Error()
+		}
+
+		if len(logs) == 0 {
+			return dto.NewAuditVerifyResponse(verified, prev), nil
+		}
+
+		verified += int64(len(logs))
+		prev = logs[len(logs)-1]
+	}
+}
+
+// auditActor returns the subject of the current request. A client token has no
+// user behind it, the client is then both the actor and the client of the log.
+func auditActor(ctx context.Context) domain.AuditActor {
+	actor := domain.AuditActor{
+		ID:        xcontext.RequestSubjectID(ctx),
+		RequestID: xcontext.RequestID(ctx),
+	}
+
+	if actor.ID != 0 {
+		subjectType := xcontext.RequestSubjectType(ctx)
+		actor.Type = subjectType.String()
+		if subjectType == enumdef.SubjectClient {
+			actor.ClientID = actor.ID.String()
+		}
+	}
+
+	return actor
+}
+
+// recordAudit appends a log to the audit chain. It must be called inside a
+// transaction, the chain is locked until the transaction ends.
+func recordAudit(
+	ctx context.Context,
+	auditDomain abstraction.AuditDomain,
+	auditRepo abstraction.AuditLogRepository,
+	action, targetType, targetID string,
+	metadata map[string]string,
+) error {
+	log, err := auditDomain.New(auditActor(ctx), action, targetType, targetID, metadata)
+	if err != nil {
+		return err
+	}
+
+	return appendAudit(ctx, auditDomain, auditRepo, log)
+}
+
+// recordAuditLater adds a log to the outbox instead of the audit chain. The
+// relay appends it later, so the caller does not take the chain lock.
+func recordAuditLater(
+	ctx context.Context,
+	auditDomain abstraction.AuditDomain,
+	outboxDomain abstraction.OutboxDomain,
+	outboxRepo abstraction.OutboxRepository,
+	action, targetType, targetID string,
+	metadata map[string]string,
+) error {
+	log, err := auditDomain.New(auditActor(ctx), action, targetType, targetID, metadata)
+	if err != nil {
+		return err
+	}
+
+	msg, err := outboxDomain.NewAuditLog(log)
+	if err != nil {
+		return err
+	}
+
+	return outboxRepo.Create(ctx, msg)
+}
+
+// appendAudit links the log to the last log of the chain and saves it. It must
+// be called inside a transaction, the chain is locked until the transaction
+// ends.
+func appendAudit(
+	ctx context.Context,
+	auditDomain abstraction.AuditDomain,
+	auditRepo abstraction.AuditLogRepository,
+	log *domain.AuditLog,
+) error {
+	if err := auditRepo.LockChain(ctx); err != nil {
+		return err
+	}
+
+	prev, err := auditRepo.GetLast(ctx)
+	if err != nil && !errors.Is(err, errordef.ErrNotFound) {
+		return err
+	}
+
+	auditDomain.Chain(log, prev)
+	return auditRepo.Create(ctx, log)
+}
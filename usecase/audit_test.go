@@ -0,0 +1,27 @@
+package usecase_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+func TestAuditListRequiresAdminScope(t *testing.T) {
+	admin := &domain.User{ID: 1, Username: "admin", Role: enumdef.UserRoleAdmin}
+	auditUsecase := usecase.NewAuditUsecase(
+		domain.NewAuditDomain(newSnowflakeNode()), newFakeUserRepository(admin), &fakeAuditLogRepository{})
+
+	// The admin role is not enough, a token handed to another client must be
+	// granted the admin scope.
+	_, err := auditUsecase.List(newUserContext(admin.ID, scopedef.UserReadUserProfile), &dto.AuditListRequest{})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = auditUsecase.List(newUserContext(admin.ID, scopedef.AdminReadUserProfile), &dto.AuditListRequest{})
+	require.NoError(t, err)
+}
@@ -2,12 +2,16 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"slices"
+	"time"
 
+	"github.com/todennus/shared/enumdef"
 	"github.com/todennus/shared/errordef"
 	"github.com/todennus/shared/scopedef"
 	"github.com/todennus/shared/tokendef"
 	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/usecase/abstraction"
 	"github.com/todennus/user-service/usecase/dto"
 	"github.com/todennus/x/token"
@@ -18,23 +22,49 @@ import (
 type AvatarUsecase struct {
 	tokenEngine token.Engine
 
+	avatarPresignedURLExpiration time.Duration
+
+	// avatarPolicyExpiration is the duration during which the policy of an
+	// issued upload token is kept, it must cover both the upload_token and
+	// the file_token lifetime.
+	avatarPolicyExpiration time.Duration
+
 	avatarDomain abstraction.AvatarDomain
+	eventDomain  abstraction.EventDomain
+	outboxDomain abstraction.OutboxDomain
 
-	fileRepo abstraction.FileRepository
-	userRepo abstraction.UserRepository
+	fileRepo          abstraction.FileRepository
+	userRepo          abstraction.UserRepository
+	avatarPolicyRepo  abstraction.AvatarPolicyRepository
+	avatarHistoryRepo abstraction.AvatarHistoryRepository
+	outboxRepo        abstraction.OutboxRepository
 }
 
 func NewAvatarUsecase(
 	tokenEngine token.Engine,
+	avatarPresignedURLExpiration time.Duration,
+	avatarPolicyExpiration time.Duration,
 	avatarDomain abstraction.AvatarDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
 	fileRepo abstraction.FileRepository,
 	userRepo abstraction.UserRepository,
+	avatarPolicyRepo abstraction.AvatarPolicyRepository,
+	avatarHistoryRepo abstraction.AvatarHistoryRepository,
+	outboxRepo abstraction.OutboxRepository,
 ) *AvatarUsecase {
 	return &AvatarUsecase{
-		tokenEngine:  tokenEngine,
-		avatarDomain: avatarDomain,
-		fileRepo:     fileRepo,
-		userRepo:     userRepo,
+		tokenEngine:                  tokenEngine,
+		avatarPresignedURLExpiration: avatarPresignedURLExpiration,
+		avatarPolicyExpiration:       avatarPolicyExpiration,
+		avatarDomain:                 avatarDomain,
+		eventDomain:                  eventDomain,
+		outboxDomain:                 outboxDomain,
+		fileRepo:                     fileRepo,
+		userRepo:                     userRepo,
+		avatarPolicyRepo:             avatarPolicyRepo,
+		avatarHistoryRepo:            avatarHistoryRepo,
+		outboxRepo:                   outboxRepo,
 	}
 }
 
@@ -50,12 +80,25 @@ func (usecase *AvatarUsecase) GetUploadToken(
 		return nil, xerror.Enrich(errordef.ErrForbidden, "permission denied")
 	}
 
-	uploadToken, err := usecase.fileRepo.RegisterUpload(
-		ctx, usecase.avatarDomain.GetPolicy(xcontext.RequestSubjectID(ctx)))
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	policy := usecase.avatarDomain.GetPolicy(user)
+	uploadToken, err := usecase.fileRepo.RegisterUpload(ctx, policy)
 	if err != nil {
 		return nil, errordef.ErrServer.Hide(err, "failed-to-register-upload-token")
 	}
 
+	// Remember the policy of this upload, so that the avatar is validated
+	// against the same policy later even if the policy file or the user role
+	// has changed in the meantime. It is kept per upload token, so concurrent
+	// uploads of the same user do not overwrite the policy of each other.
+	if err := usecase.avatarPolicyRepo.Save(ctx, uploadToken, policy, usecase.avatarPolicyExpiration); err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-save-avatar-policy", "uid", req.UserID)
+	}
+
 	return dto.NewAvatarGetUploadTokenResponse(uploadToken), nil
 }
 
@@ -76,10 +119,13 @@ func (usecase *AvatarUsecase) Update(
 		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid token").Hide(err, "failed-to-parse-token")
 	}
 
-	policy := usecase.avatarDomain.GetPolicy(xcontext.RequestSubjectID(ctx))
+	policy, err := usecase.getUploadPolicy(ctx, req.UserID, req.UploadToken)
+	if err != nil {
+		return nil, err
+	}
 
 	userID := fileToken.SnowflakeUserID()
-	if userID != policy.UserID {
+	if userID != policy.UserID || userID != req.UserID {
 		return nil, xerror.Enrich(errordef.ErrForbidden, "the user doesn't have the permission to use this token")
 	}
 
@@ -111,14 +157,385 @@ func (usecase *AvatarUsecase) Update(
 	incOwnershipID := []snowflake.ID{newAvatar}
 	decOwnershipID := []snowflake.ID{}
 	if currentAvatar != 0 {
-		decOwnershipID = append(decOwnershipID, currentAvatar)
+		decOwnershipID, err = usecase.retainAvatar(ctx, userID, currentAvatar)
+		if err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, errordef.ErrServer.Hide(err, "failed-to-retain-avatar",
+				"user_id", userID, "ownership_id", currentAvatar)
+		}
 	}
 
-	if err := usecase.fileRepo.ChangeRefcount(ctx, incOwnershipID, decOwnershipID); err != nil {
+	if err := usecase.changeRefcount(ctx, incOwnershipID, decOwnershipID); err != nil {
 		ctx = xcontext.DBRollback(ctx)
 		return nil, errordef.ErrServer.Hide(err, "failed-to-change-avatar-ref-count",
 			"user_id", userID, "inc", incOwnershipID, "dec", decOwnershipID)
 	}
 
+	if err := usecase.recordAvatarChanged(ctx, userID, currentAvatar, newAvatar); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-avatar-changed-event", "user_id", userID)
+	}
+
+	// The policy only serves one upload.
+	if req.UploadToken != "" {
+		if err := usecase.avatarPolicyRepo.Delete(ctx, req.UploadToken); err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-delete-avatar-policy", "err", err, "uid", userID)
+		}
+	}
+
 	return dto.NewAvatarUpdateResponse(), nil
 }
+
+// getUploadPolicy returns the policy saved when the upload token was issued.
+// The clients which do not send the upload token are validated against the
+// current policy of the user instead.
+func (usecase *AvatarUsecase) getUploadPolicy(
+	ctx context.Context,
+	userID snowflake.ID,
+	uploadToken string,
+) (*domain.AvatarPolicy, error) {
+	if uploadToken == "" {
+		user, err := usecase.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+		}
+
+		return usecase.avatarDomain.GetPolicy(user), nil
+	}
+
+	policy, err := usecase.avatarPolicyRepo.Get(ctx, uploadToken)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the upload session has expired, please upload again")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-avatar-policy", "uid", userID)
+	}
+
+	return policy, nil
+}
+
+func (usecase *AvatarUsecase) Remove(
+	ctx context.Context,
+	req *dto.AvatarRemoveRequest,
+) (*dto.AvatarRemoveResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAnyUser(scopedef.UserUpdateUserAvatar).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	// Only the owner can remove their avatar, except admins who may remove any
+	// avatar for moderation purpose with a reason.
+	isModeration := req.UserID != xcontext.RequestSubjectID(ctx)
+	if isModeration {
+		requester, err := usecase.userRepo.GetByID(ctx, xcontext.RequestSubjectID(ctx))
+		if err != nil {
+			if errors.Is(err, errordef.ErrNotFound) {
+				return nil, xerror.Enrich(errordef.ErrForbidden, "permission denied")
+			}
+
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-requester", "uid", xcontext.RequestSubjectID(ctx))
+		}
+
+		if requester.Role != enumdef.UserRoleAdmin {
+			return nil, xerror.Enrich(errordef.ErrForbidden, "permission denied")
+		}
+
+		if req.Reason == "" {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require a reason when removing avatar of other users")
+		}
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	currentAvatar, err := usecase.userRepo.GetAvatarByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-current-avatar")
+	}
+
+	if currentAvatar == 0 {
+		return dto.NewAvatarRemoveResponse(), nil
+	}
+
+	if err := usecase.userRepo.RemoveAvatarByID(ctx, req.UserID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-remove-avatar", "user_id", req.UserID)
+	}
+
+	// A moderated avatar must not be restorable, so it is released instead of
+	// being kept in the history.
+	decOwnershipID := []snowflake.ID{currentAvatar}
+	if !isModeration {
+		decOwnershipID, err = usecase.retainAvatar(ctx, req.UserID, currentAvatar)
+		if err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, errordef.ErrServer.Hide(err, "failed-to-retain-avatar",
+				"user_id", req.UserID, "ownership_id", currentAvatar)
+		}
+	}
+
+	if err := usecase.changeRefcount(ctx, nil, decOwnershipID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-change-avatar-ref-count",
+			"user_id", req.UserID, "dec", decOwnershipID)
+	}
+
+	if err := usecase.recordAvatarChanged(ctx, req.UserID, currentAvatar, 0); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-avatar-changed-event", "user_id", req.UserID)
+	}
+
+	xcontext.Logger(ctx).Info("avatar-removed",
+		"actor", xcontext.RequestSubjectID(ctx), "user_id", req.UserID,
+		"ownership_id", currentAvatar, "reason", req.Reason)
+
+	return dto.NewAvatarRemoveResponse(), nil
+}
+
+func (usecase *AvatarUsecase) GetDefault(
+	ctx context.Context,
+	req *dto.AvatarGetDefaultRequest,
+) (*dto.AvatarGetDefaultResponse, error) {
+	if req.UserID == 0 {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require user id")
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	contentType, content, err := usecase.avatarDomain.NewDefault(user).Render(req.Format, req.Size)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-render-default-avatar").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	return dto.NewAvatarGetDefaultResponse(contentType, content), nil
+}
+
+func (usecase *AvatarUsecase) ListHistory(
+	ctx context.Context,
+	req *dto.AvatarListHistoryRequest,
+) (*dto.AvatarListHistoryResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).
+		RequireAdmin(scopedef.AdminReadUserProfile).
+		RequireUser(ctx, scopedef.UserReadUserAvatar, req.UserID).
+		IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	histories, err := usecase.avatarHistoryRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-avatar-histories", "uid", req.UserID)
+	}
+
+	avatarURLs := []string{}
+	for _, history := range histories {
+		url, err := usecase.fileRepo.CreatePresignedURL(ctx, history.OwnershipID, usecase.avatarPresignedURLExpiration)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-presigned-url", "avatar", history.OwnershipID)
+		}
+
+		avatarURLs = append(avatarURLs, url)
+	}
+
+	return dto.NewAvatarListHistoryResponse(histories, avatarURLs), nil
+}
+
+func (usecase *AvatarUsecase) Restore(
+	ctx context.Context,
+	req *dto.AvatarRestoreRequest,
+) (*dto.AvatarRestoreResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAnyUser(scopedef.UserUpdateUserAvatar).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if req.UserID != xcontext.RequestSubjectID(ctx) {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "permission denied")
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	histories, err := usecase.avatarHistoryRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-avatar-histories", "uid", req.UserID)
+	}
+
+	// Restore the most recent avatar if no history is specified.
+	var target *domain.AvatarHistory
+	for _, history := range histories {
+		if req.HistoryID == 0 || history.ID == req.HistoryID {
+			target = history
+			break
+		}
+	}
+
+	if target == nil {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found any previous avatar to restore")
+	}
+
+	currentAvatar, err := usecase.userRepo.GetAvatarByID(ctx, req.UserID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-current-avatar")
+	}
+
+	if err := usecase.avatarHistoryRepo.Delete(ctx, target.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-delete-avatar-history", "history_id", target.ID)
+	}
+
+	if err := usecase.userRepo.UpdateAvatarByID(ctx, req.UserID, target.OwnershipID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-avatar",
+			"user_id", req.UserID, "ownership_id", target.OwnershipID)
+	}
+
+	// The restored avatar keeps the reference held by its history entry, so
+	// only evicted histories need to be released.
+	decOwnershipID := []snowflake.ID{}
+	if currentAvatar != 0 {
+		decOwnershipID, err = usecase.retainAvatar(ctx, req.UserID, currentAvatar)
+		if err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, errordef.ErrServer.Hide(err, "failed-to-retain-avatar",
+				"user_id", req.UserID, "ownership_id", currentAvatar)
+		}
+	}
+
+	if err := usecase.changeRefcount(ctx, nil, decOwnershipID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-change-avatar-ref-count",
+			"user_id", req.UserID, "dec", decOwnershipID)
+	}
+
+	if err := usecase.recordAvatarChanged(ctx, req.UserID, currentAvatar, target.OwnershipID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-avatar-changed-event", "user_id", req.UserID)
+	}
+
+	return dto.NewAvatarRestoreResponse(), nil
+}
+
+// retainAvatar keeps the replaced avatar in the user history and returns the
+// ownership ids evicted from the history, whose refcount must be decreased.
+func (usecase *AvatarUsecase) retainAvatar(ctx context.Context, userID, ownershipID snowflake.ID) ([]snowflake.ID, error) {
+	if err := usecase.avatarHistoryRepo.Create(ctx, usecase.avatarDomain.NewHistory(userID, ownershipID)); err != nil {
+		return nil, err
+	}
+
+	histories, err := usecase.avatarHistoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	evictedIDs := []snowflake.ID{}
+	evictedOwnershipIDs := []snowflake.ID{}
+	for _, history := range usecase.avatarDomain.EvictHistory(histories) {
+		evictedIDs = append(evictedIDs, history.ID)
+		evictedOwnershipIDs = append(evictedOwnershipIDs, history.OwnershipID)
+	}
+
+	if err := usecase.avatarHistoryRepo.Delete(ctx, evictedIDs...); err != nil {
+		return nil, err
+	}
+
+	return evictedOwnershipIDs, nil
+}
+
+// FindDangling finds the avatars whose file no longer exists in the file
+// service and, with req.Fix, resets them to the default avatar. It does not
+// compare the refcounts, the file service does not expose them.
+func (usecase *AvatarUsecase) FindDangling(
+	ctx context.Context,
+	req *dto.AvatarFindDanglingRequest,
+) (*dto.AvatarFindDanglingResponse, error) {
+	resp := dto.NewAvatarFindDanglingResponse()
+
+	var afterID snowflake.ID
+	for {
+		users, err := usecase.userRepo.GetWithAvatar(ctx, afterID, req.BatchSize)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-users-with-avatar", "after", afterID)
+		}
+
+		if len(users) == 0 {
+			break
+		}
+
+		for _, user := range users {
+			afterID = user.ID
+			resp.Checked++
+
+			_, err := usecase.fileRepo.CreatePresignedURL(ctx, user.Avatar, time.Second)
+			if err == nil {
+				continue
+			}
+
+			if !errors.Is(err, errordef.ErrNotFound) {
+				return nil, errordef.ErrServer.Hide(err, "failed-to-check-avatar",
+					"user_id", user.ID, "avatar", user.Avatar)
+			}
+
+			resp.Dangling = append(resp.Dangling, dto.NewAvatarDangling(user.ID, user.Avatar))
+			if req.Fix {
+				if err := usecase.resetDanglingAvatar(ctx, user); err != nil {
+					return nil, errordef.ErrServer.Hide(err, "failed-to-reset-dangling-avatar", "user_id", user.ID)
+				}
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+func (usecase *AvatarUsecase) resetDanglingAvatar(ctx context.Context, user *domain.User) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.RemoveAvatarByID(ctx, user.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.recordAvatarChanged(ctx, user.ID, user.Avatar, 0); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *AvatarUsecase) recordAvatarChanged(ctx context.Context, userID, previousOwnershipID, ownershipID snowflake.ID) error {
+	event, err := usecase.eventDomain.NewUserAvatarChanged(userID, previousOwnershipID, ownershipID)
+	if err != nil {
+		return err
+	}
+
+	return recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+}
+
+// changeRefcount records the refcount change in the outbox of the current
+// transaction. The relay delivers it to the file service after the commit, so
+// the file service never counts a change which was rolled back.
+func (usecase *AvatarUsecase) changeRefcount(ctx context.Context, inc, dec []snowflake.ID) error {
+	if len(inc) == 0 && len(dec) == 0 {
+		return nil
+	}
+
+	msg, err := usecase.outboxDomain.NewRefcountChange(inc, dec)
+	if err != nil {
+		return err
+	}
+
+	return usecase.outboxRepo.Create(ctx, msg)
+}
@@ -0,0 +1,95 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/tokendef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/xybor-x/snowflake"
+)
+
+type avatarFixture struct {
+	tokenEngine      *fakeTokenEngine
+	userRepo         *fakeUserRepository
+	avatarPolicyRepo *fakeAvatarPolicyRepository
+
+	avatar *usecase.AvatarUsecase
+}
+
+func newAvatarFixture(users ...*domain.User) *avatarFixture {
+	node := newSnowflakeNode()
+	avatarDomain := domain.NewAvatarDomain(node, domain.AvatarPolicyRules{
+		Default:  domain.AvatarPolicyRule{AllowedTypes: []string{"image/png"}, MaxSize: 1000},
+		Verified: &domain.AvatarPolicyRule{AllowedTypes: []string{"image/png", "image/webp"}, MaxSize: 1000},
+	}, 3, "http://localhost")
+
+	f := &avatarFixture{
+		tokenEngine:      newFakeTokenEngine(),
+		userRepo:         newFakeUserRepository(users...),
+		avatarPolicyRepo: newFakeAvatarPolicyRepository(),
+	}
+
+	f.avatar = usecase.NewAvatarUsecase(
+		f.tokenEngine, time.Minute, time.Minute, avatarDomain, domain.NewEventDomain(node),
+		domain.NewOutboxDomain(node, 3, time.Second, time.Minute, time.Minute),
+		&fakeFileRepository{}, f.userRepo, f.avatarPolicyRepo, nil, newFakeOutboxRepository())
+
+	return f
+}
+
+// update sets the avatar of the user to a file of the type and size.
+func (f *avatarFixture) update(
+	t *testing.T,
+	user *domain.User,
+	uploadToken, fileType string,
+	size int,
+) error {
+	fileToken, err := f.tokenEngine.Generate(context.Background(), &tokendef.FileToken{
+		OwnershipID: snowflake.ID(100).String(),
+		UserID:      user.ID.String(),
+		Type:        fileType,
+		Size:        size,
+	})
+	require.NoError(t, err)
+
+	_, err = f.avatar.Update(newUserContext(user.ID, scopedef.UserUpdateUserAvatar), &dto.AvatarUpdateRequest{
+		UserID:      user.ID,
+		UploadToken: uploadToken,
+		FileToken:   fileToken,
+	})
+	return err
+}
+
+func TestAvatarUpdateWithoutUploadTokenUsesCurrentPolicy(t *testing.T) {
+	alice := &domain.User{ID: 1, Username: "alice", EmailVerified: true}
+	bob := &domain.User{ID: 2, Username: "bob"}
+	f := newAvatarFixture(alice, bob)
+
+	require.NoError(t, f.update(t, alice, "", "image/webp", 500))
+	require.Equal(t, snowflake.ID(100), f.userRepo.users[alice.ID].Avatar)
+
+	require.ErrorIs(t, f.update(t, alice, "", "image/webp", 2000), errordef.ErrRequestInvalid)
+	require.ErrorIs(t, f.update(t, bob, "", "image/webp", 500), errordef.ErrFileMismatchedType)
+}
+
+func TestAvatarUpdateUsesPolicyOfUploadToken(t *testing.T) {
+	bob := &domain.User{ID: 2, Username: "bob"}
+	f := newAvatarFixture(bob)
+
+	// The policy saved with the upload wins over the current policy of bob.
+	policy := &domain.AvatarPolicy{UserID: bob.ID, AllowedTypes: []string{"image/gif"}, MaxSize: 1000}
+	require.NoError(t, f.avatarPolicyRepo.Save(context.Background(), "upload", policy, time.Minute))
+
+	require.ErrorIs(t, f.update(t, bob, "upload", "image/png", 500), errordef.ErrFileMismatchedType)
+	require.NoError(t, f.update(t, bob, "upload", "image/gif", 500))
+
+	// The policy only serves one upload.
+	require.ErrorIs(t, f.update(t, bob, "upload", "image/gif", 500), errordef.ErrRequestInvalid)
+}
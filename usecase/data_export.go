@@ -0,0 +1,344 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// DataExportUsecase gives users everything this service stores about them. The
+// archive of a small account is generated within the request, the others are
+// generated by the worker and downloaded later.
+type DataExportUsecase struct {
+	avatarPresignedURLExpiration time.Duration
+
+	// syncLimit is the number of audit logs above which the archive is
+	// generated by the worker.
+	syncLimit int
+
+	dataExportDomain abstraction.DataExportDomain
+	avatarDomain     abstraction.AvatarDomain
+	auditDomain      abstraction.AuditDomain
+
+	userRepo           abstraction.UserRepository
+	avatarHistoryRepo  abstraction.AvatarHistoryRepository
+	linkedIdentityRepo abstraction.LinkedIdentityRepository
+	auditRepo          abstraction.AuditLogRepository
+	dataExportRepo     abstraction.DataExportRepository
+	fileRepo           abstraction.FileRepository
+}
+
+func NewDataExportUsecase(
+	avatarPresignedURLExpiration time.Duration,
+	syncLimit int,
+	dataExportDomain abstraction.DataExportDomain,
+	avatarDomain abstraction.AvatarDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	avatarHistoryRepo abstraction.AvatarHistoryRepository,
+	linkedIdentityRepo abstraction.LinkedIdentityRepository,
+	auditRepo abstraction.AuditLogRepository,
+	dataExportRepo abstraction.DataExportRepository,
+	fileRepo abstraction.FileRepository,
+) *DataExportUsecase {
+	return &DataExportUsecase{
+		avatarPresignedURLExpiration: avatarPresignedURLExpiration,
+		syncLimit:                    syncLimit,
+		dataExportDomain:             dataExportDomain,
+		avatarDomain:                 avatarDomain,
+		auditDomain:                  auditDomain,
+		userRepo:                     userRepo,
+		avatarHistoryRepo:            avatarHistoryRepo,
+		linkedIdentityRepo:           linkedIdentityRepo,
+		auditRepo:                    auditRepo,
+		dataExportRepo:               dataExportRepo,
+		fileRepo:                     fileRepo,
+	}
+}
+
+// Request returns the archive of the user, or the pending export if the
+// archive is too large to be generated within the request. A user has at most
+// one pending export.
+func (usecase *DataExportUsecase) Request(
+	ctx context.Context,
+	req *dto.DataExportRequestRequest,
+) (*dto.DataExportRequestResponse, error) {
+	if err := requireSelf(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	pending, err := usecase.dataExportRepo.GetPendingByUserID(ctx, req.UserID)
+	if err == nil {
+		return dto.NewDataExportRequestPendingResponse(pending), nil
+	}
+
+	if !errors.Is(err, errordef.ErrNotFound) {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-pending-data-export", "uid", req.UserID)
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	logs, err := usecase.auditRepo.GetByUser(ctx, user.ID, 0, usecase.syncLimit+1)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-audit-logs", "uid", user.ID)
+	}
+
+	if len(logs) > usecase.syncLimit {
+		export := usecase.dataExportDomain.New(user.ID)
+		if err := usecase.createExport(ctx, export); err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-create-data-export", "uid", user.ID)
+		}
+
+		return dto.NewDataExportRequestPendingResponse(export), nil
+	}
+
+	if err := usecase.recordDataExport(ctx, user.ID, map[string]string{"mode": "sync"}); err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	histories, err := usecase.avatarHistoryRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-avatar-histories", "uid", user.ID)
+	}
+
+	identities, err := usecase.linkedIdentityRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-linked-identities", "uid", user.ID)
+	}
+
+	archive, err := usecase.encodeArchive(ctx, usecase.dataExportDomain.NewArchive(user, histories, identities, logs))
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewDataExportRequestArchiveResponse(archive), nil
+}
+
+func (usecase *DataExportUsecase) Get(
+	ctx context.Context,
+	req *dto.DataExportGetRequest,
+) (*dto.DataExportGetResponse, error) {
+	export, err := usecase.getExport(ctx, req.UserID, req.ExportID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewDataExportGetResponse(export), nil
+}
+
+// Download returns the archive of a completed export, with an avatar url valid
+// from now on.
+func (usecase *DataExportUsecase) Download(
+	ctx context.Context,
+	req *dto.DataExportDownloadRequest,
+) (*dto.DataExportDownloadResponse, error) {
+	export, err := usecase.getExport(ctx, req.UserID, req.ExportID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := usecase.dataExportDomain.DecodeArchive(export)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-decode-data-export").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	content, err := usecase.encodeArchive(ctx, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewDataExportDownloadResponse(content), nil
+}
+
+// Process generates the archives of the pending exports and deletes the
+// expired ones, it is run by the worker.
+func (usecase *DataExportUsecase) Process(
+	ctx context.Context,
+	req *dto.DataExportProcessRequest,
+) (*dto.DataExportProcessResponse, error) {
+	resp := &dto.DataExportProcessResponse{}
+
+	deleted, err := usecase.dataExportRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-delete-expired-data-exports")
+	}
+	resp.Deleted = deleted
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	exports, err := usecase.dataExportRepo.ClaimPending(ctx, req.BatchSize)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-claim-data-exports")
+	}
+
+	for _, export := range exports {
+		archive, err := usecase.generateArchive(ctx, export.UserID)
+		if err == nil {
+			err = usecase.dataExportDomain.Complete(export, archive)
+		}
+
+		if err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-generate-data-export",
+				"err", err, "export_id", export.ID, "uid", export.UserID)
+			usecase.dataExportDomain.Fail(export, "failed to generate the archive, please request a new export")
+			resp.Failed++
+		} else {
+			resp.Completed++
+		}
+
+		if err := usecase.dataExportRepo.Update(ctx, export); err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-update-data-export", "export_id", export.ID)
+		}
+	}
+
+	return resp, nil
+}
+
+func (usecase *DataExportUsecase) generateArchive(ctx context.Context, userID snowflake.ID) (*domain.UserDataArchive, error) {
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	histories, err := usecase.avatarHistoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := usecase.linkedIdentityRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := []*domain.AuditLog{}
+	for {
+		afterSeq := int64(0)
+		if len(logs) > 0 {
+			afterSeq = logs[len(logs)-1].Seq
+		}
+
+		page, err := usecase.auditRepo.GetByUser(ctx, userID, afterSeq, dto.DataExportAuditLogBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, page...)
+		if len(page) < dto.DataExportAuditLogBatchSize {
+			return usecase.dataExportDomain.NewArchive(user, histories, identities, logs), nil
+		}
+	}
+}
+
+// encodeArchive presigns the avatar url of the archive. An avatar which does
+// not exist anymore is exported without url.
+func (usecase *DataExportUsecase) encodeArchive(ctx context.Context, archive *domain.UserDataArchive) ([]byte, error) {
+	if archive.Avatar.OwnershipID == 0 {
+		archive.Avatar.URL = usecase.avatarDomain.DefaultURL(archive.Profile.ID)
+	} else {
+		url, err := usecase.fileRepo.CreatePresignedURL(ctx, archive.Avatar.OwnershipID, usecase.avatarPresignedURLExpiration)
+		if err != nil && !errors.Is(err, errordef.ErrNotFound) {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-presigned-url", "avatar", archive.Avatar.OwnershipID)
+		}
+
+		archive.Avatar.URL = url
+	}
+
+	content, err := usecase.dataExportDomain.EncodeArchive(archive)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-encode-data-export")
+	}
+
+	return content, nil
+}
+
+// getExport returns an export of the user which has not expired.
+func (usecase *DataExportUsecase) getExport(ctx context.Context, userID, exportID snowflake.ID) (*domain.DataExport, error) {
+	if err := requireSelf(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	export, err := usecase.dataExportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found export with id %d", exportID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-data-export", "export_id", exportID)
+	}
+
+	if export.UserID != userID || usecase.dataExportDomain.IsExpired(export) {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found export with id %d", exportID)
+	}
+
+	return export, nil
+}
+
+func (usecase *DataExportUsecase) createExport(ctx context.Context, export *domain.DataExport) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.dataExportRepo.Create(ctx, export); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserDataExport,
+		domain.AuditTargetUser, export.UserID.String(), map[string]string{
+			"mode":      "async",
+			"export_id": export.ID.String(),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+func (usecase *DataExportUsecase) recordDataExport(ctx context.Context, userID snowflake.ID, metadata map[string]string) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserDataExport,
+		domain.AuditTargetUser, userID.String(), metadata)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// requireSelf allows a user to access their own data only, with the
+// read:user.profile scope.
+func requireSelf(ctx context.Context, userID snowflake.ID) error {
+	if xcontext.RequestSubjectID(ctx) == 0 || xcontext.RequestSubjectType(ctx) != enumdef.SubjectUser {
+		return xerror.Enrich(errordef.ErrForbidden, "require a user token")
+	}
+
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireUser(ctx, scopedef.UserReadUserProfile, userID).IsUnsatisfied() {
+		return xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	return nil
+}
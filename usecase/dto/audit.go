@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+type AuditListRequest struct {
+	ActorID    snowflake.ID
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	BeforeSeq  int64
+	Limit      int
+}
+
+type AuditListResponse struct {
+	Logs []*resource.AuditLog
+}
+
+func NewAuditListResponse(logs []*domain.AuditLog) *AuditListResponse {
+	resp := &AuditListResponse{Logs: []*resource.AuditLog{}}
+	for i := range logs {
+		resp.Logs = append(resp.Logs, resource.NewAuditLog(logs[i]))
+	}
+
+	return resp
+}
+
+type AuditVerifyRequest struct {
+	BatchSize int
+}
+
+// AuditVerifyResponse holds the last verified log, it is the head of the chain
+// if the chain is intact.
+type AuditVerifyResponse struct {
+	Verified int64
+	LastSeq  int64
+	LastHash string
+}
+
+func NewAuditVerifyResponse(verified int64, last *domain.AuditLog) *AuditVerifyResponse {
+	resp := &AuditVerifyResponse{Verified: verified}
+	if last != nil {
+		resp.LastSeq = last.Seq
+		resp.LastHash = last.Hash
+	}
+
+	return resp
+}
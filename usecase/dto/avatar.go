@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/xybor-x/snowflake"
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
 
 type AvatarGetUploadTokenRequest struct {
 	UserID snowflake.ID
@@ -15,8 +19,9 @@ func NewAvatarGetUploadTokenResponse(uploadToken string) *AvatarGetUploadTokenRe
 }
 
 type AvatarUpdateRequest struct {
-	UserID    snowflake.ID
-	FileToken string
+	UserID      snowflake.ID
+	UploadToken string
+	FileToken   string
 }
 
 type AvatarUpdateResponse struct {
@@ -25,3 +30,82 @@ type AvatarUpdateResponse struct {
 func NewAvatarUpdateResponse() *AvatarUpdateResponse {
 	return &AvatarUpdateResponse{}
 }
+
+type AvatarRemoveRequest struct {
+	UserID snowflake.ID
+	Reason string
+}
+
+type AvatarRemoveResponse struct {
+}
+
+func NewAvatarRemoveResponse() *AvatarRemoveResponse {
+	return &AvatarRemoveResponse{}
+}
+
+type AvatarGetDefaultRequest struct {
+	UserID snowflake.ID
+	Format string
+	Size   int
+}
+
+type AvatarGetDefaultResponse struct {
+	ContentType string
+	Content     []byte
+}
+
+func NewAvatarGetDefaultResponse(contentType string, content []byte) *AvatarGetDefaultResponse {
+	return &AvatarGetDefaultResponse{ContentType: contentType, Content: content}
+}
+
+type AvatarListHistoryRequest struct {
+	UserID snowflake.ID
+}
+
+type AvatarListHistoryResponse struct {
+	Histories []*resource.AvatarHistory
+}
+
+func NewAvatarListHistoryResponse(histories []*domain.AvatarHistory, avatarURLs []string) *AvatarListHistoryResponse {
+	resp := &AvatarListHistoryResponse{Histories: []*resource.AvatarHistory{}}
+	for i := range histories {
+		resp.Histories = append(resp.Histories, resource.NewAvatarHistory(histories[i], avatarURLs[i]))
+	}
+
+	return resp
+}
+
+type AvatarRestoreRequest struct {
+	UserID    snowflake.ID
+	HistoryID snowflake.ID
+}
+
+type AvatarRestoreResponse struct {
+}
+
+func NewAvatarRestoreResponse() *AvatarRestoreResponse {
+	return &AvatarRestoreResponse{}
+}
+
+type AvatarFindDanglingRequest struct {
+	BatchSize int
+	Fix       bool
+}
+
+type AvatarDangling struct {
+	UserID      snowflake.ID
+	OwnershipID snowflake.ID
+}
+
+func NewAvatarDangling(userID, ownershipID snowflake.ID) *AvatarDangling {
+	return &AvatarDangling{UserID: userID, OwnershipID: ownershipID}
+}
+
+type AvatarFindDanglingResponse struct {
+	Checked  int
+	Dangling []*AvatarDangling
+}
+
+func NewAvatarFindDanglingResponse() *AvatarFindDanglingResponse {
+	return &AvatarFindDanglingResponse{Dangling: []*AvatarDangling{}}
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+// DataExportAuditLogBatchSize is the number of audit logs read at once while
+// generating an archive.
+const DataExportAuditLogBatchSize = 1000
+
+type DataExportRequestRequest struct {
+	UserID snowflake.ID
+}
+
+// DataExportRequestResponse holds the archive if it was generated within the
+// request, otherwise the export generated by the worker.
+type DataExportRequestResponse struct {
+	Archive []byte
+	Export  *resource.DataExport
+}
+
+func NewDataExportRequestArchiveResponse(archive []byte) *DataExportRequestResponse {
+	return &DataExportRequestResponse{Archive: archive}
+}
+
+func NewDataExportRequestPendingResponse(export *domain.DataExport) *DataExportRequestResponse {
+	return &DataExportRequestResponse{Export: resource.NewDataExport(export)}
+}
+
+type DataExportGetRequest struct {
+	UserID   snowflake.ID
+	ExportID snowflake.ID
+}
+
+type DataExportGetResponse struct {
+	Export *resource.DataExport
+}
+
+func NewDataExportGetResponse(export *domain.DataExport) *DataExportGetResponse {
+	return &DataExportGetResponse{Export: resource.NewDataExport(export)}
+}
+
+type DataExportDownloadRequest struct {
+	UserID   snowflake.ID
+	ExportID snowflake.ID
+}
+
+type DataExportDownloadResponse struct {
+	Archive []byte
+}
+
+func NewDataExportDownloadResponse(archive []byte) *DataExportDownloadResponse {
+	return &DataExportDownloadResponse{Archive: archive}
+}
+
+type DataExportProcessRequest struct {
+	BatchSize int
+}
+
+type DataExportProcessResponse struct {
+	Completed int
+	Failed    int
+	Deleted   int64
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type EmailVerificationSendRequest struct {
+	Email string
+}
+
+// EmailVerificationSendResponse is the same whether an email is sent or not.
+type EmailVerificationSendResponse struct{}
+
+func NewEmailVerificationSendResponse() *EmailVerificationSendResponse {
+	return &EmailVerificationSendResponse{}
+}
+
+type EmailVerificationVerifyRequest struct {
+	Token string
+}
+
+type EmailVerificationVerifyResponse struct {
+	UserID        snowflake.ID
+	EmailVerified bool
+}
+
+func NewEmailVerificationVerifyResponse(user *domain.User) *EmailVerificationVerifyResponse {
+	return &EmailVerificationVerifyResponse{
+		UserID:        user.ID,
+		EmailVerified: user.EmailVerified,
+	}
+}
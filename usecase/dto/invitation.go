@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	DefaultInvitationListLimit = 50
+	MaximumInvitationListLimit = 200
+)
+
+// InvitationCreateRequest has a zero Expiration for the default expiration.
+type InvitationCreateRequest struct {
+	Role       string
+	Expiration time.Duration
+}
+
+// InvitationCreateResponse holds the token of the invitation, it is only
+// returned once.
+type InvitationCreateResponse struct {
+	Invitation *resource.Invitation
+	Token      string
+}
+
+func NewInvitationCreateResponse(invitation *domain.Invitation, status, token string) *InvitationCreateResponse {
+	return &InvitationCreateResponse{
+		Invitation: resource.NewInvitation(invitation, status),
+		Token:      token,
+	}
+}
+
+// InvitationListRequest has a zero Limit for the default limit.
+type InvitationListRequest struct {
+	BeforeID snowflake.ID
+	Limit    int
+}
+
+type InvitationListResponse struct {
+	Invitations []*resource.Invitation
+}
+
+// NewInvitationListResponse takes the status of every invitation, in the same
+// order.
+func NewInvitationListResponse(invitations []*domain.Invitation, statuses []string) *InvitationListResponse {
+	resp := &InvitationListResponse{Invitations: []*resource.Invitation{}}
+	for i, invitation := range invitations {
+		resp.Invitations = append(resp.Invitations, resource.NewInvitation(invitation, statuses[i]))
+	}
+
+	return resp
+}
+
+type InvitationRevokeRequest struct {
+	InvitationID snowflake.ID
+}
+
+type InvitationRevokeResponse struct {
+	Invitation *resource.Invitation
+}
+
+func NewInvitationRevokeResponse(invitation *domain.Invitation, status string) *InvitationRevokeResponse {
+	return &InvitationRevokeResponse{Invitation: resource.NewInvitation(invitation, status)}
+}
+
+type InvitationRedeemRequest struct {
+	Token    string
+	Username string
+	Password string
+}
+
+type InvitationRedeemResponse struct {
+	User *resource.User
+}
+
+func NewInvitationRedeemResponse(user *domain.User, avatarURL string) *InvitationRedeemResponse {
+	return &InvitationRedeemResponse{User: resource.NewUser(user, avatarURL)}
+}
@@ -0,0 +1,10 @@
+package dto
+
+type LDAPSyncRequest struct{}
+
+type LDAPSyncResponse struct {
+	Created  int
+	Updated  int
+	Disabled int
+	Failed   int
+}
@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+// FederatedUsernameCandidateCount is the number of usernames tried for a user
+// created from an external identity.
+const FederatedUsernameCandidateCount = 10
+
+type LinkedIdentityListRequest struct {
+	UserID snowflake.ID
+}
+
+type LinkedIdentityListResponse struct {
+	Identities []*resource.LinkedIdentity
+}
+
+func NewLinkedIdentityListResponse(identities []*domain.LinkedIdentity) *LinkedIdentityListResponse {
+	resp := &LinkedIdentityListResponse{Identities: []*resource.LinkedIdentity{}}
+	for _, identity := range identities {
+		resp.Identities = append(resp.Identities, resource.NewLinkedIdentity(identity))
+	}
+
+	return resp
+}
+
+type LinkedIdentityLinkRequest struct {
+	UserID   snowflake.ID
+	Provider string
+	Subject  string
+	Email    string
+}
+
+type LinkedIdentityLinkResponse struct {
+	Identity *resource.LinkedIdentity
+}
+
+func NewLinkedIdentityLinkResponse(identity *domain.LinkedIdentity) *LinkedIdentityLinkResponse {
+	return &LinkedIdentityLinkResponse{Identity: resource.NewLinkedIdentity(identity)}
+}
+
+type LinkedIdentityUnlinkRequest struct {
+	UserID     snowflake.ID
+	IdentityID snowflake.ID
+}
+
+type LinkedIdentityUnlinkResponse struct{}
+
+func NewLinkedIdentityUnlinkResponse() *LinkedIdentityUnlinkResponse {
+	return &LinkedIdentityUnlinkResponse{}
+}
+
+type LinkedIdentityGetUserRequest struct {
+	Provider string
+	Subject  string
+}
+
+type LinkedIdentityGetUserResponse struct {
+	User *resource.User
+}
+
+func NewLinkedIdentityGetUserResponse(user *domain.User) *LinkedIdentityGetUserResponse {
+	return &LinkedIdentityGetUserResponse{User: resource.NewUser(user, "")}
+}
+
+// LinkedIdentityProvisionRequest holds the claims of the external identity,
+// as verified by the auth server.
+type LinkedIdentityProvisionRequest struct {
+	Provider          string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	DisplayName       string
+}
+
+// LinkedIdentityProvisionResponse has Created unset if the identity was
+// already linked to User.
+type LinkedIdentityProvisionResponse struct {
+	User    *resource.User
+	Created bool
+}
+
+func NewLinkedIdentityProvisionResponse(user *domain.User, avatarURL string, created bool) *LinkedIdentityProvisionResponse {
+	return &LinkedIdentityProvisionResponse{User: resource.NewUser(user, avatarURL), Created: created}
+}
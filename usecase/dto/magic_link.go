@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type MagicLinkSendRequest struct {
+	Email string
+}
+
+// MagicLinkSendResponse does not tell whether a link was sent, so it cannot be
+// used to find out the emails of the users.
+type MagicLinkSendResponse struct{}
+
+func NewMagicLinkSendResponse() *MagicLinkSendResponse {
+	return &MagicLinkSendResponse{}
+}
+
+type MagicLinkRedeemRequest struct {
+	Token string
+}
+
+type MagicLinkRedeemResponse struct {
+	User *resource.User
+}
+
+func NewMagicLinkRedeemResponse(user *domain.User) *MagicLinkRedeemResponse {
+	return &MagicLinkRedeemResponse{User: resource.NewUser(user, "")}
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+)
+
+type OutboxRelayRequest struct {
+	BatchSize int
+}
+
+type OutboxRelayResponse struct {
+	Delivered int
+	Failed    int
+}
+
+func NewOutboxRelayResponse() *OutboxRelayResponse {
+	return &OutboxRelayResponse{}
+}
+
+type OutboxReviveDeadRequest struct {
+	Topic  string
+	DryRun bool
+}
+
+type OutboxReviveDeadResponse struct {
+	Messages []*resource.OutboxMessage
+}
+
+func NewOutboxReviveDeadResponse(msgs []*domain.OutboxMessage) *OutboxReviveDeadResponse {
+	resp := &OutboxReviveDeadResponse{Messages: []*resource.OutboxMessage{}}
+	for i := range msgs {
+		resp.Messages = append(resp.Messages, resource.NewOutboxMessage(msgs[i]))
+	}
+
+	return resp
+}
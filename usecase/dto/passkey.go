@@ -0,0 +1,120 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+type PasskeyBeginRegistrationRequest struct{}
+
+// PasskeyBeginRegistrationResponse holds the options given to
+// navigator.credentials.create, the session is required to finish the
+// registration.
+type PasskeyBeginRegistrationResponse struct {
+	SessionID string
+	Options   []byte
+}
+
+func NewPasskeyBeginRegistrationResponse(session *domain.PasskeySession, options []byte) *PasskeyBeginRegistrationResponse {
+	return &PasskeyBeginRegistrationResponse{SessionID: session.ID, Options: options}
+}
+
+// PasskeyFinishRegistrationRequest has the credential returned by the
+// browser, an empty Name gives the default name.
+type PasskeyFinishRegistrationRequest struct {
+	SessionID             string
+	Name                  string
+	Credential            []byte
+	ReauthenticationToken string
+}
+
+type PasskeyFinishRegistrationResponse struct {
+	Passkey *resource.Passkey
+}
+
+func NewPasskeyFinishRegistrationResponse(passkey *domain.Passkey) *PasskeyFinishRegistrationResponse {
+	return &PasskeyFinishRegistrationResponse{Passkey: resource.NewPasskey(passkey)}
+}
+
+type PasskeyListRequest struct{}
+
+type PasskeyListResponse struct {
+	Passkeys []*resource.Passkey
+}
+
+func NewPasskeyListResponse(passkeys []*domain.Passkey) *PasskeyListResponse {
+	resp := &PasskeyListResponse{Passkeys: []*resource.Passkey{}}
+	for _, passkey := range passkeys {
+		resp.Passkeys = append(resp.Passkeys, resource.NewPasskey(passkey))
+	}
+
+	return resp
+}
+
+type PasskeyRenameRequest struct {
+	PasskeyID snowflake.ID
+	Name      string
+}
+
+type PasskeyRenameResponse struct {
+	Passkey *resource.Passkey
+}
+
+func NewPasskeyRenameResponse(passkey *domain.Passkey) *PasskeyRenameResponse {
+	return &PasskeyRenameResponse{Passkey: resource.NewPasskey(passkey)}
+}
+
+type PasskeyDeleteRequest struct {
+	PasskeyID snowflake.ID
+}
+
+type PasskeyDeleteResponse struct{}
+
+func NewPasskeyDeleteResponse() *PasskeyDeleteResponse {
+	return &PasskeyDeleteResponse{}
+}
+
+// PasskeyBeginAssertionRequest has an empty Username to let the authenticator
+// choose a discoverable credential.
+type PasskeyBeginAssertionRequest struct {
+	Username string
+}
+
+// PasskeyBeginAssertionResponse holds the options given to
+// navigator.credentials.get, the session is required to verify the assertion.
+type PasskeyBeginAssertionResponse struct {
+	SessionID string
+	Options   []byte
+}
+
+func NewPasskeyBeginAssertionResponse(session *domain.PasskeySession, options []byte) *PasskeyBeginAssertionResponse {
+	return &PasskeyBeginAssertionResponse{SessionID: session.ID, Options: options}
+}
+
+type PasskeyVerifyAssertionRequest struct {
+	SessionID  string
+	Credential []byte
+}
+
+// PasskeyVerifyAssertionResponse has a re-authentication token of the user,
+// the assertion proves that the user has just signed in.
+type PasskeyVerifyAssertionResponse struct {
+	User                      *resource.User
+	ReauthenticationToken     string
+	ReauthenticationExpiresAt time.Time
+}
+
+func NewPasskeyVerifyAssertionResponse(
+	user *domain.User,
+	reauthenticationToken string,
+	reauthenticationExpiresAt time.Time,
+) *PasskeyVerifyAssertionResponse {
+	return &PasskeyVerifyAssertionResponse{
+		User:                      resource.NewUser(user, ""),
+		ReauthenticationToken:     reauthenticationToken,
+		ReauthenticationExpiresAt: reauthenticationExpiresAt,
+	}
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+type PasswordResetIssueRequest struct {
+	UserID snowflake.ID
+}
+
+type PasswordResetIssueResponse struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func NewPasswordResetIssueResponse(token string, expiresAt time.Time) *PasswordResetIssueResponse {
+	return &PasswordResetIssueResponse{Token: token, ExpiresAt: expiresAt}
+}
+
+type PasswordResetRequest struct {
+	Token       string
+	NewPassword string
+}
+
+type PasswordResetResponse struct{}
+
+func NewPasswordResetResponse() *PasswordResetResponse {
+	return &PasswordResetResponse{}
+}
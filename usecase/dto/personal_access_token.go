@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+// PersonalAccessTokenCreateRequest has a zero Expiration for the default
+// expiration. Scope is space separated.
+type PersonalAccessTokenCreateRequest struct {
+	Name       string
+	Scope      string
+	Expiration time.Duration
+}
+
+// PersonalAccessTokenCreateResponse holds the token, it is only returned
+// once.
+type PersonalAccessTokenCreateResponse struct {
+	PersonalAccessToken *resource.PersonalAccessToken
+	Token               string
+}
+
+func NewPersonalAccessTokenCreateResponse(
+	pat *domain.PersonalAccessToken,
+	status, token string,
+) *PersonalAccessTokenCreateResponse {
+	return &PersonalAccessTokenCreateResponse{
+		PersonalAccessToken: resource.NewPersonalAccessToken(pat, status),
+		Token:               token,
+	}
+}
+
+type PersonalAccessTokenListRequest struct{}
+
+type PersonalAccessTokenListResponse struct {
+	PersonalAccessTokens []*resource.PersonalAccessToken
+}
+
+// NewPersonalAccessTokenListResponse takes the status of every token, in the
+// same order.
+func NewPersonalAccessTokenListResponse(
+	tokens []*domain.PersonalAccessToken,
+	statuses []string,
+) *PersonalAccessTokenListResponse {
+	resp := &PersonalAccessTokenListResponse{PersonalAccessTokens: []*resource.PersonalAccessToken{}}
+	for i, token := range tokens {
+		resp.PersonalAccessTokens = append(resp.PersonalAccessTokens, resource.NewPersonalAccessToken(token, statuses[i]))
+	}
+
+	return resp
+}
+
+type PersonalAccessTokenRevokeRequest struct {
+	TokenID snowflake.ID
+}
+
+type PersonalAccessTokenRevokeResponse struct {
+	PersonalAccessToken *resource.PersonalAccessToken
+}
+
+func NewPersonalAccessTokenRevokeResponse(
+	token *domain.PersonalAccessToken,
+	status string,
+) *PersonalAccessTokenRevokeResponse {
+	return &PersonalAccessTokenRevokeResponse{PersonalAccessToken: resource.NewPersonalAccessToken(token, status)}
+}
+
+type PersonalAccessTokenIntrospectRequest struct {
+	Token string
+}
+
+// PersonalAccessTokenIntrospectResponse only has Active set if the token is
+// unknown, expired, revoked or if its user cannot sign in. Scope is the scope
+// of the token which is still allowed to its user.
+type PersonalAccessTokenIntrospectResponse struct {
+	Active    bool
+	TokenID   snowflake.ID
+	UserID    snowflake.ID
+	Username  string
+	Scope     string
+	ExpiresAt time.Time
+}
+
+func NewPersonalAccessTokenIntrospectResponse(
+	token *domain.PersonalAccessToken,
+	user *domain.User,
+	scope string,
+) *PersonalAccessTokenIntrospectResponse {
+	if token == nil {
+		return &PersonalAccessTokenIntrospectResponse{Active: false}
+	}
+
+	return &PersonalAccessTokenIntrospectResponse{
+		Active:    true,
+		TokenID:   token.ID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Scope:     scope,
+		ExpiresAt: token.ExpiresAt,
+	}
+}
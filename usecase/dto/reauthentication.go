@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/xybor-x/snowflake"
+)
+
+// ReauthenticateRequest has the password of the user, it is empty when the
+// auth server issues the token.
+type ReauthenticateRequest struct {
+	UserID   snowflake.ID
+	Password string
+}
+
+type ReauthenticateResponse struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func NewReauthenticateResponse(token string, expiresAt time.Time) *ReauthenticateResponse {
+	return &ReauthenticateResponse{Token: token, ExpiresAt: expiresAt}
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+type RegistrationGetPolicyRequest struct{}
+
+type RegistrationGetPolicyResponse struct {
+	Mode                      string
+	EmailRequired             bool
+	EmailVerificationRequired bool
+	InvitationRequired        bool
+	CaptchaRequired           bool
+}
+
+func NewRegistrationGetPolicyResponse(policy domain.RegistrationPolicy, captchaRequired bool) *RegistrationGetPolicyResponse {
+	return &RegistrationGetPolicyResponse{
+		Mode:                      policy.Mode,
+		EmailRequired:             policy.EmailRequired,
+		EmailVerificationRequired: policy.EmailVerificationRequired,
+		InvitationRequired:        policy.InvitationRequired,
+		CaptchaRequired:           captchaRequired,
+	}
+}
+
+// RegistrationRegisterRequest has the ip address of the client, it is used to
+// rate limit the registrations and to verify the captcha.
+type RegistrationRegisterRequest struct {
+	Username        string
+	Password        string
+	Email           string
+	InvitationToken string
+	CaptchaToken    string
+	RemoteIP        string
+}
+
+type RegistrationRegisterResponse struct {
+	User    *resource.User
+	Pending bool
+}
+
+func NewRegistrationRegisterResponse(user *domain.User, avatarURL string) *RegistrationRegisterResponse {
+	return &RegistrationRegisterResponse{
+		User:    resource.NewUser(user, avatarURL),
+		Pending: user.Pending,
+	}
+}
+
+type RegistrationApproveRequest struct {
+	UserID snowflake.ID
+}
+
+type RegistrationApproveResponse struct {
+	UserID  snowflake.ID
+	Pending bool
+}
+
+func NewRegistrationApproveResponse(user *domain.User) *RegistrationApproveResponse {
+	return &RegistrationApproveResponse{
+		UserID:  user.ID,
+		Pending: user.Pending,
+	}
+}
@@ -0,0 +1,40 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type AuditLog struct {
+	ID         snowflake.ID
+	Seq        int64
+	ActorID    snowflake.ID
+	ActorType  string
+	Action     string
+	TargetType string
+	TargetID   string
+	ClientID   string
+	RequestID  string
+	Metadata   map[string]string
+	CreatedAt  time.Time
+	Hash       string
+}
+
+func NewAuditLog(log *domain.AuditLog) *AuditLog {
+	return &AuditLog{
+		ID:         log.ID,
+		Seq:        log.Seq,
+		ActorID:    log.ActorID,
+		ActorType:  log.ActorType,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		ClientID:   log.ClientID,
+		RequestID:  log.RequestID,
+		Metadata:   log.Metadata,
+		CreatedAt:  log.CreatedAt,
+		Hash:       log.Hash,
+	}
+}
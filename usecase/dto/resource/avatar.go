@@ -0,0 +1,22 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type AvatarHistory struct {
+	ID         snowflake.ID
+	AvatarURL  string
+	ReplacedAt time.Time
+}
+
+func NewAvatarHistory(history *domain.AvatarHistory, avatarURL string) *AvatarHistory {
+	return &AvatarHistory{
+		ID:         history.ID,
+		AvatarURL:  avatarURL,
+		ReplacedAt: history.ReplacedAt,
+	}
+}
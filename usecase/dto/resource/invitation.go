@@ -0,0 +1,34 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type Invitation struct {
+	ID         snowflake.ID
+	Role       string
+	Status     string
+	CreatedBy  snowflake.ID
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	RedeemedAt time.Time
+	RedeemedBy snowflake.ID
+	RevokedAt  time.Time
+}
+
+func NewInvitation(invitation *domain.Invitation, status string) *Invitation {
+	return &Invitation{
+		ID:         invitation.ID,
+		Role:       invitation.Role.String(),
+		Status:     status,
+		CreatedBy:  invitation.CreatedBy,
+		CreatedAt:  invitation.CreatedAt,
+		ExpiresAt:  invitation.ExpiresAt,
+		RedeemedAt: invitation.RedeemedAt,
+		RedeemedBy: invitation.RedeemedBy,
+		RevokedAt:  invitation.RevokedAt,
+	}
+}
@@ -0,0 +1,26 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type LinkedIdentity struct {
+	ID       snowflake.ID
+	Provider string
+	Subject  string
+	Email    string
+	LinkedAt time.Time
+}
+
+func NewLinkedIdentity(identity *domain.LinkedIdentity) *LinkedIdentity {
+	return &LinkedIdentity{
+		ID:       identity.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: identity.LinkedAt,
+	}
+}
@@ -0,0 +1,28 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type OutboxMessage struct {
+	ID        snowflake.ID
+	Topic     string
+	Payload   string
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+}
+
+func NewOutboxMessage(msg *domain.OutboxMessage) *OutboxMessage {
+	return &OutboxMessage{
+		ID:        msg.ID,
+		Topic:     msg.Topic,
+		Payload:   string(msg.Payload),
+		Attempts:  msg.Attempts,
+		LastError: msg.LastError,
+		CreatedAt: msg.CreatedAt,
+	}
+}
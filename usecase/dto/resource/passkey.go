@@ -0,0 +1,33 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+// Passkey never holds the public key of the credential.
+type Passkey struct {
+	ID             snowflake.ID
+	Name           string
+	AAGUID         []byte
+	Transports     []string
+	BackupEligible bool
+	BackupState    bool
+	CreatedAt      time.Time
+	LastUsedAt     time.Time
+}
+
+func NewPasskey(passkey *domain.Passkey) *Passkey {
+	return &Passkey{
+		ID:             passkey.ID,
+		Name:           passkey.Name,
+		AAGUID:         passkey.AAGUID,
+		Transports:     passkey.Transports,
+		BackupEligible: passkey.BackupEligible,
+		BackupState:    passkey.BackupState,
+		CreatedAt:      passkey.CreatedAt,
+		LastUsedAt:     passkey.LastUsedAt,
+	}
+}
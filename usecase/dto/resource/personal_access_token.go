@@ -0,0 +1,33 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+// PersonalAccessToken never holds the token itself.
+type PersonalAccessToken struct {
+	ID         snowflake.ID
+	Name       string
+	Scope      string
+	Status     string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+func NewPersonalAccessToken(token *domain.PersonalAccessToken, status string) *PersonalAccessToken {
+	return &PersonalAccessToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scope:      token.Scope,
+		Status:     status,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		RevokedAt:  token.RevokedAt,
+	}
+}
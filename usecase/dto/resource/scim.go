@@ -0,0 +1,31 @@
+package resource
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type ScimUser struct {
+	ID           snowflake.ID
+	UserName     string
+	DisplayName  string
+	Active       bool
+	LastModified time.Time
+
+	// Version changes whenever the user is updated, it is used as the ETag.
+	Version string
+}
+
+func NewScimUser(user *domain.User) *ScimUser {
+	return &ScimUser{
+		ID:           user.ID,
+		UserName:     user.Username,
+		DisplayName:  user.DisplayName,
+		Active:       user.Active,
+		LastModified: user.UpdatedAt,
+		Version:      strconv.FormatInt(user.UpdatedAt.UnixMicro(), 36),
+	}
+}
@@ -52,3 +52,17 @@ func NewUser(user *domain.User, avatarURL string) *User {
 
 	return usecaseUser
 }
+
+type UsernameReason struct {
+	Code    string
+	Message string
+}
+
+func NewUsernameReasons(reasons []domain.UsernameReason) []*UsernameReason {
+	result := []*UsernameReason{}
+	for _, reason := range reasons {
+		result = append(result, &UsernameReason{Code: reason.Code, Message: reason.Message})
+	}
+
+	return result
+}
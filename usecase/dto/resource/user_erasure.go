@@ -0,0 +1,22 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type UserErasure struct {
+	UserID     snowflake.ID
+	EraseAfter time.Time
+	ErasedAt   time.Time
+}
+
+func NewUserErasure(user *domain.User) *UserErasure {
+	return &UserErasure{
+		UserID:     user.ID,
+		EraseAfter: user.EraseAfter,
+		ErasedAt:   user.ErasedAt,
+	}
+}
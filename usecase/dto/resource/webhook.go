@@ -0,0 +1,83 @@
+package resource
+
+import (
+	"time"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/xybor-x/snowflake"
+)
+
+type Webhook struct {
+	ID         snowflake.ID
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedBy  snowflake.ID
+	CreatedAt  time.Time
+}
+
+// NewWebhook hides the secret, it is only shown once when the webhook is
+// created.
+func NewWebhook(webhook *domain.Webhook) *Webhook {
+	return &Webhook{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: webhook.EventTypes,
+		CreatedBy:  webhook.CreatedBy,
+		CreatedAt:  webhook.CreatedAt,
+	}
+}
+
+func NewWebhookWithSecret(webhook *domain.Webhook) *Webhook {
+	resource := NewWebhook(webhook)
+	resource.Secret = webhook.Secret
+	return resource
+}
+
+type WebhookDeliveryLog struct {
+	ID          snowflake.ID
+	DeliveryID  snowflake.ID
+	EventID     snowflake.ID
+	EventType   string
+	Attempt     int
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+	AttemptedAt time.Time
+}
+
+func NewWebhookDeliveryLog(log *domain.WebhookDeliveryLog) *WebhookDeliveryLog {
+	return &WebhookDeliveryLog{
+		ID:          log.ID,
+		DeliveryID:  log.DeliveryID,
+		EventID:     log.EventID,
+		EventType:   log.EventType,
+		Attempt:     log.Attempt,
+		StatusCode:  log.StatusCode,
+		Error:       log.Error,
+		Duration:    log.Duration,
+		AttemptedAt: log.AttemptedAt,
+	}
+}
+
+type WebhookDeadLetter struct {
+	ID        snowflake.ID
+	WebhookID snowflake.ID
+	EventID   snowflake.ID
+	EventType string
+	Attempts  int
+	LastError string
+	DeadAt    time.Time
+}
+
+func NewWebhookDeadLetter(deadLetter *domain.WebhookDeadLetter) *WebhookDeadLetter {
+	return &WebhookDeadLetter{
+		ID:        deadLetter.ID,
+		WebhookID: deadLetter.WebhookID,
+		EventID:   deadLetter.EventID,
+		EventType: deadLetter.EventType,
+		Attempts:  deadLetter.Attempts,
+		LastError: deadLetter.LastError,
+		DeadAt:    deadLetter.DeadAt,
+	}
+}
@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"errors"
+
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+// The SCIM specific errors, they are returned as the scimType of the SCIM
+// error response.
+var (
+	ErrScimFilterInvalid     = errors.New("invalidFilter")
+	ErrScimPathInvalid       = errors.New("invalidPath")
+	ErrScimValueInvalid      = errors.New("invalidValue")
+	ErrScimMutability        = errors.New("mutability")
+	ErrScimVersionMismatched = errors.New("invalidVers")
+)
+
+type ScimCreateUserRequest struct {
+	UserName    string
+	DisplayName string
+	Password    string
+	Active      *bool
+}
+
+type ScimCreateUserResponse struct {
+	User *resource.ScimUser
+}
+
+func NewScimCreateUserResponse(user *domain.User) *ScimCreateUserResponse {
+	return &ScimCreateUserResponse{User: resource.NewScimUser(user)}
+}
+
+type ScimGetUserRequest struct {
+	UserID snowflake.ID
+}
+
+type ScimGetUserResponse struct {
+	User *resource.ScimUser
+}
+
+func NewScimGetUserResponse(user *domain.User) *ScimGetUserResponse {
+	return &ScimGetUserResponse{User: resource.NewScimUser(user)}
+}
+
+// ScimListUsersRequest has a 1-based StartIndex.
+type ScimListUsersRequest struct {
+	Filter     string
+	StartIndex int
+	Count      int
+}
+
+type ScimListUsersResponse struct {
+	TotalResults int64
+	StartIndex   int
+	Users        []*resource.ScimUser
+}
+
+func NewScimListUsersResponse(users []*domain.User, total int64, startIndex int) *ScimListUsersResponse {
+	resp := &ScimListUsersResponse{
+		TotalResults: total,
+		StartIndex:   startIndex,
+		Users:        []*resource.ScimUser{},
+	}
+
+	for i := range users {
+		resp.Users = append(resp.Users, resource.NewScimUser(users[i]))
+	}
+
+	return resp
+}
+
+// ScimReplaceUserRequest replaces the whole user, an empty Version skips the
+// version check.
+type ScimReplaceUserRequest struct {
+	UserID      snowflake.ID
+	Version     string
+	UserName    string
+	DisplayName string
+	Password    string
+	Active      *bool
+}
+
+type ScimReplaceUserResponse struct {
+	User *resource.ScimUser
+}
+
+func NewScimReplaceUserResponse(user *domain.User) *ScimReplaceUserResponse {
+	return &ScimReplaceUserResponse{User: resource.NewScimUser(user)}
+}
+
+// ScimPatchUserRequest applies the operations to the user, an empty Version
+// skips the version check.
+type ScimPatchUserRequest struct {
+	UserID     snowflake.ID
+	Version    string
+	Operations []domain.ScimPatchOperation
+}
+
+type ScimPatchUserResponse struct {
+	User *resource.ScimUser
+}
+
+func NewScimPatchUserResponse(user *domain.User) *ScimPatchUserResponse {
+	return &ScimPatchUserResponse{User: resource.NewScimUser(user)}
+}
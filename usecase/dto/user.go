@@ -2,6 +2,8 @@ package dto
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/usecase/dto/resource"
@@ -17,9 +19,9 @@ type UserRegisterResponse struct {
 	User *resource.User
 }
 
-func NewUserRegisterResponse(user *domain.User) *UserRegisterResponse {
+func NewUserRegisterResponse(user *domain.User, avatarURL string) *UserRegisterResponse {
 	return &UserRegisterResponse{
-		User: resource.NewUser(user, ""),
+		User: resource.NewUser(user, avatarURL),
 	}
 }
 
@@ -32,9 +34,9 @@ type UserRegisterFirstResponse struct {
 	User *resource.User
 }
 
-func NewUserRegisterFirstResponse(user *domain.User) *UserRegisterFirstResponse {
+func NewUserRegisterFirstResponse(user *domain.User, avatarURL string) *UserRegisterFirstResponse {
 	return &UserRegisterFirstResponse{
-		User: resource.NewUser(user, ""),
+		User: resource.NewUser(user, avatarURL),
 	}
 }
 
@@ -66,17 +68,99 @@ func NewUserGetByUsernameResponse(ctx context.Context, user *domain.User, avatar
 	}
 }
 
+const (
+	DefaultUserSearchLimit = 20
+	MaximumUserSearchLimit = 50
+)
+
+// UserSearchRequest has a zero Limit for the default limit.
+type UserSearchRequest struct {
+	Query string
+	Limit int
+}
+
+type UserSearchResponse struct {
+	Users []*resource.User
+}
+
+// NewUserSearchResponse takes the avatar url of every user, in the same order.
+func NewUserSearchResponse(ctx context.Context, users []*domain.User, avatarURLs []string) *UserSearchResponse {
+	resp := &UserSearchResponse{Users: []*resource.User{}}
+	for i, user := range users {
+		resp.Users = append(resp.Users, resource.NewUserWithFilter(ctx, user, avatarURLs[i]))
+	}
+
+	return resp
+}
+
+// UsernameSuggestionCount is the number of alternatives suggested for an
+// unavailable username.
+const UsernameSuggestionCount = 3
+
+// ErrTooManyRequests is returned when a client exceeds a rate limit.
+var ErrTooManyRequests = errors.New("too_many_requests")
+
+type UserCheckUsernameRequest struct {
+	Username string
+}
+
+type UserCheckUsernameResponse struct {
+	Username    string
+	Available   bool
+	Reasons     []*resource.UsernameReason
+	Suggestions []string
+}
+
+func NewUserCheckUsernameResponse(
+	username string,
+	reasons []domain.UsernameReason,
+	suggestions []string,
+) *UserCheckUsernameResponse {
+	return &UserCheckUsernameResponse{
+		Username:    username,
+		Available:   len(reasons) == 0,
+		Reasons:     resource.NewUsernameReasons(reasons),
+		Suggestions: suggestions,
+	}
+}
+
 type UserValidateCredentialsRequest struct {
 	Username string
 	Password string
 }
 
+// UserValidateCredentialsResponse tells the status of the password, the user
+// must change an expired password before signing in.
 type UserValidateCredentialsResponse struct {
-	User *resource.User
+	User              *resource.User
+	PasswordStatus    string
+	PasswordExpiresAt time.Time
 }
 
-func NewUserValidateCredentialsResponse(user *domain.User) *UserValidateCredentialsResponse {
+func NewUserValidateCredentialsResponse(
+	user *domain.User,
+	passwordStatus string,
+	passwordExpiresAt time.Time,
+) *UserValidateCredentialsResponse {
 	return &UserValidateCredentialsResponse{
-		User: resource.NewUser(user, ""),
+		User:              resource.NewUser(user, ""),
+		PasswordStatus:    passwordStatus,
+		PasswordExpiresAt: passwordExpiresAt,
 	}
 }
+
+// UserChangePasswordRequest always requires the current password, even when
+// it is expired.
+type UserChangePasswordRequest struct {
+	UserID          snowflake.ID
+	CurrentPassword string
+	NewPassword     string
+}
+
+type UserChangePasswordResponse struct {
+	PasswordExpiresAt time.Time
+}
+
+func NewUserChangePasswordResponse(passwordExpiresAt time.Time) *UserChangePasswordResponse {
+	return &UserChangePasswordResponse{PasswordExpiresAt: passwordExpiresAt}
+}
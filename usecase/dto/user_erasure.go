@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+// UserErasureAuditLogBatchSize is the number of audit logs scrubbed at once
+// while erasing a user.
+const UserErasureAuditLogBatchSize = 1000
+
+// UserErasureScheduleRequest requires a re-authentication token of the user
+// when users schedule their own erasure.
+type UserErasureScheduleRequest struct {
+	UserID                snowflake.ID
+	ReauthenticationToken string
+}
+
+type UserErasureScheduleResponse struct {
+	Erasure *resource.UserErasure
+}
+
+func NewUserErasureScheduleResponse(user *domain.User) *UserErasureScheduleResponse {
+	return &UserErasureScheduleResponse{Erasure: resource.NewUserErasure(user)}
+}
+
+type UserErasureCancelRequest struct {
+	UserID snowflake.ID
+}
+
+type UserErasureCancelResponse struct {
+	Erasure *resource.UserErasure
+}
+
+func NewUserErasureCancelResponse(user *domain.User) *UserErasureCancelResponse {
+	return &UserErasureCancelResponse{Erasure: resource.NewUserErasure(user)}
+}
+
+type UserErasureProcessRequest struct {
+	BatchSize int
+}
+
+type UserErasureProcessResponse struct {
+	Erased int
+	Failed int
+}
@@ -0,0 +1,22 @@
+package dto
+
+import "io"
+
+const (
+	DefaultUserExportBatchSize = 500
+	MaximumUserExportBatchSize = 5000
+)
+
+// UserExportRequest writes the users to Writer, the hashes and the avatars are
+// only written if requested.
+type UserExportRequest struct {
+	Format         string
+	Writer         io.Writer
+	IncludeHashes  bool
+	IncludeAvatars bool
+	BatchSize      int
+}
+
+type UserExportResponse struct {
+	Exported int
+}
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"io"
+
+	"github.com/xybor-x/snowflake"
+)
+
+const (
+	DefaultUserImportBatchSize = 100
+	MaximumUserImportBatchSize = 1000
+)
+
+// The status of an imported row. A row is valid when it would be created but
+// the import is a dry run.
+const (
+	UserImportStatusCreated = "created"
+	UserImportStatusValid   = "valid"
+	UserImportStatusFailed  = "failed"
+)
+
+type UserImportRequest struct {
+	Format    string
+	Reader    io.Reader
+	DryRun    bool
+	BatchSize int
+}
+
+// UserImportResult is the result of a row, UserID is only set for a created
+// user and Error only for a failed row.
+type UserImportResult struct {
+	Line     int
+	Username string
+	Status   string
+	UserID   snowflake.ID
+	Error    string
+}
+
+type UserImportResponse struct {
+	DryRun  bool
+	Created int
+	Valid   int
+	Failed  int
+	Results []*UserImportResult
+}
+
+func NewUserImportResponse(dryRun bool, results []*UserImportResult) *UserImportResponse {
+	resp := &UserImportResponse{DryRun: dryRun, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case UserImportStatusCreated:
+			resp.Created++
+		case UserImportStatusValid:
+			resp.Valid++
+		case UserImportStatusFailed:
+			resp.Failed++
+		}
+	}
+
+	return resp
+}
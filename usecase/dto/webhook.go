@@ -0,0 +1,111 @@
+package dto
+
+import (
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/xybor-x/snowflake"
+)
+
+type WebhookCreateRequest struct {
+	URL        string
+	EventTypes []string
+}
+
+type WebhookCreateResponse struct {
+	Webhook *resource.Webhook
+}
+
+func NewWebhookCreateResponse(webhook *domain.Webhook) *WebhookCreateResponse {
+	return &WebhookCreateResponse{Webhook: resource.NewWebhookWithSecret(webhook)}
+}
+
+type WebhookListRequest struct {
+}
+
+type WebhookListResponse struct {
+	Webhooks []*resource.Webhook
+}
+
+func NewWebhookListResponse(webhooks []*domain.Webhook) *WebhookListResponse {
+	resp := &WebhookListResponse{Webhooks: []*resource.Webhook{}}
+	for i := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, resource.NewWebhook(webhooks[i]))
+	}
+
+	return resp
+}
+
+type WebhookDeleteRequest struct {
+	WebhookID snowflake.ID
+}
+
+type WebhookDeleteResponse struct {
+}
+
+func NewWebhookDeleteResponse() *WebhookDeleteResponse {
+	return &WebhookDeleteResponse{}
+}
+
+type WebhookListDeliveryLogsRequest struct {
+	WebhookID snowflake.ID
+	Limit     int
+}
+
+type WebhookListDeliveryLogsResponse struct {
+	Logs []*resource.WebhookDeliveryLog
+}
+
+func NewWebhookListDeliveryLogsResponse(logs []*domain.WebhookDeliveryLog) *WebhookListDeliveryLogsResponse {
+	resp := &WebhookListDeliveryLogsResponse{Logs: []*resource.WebhookDeliveryLog{}}
+	for i := range logs {
+		resp.Logs = append(resp.Logs, resource.NewWebhookDeliveryLog(logs[i]))
+	}
+
+	return resp
+}
+
+type WebhookListDeadLettersRequest struct {
+	WebhookID snowflake.ID
+}
+
+type WebhookListDeadLettersResponse struct {
+	DeadLetters []*resource.WebhookDeadLetter
+}
+
+func NewWebhookListDeadLettersResponse(deadLetters []*domain.WebhookDeadLetter) *WebhookListDeadLettersResponse {
+	resp := &WebhookListDeadLettersResponse{DeadLetters: []*resource.WebhookDeadLetter{}}
+	for i := range deadLetters {
+		resp.DeadLetters = append(resp.DeadLetters, resource.NewWebhookDeadLetter(deadLetters[i]))
+	}
+
+	return resp
+}
+
+type WebhookDispatchRequest struct {
+	BatchSize int
+}
+
+type WebhookDispatchResponse struct {
+	Delivered int
+	Failed    int
+	Dead      int
+}
+
+func NewWebhookDispatchResponse() *WebhookDispatchResponse {
+	return &WebhookDispatchResponse{}
+}
+
+// WebhookReplayRequest replays a single dead letter if DeadLetterID is set,
+// the dead letters of a webhook if WebhookID is set, or every dead letter.
+type WebhookReplayRequest struct {
+	WebhookID    snowflake.ID
+	DeadLetterID snowflake.ID
+}
+
+type WebhookReplayResponse struct {
+	Replayed int
+}
+
+func NewWebhookReplayResponse(replayed int) *WebhookReplayResponse {
+	return &WebhookReplayResponse{Replayed: replayed}
+}
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// EmailVerificationUsecase lets users prove that they receive the emails sent
+// to their email, with a link holding a short-lived signed token. The requests
+// are not authenticated, so the pending users can verify their email before
+// they are allowed to sign in.
+type EmailVerificationUsecase struct {
+	tokenEngine     token.Engine
+	rateLimit       int
+	rateLimitWindow time.Duration
+
+	emailVerificationDomain abstraction.EmailVerificationDomain
+	userDomain              abstraction.UserDomain
+	auditDomain             abstraction.AuditDomain
+
+	userRepo      abstraction.UserRepository
+	auditRepo     abstraction.AuditLogRepository
+	rateLimitRepo abstraction.RateLimitRepository
+
+	// mailSender is nil if no email can be sent.
+	mailSender abstraction.MailSender
+}
+
+func NewEmailVerificationUsecase(
+	tokenEngine token.Engine,
+	rateLimit int,
+	rateLimitWindow time.Duration,
+	emailVerificationDomain abstraction.EmailVerificationDomain,
+	userDomain abstraction.UserDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+	rateLimitRepo abstraction.RateLimitRepository,
+	mailSender abstraction.MailSender,
+) *EmailVerificationUsecase {
+	return &EmailVerificationUsecase{
+		tokenEngine:             tokenEngine,
+		rateLimit:               rateLimit,
+		rateLimitWindow:         rateLimitWindow,
+		emailVerificationDomain: emailVerificationDomain,
+		userDomain:              userDomain,
+		auditDomain:             auditDomain,
+		userRepo:                userRepo,
+		auditRepo:               auditRepo,
+		rateLimitRepo:           rateLimitRepo,
+		mailSender:              mailSender,
+	}
+}
+
+// Send sends a verification link to the email if it is the unverified email of
+// a user. The response is the same whether a link is sent or not.
+func (usecase *EmailVerificationUsecase) Send(
+	ctx context.Context,
+	req *dto.EmailVerificationSendRequest,
+) (*dto.EmailVerificationSendResponse, error) {
+	if usecase.mailSender == nil {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "email verification is disabled")
+	}
+
+	email, err := usecase.userDomain.NormalizeEmail(req.Email)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-normalize-email").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	key := fmt.Sprintf("email-verification:%s", email)
+	allowed, retryAfter, err := usecase.rateLimitRepo.Allow(ctx, key, usecase.rateLimit, usecase.rateLimitWindow)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-rate-limit", "key", key)
+	}
+
+	if !allowed {
+		return nil, xerror.Enrich(dto.ErrTooManyRequests, "too many requests, retry after %d seconds",
+			int(math.Ceil(retryAfter.Seconds())))
+	}
+
+	user, err := usecase.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return dto.NewEmailVerificationSendResponse(), nil
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user-by-email")
+	}
+
+	if user.EmailVerified || usecase.userDomain.IsErased(user) {
+		return dto.NewEmailVerificationSendResponse(), nil
+	}
+
+	claims, err := sendEmailVerification(ctx, usecase.tokenEngine, usecase.emailVerificationDomain,
+		usecase.mailSender, user)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-send-email-verification", "uid", user.ID)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserEmailVerificationSend,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"token_id":   claims.ID,
+			"expires_at": time.Unix(int64(claims.ExpiresAt), 0).UTC().Format(time.RFC3339),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewEmailVerificationSendResponse(), nil
+}
+
+// Verify marks the email of the token as verified. The token is rejected if
+// the email of the user has changed since it was sent, verifying an email
+// which is already verified returns it unchanged.
+func (usecase *EmailVerificationUsecase) Verify(
+	ctx context.Context,
+	req *dto.EmailVerificationVerifyRequest,
+) (*dto.EmailVerificationVerifyResponse, error) {
+	if req.Token == "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require token")
+	}
+
+	claims := &domain.EmailVerificationToken{}
+	if err := usecase.tokenEngine.Validate(ctx, req.Token, claims); err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid or expired token").
+			Hide(err, "failed-to-validate-email-verification-token")
+	}
+
+	userID, err := snowflake.ParseString(claims.UserID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid or expired token")
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid or expired token")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	if err := usecase.emailVerificationDomain.CheckToken(user, claims); err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid or expired token").
+			Hide(err, "failed-to-check-email-verification-token", "uid", user.ID)
+	}
+
+	version := user.UpdatedAt
+	if !usecase.userDomain.VerifyEmail(user) {
+		return dto.NewEmailVerificationVerifyResponse(user), nil
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Update(ctx, user, version); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the user has been changed, please retry")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-user", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserEmailVerify,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"token_id": claims.ID})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewEmailVerificationVerifyResponse(user), nil
+}
+
+// sendEmailVerification sends the verification link of the email of the user
+// and returns the claims of its token.
+func sendEmailVerification(
+	ctx context.Context,
+	tokenEngine token.Engine,
+	emailVerificationDomain abstraction.EmailVerificationDomain,
+	mailSender abstraction.MailSender,
+	user *domain.User,
+) (*domain.EmailVerificationToken, error) {
+	claims := emailVerificationDomain.NewToken(user)
+	signedToken, err := tokenEngine.Generate(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	mail, err := emailVerificationDomain.NewMail(user, signedToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mailSender.Send(ctx, mail); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
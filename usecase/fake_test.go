@@ -0,0 +1,901 @@
+package usecase_test
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/x/scope"
+	"github.com/todennus/x/token"
+	"github.com/xybor-x/snowflake"
+)
+
+var errFake = errors.New("fake error")
+
+func newSnowflakeNode() *snowflake.Node {
+	node, err := snowflake.NewNode(1)
+	if err != nil {
+		panic(err)
+	}
+
+	return node
+}
+
+// newRequestContext returns the context of an anonymous request.
+func newRequestContext() context.Context {
+	return xcontext.WithRequestID(context.Background(), "request-id")
+}
+
+// newUserContext returns the context of a request authenticated as the user
+// with the scopes.
+func newUserContext(userID snowflake.ID, scopes ...scope.Scoper) context.Context {
+	ctx := xcontext.WithRequestSubjectID(newRequestContext(), userID)
+	ctx = xcontext.WithRequestSubjectType(ctx, enumdef.SubjectUser)
+	return xcontext.WithScope(ctx, scope.NewScopes(scopes...))
+}
+
+type fakeOutboxRepository struct {
+	mu   sync.Mutex
+	msgs map[snowflake.ID]*domain.OutboxMessage
+
+	// failUpdate makes Update fail for the message with this id.
+	failUpdate snowflake.ID
+
+	// failCreate makes Create fail for every message.
+	failCreate bool
+}
+
+func newFakeOutboxRepository() *fakeOutboxRepository {
+	return &fakeOutboxRepository{msgs: map[snowflake.ID]*domain.OutboxMessage{}}
+}
+
+func (repo *fakeOutboxRepository) Create(ctx context.Context, msg *domain.OutboxMessage) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.failCreate {
+		return errFake
+	}
+
+	clone := *msg
+	repo.msgs[msg.ID] = &clone
+	return nil
+}
+
+func (repo *fakeOutboxRepository) ClaimPending(
+	ctx context.Context,
+	topics []string,
+	maxAttempts int,
+	limit int,
+) ([]*domain.OutboxMessage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	msgs := []*domain.OutboxMessage{}
+	for _, msg := range repo.sorted() {
+		if len(msgs) >= limit {
+			break
+		}
+
+		if slices.Contains(topics, msg.Topic) && msg.DeliveredAt.IsZero() &&
+			msg.Attempts < maxAttempts && !msg.NextAttemptAt.After(time.Now()) {
+			clone := *msg
+			msgs = append(msgs, &clone)
+		}
+	}
+
+	return msgs, nil
+}
+
+func (repo *fakeOutboxRepository) GetDead(ctx context.Context, topic string, maxAttempts int) ([]*domain.OutboxMessage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	msgs := []*domain.OutboxMessage{}
+	for _, msg := range repo.sorted() {
+		if msg.Topic == topic && msg.DeliveredAt.IsZero() && msg.Attempts >= maxAttempts {
+			clone := *msg
+			msgs = append(msgs, &clone)
+		}
+	}
+
+	return msgs, nil
+}
+
+func (repo *fakeOutboxRepository) Update(ctx context.Context, msg *domain.OutboxMessage) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if msg.ID == repo.failUpdate && !msg.DeliveredAt.IsZero() {
+		return errFake
+	}
+
+	if _, ok := repo.msgs[msg.ID]; !ok {
+		return errordef.ErrNotFound
+	}
+
+	clone := *msg
+	repo.msgs[msg.ID] = &clone
+	return nil
+}
+
+func (repo *fakeOutboxRepository) get(id snowflake.ID) *domain.OutboxMessage {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	clone := *repo.msgs[id]
+	return &clone
+}
+
+func (repo *fakeOutboxRepository) sorted() []*domain.OutboxMessage {
+	msgs := []*domain.OutboxMessage{}
+	for _, msg := range repo.msgs {
+		msgs = append(msgs, msg)
+	}
+
+	slices.SortFunc(msgs, func(a, b *domain.OutboxMessage) int { return int(a.ID.Int64() - b.ID.Int64()) })
+	return msgs
+}
+
+type refcountCall struct {
+	inc, dec []snowflake.ID
+}
+
+type fakeFileRepository struct {
+	mu    sync.Mutex
+	calls []refcountCall
+	err   error
+}
+
+func (repo *fakeFileRepository) RegisterUpload(ctx context.Context, policy *domain.AvatarPolicy) (string, error) {
+	return "upload-token", nil
+}
+
+func (repo *fakeFileRepository) CreatePresignedURL(ctx context.Context, ownershipID snowflake.ID, expiration time.Duration) (string, error) {
+	return "https://file.example/" + ownershipID.String(), nil
+}
+
+func (repo *fakeFileRepository) ChangeRefcount(ctx context.Context, inc, dec []snowflake.ID) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.calls = append(repo.calls, refcountCall{inc: inc, dec: dec})
+	return repo.err
+}
+
+type fakeEventRepository struct {
+	mu     sync.Mutex
+	events []*domain.Event
+}
+
+func (repo *fakeEventRepository) Publish(ctx context.Context, event *domain.Event) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.events = append(repo.events, event)
+	return nil
+}
+
+type fakeWebhookRepository struct {
+	webhooks []*domain.Webhook
+}
+
+func (repo *fakeWebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	repo.webhooks = append(repo.webhooks, webhook)
+	return nil
+}
+
+func (repo *fakeWebhookRepository) GetByID(ctx context.Context, webhookID snowflake.ID) (*domain.Webhook, error) {
+	for _, webhook := range repo.webhooks {
+		if webhook.ID == webhookID {
+			return webhook, nil
+		}
+	}
+
+	return nil, errordef.ErrNotFound
+}
+
+func (repo *fakeWebhookRepository) GetAll(ctx context.Context) ([]*domain.Webhook, error) {
+	return repo.webhooks, nil
+}
+
+func (repo *fakeWebhookRepository) Delete(ctx context.Context, webhookID snowflake.ID) error {
+	return nil
+}
+
+type fakeWebhookDeliveryRepository struct {
+	deliveries []*domain.WebhookDelivery
+	logs       []*domain.WebhookDeliveryLog
+	err        error
+
+	// failUpdate makes Update fail for the delivered delivery with this id.
+	failUpdate snowflake.ID
+}
+
+func (repo *fakeWebhookDeliveryRepository) Create(ctx context.Context, deliveries ...*domain.WebhookDelivery) error {
+	if repo.err != nil {
+		return repo.err
+	}
+
+	repo.deliveries = append(repo.deliveries, deliveries...)
+	return nil
+}
+
+func (repo *fakeWebhookDeliveryRepository) ClaimPending(ctx context.Context, limit int) ([]*domain.WebhookDelivery, error) {
+	deliveries := []*domain.WebhookDelivery{}
+	for _, delivery := range repo.deliveries {
+		if len(deliveries) >= limit {
+			break
+		}
+
+		if delivery.DeliveredAt.IsZero() && !delivery.NextAttemptAt.After(time.Now()) {
+			clone := *delivery
+			deliveries = append(deliveries, &clone)
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (repo *fakeWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	if delivery.ID == repo.failUpdate && !delivery.DeliveredAt.IsZero() {
+		return errFake
+	}
+
+	for i := range repo.deliveries {
+		if repo.deliveries[i].ID == delivery.ID {
+			clone := *delivery
+			repo.deliveries[i] = &clone
+			return nil
+		}
+	}
+
+	return errordef.ErrNotFound
+}
+
+func (repo *fakeWebhookDeliveryRepository) Delete(ctx context.Context, deliveryID snowflake.ID) error {
+	repo.deliveries = slices.DeleteFunc(repo.deliveries, func(delivery *domain.WebhookDelivery) bool {
+		return delivery.ID == deliveryID
+	})
+	return nil
+}
+
+func (repo *fakeWebhookDeliveryRepository) CreateLog(ctx context.Context, log *domain.WebhookDeliveryLog) error {
+	repo.logs = append(repo.logs, log)
+	return nil
+}
+
+func (repo *fakeWebhookDeliveryRepository) GetLogsByWebhookID(
+	ctx context.Context,
+	webhookID snowflake.ID,
+	limit int,
+) ([]*domain.WebhookDeliveryLog, error) {
+	return nil, nil
+}
+
+func (repo *fakeWebhookDeliveryRepository) get(deliveryID snowflake.ID) *domain.WebhookDelivery {
+	for _, delivery := range repo.deliveries {
+		if delivery.ID == deliveryID {
+			clone := *delivery
+			return &clone
+		}
+	}
+
+	return nil
+}
+
+type fakeWebhookDeadLetterRepository struct {
+	deadLetters []*domain.WebhookDeadLetter
+}
+
+func (repo *fakeWebhookDeadLetterRepository) Create(ctx context.Context, deadLetter *domain.WebhookDeadLetter) error {
+	repo.deadLetters = append(repo.deadLetters, deadLetter)
+	return nil
+}
+
+func (repo *fakeWebhookDeadLetterRepository) GetByID(
+	ctx context.Context,
+	deadLetterID snowflake.ID,
+) (*domain.WebhookDeadLetter, error) {
+	for _, deadLetter := range repo.deadLetters {
+		if deadLetter.ID == deadLetterID {
+			return deadLetter, nil
+		}
+	}
+
+	return nil, errordef.ErrNotFound
+}
+
+func (repo *fakeWebhookDeadLetterRepository) GetByWebhookID(
+	ctx context.Context,
+	webhookID snowflake.ID,
+) ([]*domain.WebhookDeadLetter, error) {
+	deadLetters := []*domain.WebhookDeadLetter{}
+	for _, deadLetter := range repo.deadLetters {
+		if deadLetter.WebhookID == webhookID {
+			deadLetters = append(deadLetters, deadLetter)
+		}
+	}
+
+	return deadLetters, nil
+}
+
+func (repo *fakeWebhookDeadLetterRepository) GetAll(ctx context.Context) ([]*domain.WebhookDeadLetter, error) {
+	return repo.deadLetters, nil
+}
+
+func (repo *fakeWebhookDeadLetterRepository) Delete(ctx context.Context, deadLetterID snowflake.ID) error {
+	repo.deadLetters = slices.DeleteFunc(repo.deadLetters, func(deadLetter *domain.WebhookDeadLetter) bool {
+		return deadLetter.ID == deadLetterID
+	})
+	return nil
+}
+
+// fakeWebhookSender records the sent deliveries, it fails with err if it is
+// set.
+type fakeWebhookSender struct {
+	sent []snowflake.ID
+	err  error
+}
+
+func (sender *fakeWebhookSender) Send(
+	ctx context.Context,
+	webhook *domain.Webhook,
+	delivery *domain.WebhookDelivery,
+	signature string,
+) (int, error) {
+	sender.sent = append(sender.sent, delivery.ID)
+	if sender.err != nil {
+		return 500, sender.err
+	}
+
+	return 200, nil
+}
+
+// fakeUserRepository keeps copies of the users, like a database. It only
+// implements the methods used by the tests, the others panic.
+type fakeUserRepository struct {
+	abstraction.UserRepository
+
+	users map[snowflake.ID]domain.User
+}
+
+func newFakeUserRepository(users ...*domain.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: map[snowflake.ID]domain.User{}}
+	for _, user := range users {
+		repo.users[user.ID] = *user
+	}
+
+	return repo
+}
+
+func (repo *fakeUserRepository) Create(ctx context.Context, user *domain.User) error {
+	for _, existing := range repo.users {
+		if existing.Username == user.Username {
+			return errordef.ErrDuplicated
+		}
+	}
+
+	repo.users[user.ID] = *user
+	return nil
+}
+
+func (repo *fakeUserRepository) GetByID(ctx context.Context, userID snowflake.ID) (*domain.User, error) {
+	user, ok := repo.users[userID]
+	if !ok {
+		return nil, errordef.ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (repo *fakeUserRepository) GetAvatarByID(ctx context.Context, userID snowflake.ID) (snowflake.ID, error) {
+	user, ok := repo.users[userID]
+	if !ok {
+		return 0, errordef.ErrNotFound
+	}
+
+	return user.Avatar, nil
+}
+
+func (repo *fakeUserRepository) UpdateAvatarByID(ctx context.Context, userID, ownershipID snowflake.ID) error {
+	user, ok := repo.users[userID]
+	if !ok {
+		return errordef.ErrNotFound
+	}
+
+	user.Avatar = ownershipID
+	repo.users[userID] = user
+	return nil
+}
+
+func (repo *fakeUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	for _, user := range repo.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+
+	return nil, errordef.ErrNotFound
+}
+
+func (repo *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range repo.users {
+		if user.Email != "" && domain.CanonicalEmail(user.Email) == domain.CanonicalEmail(email) {
+			return &user, nil
+		}
+	}
+
+	return nil, errordef.ErrNotFound
+}
+
+func (repo *fakeUserRepository) FindExistingCanonicalUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	existing := []string{}
+	for _, user := range repo.users {
+		canonical := domain.CanonicalUsername(user.Username)
+		if slices.ContainsFunc(usernames, func(username string) bool {
+			return domain.CanonicalUsername(username) == canonical
+		}) {
+			existing = append(existing, canonical)
+		}
+	}
+
+	return existing, nil
+}
+
+func (repo *fakeUserRepository) GetLDAPSourced(ctx context.Context) ([]*domain.User, error) {
+	users := []*domain.User{}
+	for _, user := range repo.users {
+		if user.LDAPID != "" && user.ErasedAt.IsZero() {
+			users = append(users, &user)
+		}
+	}
+
+	slices.SortFunc(users, func(a, b *domain.User) int { return cmp.Compare(a.ID, b.ID) })
+	return users, nil
+}
+
+func (repo *fakeUserRepository) Update(ctx context.Context, user *domain.User, version time.Time) error {
+	existing, ok := repo.users[user.ID]
+	if !ok || !existing.UpdatedAt.Equal(version) {
+		return abstraction.ErrStaleVersion
+	}
+
+	repo.users[user.ID] = *user
+	return nil
+}
+
+type fakeAvatarPolicyRepository struct {
+	policies map[string]domain.AvatarPolicy
+}
+
+func newFakeAvatarPolicyRepository() *fakeAvatarPolicyRepository {
+	return &fakeAvatarPolicyRepository{policies: map[string]domain.AvatarPolicy{}}
+}
+
+func (repo *fakeAvatarPolicyRepository) Save(
+	ctx context.Context,
+	uploadToken string,
+	policy *domain.AvatarPolicy,
+	expiration time.Duration,
+) error {
+	repo.policies[uploadToken] = *policy
+	return nil
+}
+
+func (repo *fakeAvatarPolicyRepository) Get(ctx context.Context, uploadToken string) (*domain.AvatarPolicy, error) {
+	policy, ok := repo.policies[uploadToken]
+	if !ok {
+		return nil, errordef.ErrNotFound
+	}
+
+	return &policy, nil
+}
+
+func (repo *fakeAvatarPolicyRepository) Delete(ctx context.Context, uploadToken string) error {
+	delete(repo.policies, uploadToken)
+	return nil
+}
+
+// fakeTokenEngine keeps the claims of the generated tokens instead of signing
+// them.
+type fakeTokenEngine struct {
+	claims map[string][]byte
+}
+
+func newFakeTokenEngine() *fakeTokenEngine {
+	return &fakeTokenEngine{claims: map[string][]byte{}}
+}
+
+func (engine *fakeTokenEngine) Type() string {
+	return "fake"
+}
+
+func (engine *fakeTokenEngine) Generate(ctx context.Context, claims token.Claims) (string, error) {
+	content, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	tok := fmt.Sprintf("token-%d", len(engine.claims))
+	engine.claims[tok] = content
+	return tok, nil
+}
+
+func (engine *fakeTokenEngine) Validate(ctx context.Context, tok string, claims token.Claims) error {
+	content, ok := engine.claims[tok]
+	if !ok {
+		return errFake
+	}
+
+	return json.Unmarshal(content, claims)
+}
+
+type fakePasswordHistoryRepository struct {
+	histories []*domain.PasswordHistory
+}
+
+func (repo *fakePasswordHistoryRepository) Create(ctx context.Context, history *domain.PasswordHistory) error {
+	repo.histories = append(repo.histories, history)
+	return nil
+}
+
+func (repo *fakePasswordHistoryRepository) GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.PasswordHistory, error) {
+	histories := []*domain.PasswordHistory{}
+	for _, history := range repo.histories {
+		if history.UserID == userID {
+			histories = append(histories, history)
+		}
+	}
+
+	// The newest first, like the database.
+	slices.SortFunc(histories, func(a, b *domain.PasswordHistory) int { return cmp.Compare(b.ID, a.ID) })
+	return histories, nil
+}
+
+func (repo *fakePasswordHistoryRepository) Delete(ctx context.Context, historyIDs ...snowflake.ID) error {
+	repo.histories = slices.DeleteFunc(repo.histories, func(history *domain.PasswordHistory) bool {
+		return slices.Contains(historyIDs, history.ID)
+	})
+
+	return nil
+}
+
+func (repo *fakePasswordHistoryRepository) DeleteByUserID(ctx context.Context, userID snowflake.ID) error {
+	repo.histories = slices.DeleteFunc(repo.histories, func(history *domain.PasswordHistory) bool {
+		return history.UserID == userID
+	})
+
+	return nil
+}
+
+// fakeAuditLogRepository only keeps the chain, the queries other than Find are
+// not implemented and Find ignores the filter.
+type fakeAuditLogRepository struct {
+	abstraction.AuditLogRepository
+
+	logs []*domain.AuditLog
+}
+
+func (repo *fakeAuditLogRepository) LockChain(ctx context.Context) error {
+	return nil
+}
+
+func (repo *fakeAuditLogRepository) GetLast(ctx context.Context) (*domain.AuditLog, error) {
+	if len(repo.logs) == 0 {
+		return nil, errordef.ErrNotFound
+	}
+
+	return repo.logs[len(repo.logs)-1], nil
+}
+
+func (repo *fakeAuditLogRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	repo.logs = append(repo.logs, log)
+	return nil
+}
+
+func (repo *fakeAuditLogRepository) Find(
+	ctx context.Context,
+	filter *abstraction.AuditLogFilter,
+	limit int,
+) ([]*domain.AuditLog, error) {
+	return repo.logs, nil
+}
+
+func (repo *fakeAuditLogRepository) actions() []string {
+	actions := []string{}
+	for _, log := range repo.logs {
+		actions = append(actions, log.Action)
+	}
+
+	return actions
+}
+
+// fakeRateLimitRepository allows every request.
+type fakeRateLimitRepository struct{}
+
+func (repo *fakeRateLimitRepository) Allow(
+	ctx context.Context,
+	key string,
+	limit int,
+	window time.Duration,
+) (bool, time.Duration, error) {
+	return true, 0, nil
+}
+
+type fakeInvitationRepository struct {
+	invitations map[snowflake.ID]domain.Invitation
+}
+
+func newFakeInvitationRepository(invitations ...*domain.Invitation) *fakeInvitationRepository {
+	repo := &fakeInvitationRepository{invitations: map[snowflake.ID]domain.Invitation{}}
+	for _, invitation := range invitations {
+		repo.invitations[invitation.ID] = *invitation
+	}
+
+	return repo
+}
+
+func (repo *fakeInvitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	repo.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+func (repo *fakeInvitationRepository) GetByID(ctx context.Context, invitationID snowflake.ID) (*domain.Invitation, error) {
+	invitation, ok := repo.invitations[invitationID]
+	if !ok {
+		return nil, errordef.ErrNotFound
+	}
+
+	return &invitation, nil
+}
+
+func (repo *fakeInvitationRepository) GetBeforeID(
+	ctx context.Context,
+	beforeID snowflake.ID,
+	limit int,
+) ([]*domain.Invitation, error) {
+	return nil, nil
+}
+
+// Update only saves an invitation which is neither redeemed nor revoked, like
+// the database.
+func (repo *fakeInvitationRepository) Update(ctx context.Context, invitation *domain.Invitation) error {
+	existing, ok := repo.invitations[invitation.ID]
+	if !ok || !existing.RedeemedAt.IsZero() || !existing.RevokedAt.IsZero() {
+		return abstraction.ErrStaleVersion
+	}
+
+	repo.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+// fakeMailSender keeps the sent mails.
+type fakeMailSender struct {
+	mails []*domain.Mail
+}
+
+func (sender *fakeMailSender) Send(ctx context.Context, mail *domain.Mail) error {
+	sender.mails = append(sender.mails, mail)
+	return nil
+}
+
+// fakePersonalAccessTokenRepository keeps copies of the tokens, the users are
+// not locked since the tests do not run concurrently.
+type fakePersonalAccessTokenRepository struct {
+	abstraction.PersonalAccessTokenRepository
+
+	tokens map[snowflake.ID]domain.PersonalAccessToken
+}
+
+func newFakePersonalAccessTokenRepository() *fakePersonalAccessTokenRepository {
+	return &fakePersonalAccessTokenRepository{tokens: map[snowflake.ID]domain.PersonalAccessToken{}}
+}
+
+func (repo *fakePersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	repo.tokens[token.ID] = *token
+	return nil
+}
+
+func (repo *fakePersonalAccessTokenRepository) GetByID(
+	ctx context.Context,
+	tokenID snowflake.ID,
+) (*domain.PersonalAccessToken, error) {
+	token, ok := repo.tokens[tokenID]
+	if !ok {
+		return nil, errordef.ErrNotFound
+	}
+
+	return &token, nil
+}
+
+func (repo *fakePersonalAccessTokenRepository) GetByHash(
+	ctx context.Context,
+	tokenHash string,
+) (*domain.PersonalAccessToken, error) {
+	for _, token := range repo.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+
+	return nil, errordef.ErrNotFound
+}
+
+func (repo *fakePersonalAccessTokenRepository) LockUser(ctx context.Context, userID snowflake.ID) error {
+	return nil
+}
+
+func (repo *fakePersonalAccessTokenRepository) CountActiveByUserID(ctx context.Context, userID snowflake.ID) (int, error) {
+	n := 0
+	for _, token := range repo.tokens {
+		if token.UserID == userID && token.RevokedAt.IsZero() && time.Now().Before(token.ExpiresAt) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+func (repo *fakePersonalAccessTokenRepository) Revoke(ctx context.Context, token *domain.PersonalAccessToken) error {
+	repo.tokens[token.ID] = *token
+	return nil
+}
+
+func (repo *fakePersonalAccessTokenRepository) UpdateLastUsedAt(
+	ctx context.Context,
+	token *domain.PersonalAccessToken,
+) error {
+	repo.tokens[token.ID] = *token
+	return nil
+}
+
+type fakeLinkedIdentityRepository struct {
+	abstraction.LinkedIdentityRepository
+
+	identities map[snowflake.ID]domain.LinkedIdentity
+}
+
+func newFakeLinkedIdentityRepository(identities ...*domain.LinkedIdentity) *fakeLinkedIdentityRepository {
+	repo := &fakeLinkedIdentityRepository{identities: map[snowflake.ID]domain.LinkedIdentity{}}
+	for _, identity := range identities {
+		repo.identities[identity.ID] = *identity
+	}
+
+	return repo
+}
+
+func (repo *fakeLinkedIdentityRepository) GetByUserID(
+	ctx context.Context,
+	userID snowflake.ID,
+) ([]*domain.LinkedIdentity, error) {
+	identities := []*domain.LinkedIdentity{}
+	for _, identity := range repo.identities {
+		if identity.UserID == userID {
+			identities = append(identities, &identity)
+		}
+	}
+
+	return identities, nil
+}
+
+func (repo *fakeLinkedIdentityRepository) Delete(ctx context.Context, identityID snowflake.ID) error {
+	delete(repo.identities, identityID)
+	return nil
+}
+
+type fakePasskeyRepository struct {
+	abstraction.PasskeyRepository
+
+	passkeys map[snowflake.ID]domain.Passkey
+}
+
+func newFakePasskeyRepository(passkeys ...*domain.Passkey) *fakePasskeyRepository {
+	repo := &fakePasskeyRepository{passkeys: map[snowflake.ID]domain.Passkey{}}
+	for _, passkey := range passkeys {
+		repo.passkeys[passkey.ID] = *passkey
+	}
+
+	return repo
+}
+
+func (repo *fakePasskeyRepository) GetByUserID(ctx context.Context, userID snowflake.ID) ([]*domain.Passkey, error) {
+	passkeys := []*domain.Passkey{}
+	for _, passkey := range repo.passkeys {
+		if passkey.UserID == userID {
+			passkeys = append(passkeys, &passkey)
+		}
+	}
+
+	return passkeys, nil
+}
+
+func (repo *fakePasskeyRepository) Create(ctx context.Context, passkey *domain.Passkey) error {
+	for _, existing := range repo.passkeys {
+		if string(existing.CredentialID) == string(passkey.CredentialID) {
+			return errordef.ErrDuplicated
+		}
+	}
+
+	repo.passkeys[passkey.ID] = *passkey
+	return nil
+}
+
+func (repo *fakePasskeyRepository) GetByID(ctx context.Context, passkeyID snowflake.ID) (*domain.Passkey, error) {
+	passkey, ok := repo.passkeys[passkeyID]
+	if !ok {
+		return nil, errordef.ErrNotFound
+	}
+
+	return &passkey, nil
+}
+
+func (repo *fakePasskeyRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*domain.Passkey, error) {
+	for _, passkey := range repo.passkeys {
+		if string(passkey.CredentialID) == string(credentialID) {
+			return &passkey, nil
+		}
+	}
+
+	return nil, errordef.ErrNotFound
+}
+
+func (repo *fakePasskeyRepository) UpdateUsage(ctx context.Context, passkey *domain.Passkey) error {
+	repo.passkeys[passkey.ID] = *passkey
+	return nil
+}
+
+func (repo *fakePasskeyRepository) Delete(ctx context.Context, passkeyID snowflake.ID) error {
+	delete(repo.passkeys, passkeyID)
+	return nil
+}
+
+type fakePasskeySessionRepository struct {
+	sessions map[string]domain.PasskeySession
+}
+
+func newFakePasskeySessionRepository() *fakePasskeySessionRepository {
+	return &fakePasskeySessionRepository{sessions: map[string]domain.PasskeySession{}}
+}
+
+func (repo *fakePasskeySessionRepository) Save(ctx context.Context, session *domain.PasskeySession) error {
+	repo.sessions[session.ID] = *session
+	return nil
+}
+
+func (repo *fakePasskeySessionRepository) Take(ctx context.Context, sessionID string) (*domain.PasskeySession, error) {
+	session, ok := repo.sessions[sessionID]
+	if !ok {
+		return nil, errordef.ErrNotFound
+	}
+
+	delete(repo.sessions, sessionID)
+	return &session, nil
+}
+
+type fakeMagicLinkRepository struct {
+	redeemed map[string]time.Time
+}
+
+func newFakeMagicLinkRepository() *fakeMagicLinkRepository {
+	return &fakeMagicLinkRepository{redeemed: map[string]time.Time{}}
+}
+
+func (repo *fakeMagicLinkRepository) MarkRedeemed(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	if _, ok := repo.redeemed[tokenID]; ok {
+		return false, nil
+	}
+
+	repo.redeemed[tokenID] = expiresAt
+	return true, nil
+}
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// InvitationUsecase lets admins invite people, who then create their user with
+// the username and password of their choice. The invitee receives a signed
+// token referring to the invitation, the invitation is redeemed at most once.
+type InvitationUsecase struct {
+	tokenEngine       token.Engine
+	defaultExpiration time.Duration
+
+	invitationDomain abstraction.InvitationDomain
+	userDomain       abstraction.UserDomain
+	avatarDomain     abstraction.AvatarDomain
+	eventDomain      abstraction.EventDomain
+	outboxDomain     abstraction.OutboxDomain
+	auditDomain      abstraction.AuditDomain
+
+	invitationRepo abstraction.InvitationRepository
+	userRepo       abstraction.UserRepository
+	outboxRepo     abstraction.OutboxRepository
+	auditRepo      abstraction.AuditLogRepository
+}
+
+func NewInvitationUsecase(
+	tokenEngine token.Engine,
+	defaultExpiration time.Duration,
+	invitationDomain abstraction.InvitationDomain,
+	userDomain abstraction.UserDomain,
+	avatarDomain abstraction.AvatarDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	invitationRepo abstraction.InvitationRepository,
+	userRepo abstraction.UserRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *InvitationUsecase {
+	return &InvitationUsecase{
+		tokenEngine:       tokenEngine,
+		defaultExpiration: defaultExpiration,
+		invitationDomain:  invitationDomain,
+		userDomain:        userDomain,
+		avatarDomain:      avatarDomain,
+		eventDomain:       eventDomain,
+		outboxDomain:      outboxDomain,
+		auditDomain:       auditDomain,
+		invitationRepo:    invitationRepo,
+		userRepo:          userRepo,
+		outboxRepo:        outboxRepo,
+		auditRepo:         auditRepo,
+	}
+}
+
+// Create creates an invitation and returns its token, which cannot be read
+// again.
+func (usecase *InvitationUsecase) Create(
+	ctx context.Context,
+	req *dto.InvitationCreateRequest,
+) (*dto.InvitationCreateResponse, error) {
+	if err := usecase.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	expiration := req.Expiration
+	if expiration == 0 {
+		expiration = usecase.defaultExpiration
+	}
+
+	invitation, err := usecase.invitationDomain.New(xcontext.RequestSubjectID(ctx), req.Role, expiration)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-invitation").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	invitationToken, err := usecase.tokenEngine.Generate(ctx, usecase.invitationDomain.NewToken(invitation))
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-generate-invitation-token")
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.invitationRepo.Create(ctx, invitation); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-invitation")
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditInvitationCreate,
+		domain.AuditTargetInvitation, invitation.ID.String(), map[string]string{
+			"role":       invitation.Role.String(),
+			"expires_at": invitation.ExpiresAt.Format(time.RFC3339),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "invitation_id", invitation.ID)
+	}
+
+	return dto.NewInvitationCreateResponse(invitation,
+		usecase.invitationDomain.Status(invitation), invitationToken), nil
+}
+
+func (usecase *InvitationUsecase) List(
+	ctx context.Context,
+	req *dto.InvitationListRequest,
+) (*dto.InvitationListResponse, error) {
+	if err := usecase.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = dto.DefaultInvitationListLimit
+	}
+
+	if limit < 0 || limit > dto.MaximumInvitationListLimit {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "limit must be in range [1, %d]", dto.MaximumInvitationListLimit)
+	}
+
+	invitations, err := usecase.invitationRepo.GetBeforeID(ctx, req.BeforeID, limit)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-invitations")
+	}
+
+	statuses := []string{}
+	for _, invitation := range invitations {
+		statuses = append(statuses, usecase.invitationDomain.Status(invitation))
+	}
+
+	return dto.NewInvitationListResponse(invitations, statuses), nil
+}
+
+// Revoke prevents a pending invitation from being redeemed.
+func (usecase *InvitationUsecase) Revoke(
+	ctx context.Context,
+	req *dto.InvitationRevokeRequest,
+) (*dto.InvitationRevokeResponse, error) {
+	if err := usecase.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	invitation, err := usecase.getInvitation(ctx, req.InvitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := usecase.invitationDomain.Revoke(invitation); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-revoke-invitation").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.invitationRepo.Update(ctx, invitation); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the invitation has already been redeemed or revoked")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-invitation", "invitation_id", invitation.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditInvitationRevoke,
+		domain.AuditTargetInvitation, invitation.ID.String(), nil)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "invitation_id", invitation.ID)
+	}
+
+	return dto.NewInvitationRevokeResponse(invitation, usecase.invitationDomain.Status(invitation)), nil
+}
+
+// Redeem creates the user of the invitee with the role of the invitation. It
+// requires no access token, the invitation token is the credential.
+func (usecase *InvitationUsecase) Redeem(
+	ctx context.Context,
+	req *dto.InvitationRedeemRequest,
+) (*dto.InvitationRedeemResponse, error) {
+	claims := &domain.InvitationToken{}
+	if err := usecase.tokenEngine.Validate(ctx, req.Token, claims); err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid invitation token").
+			Hide(err, "failed-to-parse-invitation-token")
+	}
+
+	invitationID, err := snowflake.ParseString(claims.ID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid invitation token")
+	}
+
+	invitation, err := usecase.getInvitation(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.userDomain.New(req.Username, req.Password)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-user").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	user.Role = invitation.Role
+	if err := usecase.invitationDomain.Redeem(invitation, user.ID); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-redeem-invitation").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	taken, err := usecase.userRepo.FindExistingCanonicalUsernames(ctx, []string{user.Username})
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-find-existing-username", "username", user.Username)
+	}
+
+	if len(taken) > 0 {
+		return nil, xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed", user.Username)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return nil, xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed", user.Username)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-user")
+	}
+
+	if err := usecase.invitationRepo.Update(ctx, invitation); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the invitation has already been redeemed or revoked")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-invitation", "invitation_id", invitation.ID)
+	}
+
+	event, err := usecase.eventDomain.NewUserCreated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-user-created-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditInvitationRedeem,
+		domain.AuditTargetInvitation, invitation.ID.String(), map[string]string{
+			"user_id":  user.ID.String(),
+			"username": user.Username,
+			"role":     user.Role.String(),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "invitation_id", invitation.ID)
+	}
+
+	return dto.NewInvitationRedeemResponse(user, usecase.avatarDomain.DefaultURL(user.ID)), nil
+}
+
+func (usecase *InvitationUsecase) getInvitation(ctx context.Context, invitationID snowflake.ID) (*domain.Invitation, error) {
+	invitation, err := usecase.invitationRepo.GetByID(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found invitation with id %d", invitationID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-invitation", "invitation_id", invitationID)
+	}
+
+	return invitation, nil
+}
+
+func (usecase *InvitationUsecase) requireAdmin(ctx context.Context) error {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	return requireAdminRole(ctx, usecase.userRepo)
+}
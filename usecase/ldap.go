@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// LDAPUsecase synchronizes the users sourced from LDAP with the directory. The
+// entries of the directory are created or updated locally, the users whose
+// entry disappeared are disabled. The directory also validates the passwords
+// of these users, see UserUsecase.ValidateCredentials.
+type LDAPUsecase struct {
+	userDomain   abstraction.UserDomain
+	eventDomain  abstraction.EventDomain
+	outboxDomain abstraction.OutboxDomain
+	auditDomain  abstraction.AuditDomain
+
+	userRepo   abstraction.UserRepository
+	outboxRepo abstraction.OutboxRepository
+	auditRepo  abstraction.AuditLogRepository
+
+	ldapDirectory abstraction.LDAPDirectory
+}
+
+func NewLDAPUsecase(
+	userDomain abstraction.UserDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+	ldapDirectory abstraction.LDAPDirectory,
+) *LDAPUsecase {
+	return &LDAPUsecase{
+		userDomain:    userDomain,
+		eventDomain:   eventDomain,
+		outboxDomain:  outboxDomain,
+		auditDomain:   auditDomain,
+		userRepo:      userRepo,
+		outboxRepo:    outboxRepo,
+		auditRepo:     auditRepo,
+		ldapDirectory: ldapDirectory,
+	}
+}
+
+// Sync applies the entries of the directory to the local users. Each user is
+// saved in its own transaction, a user who fails is retried on the next sync.
+func (usecase *LDAPUsecase) Sync(ctx context.Context, req *dto.LDAPSyncRequest) (*dto.LDAPSyncResponse, error) {
+	entries, err := usecase.ldapDirectory.Search(ctx)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-search-ldap-directory")
+	}
+
+	users, err := usecase.userRepo.GetLDAPSourced(ctx)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-ldap-users")
+	}
+
+	usersByLDAPID := map[string]*domain.User{}
+	for _, user := range users {
+		usersByLDAPID[user.LDAPID] = user
+	}
+
+	resp := &dto.LDAPSyncResponse{}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			continue
+		}
+
+		seen[entry.ID] = true
+
+		user, ok := usersByLDAPID[entry.ID]
+		if !ok {
+			if err := usecase.create(ctx, entry); err != nil {
+				xcontext.Logger(ctx).Warn("failed-to-create-ldap-user", "err", err, "ldap_id", entry.ID)
+				resp.Failed++
+				continue
+			}
+
+			resp.Created++
+			continue
+		}
+
+		updated, err := usecase.update(ctx, user, entry)
+		if err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-update-ldap-user", "err", err, "uid", user.ID)
+			resp.Failed++
+			continue
+		}
+
+		if updated {
+			resp.Updated++
+		}
+	}
+
+	// An empty directory is more likely a misconfigured filter than the
+	// departure of every user, nobody is disabled.
+	if len(entries) == 0 {
+		return resp, nil
+	}
+
+	for _, user := range users {
+		if seen[user.LDAPID] {
+			continue
+		}
+
+		version := user.UpdatedAt
+		if !usecase.userDomain.DisableLDAP(user) {
+			continue
+		}
+
+		if err := usecase.save(ctx, user, version, domain.AuditUserLDAPDisable); err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-disable-ldap-user", "err", err, "uid", user.ID)
+			resp.Failed++
+			continue
+		}
+
+		resp.Disabled++
+	}
+
+	return resp, nil
+}
+
+func (usecase *LDAPUsecase) create(ctx context.Context, entry *domain.LDAPEntry) error {
+	entry, err := usecase.withAvailableEmail(ctx, entry, 0)
+	if err != nil {
+		return err
+	}
+
+	user, err := usecase.userDomain.NewFromLDAP(entry)
+	if err != nil {
+		return err
+	}
+
+	taken, err := usecase.userRepo.FindExistingCanonicalUsernames(ctx, []string{user.Username})
+	if err != nil {
+		return err
+	}
+
+	if len(taken) > 0 {
+		return xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed", user.Username)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	event, err := usecase.eventDomain.NewUserCreated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserLDAPProvision,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"username": user.Username,
+			"ldap_id":  user.LDAPID,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// update applies the entry to the user and reports whether the user was
+// changed.
+func (usecase *LDAPUsecase) update(ctx context.Context, user *domain.User, entry *domain.LDAPEntry) (bool, error) {
+	entry, err := usecase.withAvailableEmail(ctx, entry, user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	version := user.UpdatedAt
+	changed, err := usecase.userDomain.SyncLDAP(user, entry)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	if err := usecase.save(ctx, user, version, domain.AuditUserLDAPUpdate); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// save saves the user if it is still at version, then records the
+// user.updated event and the audit log.
+func (usecase *LDAPUsecase) save(ctx context.Context, user *domain.User, version time.Time, action string) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Update(ctx, user, version); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	event, err := usecase.eventDomain.NewUserUpdated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, action,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"username": user.Username,
+			"ldap_id":  user.LDAPID,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// withAvailableEmail returns the entry without its email if a user other
+// than userID has it, the email is optional.
+func (usecase *LDAPUsecase) withAvailableEmail(
+	ctx context.Context,
+	entry *domain.LDAPEntry,
+	userID snowflake.ID,
+) (*domain.LDAPEntry, error) {
+	if entry.Email == "" {
+		return entry, nil
+	}
+
+	other, err := usecase.userRepo.GetByEmail(ctx, entry.Email)
+	if errors.Is(err, errordef.ErrNotFound) {
+		return entry, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if other.ID == userID {
+		return entry, nil
+	}
+
+	available := *entry
+	available.Email = ""
+	return &available, nil
+}
@@ -0,0 +1,131 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/service/ldap"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type ldapFixture struct {
+	userDomain *domain.UserDomain
+	userRepo   *fakeUserRepository
+	auditRepo  *fakeAuditLogRepository
+	directory  *ldap.MemoryDirectory
+
+	ldap *usecase.LDAPUsecase
+}
+
+func newLDAPFixture(t *testing.T, entries ...ldap.MemoryEntry) *ldapFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	f := &ldapFixture{
+		userDomain: userDomain,
+		userRepo:   newFakeUserRepository(),
+		auditRepo:  &fakeAuditLogRepository{},
+		directory:  ldap.NewMemoryDirectory(entries),
+	}
+
+	f.ldap = usecase.NewLDAPUsecase(
+		userDomain, domain.NewEventDomain(node), domain.NewOutboxDomain(node, 3, time.Second, time.Minute, time.Minute),
+		domain.NewAuditDomain(node), f.userRepo, newFakeOutboxRepository(), f.auditRepo, f.directory)
+
+	return f
+}
+
+func (f *ldapFixture) sync(t *testing.T) *dto.LDAPSyncResponse {
+	resp, err := f.ldap.Sync(newRequestContext(), &dto.LDAPSyncRequest{})
+	require.NoError(t, err)
+	return resp
+}
+
+func (f *ldapFixture) getUser(t *testing.T, username string) *domain.User {
+	user, err := f.userRepo.GetByUsername(context.Background(), username)
+	require.NoError(t, err)
+	return user
+}
+
+func TestLDAPSyncCreatesUsers(t *testing.T) {
+	f := newLDAPFixture(t,
+		ldap.MemoryEntry{ID: "alice-id", Username: "alice", DisplayName: "Alice", Email: "Alice@Example.com"},
+		ldap.MemoryEntry{ID: "bob-id", Username: "bobby"},
+	)
+
+	require.Equal(t, &dto.LDAPSyncResponse{Created: 2}, f.sync(t))
+
+	alice := f.getUser(t, "alice")
+	require.True(t, alice.Active)
+	require.False(t, alice.HasPassword)
+	require.Equal(t, "alice-id", alice.LDAPID)
+	require.Equal(t, "Alice", alice.DisplayName)
+	require.Equal(t, "alice@example.com", alice.Email)
+	require.True(t, alice.EmailVerified)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserLDAPProvision)
+
+	// Nothing changed.
+	require.Equal(t, &dto.LDAPSyncResponse{}, f.sync(t))
+}
+
+func TestLDAPSyncUpdatesUsers(t *testing.T) {
+	f := newLDAPFixture(t, ldap.MemoryEntry{ID: "alice-id", Username: "alice", DisplayName: "Alice"})
+	f.sync(t)
+
+	f.directory.Put(ldap.MemoryEntry{ID: "alice-id", Username: "alice2", DisplayName: "Alice Liddell"})
+	require.Equal(t, &dto.LDAPSyncResponse{Updated: 1}, f.sync(t))
+
+	alice := f.getUser(t, "alice2")
+	require.Equal(t, "Alice Liddell", alice.DisplayName)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserLDAPUpdate)
+}
+
+func TestLDAPSyncDisablesDepartedUsers(t *testing.T) {
+	f := newLDAPFixture(t,
+		ldap.MemoryEntry{ID: "alice-id", Username: "alice"},
+		ldap.MemoryEntry{ID: "bob-id", Username: "bobby"},
+	)
+	f.sync(t)
+
+	f.directory.Remove("bob-id")
+	require.Equal(t, &dto.LDAPSyncResponse{Disabled: 1}, f.sync(t))
+	require.False(t, f.getUser(t, "bobby").Active)
+	require.True(t, f.getUser(t, "alice").Active)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserLDAPDisable)
+
+	// Coming back to the subtree does not activate the user again.
+	f.directory.Put(ldap.MemoryEntry{ID: "bob-id", Username: "bobby"})
+	require.Equal(t, &dto.LDAPSyncResponse{}, f.sync(t))
+	require.False(t, f.getUser(t, "bobby").Active)
+}
+
+func TestLDAPSyncKeepsLocalDeactivation(t *testing.T) {
+	f := newLDAPFixture(t, ldap.MemoryEntry{ID: "alice-id", Username: "alice", DisplayName: "Alice"})
+	f.sync(t)
+
+	alice := f.getUser(t, "alice")
+	f.userDomain.SetActive(alice, false)
+	f.userRepo.users[alice.ID] = *alice
+
+	f.directory.Put(ldap.MemoryEntry{ID: "alice-id", Username: "alice", DisplayName: "Alice Liddell"})
+	require.Equal(t, &dto.LDAPSyncResponse{Updated: 1}, f.sync(t))
+
+	alice = f.getUser(t, "alice")
+	require.False(t, alice.Active)
+	require.Equal(t, "Alice Liddell", alice.DisplayName)
+}
+
+func TestLDAPSyncKeepsUsersOnEmptyDirectory(t *testing.T) {
+	f := newLDAPFixture(t, ldap.MemoryEntry{ID: "alice-id", Username: "alice"})
+	f.sync(t)
+
+	f.directory.Remove("alice-id")
+	require.Equal(t, &dto.LDAPSyncResponse{}, f.sync(t))
+	require.True(t, f.getUser(t, "alice").Active)
+}
@@ -0,0 +1,404 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// LinkedIdentityUsecase maps the identities of external providers, such as
+// GitHub or Google, to the users. The auth server verifies the identities,
+// links them, resolves them into users and creates the users signing in for
+// the first time. A user always keeps a password or a linked identity.
+type LinkedIdentityUsecase struct {
+	linkedIdentityDomain abstraction.LinkedIdentityDomain
+	userDomain           abstraction.UserDomain
+	avatarDomain         abstraction.AvatarDomain
+	eventDomain          abstraction.EventDomain
+	outboxDomain         abstraction.OutboxDomain
+	auditDomain          abstraction.AuditDomain
+
+	linkedIdentityRepo abstraction.LinkedIdentityRepository
+	userRepo           abstraction.UserRepository
+	passkeyRepo        abstraction.PasskeyRepository
+	outboxRepo         abstraction.OutboxRepository
+	auditRepo          abstraction.AuditLogRepository
+}
+
+func NewLinkedIdentityUsecase(
+	linkedIdentityDomain abstraction.LinkedIdentityDomain,
+	userDomain abstraction.UserDomain,
+	avatarDomain abstraction.AvatarDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	linkedIdentityRepo abstraction.LinkedIdentityRepository,
+	userRepo abstraction.UserRepository,
+	passkeyRepo abstraction.PasskeyRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *LinkedIdentityUsecase {
+	return &LinkedIdentityUsecase{
+		linkedIdentityDomain: linkedIdentityDomain,
+		userDomain:           userDomain,
+		avatarDomain:         avatarDomain,
+		eventDomain:          eventDomain,
+		outboxDomain:         outboxDomain,
+		auditDomain:          auditDomain,
+		linkedIdentityRepo:   linkedIdentityRepo,
+		userRepo:             userRepo,
+		passkeyRepo:          passkeyRepo,
+		outboxRepo:           outboxRepo,
+		auditRepo:            auditRepo,
+	}
+}
+
+func (usecase *LinkedIdentityUsecase) List(
+	ctx context.Context,
+	req *dto.LinkedIdentityListRequest,
+) (*dto.LinkedIdentityListResponse, error) {
+	if err := usecase.authorize(ctx, req.UserID, false); err != nil {
+		return nil, err
+	}
+
+	identities, err := usecase.linkedIdentityRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-linked-identities", "uid", req.UserID)
+	}
+
+	return dto.NewLinkedIdentityListResponse(identities), nil
+}
+
+// Link links an external identity verified by the auth server to the user, a
+// user has at most one identity per provider. A linked identity signs in as
+// the user, so it requires the admin create:user scope like Provision.
+func (usecase *LinkedIdentityUsecase) Link(
+	ctx context.Context,
+	req *dto.LinkedIdentityLinkRequest,
+) (*dto.LinkedIdentityLinkResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	user, err := usecase.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := usecase.linkedIdentityDomain.New(user.ID, &domain.ExternalClaims{
+		Provider: req.Provider,
+		Subject:  req.Subject,
+		Email:    req.Email,
+	})
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-linked-identity").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.linkedIdentityRepo.Create(ctx, identity); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return nil, xerror.Enrich(errordef.ErrDuplicated,
+				"the identity is linked to another user or the user already has a %s identity", identity.Provider)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-linked-identity", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserIdentityLink,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"identity_id": identity.ID.String(),
+			"provider":    identity.Provider,
+			"subject":     identity.Subject,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewLinkedIdentityLinkResponse(identity), nil
+}
+
+// Unlink removes an identity of the user, unless the user would have no way to
+// sign in left.
+func (usecase *LinkedIdentityUsecase) Unlink(
+	ctx context.Context,
+	req *dto.LinkedIdentityUnlinkRequest,
+) (*dto.LinkedIdentityUnlinkResponse, error) {
+	if err := usecase.authorize(ctx, req.UserID, true); err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := usecase.linkedIdentityRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-linked-identities", "uid", user.ID)
+	}
+
+	i := slices.IndexFunc(identities, func(identity *domain.LinkedIdentity) bool {
+		return identity.ID == req.IdentityID
+	})
+	if i < 0 {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found linked identity with id %d", req.IdentityID)
+	}
+
+	passkeys, err := usecase.passkeyRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-passkeys", "uid", user.ID)
+	}
+
+	if err := usecase.linkedIdentityDomain.CheckUnlink(user, identities, passkeys); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-unlink-identity").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.linkedIdentityRepo.Delete(ctx, req.IdentityID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-delete-linked-identity", "identity_id", req.IdentityID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserIdentityUnlink,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"identity_id": req.IdentityID.String(),
+			"provider":    identities[i].Provider,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewLinkedIdentityUnlinkResponse(), nil
+}
+
+// GetUser resolves an external identity into its user, for the auth server to
+// sign the user in. Users who cannot sign in are rejected as with a password.
+func (usecase *LinkedIdentityUsecase) GetUser(
+	ctx context.Context,
+	req *dto.LinkedIdentityGetUserRequest,
+) (*dto.LinkedIdentityGetUserResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	provider := usecase.linkedIdentityDomain.NormalizeProvider(req.Provider)
+	identity, err := usecase.linkedIdentityRepo.GetByExternalIdentity(ctx, provider, req.Subject)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "the identity is not linked to any user")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-linked-identity", "provider", provider)
+	}
+
+	user, err := usecase.getUser(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSignIn(user); err != nil {
+		return nil, err
+	}
+
+	return dto.NewLinkedIdentityGetUserResponse(user), nil
+}
+
+// Provision returns the user of the external identity, creating it if the
+// identity is not linked yet. The username is taken from the claims, or is
+// derived from them if it is not available. The verified email of the identity
+// becomes the email of the user if no other user has it.
+func (usecase *LinkedIdentityUsecase) Provision(
+	ctx context.Context,
+	req *dto.LinkedIdentityProvisionRequest,
+) (*dto.LinkedIdentityProvisionResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	claims := &domain.ExternalClaims{
+		Provider:          req.Provider,
+		Subject:           req.Subject,
+		Email:             req.Email,
+		EmailVerified:     req.EmailVerified,
+		PreferredUsername: req.PreferredUsername,
+		DisplayName:       req.DisplayName,
+	}
+
+	identity, err := usecase.linkedIdentityDomain.New(0, claims)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-linked-identity").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	existing, err := usecase.linkedIdentityRepo.GetByExternalIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := usecase.getUser(ctx, existing.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkSignIn(user); err != nil {
+			return nil, err
+		}
+
+		return dto.NewLinkedIdentityProvisionResponse(user, usecase.avatarDomain.DefaultURL(user.ID), false), nil
+	}
+
+	if !errors.Is(err, errordef.ErrNotFound) {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-linked-identity", "provider", identity.Provider)
+	}
+
+	username, err := usecase.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.userDomain.NewFederated(username, req.DisplayName)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-user").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	if req.EmailVerified && identity.Email != "" {
+		_, err := usecase.userRepo.GetByEmail(ctx, identity.Email)
+		if errors.Is(err, errordef.ErrNotFound) {
+			user.Email, user.EmailVerified = identity.Email, true
+		} else if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-user-by-email")
+		}
+	}
+
+	identity.UserID = user.ID
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return nil, xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed, please retry", user.Username)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-user")
+	}
+
+	if err := usecase.linkedIdentityRepo.Create(ctx, identity); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return nil, xerror.Enrich(errordef.ErrDuplicated, "the identity has just been linked, please retry")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-linked-identity", "uid", user.ID)
+	}
+
+	event, err := usecase.eventDomain.NewUserCreated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-user-created-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserFederatedProvision,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"username":    user.Username,
+			"identity_id": identity.ID.String(),
+			"provider":    identity.Provider,
+			"subject":     identity.Subject,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewLinkedIdentityProvisionResponse(user, usecase.avatarDomain.DefaultURL(user.ID), true), nil
+}
+
+// availableUsername returns the first username derived from the claims which
+// is not taken yet.
+func (usecase *LinkedIdentityUsecase) availableUsername(ctx context.Context, claims *domain.ExternalClaims) (string, error) {
+	candidates := usecase.userDomain.FederatedUsernames(claims, dto.FederatedUsernameCandidateCount)
+	if len(candidates) == 0 {
+		return "", xerror.Enrich(errordef.ErrDuplicated, "unable to find an available username")
+	}
+
+	taken, err := usecase.userRepo.FindExistingCanonicalUsernames(ctx, candidates)
+	if err != nil {
+		return "", errordef.ErrServer.Hide(err, "failed-to-find-existing-username")
+	}
+
+	for _, candidate := range candidates {
+		if !slices.Contains(taken, domain.CanonicalUsername(candidate)) {
+			return candidate, nil
+		}
+	}
+
+	return "", xerror.Enrich(errordef.ErrDuplicated, "unable to find an available username")
+}
+
+// checkSignIn rejects the users who cannot sign in through an identity.
+func checkSignIn(user *domain.User) error {
+	if !user.Active {
+		return xerror.Enrich(errordef.ErrCredentialsInvalid, "the user is inactive")
+	}
+
+	if user.Pending {
+		return xerror.Enrich(errordef.ErrCredentialsInvalid, "the account is pending approval")
+	}
+
+	return nil
+}
+
+// authorize allows users to manage their own identities, and admins to manage
+// the identities of any user. Users changing their identities also need the
+// update:user.identity scope.
+func (usecase *LinkedIdentityUsecase) authorize(ctx context.Context, userID snowflake.ID, write bool) error {
+	if xcontext.RequestSubjectType(ctx) == enumdef.SubjectUser && xcontext.RequestSubjectID(ctx) == userID {
+		if write {
+			return requireSelfScope(ctx, userID, UserUpdateUserIdentity)
+		}
+
+		return requireSelf(ctx, userID)
+	}
+
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	return requireAdminRole(ctx, usecase.userRepo)
+}
+
+// getUser returns the user if it is not erased.
+func (usecase *LinkedIdentityUsecase) getUser(ctx context.Context, userID snowflake.ID) (*domain.User, error) {
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, errordef.ErrNotFound) {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	if err != nil || usecase.userDomain.IsErased(user) {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", userID)
+	}
+
+	return user, nil
+}
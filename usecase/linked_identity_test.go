@@ -0,0 +1,70 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type linkedIdentityFixture struct {
+	user         *domain.User
+	identity     *domain.LinkedIdentity
+	identityRepo *fakeLinkedIdentityRepository
+
+	linkedIdentity *usecase.LinkedIdentityUsecase
+}
+
+func newLinkedIdentityFixture(t *testing.T, passkeys ...*domain.Passkey) *linkedIdentityFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	user, err := userDomain.NewFederated("alice", "Alice")
+	require.NoError(t, err)
+
+	identity := &domain.LinkedIdentity{ID: node.Generate(), UserID: user.ID, Provider: "github", Subject: "42"}
+	for _, passkey := range passkeys {
+		passkey.UserID = user.ID
+	}
+
+	f := &linkedIdentityFixture{
+		user:         user,
+		identity:     identity,
+		identityRepo: newFakeLinkedIdentityRepository(identity),
+	}
+
+	f.linkedIdentity = usecase.NewLinkedIdentityUsecase(
+		domain.NewLinkedIdentityDomain(node, []string{"github"}), userDomain, nil, nil, nil,
+		domain.NewAuditDomain(node), f.identityRepo, newFakeUserRepository(user),
+		newFakePasskeyRepository(passkeys...), nil, &fakeAuditLogRepository{})
+
+	return f
+}
+
+func TestUnlinkIdentityRequiresWriteScope(t *testing.T) {
+	f := newLinkedIdentityFixture(t, &domain.Passkey{ID: 1})
+	req := &dto.LinkedIdentityUnlinkRequest{UserID: f.user.ID, IdentityID: f.identity.ID}
+
+	_, err := f.linkedIdentity.Unlink(newUserContext(f.user.ID, scopedef.UserReadUserProfile), req)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+	require.Len(t, f.identityRepo.identities, 1)
+
+	// The user keeps the passkey to sign in.
+	_, err = f.linkedIdentity.Unlink(newUserContext(f.user.ID, usecase.UserUpdateUserIdentity), req)
+	require.NoError(t, err)
+	require.Empty(t, f.identityRepo.identities)
+}
+
+func TestUnlinkIdentityScopeIsNotGrantedToPersonalAccessTokens(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	_, err := f.create(f.admin, usecase.UserUpdateUserIdentity)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// MagicLinkUsecase lets users sign in with a link sent to their email instead
+// of their password. The link holds a short-lived signed token, which is
+// redeemed at most once. The auth server sends the links and redeems them,
+// like it validates the credentials.
+type MagicLinkUsecase struct {
+	tokenEngine     token.Engine
+	rateLimit       int
+	rateLimitWindow time.Duration
+
+	magicLinkDomain abstraction.MagicLinkDomain
+	userDomain      abstraction.UserDomain
+	auditDomain     abstraction.AuditDomain
+
+	userRepo      abstraction.UserRepository
+	auditRepo     abstraction.AuditLogRepository
+	magicLinkRepo abstraction.MagicLinkRepository
+	rateLimitRepo abstraction.RateLimitRepository
+
+	// mailSender is nil if the magic links are disabled.
+	mailSender abstraction.MailSender
+}
+
+func NewMagicLinkUsecase(
+	tokenEngine token.Engine,
+	rateLimit int,
+	rateLimitWindow time.Duration,
+	magicLinkDomain abstraction.MagicLinkDomain,
+	userDomain abstraction.UserDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+	magicLinkRepo abstraction.MagicLinkRepository,
+	rateLimitRepo abstraction.RateLimitRepository,
+	mailSender abstraction.MailSender,
+) *MagicLinkUsecase {
+	return &MagicLinkUsecase{
+		tokenEngine:     tokenEngine,
+		rateLimit:       rateLimit,
+		rateLimitWindow: rateLimitWindow,
+		magicLinkDomain: magicLinkDomain,
+		userDomain:      userDomain,
+		auditDomain:     auditDomain,
+		userRepo:        userRepo,
+		auditRepo:       auditRepo,
+		magicLinkRepo:   magicLinkRepo,
+		rateLimitRepo:   rateLimitRepo,
+		mailSender:      mailSender,
+	}
+}
+
+// Send sends a magic link to the email if it is the verified email of a user
+// who can sign in. The response is the same whether a link is sent or not.
+func (usecase *MagicLinkUsecase) Send(
+	ctx context.Context,
+	req *dto.MagicLinkSendRequest,
+) (*dto.MagicLinkSendResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if usecase.mailSender == nil {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "magic links are disabled")
+	}
+
+	email, err := usecase.userDomain.NormalizeEmail(req.Email)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-normalize-email").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	key := fmt.Sprintf("magic-link:%s", email)
+	allowed, retryAfter, err := usecase.rateLimitRepo.Allow(ctx, key, usecase.rateLimit, usecase.rateLimitWindow)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-rate-limit", "key", key)
+	}
+
+	if !allowed {
+		return nil, xerror.Enrich(dto.ErrTooManyRequests, "too many requests, retry after %d seconds",
+			int(math.Ceil(retryAfter.Seconds())))
+	}
+
+	user, err := usecase.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return dto.NewMagicLinkSendResponse(), nil
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user-by-email")
+	}
+
+	if !user.Active || user.Pending || !user.EmailVerified || usecase.userDomain.IsErased(user) {
+		return dto.NewMagicLinkSendResponse(), nil
+	}
+
+	claims := usecase.magicLinkDomain.NewToken(user)
+	signedToken, err := usecase.tokenEngine.Generate(ctx, claims)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-generate-magic-link-token")
+	}
+
+	mail, err := usecase.magicLinkDomain.NewMail(user, signedToken)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-new-magic-link-mail")
+	}
+
+	if err := usecase.mailSender.Send(ctx, mail); err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-send-magic-link", "uid", user.ID)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserMagicLinkSend,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"token_id":   claims.ID,
+			"expires_at": time.Unix(int64(claims.ExpiresAt), 0).UTC().Format(time.RFC3339),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewMagicLinkSendResponse(), nil
+}
+
+// Redeem returns the user of the magic link, like ValidateCredentials. A
+// token is rejected once redeemed, or if the email of its user has changed
+// since it was sent or is not verified. The failures are audited.
+func (usecase *MagicLinkUsecase) Redeem(
+	ctx context.Context,
+	req *dto.MagicLinkRedeemRequest,
+) (*dto.MagicLinkRedeemResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if req.Token == "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require token")
+	}
+
+	claims := &domain.MagicLinkToken{}
+	if err := usecase.tokenEngine.Validate(ctx, req.Token, claims); err != nil {
+		return nil, usecase.redeemFailed(ctx, "", "invalid_token", err)
+	}
+
+	userID, err := snowflake.ParseString(claims.UserID)
+	if err != nil {
+		return nil, usecase.redeemFailed(ctx, "", "invalid_token", err)
+	}
+
+	ok, err := usecase.magicLinkRepo.MarkRedeemed(ctx, claims.ID, time.Unix(int64(claims.ExpiresAt), 0))
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-mark-magic-link-redeemed", "token_id", claims.ID)
+	}
+
+	if !ok {
+		return nil, usecase.redeemFailed(ctx, userID.String(), "replayed_token", nil)
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, usecase.redeemFailed(ctx, userID.String(), "unknown_user", nil)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	if user.Email == "" || domain.CanonicalEmail(user.Email) != claims.Email {
+		return nil, usecase.redeemFailed(ctx, user.ID.String(), "email_changed", nil)
+	}
+
+	if !user.EmailVerified {
+		return nil, usecase.redeemFailed(ctx, user.ID.String(), "unverified_email", nil)
+	}
+
+	if !user.Active {
+		return nil, usecase.redeemFailed(ctx, user.ID.String(), "inactive_user", nil)
+	}
+
+	if user.Pending {
+		return nil, usecase.redeemFailed(ctx, user.ID.String(), "pending_approval", nil)
+	}
+
+	return dto.NewMagicLinkRedeemResponse(user), nil
+}
+
+// redeemFailed audits a failed redemption in its own transaction and returns
+// the error of the request, the cause is only logged.
+func (usecase *MagicLinkUsecase) redeemFailed(ctx context.Context, userID, reason string, cause error) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserMagicLinkRedeemFailed,
+		domain.AuditTargetUser, userID, map[string]string{"reason": reason})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", userID)
+	}
+
+	if cause == nil {
+		cause = errors.New(reason)
+	}
+
+	return xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid or expired magic link").
+		Hide(cause, "failed-to-redeem-magic-link", "reason", reason)
+}
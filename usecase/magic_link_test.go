@@ -0,0 +1,131 @@
+package usecase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+)
+
+type magicLinkFixture struct {
+	userRepo   *fakeUserRepository
+	auditRepo  *fakeAuditLogRepository
+	mailSender *fakeMailSender
+	alice      *domain.User
+
+	magicLink *usecase.MagicLinkUsecase
+}
+
+func newMagicLinkFixture(t *testing.T, emailVerified bool) *magicLinkFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	alice, err := userDomain.NewFirst("alice", "P@ssw0rd123")
+	require.NoError(t, err)
+	alice.Email, alice.EmailVerified = "alice@example.com", emailVerified
+
+	engine := token.NewJWTEngine()
+	require.NoError(t, engine.WithHMAC("secret"))
+
+	f := &magicLinkFixture{
+		userRepo:   newFakeUserRepository(alice),
+		auditRepo:  &fakeAuditLogRepository{},
+		mailSender: &fakeMailSender{},
+		alice:      alice,
+	}
+
+	f.magicLink = usecase.NewMagicLinkUsecase(
+		engine, 10, time.Minute, domain.NewMagicLinkDomain(node, time.Minute, "http://localhost/magic-link"),
+		userDomain, domain.NewAuditDomain(node), f.userRepo, f.auditRepo, newFakeMagicLinkRepository(),
+		&fakeRateLimitRepository{}, f.mailSender)
+
+	return f
+}
+
+func (f *magicLinkFixture) send(t *testing.T, email string) {
+	ctx := newUserContext(f.alice.ID, scopedef.AdminValidateUser)
+	_, err := f.magicLink.Send(ctx, &dto.MagicLinkSendRequest{Email: email})
+	require.NoError(t, err)
+}
+
+func (f *magicLinkFixture) redeem(signedToken string) (*dto.MagicLinkRedeemResponse, error) {
+	ctx := newUserContext(f.alice.ID, scopedef.AdminValidateUser)
+	return f.magicLink.Redeem(ctx, &dto.MagicLinkRedeemRequest{Token: signedToken})
+}
+
+func TestMagicLinkRedeem(t *testing.T) {
+	f := newMagicLinkFixture(t, true)
+
+	f.send(t, "Alice@Example.com")
+	require.Len(t, f.mailSender.mails, 1)
+	require.Equal(t, "alice@example.com", f.mailSender.mails[0].To)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserMagicLinkSend)
+
+	resp, err := f.redeem(mailToken(t, f.mailSender.mails[0]))
+	require.NoError(t, err)
+	require.Equal(t, f.alice.ID, resp.User.ID)
+
+	_, err = f.redeem("invalid-token")
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+}
+
+func TestMagicLinkCannotBeReplayed(t *testing.T) {
+	f := newMagicLinkFixture(t, true)
+
+	f.send(t, "alice@example.com")
+	signedToken := mailToken(t, f.mailSender.mails[0])
+
+	_, err := f.redeem(signedToken)
+	require.NoError(t, err)
+
+	_, err = f.redeem(signedToken)
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserMagicLinkRedeemFailed)
+}
+
+func TestMagicLinkIsNotSentToUnverifiedEmail(t *testing.T) {
+	f := newMagicLinkFixture(t, false)
+
+	f.send(t, "alice@example.com")
+	require.Empty(t, f.mailSender.mails)
+
+	f.send(t, "nobody@example.com")
+	require.Empty(t, f.mailSender.mails)
+}
+
+func TestMagicLinkRejectsUnverifiedEmail(t *testing.T) {
+	f := newMagicLinkFixture(t, true)
+
+	f.send(t, "alice@example.com")
+	signedToken := mailToken(t, f.mailSender.mails[0])
+
+	// A link sent before the email lost its verification is rejected.
+	user := f.userRepo.users[f.alice.ID]
+	user.EmailVerified = false
+	f.userRepo.users[f.alice.ID] = user
+
+	_, err := f.redeem(signedToken)
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+}
+
+func TestMagicLinkRejectsChangedEmail(t *testing.T) {
+	f := newMagicLinkFixture(t, true)
+
+	f.send(t, "alice@example.com")
+	signedToken := mailToken(t, f.mailSender.mails[0])
+
+	user := f.userRepo.users[f.alice.ID]
+	user.Email = "alice@other.example.com"
+	f.userRepo.users[f.alice.ID] = user
+
+	_, err := f.redeem(signedToken)
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+)
+
+type outboxHandler func(ctx context.Context, msg *domain.OutboxMessage) error
+
+type OutboxUsecase struct {
+	handlers map[string]outboxHandler
+
+	outboxDomain  abstraction.OutboxDomain
+	webhookDomain abstraction.WebhookDomain
+	auditDomain   abstraction.AuditDomain
+
+	outboxRepo          abstraction.OutboxRepository
+	fileRepo            abstraction.FileRepository
+	eventRepo           abstraction.EventRepository
+	webhookRepo         abstraction.WebhookRepository
+	webhookDeliveryRepo abstraction.WebhookDeliveryRepository
+	auditRepo           abstraction.AuditLogRepository
+}
+
+func NewOutboxUsecase(
+	outboxDomain abstraction.OutboxDomain,
+	webhookDomain abstraction.WebhookDomain,
+	auditDomain abstraction.AuditDomain,
+	outboxRepo abstraction.OutboxRepository,
+	fileRepo abstraction.FileRepository,
+	eventRepo abstraction.EventRepository,
+	webhookRepo abstraction.WebhookRepository,
+	webhookDeliveryRepo abstraction.WebhookDeliveryRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *OutboxUsecase {
+	usecase := &OutboxUsecase{
+		outboxDomain:        outboxDomain,
+		webhookDomain:       webhookDomain,
+		auditDomain:         auditDomain,
+		outboxRepo:          outboxRepo,
+		fileRepo:            fileRepo,
+		eventRepo:           eventRepo,
+		webhookRepo:         webhookRepo,
+		webhookDeliveryRepo: webhookDeliveryRepo,
+		auditRepo:           auditRepo,
+	}
+
+	usecase.handlers = map[string]outboxHandler{
+		domain.OutboxTopicFileRefcount: usecase.deliverRefcountChange,
+		domain.OutboxTopicEvent:        usecase.deliverEvent,
+		domain.OutboxTopicWebhookEvent: usecase.fanOutWebhookEvent,
+		domain.OutboxTopicAuditLog:     usecase.appendAuditLog,
+	}
+
+	return usecase
+}
+
+// Relay delivers a batch of pending outbox messages. The messages are leased
+// to this relay before being delivered, so concurrent relays do not deliver
+// the same message, and the state of each message is saved as soon as it is
+// delivered. No transaction is held while calling other services.
+func (usecase *OutboxUsecase) Relay(
+	ctx context.Context,
+	req *dto.OutboxRelayRequest,
+) (*dto.OutboxRelayResponse, error) {
+	msgs, err := usecase.claim(ctx, req.BatchSize)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-claim-outbox-messages")
+	}
+
+	resp := dto.NewOutboxRelayResponse()
+	for _, msg := range msgs {
+		if err := usecase.deliver(ctx, msg); err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-update-outbox-message", "id", msg.ID)
+		}
+
+		if msg.DeliveredAt.IsZero() {
+			resp.Failed++
+		} else {
+			resp.Delivered++
+		}
+	}
+
+	return resp, nil
+}
+
+// deliver runs the handler of the message in a transaction of its own, which
+// also saves the state of the message. The writes of a handler are then
+// committed together with the delivery and take effect exactly once, but the
+// calls to other services are at-least-once: a call whose response was lost
+// fails and is made again. The transaction begins with the first write, so the
+// handlers calling other services do not hold it during the call.
+func (usecase *OutboxUsecase) deliver(ctx context.Context, msg *domain.OutboxMessage) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.handlers[msg.Topic](ctx, msg); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		xcontext.Logger(ctx).Warn("failed-to-deliver-outbox-message",
+			"err", err, "id", msg.ID, "topic", msg.Topic, "attempts", msg.Attempts+1)
+
+		usecase.outboxDomain.SetFailed(msg, err)
+		return usecase.outboxRepo.Update(ctx, msg)
+	}
+
+	usecase.outboxDomain.SetDelivered(msg)
+	if err := usecase.outboxRepo.Update(ctx, msg); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// claim leases a batch of pending messages in a short transaction.
+func (usecase *OutboxUsecase) claim(ctx context.Context, batchSize int) ([]*domain.OutboxMessage, error) {
+	topics := []string{}
+	for topic := range usecase.handlers {
+		topics = append(topics, topic)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	msgs, err := usecase.outboxRepo.ClaimPending(ctx, topics, usecase.outboxDomain.MaxDeliveryAttempts(), batchSize)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	for _, msg := range msgs {
+		usecase.outboxDomain.Lease(msg)
+		if err := usecase.outboxRepo.Update(ctx, msg); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, err
+		}
+	}
+
+	return msgs, nil
+}
+
+// ReviveDead lets the relay deliver again the messages it gave up on.
+func (usecase *OutboxUsecase) ReviveDead(
+	ctx context.Context,
+	req *dto.OutboxReviveDeadRequest,
+) (*dto.OutboxReviveDeadResponse, error) {
+	if _, ok := usecase.handlers[req.Topic]; !ok {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "unknown topic %s", req.Topic)
+	}
+
+	msgs, err := usecase.outboxRepo.GetDead(ctx, req.Topic, usecase.outboxDomain.MaxDeliveryAttempts())
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-dead-outbox-messages", "topic", req.Topic)
+	}
+
+	for _, msg := range msgs {
+		if !req.DryRun {
+			usecase.outboxDomain.Revive(msg)
+			if err := usecase.outboxRepo.Update(ctx, msg); err != nil {
+				return nil, errordef.ErrServer.Hide(err, "failed-to-revive-outbox-message", "id", msg.ID)
+			}
+		}
+	}
+
+	return dto.NewOutboxReviveDeadResponse(msgs), nil
+}
+
+// deliverRefcountChange sends the change to the file service. The file service
+// has no idempotency key, so a change whose response was lost is applied
+// twice when it is delivered again.
+func (usecase *OutboxUsecase) deliverRefcountChange(ctx context.Context, msg *domain.OutboxMessage) error {
+	change, err := usecase.outboxDomain.ParseRefcountChange(msg)
+	if err != nil {
+		return err
+	}
+
+	return usecase.fileRepo.ChangeRefcount(ctx, change.IncOwnershipIDs, change.DecOwnershipIDs)
+}
+
+func (usecase *OutboxUsecase) deliverEvent(ctx context.Context, msg *domain.OutboxMessage) error {
+	event, err := usecase.outboxDomain.ParseEvent(msg)
+	if err != nil {
+		return err
+	}
+
+	return usecase.eventRepo.Publish(ctx, event)
+}
+
+// fanOutWebhookEvent creates a delivery of the event for every webhook
+// subscribing to it. The deliveries are created in the transaction of the
+// message, so they are created exactly once.
+func (usecase *OutboxUsecase) fanOutWebhookEvent(ctx context.Context, msg *domain.OutboxMessage) error {
+	event, err := usecase.outboxDomain.ParseEvent(msg)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := usecase.webhookRepo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveries := []*domain.WebhookDelivery{}
+	for _, webhook := range webhooks {
+		if !usecase.webhookDomain.Subscribes(webhook, event.Type) {
+			continue
+		}
+
+		delivery, err := usecase.webhookDomain.NewDelivery(webhook, event)
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return usecase.webhookDeliveryRepo.Create(ctx, deliveries...)
+}
+
+// appendAuditLog appends the log to the audit chain in the transaction of the
+// message, so it is appended exactly once.
+func (usecase *OutboxUsecase) appendAuditLog(ctx context.Context, msg *domain.OutboxMessage) error {
+	log, err := usecase.outboxDomain.ParseAuditLog(msg)
+	if err != nil {
+		return err
+	}
+
+	return appendAudit(ctx, usecase.auditDomain, usecase.auditRepo, log)
+}
+
+// recordEvent adds the event to the outbox of the current transaction, so it
+// is published and sent to webhooks only if the change causing it is
+// committed.
+func recordEvent(
+	ctx context.Context,
+	outboxDomain abstraction.OutboxDomain,
+	outboxRepo abstraction.OutboxRepository,
+	event *domain.Event,
+) error {
+	msg, err := outboxDomain.NewEvent(event)
+	if err != nil {
+		return err
+	}
+
+	if err := outboxRepo.Create(ctx, msg); err != nil {
+		return err
+	}
+
+	msg, err = outboxDomain.NewWebhookEvent(event)
+	if err != nil {
+		return err
+	}
+
+	return outboxRepo.Create(ctx, msg)
+}
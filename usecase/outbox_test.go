@@ -0,0 +1,243 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/xybor-x/snowflake"
+)
+
+type outboxFixture struct {
+	outboxDomain *domain.OutboxDomain
+	outboxRepo   *fakeOutboxRepository
+	fileRepo     *fakeFileRepository
+	eventRepo    *fakeEventRepository
+	webhookRepo  *fakeWebhookRepository
+	deliveryRepo *fakeWebhookDeliveryRepository
+	auditRepo    *fakeAuditLogRepository
+	usecase      *usecase.OutboxUsecase
+}
+
+func newOutboxFixture(maxAttempts int, backoff time.Duration) *outboxFixture {
+	node := newSnowflakeNode()
+	f := &outboxFixture{
+		outboxDomain: domain.NewOutboxDomain(node, maxAttempts, backoff, backoff, time.Minute),
+		outboxRepo:   newFakeOutboxRepository(),
+		fileRepo:     &fakeFileRepository{},
+		eventRepo:    &fakeEventRepository{},
+		webhookRepo:  &fakeWebhookRepository{},
+		deliveryRepo: &fakeWebhookDeliveryRepository{},
+		auditRepo:    &fakeAuditLogRepository{},
+	}
+
+	f.usecase = usecase.NewOutboxUsecase(
+		f.outboxDomain,
+		domain.NewWebhookDomain(node, 3, time.Second, time.Second, time.Minute),
+		domain.NewAuditDomain(node),
+		f.outboxRepo,
+		f.fileRepo,
+		f.eventRepo,
+		f.webhookRepo,
+		f.deliveryRepo,
+		f.auditRepo,
+	)
+
+	return f
+}
+
+func (f *outboxFixture) addRefcountChange(t *testing.T, inc ...snowflake.ID) *domain.OutboxMessage {
+	msg, err := f.outboxDomain.NewRefcountChange(inc, nil)
+	require.NoError(t, err)
+	require.NoError(t, f.outboxRepo.Create(context.Background(), msg))
+	return msg
+}
+
+func TestOutboxRelayDeliversRefcountChange(t *testing.T) {
+	f := newOutboxFixture(3, time.Second)
+	msg := f.addRefcountChange(t, 42)
+
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Delivered)
+	require.Equal(t, 0, resp.Failed)
+
+	require.Len(t, f.fileRepo.calls, 1)
+	require.Equal(t, []snowflake.ID{42}, f.fileRepo.calls[0].inc)
+
+	saved := f.outboxRepo.get(msg.ID)
+	require.False(t, saved.DeliveredAt.IsZero())
+	require.Equal(t, 1, saved.Attempts)
+
+	resp, err = f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Zero(t, resp.Delivered+resp.Failed)
+	require.Len(t, f.fileRepo.calls, 1)
+}
+
+func TestOutboxRelayRetriesAfterBackoff(t *testing.T) {
+	f := newOutboxFixture(3, 20*time.Millisecond)
+	msg := f.addRefcountChange(t, 42)
+
+	f.fileRepo.err = errFake
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Failed)
+
+	saved := f.outboxRepo.get(msg.ID)
+	require.Equal(t, 1, saved.Attempts)
+	require.Equal(t, errFake.Error(), saved.LastError)
+	require.True(t, saved.DeliveredAt.IsZero())
+
+	// The message is not retried before the end of the backoff.
+	f.fileRepo.err = nil
+	resp, err = f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Zero(t, resp.Delivered+resp.Failed)
+
+	time.Sleep(30 * time.Millisecond)
+	resp, err = f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Delivered)
+
+	saved = f.outboxRepo.get(msg.ID)
+	require.Equal(t, 2, saved.Attempts)
+	require.Empty(t, saved.LastError)
+	require.False(t, saved.DeliveredAt.IsZero())
+	require.Len(t, f.fileRepo.calls, 2)
+}
+
+func TestOutboxRelayGivesUpAndRevives(t *testing.T) {
+	f := newOutboxFixture(2, time.Millisecond)
+	msg := f.addRefcountChange(t, 42)
+
+	f.fileRepo.err = errFake
+	for range 2 {
+		_, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Zero(t, resp.Delivered+resp.Failed)
+
+	dead, err := f.usecase.ReviveDead(context.Background(),
+		&dto.OutboxReviveDeadRequest{Topic: domain.OutboxTopicFileRefcount, DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, dead.Messages, 1)
+	require.Equal(t, 2, f.outboxRepo.get(msg.ID).Attempts)
+
+	_, err = f.usecase.ReviveDead(context.Background(),
+		&dto.OutboxReviveDeadRequest{Topic: domain.OutboxTopicFileRefcount})
+	require.NoError(t, err)
+	require.Zero(t, f.outboxRepo.get(msg.ID).Attempts)
+
+	f.fileRepo.err = nil
+	resp, err = f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Delivered)
+}
+
+func TestOutboxRelayLeasesClaimedMessages(t *testing.T) {
+	f := newOutboxFixture(3, time.Millisecond)
+	first := f.addRefcountChange(t, 1)
+	second := f.addRefcountChange(t, 2)
+
+	// The state of the second message cannot be saved, the first one stays
+	// delivered and the second one is leased, so another relay does not
+	// deliver it again before the end of the lease.
+	f.outboxRepo.failUpdate = second.ID
+	_, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.Error(t, err)
+
+	require.False(t, f.outboxRepo.get(first.ID).DeliveredAt.IsZero())
+	require.True(t, f.outboxRepo.get(second.ID).DeliveredAt.IsZero())
+	require.True(t, f.outboxRepo.get(second.ID).NextAttemptAt.After(time.Now().Add(30*time.Second)))
+
+	f.outboxRepo.failUpdate = 0
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Zero(t, resp.Delivered+resp.Failed)
+	require.Len(t, f.fileRepo.calls, 2)
+}
+
+func TestOutboxRelayPublishesEvents(t *testing.T) {
+	f := newOutboxFixture(3, time.Millisecond)
+	event, err := domain.NewEventDomain(newSnowflakeNode()).NewUserCreated(&domain.User{ID: 7, Username: "alice"})
+	require.NoError(t, err)
+
+	msg, err := f.outboxDomain.NewEvent(event)
+	require.NoError(t, err)
+	require.NoError(t, f.outboxRepo.Create(context.Background(), msg))
+
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Delivered)
+	require.Len(t, f.eventRepo.events, 1)
+	require.Equal(t, event.ID, f.eventRepo.events[0].ID)
+	require.Equal(t, domain.EventUserCreated, f.eventRepo.events[0].Type)
+}
+
+func TestOutboxRelayFansOutWebhookEvents(t *testing.T) {
+	f := newOutboxFixture(3, time.Millisecond)
+	f.webhookRepo.webhooks = []*domain.Webhook{
+		{ID: 1, EventTypes: []string{domain.WebhookAllEvents}},
+		{ID: 2, EventTypes: []string{domain.EventUserCreated}},
+		{ID: 3, EventTypes: []string{domain.EventUserErased}},
+	}
+
+	event, err := domain.NewEventDomain(newSnowflakeNode()).NewUserCreated(&domain.User{ID: 7, Username: "alice"})
+	require.NoError(t, err)
+
+	msg, err := f.outboxDomain.NewWebhookEvent(event)
+	require.NoError(t, err)
+	require.NoError(t, f.outboxRepo.Create(context.Background(), msg))
+
+	// A failed fan-out creates no delivery and is retried.
+	f.deliveryRepo.err = errFake
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Failed)
+	require.Empty(t, f.deliveryRepo.deliveries)
+	require.Equal(t, 1, f.outboxRepo.get(msg.ID).Attempts)
+
+	time.Sleep(5 * time.Millisecond)
+	f.deliveryRepo.err = nil
+	resp, err = f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Delivered)
+
+	require.Len(t, f.deliveryRepo.deliveries, 2)
+	for _, delivery := range f.deliveryRepo.deliveries {
+		require.Contains(t, []snowflake.ID{1, 2}, delivery.WebhookID)
+		require.Equal(t, event.ID, delivery.EventID)
+	}
+}
+
+func TestOutboxRelayAppendsAuditLogs(t *testing.T) {
+	f := newOutboxFixture(3, time.Millisecond)
+	auditDomain := domain.NewAuditDomain(newSnowflakeNode())
+
+	for _, reason := range []string{"unknown_username", "wrong_password"} {
+		log, err := auditDomain.New(domain.AuditActor{}, domain.AuditUserValidateCredentialsFailed,
+			domain.AuditTargetUser, "", map[string]string{"reason": reason})
+		require.NoError(t, err)
+
+		msg, err := f.outboxDomain.NewAuditLog(log)
+		require.NoError(t, err)
+		require.NoError(t, f.outboxRepo.Create(context.Background(), msg))
+	}
+
+	resp, err := f.usecase.Relay(context.Background(), &dto.OutboxRelayRequest{BatchSize: 10})
+	require.NoError(t, err)
+	require.Equal(t, 2, resp.Delivered)
+
+	require.Len(t, f.auditRepo.logs, 2)
+	require.Equal(t, "unknown_username", f.auditRepo.logs[0].Metadata["reason"])
+	require.NoError(t, auditDomain.Verify(f.auditRepo.logs, nil))
+}
@@ -0,0 +1,519 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// PasskeyUsecase lets users register WebAuthn credentials and sign in with
+// them instead of their password. The state of every ceremony is kept in a
+// session between its beginning and its end. The auth server verifies the
+// assertions, like it validates the credentials.
+type PasskeyUsecase struct {
+	tokenEngine token.Engine
+	maxPerUser  int
+
+	passkeyDomain          abstraction.PasskeyDomain
+	reauthenticationDomain abstraction.ReauthenticationDomain
+	auditDomain            abstraction.AuditDomain
+
+	passkeyRepo        abstraction.PasskeyRepository
+	passkeySessionRepo abstraction.PasskeySessionRepository
+	userRepo           abstraction.UserRepository
+	auditRepo          abstraction.AuditLogRepository
+
+	relyingParty abstraction.WebAuthnRelyingParty
+}
+
+func NewPasskeyUsecase(
+	tokenEngine token.Engine,
+	maxPerUser int,
+	passkeyDomain abstraction.PasskeyDomain,
+	reauthenticationDomain abstraction.ReauthenticationDomain,
+	auditDomain abstraction.AuditDomain,
+	passkeyRepo abstraction.PasskeyRepository,
+	passkeySessionRepo abstraction.PasskeySessionRepository,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+	relyingParty abstraction.WebAuthnRelyingParty,
+) *PasskeyUsecase {
+	return &PasskeyUsecase{
+		tokenEngine:            tokenEngine,
+		maxPerUser:             maxPerUser,
+		passkeyDomain:          passkeyDomain,
+		reauthenticationDomain: reauthenticationDomain,
+		auditDomain:            auditDomain,
+		passkeyRepo:            passkeyRepo,
+		passkeySessionRepo:     passkeySessionRepo,
+		userRepo:               userRepo,
+		auditRepo:              auditRepo,
+		relyingParty:           relyingParty,
+	}
+}
+
+// BeginRegistration starts the registration of a passkey of the requesting
+// user. The changes of the passkeys require the passkey scope, which personal
+// access tokens cannot be granted.
+func (usecase *PasskeyUsecase) BeginRegistration(
+	ctx context.Context,
+	req *dto.PasskeyBeginRegistrationRequest,
+) (*dto.PasskeyBeginRegistrationResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserPasskey)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := usecase.getPasskeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passkeys) >= usecase.maxPerUser {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "a user has at most %d passkeys", usecase.maxPerUser)
+	}
+
+	options, state, err := usecase.relyingParty.BeginRegistration(user, passkeys)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-begin-passkey-registration", "uid", userID)
+	}
+
+	session, err := usecase.saveSession(ctx, domain.PasskeyCeremonyRegistration, userID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewPasskeyBeginRegistrationResponse(session, options), nil
+}
+
+// FinishRegistration verifies the credential created by the authenticator and
+// saves it as a passkey of the requesting user. The user must have just
+// re-authenticated, so a stolen access token cannot add a passkey.
+func (usecase *PasskeyUsecase) FinishRegistration(
+	ctx context.Context,
+	req *dto.PasskeyFinishRegistrationRequest,
+) (*dto.PasskeyFinishRegistrationResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserPasskey)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = requireReauthentication(ctx, usecase.tokenEngine, usecase.reauthenticationDomain,
+		user, req.ReauthenticationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := usecase.takeSession(ctx, req.SessionID, domain.PasskeyCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.UserID != userID {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid or expired passkey session")
+	}
+
+	credential, err := usecase.relyingParty.FinishRegistration(user, session.Data, req.Credential)
+	if err != nil {
+		if errors.Is(err, abstraction.ErrCeremonyFailed) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid credential").
+				Hide(err, "failed-to-finish-passkey-registration")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-finish-passkey-registration", "uid", userID)
+	}
+
+	passkey, err := usecase.passkeyDomain.New(userID, req.Name, credential)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-passkey").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	passkeys, err := usecase.getPasskeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passkeys) >= usecase.maxPerUser {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "a user has at most %d passkeys", usecase.maxPerUser)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.passkeyRepo.Create(ctx, passkey); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return nil, xerror.Enrich(errordef.ErrDuplicated, "the passkey has already been registered")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-passkey", "uid", userID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserPasskeyRegister,
+		domain.AuditTargetUser, userID.String(), map[string]string{
+			"passkey_id":       passkey.ID.String(),
+			"name":             passkey.Name,
+			"attestation_type": passkey.AttestationType,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", userID)
+	}
+
+	return dto.NewPasskeyFinishRegistrationResponse(passkey), nil
+}
+
+func (usecase *PasskeyUsecase) List(
+	ctx context.Context,
+	req *dto.PasskeyListRequest,
+) (*dto.PasskeyListResponse, error) {
+	userID, err := requireUserSubject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeys, err := usecase.getPasskeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewPasskeyListResponse(passkeys), nil
+}
+
+func (usecase *PasskeyUsecase) Rename(
+	ctx context.Context,
+	req *dto.PasskeyRenameRequest,
+) (*dto.PasskeyRenameResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserPasskey)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := usecase.getOwnPasskey(ctx, userID, req.PasskeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := usecase.passkeyDomain.Rename(passkey, req.Name); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-rename-passkey").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	if err := usecase.passkeyRepo.UpdateName(ctx, passkey); err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-passkey", "passkey_id", passkey.ID)
+	}
+
+	return dto.NewPasskeyRenameResponse(passkey), nil
+}
+
+func (usecase *PasskeyUsecase) Delete(
+	ctx context.Context,
+	req *dto.PasskeyDeleteRequest,
+) (*dto.PasskeyDeleteResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserPasskey)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := usecase.getOwnPasskey(ctx, userID, req.PasskeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.passkeyRepo.Delete(ctx, passkey.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-delete-passkey", "passkey_id", passkey.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserPasskeyDelete,
+		domain.AuditTargetUser, userID.String(), map[string]string{
+			"passkey_id": passkey.ID.String(),
+			"name":       passkey.Name,
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", userID)
+	}
+
+	return dto.NewPasskeyDeleteResponse(), nil
+}
+
+// BeginAssertion starts a sign-in with a passkey. The authenticator chooses a
+// discoverable credential if no username is given, or if the user is unknown
+// or has no passkey, so the response does not tell whether the user exists.
+func (usecase *PasskeyUsecase) BeginAssertion(
+	ctx context.Context,
+	req *dto.PasskeyBeginAssertionRequest,
+) (*dto.PasskeyBeginAssertionResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	var user *domain.User
+	var passkeys []*domain.Passkey
+	if req.Username != "" {
+		var err error
+		user, err = usecase.userRepo.GetByUsername(ctx, req.Username)
+		if err != nil && !errors.Is(err, errordef.ErrNotFound) {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "username", req.Username)
+		}
+
+		if user != nil {
+			if passkeys, err = usecase.getPasskeys(ctx, user.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(passkeys) == 0 {
+			user = nil
+		}
+	}
+
+	options, state, err := usecase.relyingParty.BeginAssertion(user, passkeys)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-begin-passkey-assertion")
+	}
+
+	var userID snowflake.ID
+	if user != nil {
+		userID = user.ID
+	}
+
+	session, err := usecase.saveSession(ctx, domain.PasskeyCeremonyAssertion, userID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewPasskeyBeginAssertionResponse(session, options), nil
+}
+
+// VerifyAssertion verifies the assertion of the authenticator and returns the
+// user of the passkey with a re-authentication token, the failures are
+// audited.
+func (usecase *PasskeyUsecase) VerifyAssertion(
+	ctx context.Context,
+	req *dto.PasskeyVerifyAssertionRequest,
+) (*dto.PasskeyVerifyAssertionResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	session, err := usecase.takeSession(ctx, req.SessionID, domain.PasskeyCeremonyAssertion)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionUserID := ""
+	if session.UserID != 0 {
+		sessionUserID = session.UserID.String()
+	}
+
+	credentialID, err := usecase.relyingParty.AssertionCredentialID(req.Credential)
+	if err != nil {
+		return nil, usecase.assertionFailed(ctx, sessionUserID, "invalid_response", err)
+	}
+
+	passkey, err := usecase.passkeyRepo.GetByCredentialID(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, usecase.assertionFailed(ctx, sessionUserID, "unknown_passkey", nil)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-passkey")
+	}
+
+	if session.UserID != 0 && session.UserID != passkey.UserID {
+		return nil, usecase.assertionFailed(ctx, sessionUserID, "passkey_mismatch", nil)
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, passkey.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, usecase.assertionFailed(ctx, passkey.UserID.String(), "unknown_user", nil)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", passkey.UserID)
+	}
+
+	passkeys, err := usecase.getPasskeys(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := usecase.relyingParty.FinishAssertion(user, passkeys, session.Data, req.Credential)
+	if err != nil {
+		if errors.Is(err, abstraction.ErrCeremonyFailed) {
+			return nil, usecase.assertionFailed(ctx, user.ID.String(), "invalid_signature", err)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-finish-passkey-assertion", "uid", user.ID)
+	}
+
+	if err := usecase.passkeyDomain.Use(passkey, credential); err != nil {
+		if errors.Is(err, domain.ErrPasskeyCloned) {
+			return nil, usecase.assertionFailed(ctx, user.ID.String(), "cloned_passkey", err)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-use-passkey", "passkey_id", passkey.ID)
+	}
+
+	if !user.Active {
+		return nil, usecase.assertionFailed(ctx, user.ID.String(), "inactive_user", nil)
+	}
+
+	if user.Pending {
+		return nil, usecase.assertionFailed(ctx, user.ID.String(), "pending_approval", nil)
+	}
+
+	claims := usecase.reauthenticationDomain.NewToken(user, domain.ReauthenticationMethodPasskey)
+	signedToken, err := usecase.tokenEngine.Generate(ctx, claims)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-generate-reauthentication-token")
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.passkeyRepo.UpdateUsage(ctx, passkey); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-passkey-usage", "passkey_id", passkey.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserReauthenticate,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"method": domain.ReauthenticationMethodPasskey})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewPasskeyVerifyAssertionResponse(user, signedToken, time.Unix(int64(claims.ExpiresAt), 0)), nil
+}
+
+func (usecase *PasskeyUsecase) saveSession(
+	ctx context.Context,
+	ceremony string,
+	userID snowflake.ID,
+	state []byte,
+) (*domain.PasskeySession, error) {
+	session, err := usecase.passkeyDomain.NewSession(ceremony, userID, state)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-new-passkey-session")
+	}
+
+	if err := usecase.passkeySessionRepo.Save(ctx, session); err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-save-passkey-session")
+	}
+
+	return session, nil
+}
+
+// takeSession returns the session of the ceremony, the session cannot be
+// taken again.
+func (usecase *PasskeyUsecase) takeSession(
+	ctx context.Context,
+	sessionID, ceremony string,
+) (*domain.PasskeySession, error) {
+	if sessionID == "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require session_id")
+	}
+
+	session, err := usecase.passkeySessionRepo.Take(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "invalid or expired passkey session")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-take-passkey-session")
+	}
+
+	if err := usecase.passkeyDomain.CheckSession(session, ceremony); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-check-passkey-session").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	return session, nil
+}
+
+func (usecase *PasskeyUsecase) getUser(ctx context.Context, userID snowflake.ID) (*domain.User, error) {
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", userID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	return user, nil
+}
+
+func (usecase *PasskeyUsecase) getPasskeys(ctx context.Context, userID snowflake.ID) ([]*domain.Passkey, error) {
+	passkeys, err := usecase.passkeyRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-passkeys", "uid", userID)
+	}
+
+	return passkeys, nil
+}
+
+// getOwnPasskey hides the passkeys of the other users as not found.
+func (usecase *PasskeyUsecase) getOwnPasskey(
+	ctx context.Context,
+	userID, passkeyID snowflake.ID,
+) (*domain.Passkey, error) {
+	passkey, err := usecase.passkeyRepo.GetByID(ctx, passkeyID)
+	if err != nil && !errors.Is(err, errordef.ErrNotFound) {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-passkey", "passkey_id", passkeyID)
+	}
+
+	if err != nil || passkey.UserID != userID {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found passkey with id %d", passkeyID)
+	}
+
+	return passkey, nil
+}
+
+// assertionFailed audits a failed assertion in its own transaction and returns
+// the error of the request, the cause is only logged.
+func (usecase *PasskeyUsecase) assertionFailed(ctx context.Context, userID, reason string, cause error) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserPasskeyAssertionFailed,
+		domain.AuditTargetUser, userID, map[string]string{"reason": reason})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", userID)
+	}
+
+	if cause == nil {
+		cause = errors.New(reason)
+	}
+
+	return xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid passkey").
+		Hide(cause, "failed-to-verify-passkey-assertion", "reason", reason)
+}
@@ -0,0 +1,256 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/infras/service/webauthn"
+	"github.com/todennus/user-service/infras/service/webauthn/webauthntest"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+)
+
+const (
+	passkeyRPID   = "example.com"
+	passkeyOrigin = "https://auth.example.com"
+)
+
+type passkeyFixture struct {
+	engine                 token.Engine
+	reauthenticationDomain *domain.ReauthenticationDomain
+	userRepo               *fakeUserRepository
+	passkeyRepo            *fakePasskeyRepository
+	auditRepo              *fakeAuditLogRepository
+	alice                  *domain.User
+	bob                    *domain.User
+
+	passkey *usecase.PasskeyUsecase
+}
+
+func newPasskeyFixture(t *testing.T) *passkeyFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	alice, err := userDomain.NewFirst("alice", "P@ssw0rd123")
+	require.NoError(t, err)
+
+	bob, err := userDomain.NewFirst("bobby", "P@ssw0rd123")
+	require.NoError(t, err)
+
+	engine := token.NewJWTEngine()
+	require.NoError(t, engine.WithHMAC("secret"))
+
+	relyingParty, err := webauthn.NewRelyingParty(webauthn.RelyingPartyConfig{
+		ID:               passkeyRPID,
+		DisplayName:      "Todennus",
+		Origins:          []string{passkeyOrigin},
+		UserVerification: "preferred",
+		ResidentKey:      "preferred",
+	})
+	require.NoError(t, err)
+
+	f := &passkeyFixture{
+		engine:                 engine,
+		reauthenticationDomain: domain.NewReauthenticationDomain(node, time.Minute),
+		userRepo:               newFakeUserRepository(alice, bob),
+		passkeyRepo:            newFakePasskeyRepository(),
+		auditRepo:              &fakeAuditLogRepository{},
+		alice:                  alice,
+		bob:                    bob,
+	}
+
+	f.passkey = usecase.NewPasskeyUsecase(
+		engine, 5, domain.NewPasskeyDomain(node, time.Minute), f.reauthenticationDomain,
+		domain.NewAuditDomain(node), f.passkeyRepo, newFakePasskeySessionRepository(), f.userRepo,
+		f.auditRepo, relyingParty)
+
+	return f
+}
+
+// reauthenticate returns a re-authentication token of the user, as if they had
+// just confirmed their password.
+func (f *passkeyFixture) reauthenticate(t *testing.T, user *domain.User) string {
+	claims := f.reauthenticationDomain.NewToken(user, domain.ReauthenticationMethodPassword)
+	signedToken, err := f.engine.Generate(context.Background(), claims)
+	require.NoError(t, err)
+	return signedToken
+}
+
+// beginRegistration begins a registration with the passkey scope and returns
+// its session with the credential created by the authenticator.
+func (f *passkeyFixture) beginRegistration(
+	t *testing.T,
+	user *domain.User,
+	authenticator *webauthntest.Authenticator,
+) (string, []byte) {
+	resp, err := f.passkey.BeginRegistration(newUserContext(user.ID, usecase.UserUpdateUserPasskey),
+		&dto.PasskeyBeginRegistrationRequest{})
+	require.NoError(t, err)
+
+	credential, err := authenticator.Create(resp.Options)
+	require.NoError(t, err)
+
+	return resp.SessionID, credential
+}
+
+func (f *passkeyFixture) finishRegistration(
+	user *domain.User,
+	sessionID string,
+	credential []byte,
+	reauthenticationToken string,
+) (*dto.PasskeyFinishRegistrationResponse, error) {
+	return f.passkey.FinishRegistration(newUserContext(user.ID, usecase.UserUpdateUserPasskey),
+		&dto.PasskeyFinishRegistrationRequest{
+			SessionID:             sessionID,
+			Credential:            credential,
+			ReauthenticationToken: reauthenticationToken,
+		})
+}
+
+func (f *passkeyFixture) register(
+	t *testing.T,
+	user *domain.User,
+	authenticator *webauthntest.Authenticator,
+) *dto.PasskeyFinishRegistrationResponse {
+	sessionID, credential := f.beginRegistration(t, user, authenticator)
+	resp, err := f.finishRegistration(user, sessionID, credential, f.reauthenticate(t, user))
+	require.NoError(t, err)
+	return resp
+}
+
+// assert runs an assertion with the authenticator as the auth server.
+func (f *passkeyFixture) assert(
+	t *testing.T,
+	authenticator *webauthntest.Authenticator,
+) (*dto.PasskeyVerifyAssertionResponse, error) {
+	ctx := newUserContext(f.alice.ID, scopedef.AdminValidateUser)
+	resp, err := f.passkey.BeginAssertion(ctx, &dto.PasskeyBeginAssertionRequest{})
+	require.NoError(t, err)
+
+	credential, err := authenticator.Get(resp.Options)
+	require.NoError(t, err)
+
+	return f.passkey.VerifyAssertion(ctx, &dto.PasskeyVerifyAssertionRequest{
+		SessionID:  resp.SessionID,
+		Credential: credential,
+	})
+}
+
+func TestPasskeyChangesRequirePasskeyScope(t *testing.T) {
+	f := newPasskeyFixture(t)
+
+	_, err := f.passkey.BeginRegistration(newUserContext(f.alice.ID, scopedef.UserReadUserProfile),
+		&dto.PasskeyBeginRegistrationRequest{})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	passkey := f.register(t, f.alice, webauthntest.New(passkeyRPID, passkeyOrigin)).Passkey
+
+	_, err = f.passkey.Rename(newUserContext(f.alice.ID, scopedef.UserReadUserProfile),
+		&dto.PasskeyRenameRequest{PasskeyID: passkey.ID, Name: "Laptop"})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.passkey.Delete(newUserContext(f.alice.ID, scopedef.UserReadUserProfile),
+		&dto.PasskeyDeleteRequest{PasskeyID: passkey.ID})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.passkey.Delete(newUserContext(f.alice.ID, usecase.UserUpdateUserPasskey),
+		&dto.PasskeyDeleteRequest{PasskeyID: passkey.ID})
+	require.NoError(t, err)
+	require.Empty(t, f.passkeyRepo.passkeys)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserPasskeyDelete)
+}
+
+func TestPasskeyRegistrationRequiresReauthentication(t *testing.T) {
+	f := newPasskeyFixture(t)
+
+	sessionID, credential := f.beginRegistration(t, f.alice, webauthntest.New(passkeyRPID, passkeyOrigin))
+
+	_, err := f.finishRegistration(f.alice, sessionID, credential, "")
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.finishRegistration(f.alice, sessionID, credential, "invalid-token")
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.finishRegistration(f.alice, sessionID, credential, f.reauthenticate(t, f.bob))
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+	require.Empty(t, f.passkeyRepo.passkeys)
+
+	// The refused attempts do not consume the session.
+	resp, err := f.finishRegistration(f.alice, sessionID, credential, f.reauthenticate(t, f.alice))
+	require.NoError(t, err)
+	require.Equal(t, f.alice.ID, f.passkeyRepo.passkeys[resp.Passkey.ID].UserID)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserPasskeyRegister)
+}
+
+func TestPasskeyAssertionReturnsReauthenticationToken(t *testing.T) {
+	f := newPasskeyFixture(t)
+
+	authenticator := webauthntest.New(passkeyRPID, passkeyOrigin)
+	f.register(t, f.alice, authenticator)
+
+	resp, err := f.assert(t, authenticator)
+	require.NoError(t, err)
+	require.Equal(t, f.alice.ID, resp.User.ID)
+
+	claims := &domain.ReauthenticationToken{}
+	require.NoError(t, f.engine.Validate(context.Background(), resp.ReauthenticationToken, claims))
+	require.Equal(t, domain.ReauthenticationMethodPasskey, claims.Method)
+	require.Equal(t, f.alice.ID.String(), claims.UserID)
+
+	// The token lets the user register another passkey.
+	sessionID, credential := f.beginRegistration(t, f.alice, webauthntest.New(passkeyRPID, passkeyOrigin))
+	_, err = f.finishRegistration(f.alice, sessionID, credential, resp.ReauthenticationToken)
+	require.NoError(t, err)
+	require.Len(t, f.passkeyRepo.passkeys, 2)
+}
+
+func TestPasskeyAssertionRejectsClonedAuthenticator(t *testing.T) {
+	f := newPasskeyFixture(t)
+
+	authenticator := webauthntest.New(passkeyRPID, passkeyOrigin)
+	f.register(t, f.alice, authenticator)
+
+	_, err := f.assert(t, authenticator)
+	require.NoError(t, err)
+
+	authenticator.SignCount = 0
+	_, err = f.assert(t, authenticator)
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserPasskeyAssertionFailed)
+}
+
+func TestPasskeyAssertionCannotBeReplayed(t *testing.T) {
+	f := newPasskeyFixture(t)
+
+	authenticator := webauthntest.New(passkeyRPID, passkeyOrigin)
+	f.register(t, f.alice, authenticator)
+
+	ctx := newUserContext(f.alice.ID, scopedef.AdminValidateUser)
+	begin, err := f.passkey.BeginAssertion(ctx, &dto.PasskeyBeginAssertionRequest{Username: "alice"})
+	require.NoError(t, err)
+
+	credential, err := authenticator.Get(begin.Options)
+	require.NoError(t, err)
+
+	req := &dto.PasskeyVerifyAssertionRequest{SessionID: begin.SessionID, Credential: credential}
+	_, err = f.passkey.VerifyAssertion(ctx, req)
+	require.NoError(t, err)
+
+	_, err = f.passkey.VerifyAssertion(ctx, req)
+	require.ErrorIs(t, err, errordef.ErrRequestInvalid)
+}
+
+func TestPersonalAccessTokenCannotBeGrantedPasskeyScope(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	_, err := f.create(f.admin, usecase.UserUpdateUserPasskey)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
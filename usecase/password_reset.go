@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// PasswordResetUsecase lets the users set a new password without the current
+// one, for example the imported users who must reset their password. An admin
+// issues a short-lived signed token and hands it to the user, the token is
+// used at most once.
+type PasswordResetUsecase struct {
+	tokenEngine token.Engine
+
+	passwordResetDomain abstraction.PasswordResetDomain
+	userDomain          abstraction.UserDomain
+	auditDomain         abstraction.AuditDomain
+
+	userRepo            abstraction.UserRepository
+	auditRepo           abstraction.AuditLogRepository
+	passwordHistoryRepo abstraction.PasswordHistoryRepository
+}
+
+func NewPasswordResetUsecase(
+	tokenEngine token.Engine,
+	passwordResetDomain abstraction.PasswordResetDomain,
+	userDomain abstraction.UserDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+	passwordHistoryRepo abstraction.PasswordHistoryRepository,
+) *PasswordResetUsecase {
+	return &PasswordResetUsecase{
+		tokenEngine:         tokenEngine,
+		passwordResetDomain: passwordResetDomain,
+		userDomain:          userDomain,
+		auditDomain:         auditDomain,
+		userRepo:            userRepo,
+		auditRepo:           auditRepo,
+		passwordHistoryRepo: passwordHistoryRepo,
+	}
+}
+
+// Issue requires the admin role besides the admin create:user scope, the
+// token lets its holder take over the account.
+func (usecase *PasswordResetUsecase) Issue(
+	ctx context.Context,
+	req *dto.PasswordResetIssueRequest,
+) (*dto.PasswordResetIssueResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if err := requireAdminRole(ctx, usecase.userRepo); err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	if user.LDAPID != "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the password is managed by the directory")
+	}
+
+	if usecase.userDomain.IsErased(user) {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+	}
+
+	claims := usecase.passwordResetDomain.NewToken(user)
+	signedToken, err := usecase.tokenEngine.Generate(ctx, claims)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-generate-password-reset-token")
+	}
+
+	expiresAt := time.Unix(int64(claims.ExpiresAt), 0)
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserPasswordResetIssue,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"token_id":   claims.ID,
+			"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewPasswordResetIssueResponse(signedToken, expiresAt), nil
+}
+
+// Reset sets the password of the user of the token, the request is not
+// authenticated, the token is the credential. The new password follows the
+// history rules of ChangePassword.
+func (usecase *PasswordResetUsecase) Reset(
+	ctx context.Context,
+	req *dto.PasswordResetRequest,
+) (*dto.PasswordResetResponse, error) {
+	if req.Token == "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require token")
+	}
+
+	claims := &domain.PasswordResetToken{}
+	if err := usecase.tokenEngine.Validate(ctx, req.Token, claims); err != nil {
+		return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid or expired token").
+			Hide(err, "failed-to-validate-password-reset-token")
+	}
+
+	// The claims are validated, the user id is well-formed.
+	userID, _ := snowflake.ParseString(claims.UserID)
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid or expired token")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	if err := usecase.passwordResetDomain.CheckToken(user, claims); err != nil {
+		return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid or expired token").
+			Hide(err, "failed-to-check-password-reset-token", "uid", user.ID)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := changePassword(ctx, usecase.userDomain, usecase.userRepo, usecase.passwordHistoryRepo,
+		user, req.NewPassword); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserPasswordReset,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"token_id": claims.ID})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewPasswordResetResponse(), nil
+}
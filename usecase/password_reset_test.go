@@ -0,0 +1,136 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+)
+
+type passwordResetFixture struct {
+	userDomain  *domain.UserDomain
+	userRepo    *fakeUserRepository
+	historyRepo *fakePasswordHistoryRepository
+	auditRepo   *fakeAuditLogRepository
+	admin       *domain.User
+	usecase     *usecase.PasswordResetUsecase
+}
+
+func newPasswordResetFixture(t *testing.T) *passwordResetFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{HistorySize: 3})
+	require.NoError(t, err)
+
+	admin, err := userDomain.NewFirst("admin", "Adm1nP@ssw0rd")
+	require.NoError(t, err)
+
+	engine := token.NewJWTEngine()
+	require.NoError(t, engine.WithHMAC("secret"))
+
+	f := &passwordResetFixture{
+		userDomain:  userDomain,
+		userRepo:    newFakeUserRepository(admin),
+		historyRepo: &fakePasswordHistoryRepository{},
+		auditRepo:   &fakeAuditLogRepository{},
+		admin:       admin,
+	}
+
+	f.usecase = usecase.NewPasswordResetUsecase(
+		engine,
+		domain.NewPasswordResetDomain(node, time.Hour),
+		userDomain,
+		domain.NewAuditDomain(node),
+		f.userRepo,
+		f.auditRepo,
+		f.historyRepo,
+	)
+
+	return f
+}
+
+// addImportedUser adds a user who must reset their password.
+func (f *passwordResetFixture) addImportedUser(t *testing.T) *domain.User {
+	user, err := f.userDomain.New("imported", "0ldP@ssw0rd")
+	require.NoError(t, err)
+
+	user.PasswordResetRequired = true
+	require.NoError(t, f.userRepo.Create(context.Background(), user))
+	return user
+}
+
+func (f *passwordResetFixture) issue(t *testing.T, user *domain.User) string {
+	resp, err := f.usecase.Issue(newUserContext(f.admin.ID, scopedef.AdminCreateUser), &dto.PasswordResetIssueRequest{UserID: user.ID})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Token)
+	require.True(t, resp.ExpiresAt.After(time.Now()))
+	return resp.Token
+}
+
+func TestPasswordResetSetsPasswordWithoutCurrentOne(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	user := f.addImportedUser(t)
+	signedToken := f.issue(t, user)
+
+	_, err := f.usecase.Reset(newRequestContext(), &dto.PasswordResetRequest{
+		Token:       signedToken,
+		NewPassword: "n3wP@ssw0rd",
+	})
+	require.NoError(t, err)
+
+	updated, err := f.userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, updated.PasswordResetRequired)
+	require.NoError(t, f.userDomain.Validate(updated.HashedPass, "n3wP@ssw0rd"))
+	require.Len(t, f.historyRepo.histories, 1)
+	require.Equal(t,
+		[]string{domain.AuditUserPasswordResetIssue, domain.AuditUserPasswordReset},
+		f.auditRepo.actions())
+}
+
+func TestPasswordResetTokenIsUsedOnce(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	user := f.addImportedUser(t)
+	signedToken := f.issue(t, user)
+
+	_, err := f.usecase.Reset(newRequestContext(), &dto.PasswordResetRequest{
+		Token:       signedToken,
+		NewPassword: "n3wP@ssw0rd",
+	})
+	require.NoError(t, err)
+
+	_, err = f.usecase.Reset(newRequestContext(), &dto.PasswordResetRequest{
+		Token:       signedToken,
+		NewPassword: "an0th3rP@ssw0rd",
+	})
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+}
+
+func TestPasswordResetRejectsInvalidTokens(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	user := f.addImportedUser(t)
+	signedToken := f.issue(t, user)
+
+	for _, signedToken := range []string{"", "not-a-token", signedToken + "x"} {
+		_, err := f.usecase.Reset(newRequestContext(), &dto.PasswordResetRequest{
+			Token:       signedToken,
+			NewPassword: "n3wP@ssw0rd",
+		})
+		require.Error(t, err)
+	}
+}
+
+func TestPasswordResetIssueRequiresAdminRole(t *testing.T) {
+	f := newPasswordResetFixture(t)
+	user := f.addImportedUser(t)
+
+	_, err := f.usecase.Issue(newUserContext(user.ID, scopedef.AdminCreateUser), &dto.PasswordResetIssueRequest{UserID: user.ID})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/scope"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// PersonalAccessTokenUsecase lets users create tokens for their scripts, so
+// the scripts do not need their password. A token only grants a subset of the
+// scope of the access token which created it. The auth server resolves the
+// tokens into their user and scope with Introspect.
+type PersonalAccessTokenUsecase struct {
+	defaultExpiration time.Duration
+	maxPerUser        int
+
+	personalAccessTokenDomain abstraction.PersonalAccessTokenDomain
+	userDomain                abstraction.UserDomain
+	auditDomain               abstraction.AuditDomain
+
+	personalAccessTokenRepo abstraction.PersonalAccessTokenRepository
+	userRepo                abstraction.UserRepository
+	auditRepo               abstraction.AuditLogRepository
+}
+
+func NewPersonalAccessTokenUsecase(
+	defaultExpiration time.Duration,
+	maxPerUser int,
+	personalAccessTokenDomain abstraction.PersonalAccessTokenDomain,
+	userDomain abstraction.UserDomain,
+	auditDomain abstraction.AuditDomain,
+	personalAccessTokenRepo abstraction.PersonalAccessTokenRepository,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *PersonalAccessTokenUsecase {
+	return &PersonalAccessTokenUsecase{
+		defaultExpiration:         defaultExpiration,
+		maxPerUser:                maxPerUser,
+		personalAccessTokenDomain: personalAccessTokenDomain,
+		userDomain:                userDomain,
+		auditDomain:               auditDomain,
+		personalAccessTokenRepo:   personalAccessTokenRepo,
+		userRepo:                  userRepo,
+		auditRepo:                 auditRepo,
+	}
+}
+
+// Create creates a token of the requesting user and returns it, the token
+// cannot be read again.
+func (usecase *PersonalAccessTokenUsecase) Create(
+	ctx context.Context,
+	req *dto.PersonalAccessTokenCreateRequest,
+) (*dto.PersonalAccessTokenCreateResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserToken)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := xcontext.Scope(ctx)
+	requested := scope.Scopes{}
+	for _, s := range scopedef.Engine.ParseAnyScopes(req.Scope) {
+		if requested.Contains(s) {
+			continue
+		}
+
+		if personalAccessTokenForbiddenScopes.Contains(s) {
+			return nil, xerror.Enrich(errordef.ErrForbidden, "the scope %s cannot be granted to a personal access token", s.Scope())
+		}
+
+		if !granted.Contains(s) {
+			return nil, xerror.Enrich(errordef.ErrForbidden, "the scope %s is not granted to the current token", s.Scope())
+		}
+
+		requested = append(requested, s)
+	}
+
+	expiration := req.Expiration
+	if expiration == 0 {
+		expiration = usecase.defaultExpiration
+	}
+
+	pat, token, err := usecase.personalAccessTokenDomain.New(userID, req.Name, requested.String(), expiration)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-personal-access-token").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	// The user is locked until the token is created, so concurrent requests
+	// cannot exceed the limit.
+	if err := usecase.personalAccessTokenRepo.LockUser(ctx, userID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-lock-user", "uid", userID)
+	}
+
+	n, err := usecase.personalAccessTokenRepo.CountActiveByUserID(ctx, userID)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-count-personal-access-tokens", "uid", userID)
+	}
+
+	if n >= usecase.maxPerUser {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid,
+			"a user has at most %d active personal access tokens", usecase.maxPerUser)
+	}
+
+	if err := usecase.personalAccessTokenRepo.Create(ctx, pat); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-personal-access-token", "uid", userID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditPersonalAccessTokenCreate,
+		domain.AuditTargetPersonalAccessToken, pat.ID.String(), map[string]string{
+			"user_id":    userID.String(),
+			"name":       pat.Name,
+			"scope":      pat.Scope,
+			"expires_at": pat.ExpiresAt.Format(time.RFC3339),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "token_id", pat.ID)
+	}
+
+	return dto.NewPersonalAccessTokenCreateResponse(pat, usecase.personalAccessTokenDomain.Status(pat), token), nil
+}
+
+func (usecase *PersonalAccessTokenUsecase) List(
+	ctx context.Context,
+	req *dto.PersonalAccessTokenListRequest,
+) (*dto.PersonalAccessTokenListResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserToken)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := usecase.personalAccessTokenRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-personal-access-tokens", "uid", userID)
+	}
+
+	statuses := []string{}
+	for _, token := range tokens {
+		statuses = append(statuses, usecase.personalAccessTokenDomain.Status(token))
+	}
+
+	return dto.NewPersonalAccessTokenListResponse(tokens, statuses), nil
+}
+
+// Revoke revokes a token of the requesting user, revoking a revoked token
+// returns it unchanged.
+func (usecase *PersonalAccessTokenUsecase) Revoke(
+	ctx context.Context,
+	req *dto.PersonalAccessTokenRevokeRequest,
+) (*dto.PersonalAccessTokenRevokeResponse, error) {
+	userID, err := requireUserScope(ctx, UserUpdateUserToken)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := usecase.personalAccessTokenRepo.GetByID(ctx, req.TokenID)
+	if err != nil && !errors.Is(err, errordef.ErrNotFound) {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-personal-access-token", "token_id", req.TokenID)
+	}
+
+	if err != nil || token.UserID != userID {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found personal access token with id %d", req.TokenID)
+	}
+
+	if !token.RevokedAt.IsZero() {
+		return dto.NewPersonalAccessTokenRevokeResponse(token, usecase.personalAccessTokenDomain.Status(token)), nil
+	}
+
+	usecase.personalAccessTokenDomain.Revoke(token)
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.personalAccessTokenRepo.Revoke(ctx, token); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-revoke-personal-access-token", "token_id", token.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditPersonalAccessTokenRevoke,
+		domain.AuditTargetPersonalAccessToken, token.ID.String(), map[string]string{
+			"user_id": userID.String(),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "token_id", token.ID)
+	}
+
+	return dto.NewPersonalAccessTokenRevokeResponse(token, usecase.personalAccessTokenDomain.Status(token)), nil
+}
+
+// Introspect resolves a token into its user and scope, and records its use.
+// An unusable token is not an error, the response is inactive instead.
+func (usecase *PersonalAccessTokenUsecase) Introspect(
+	ctx context.Context,
+	req *dto.PersonalAccessTokenIntrospectRequest,
+) (*dto.PersonalAccessTokenIntrospectResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if !usecase.personalAccessTokenDomain.IsToken(req.Token) {
+		return dto.NewPersonalAccessTokenIntrospectResponse(nil, nil, ""), nil
+	}
+
+	token, err := usecase.personalAccessTokenRepo.GetByHash(ctx, usecase.personalAccessTokenDomain.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return dto.NewPersonalAccessTokenIntrospectResponse(nil, nil, ""), nil
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-personal-access-token")
+	}
+
+	if usecase.personalAccessTokenDomain.Status(token) != domain.PersonalAccessTokenStatusActive {
+		return dto.NewPersonalAccessTokenIntrospectResponse(nil, nil, ""), nil
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return dto.NewPersonalAccessTokenIntrospectResponse(nil, nil, ""), nil
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", token.UserID)
+	}
+
+	if !user.Active || user.Pending || usecase.userDomain.IsErased(user) {
+		return dto.NewPersonalAccessTokenIntrospectResponse(nil, nil, ""), nil
+	}
+
+	// The token never grants more than its user is still allowed, for example
+	// after the user is no longer an admin.
+	scope := allowedScope(user, token.Scope)
+	if scope == "" {
+		return dto.NewPersonalAccessTokenIntrospectResponse(nil, nil, ""), nil
+	}
+
+	if usecase.personalAccessTokenDomain.Use(token) {
+		// A failure to record the use must not prevent the user from
+		// authenticating.
+		if err := usecase.personalAccessTokenRepo.UpdateLastUsedAt(ctx, token); err != nil {
+			xcontext.Logger(ctx).Warn("failed-to-update-personal-access-token-last-used-at",
+				"err", err, "token_id", token.ID)
+		}
+	}
+
+	return dto.NewPersonalAccessTokenIntrospectResponse(token, user, scope), nil
+}
+
+// requireUserSubject returns the id of the requesting user, it rejects the
+// tokens issued to clients.
+func requireUserSubject(ctx context.Context) (snowflake.ID, error) {
+	userID := xcontext.RequestSubjectID(ctx)
+	if userID == 0 || xcontext.RequestSubjectType(ctx) != enumdef.SubjectUser {
+		return 0, xerror.Enrich(errordef.ErrForbidden, "require a user token")
+	}
+
+	return userID, nil
+}
+
+// allowedScope returns the scopes of the string which are still allowed to the
+// user, the admin scopes are only allowed to the admins.
+func allowedScope(user *domain.User, value string) string {
+	allowed := scope.Scopes{}
+	for _, s := range scopedef.Engine.ParseAnyScopes(value) {
+		if user.Role != enumdef.UserRoleAdmin && scopedef.IsTitle[scopedef.Admin](s) {
+			continue
+		}
+
+		allowed = append(allowed, s)
+	}
+
+	return allowed.String()
+}
@@ -0,0 +1,186 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/scope"
+)
+
+type personalAccessTokenFixture struct {
+	userRepo  *fakeUserRepository
+	tokenRepo *fakePersonalAccessTokenRepository
+	admin     *domain.User
+
+	personalAccessToken *usecase.PersonalAccessTokenUsecase
+}
+
+func newPersonalAccessTokenFixture(t *testing.T, maxPerUser int) *personalAccessTokenFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	admin, err := userDomain.NewFirst("admin", "Adm1nP@ssw0rd")
+	require.NoError(t, err)
+
+	f := &personalAccessTokenFixture{
+		userRepo:  newFakeUserRepository(admin),
+		tokenRepo: newFakePersonalAccessTokenRepository(),
+		admin:     admin,
+	}
+
+	f.personalAccessToken = usecase.NewPersonalAccessTokenUsecase(
+		time.Hour, maxPerUser, domain.NewPersonalAccessTokenDomain(node, 24*time.Hour), userDomain,
+		domain.NewAuditDomain(node), f.tokenRepo, f.userRepo, &fakeAuditLogRepository{})
+
+	return f
+}
+
+// create creates a token of the scopes with an access token granted these
+// scopes and the scope managing the tokens.
+func (f *personalAccessTokenFixture) create(user *domain.User, scopes ...scope.Scoper) (string, error) {
+	ctx := newUserContext(user.ID, append(scopes, usecase.UserUpdateUserToken)...)
+	resp, err := f.personalAccessToken.Create(ctx, &dto.PersonalAccessTokenCreateRequest{
+		Name:  "script",
+		Scope: scope.NewScopes(scopes...).String(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Token, nil
+}
+
+func (f *personalAccessTokenFixture) introspect(t *testing.T, token string) *dto.PersonalAccessTokenIntrospectResponse {
+	ctx := newUserContext(f.admin.ID, scopedef.AdminValidateUser)
+	resp, err := f.personalAccessToken.Introspect(ctx, &dto.PersonalAccessTokenIntrospectRequest{Token: token})
+	require.NoError(t, err)
+	return resp
+}
+
+func (f *personalAccessTokenFixture) updateUser(t *testing.T, user *domain.User, change func(user *domain.User)) {
+	user, err := f.userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	change(user)
+	f.userRepo.users[user.ID] = *user
+}
+
+func TestPersonalAccessTokenIntrospect(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	token, err := f.create(f.admin, scopedef.UserReadUserProfile)
+	require.NoError(t, err)
+
+	resp := f.introspect(t, token)
+	require.True(t, resp.Active)
+	require.Equal(t, f.admin.ID, resp.UserID)
+	require.Equal(t, scopedef.UserReadUserProfile.Scope(), resp.Scope)
+
+	require.False(t, f.introspect(t, token+"0").Active)
+	require.False(t, f.introspect(t, "not-a-token").Active)
+
+	_, err = f.personalAccessToken.Introspect(newUserContext(f.admin.ID),
+		&dto.PersonalAccessTokenIntrospectRequest{Token: token})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
+
+func TestPersonalAccessTokenCannotExceedGrantedScope(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	_, err := f.personalAccessToken.Create(
+		newUserContext(f.admin.ID, scopedef.UserReadUserProfile, usecase.UserUpdateUserToken),
+		&dto.PersonalAccessTokenCreateRequest{Name: "script", Scope: scopedef.AdminCreateUser.Scope()})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
+
+func TestPersonalAccessTokenRequiresTokenScope(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+	ctx := newUserContext(f.admin.ID, scopedef.UserReadUserProfile)
+
+	_, err := f.personalAccessToken.Create(ctx, &dto.PersonalAccessTokenCreateRequest{
+		Name:  "script",
+		Scope: scopedef.UserReadUserProfile.Scope(),
+	})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.personalAccessToken.List(ctx, &dto.PersonalAccessTokenListRequest{})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.personalAccessToken.Revoke(ctx, &dto.PersonalAccessTokenRevokeRequest{TokenID: 1})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	// A leaked token cannot be used to create other tokens.
+	_, err = f.create(f.admin, usecase.UserUpdateUserToken)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
+
+func TestPersonalAccessTokenIntrospectDropsScopesNoLongerAllowed(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	both, err := f.create(f.admin, scopedef.UserReadUserProfile, scopedef.AdminCreateUser)
+	require.NoError(t, err)
+
+	adminOnly, err := f.create(f.admin, scopedef.AdminCreateUser)
+	require.NoError(t, err)
+
+	require.Equal(t, scope.NewScopes(scopedef.UserReadUserProfile, scopedef.AdminCreateUser).String(),
+		f.introspect(t, both).Scope)
+
+	f.updateUser(t, f.admin, func(user *domain.User) { user.Role = enumdef.UserRoleUser })
+
+	resp := f.introspect(t, both)
+	require.True(t, resp.Active)
+	require.Equal(t, scopedef.UserReadUserProfile.Scope(), resp.Scope)
+
+	require.False(t, f.introspect(t, adminOnly).Active)
+}
+
+func TestPersonalAccessTokenIntrospectInactiveUser(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	token, err := f.create(f.admin, scopedef.UserReadUserProfile)
+	require.NoError(t, err)
+
+	f.updateUser(t, f.admin, func(user *domain.User) { user.Active = false })
+	require.False(t, f.introspect(t, token).Active)
+}
+
+func TestPersonalAccessTokenRevoked(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	ctx := newUserContext(f.admin.ID, scopedef.UserReadUserProfile, usecase.UserUpdateUserToken)
+	resp, err := f.personalAccessToken.Create(ctx, &dto.PersonalAccessTokenCreateRequest{
+		Name:  "script",
+		Scope: scopedef.UserReadUserProfile.Scope(),
+	})
+	require.NoError(t, err)
+
+	_, err = f.personalAccessToken.Revoke(ctx, &dto.PersonalAccessTokenRevokeRequest{
+		TokenID: resp.PersonalAccessToken.ID,
+	})
+	require.NoError(t, err)
+
+	require.False(t, f.introspect(t, resp.Token).Active)
+}
+
+func TestPersonalAccessTokenLimitPerUser(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 2)
+
+	for range 2 {
+		_, err := f.create(f.admin, scopedef.UserReadUserProfile)
+		require.NoError(t, err)
+	}
+
+	_, err := f.create(f.admin, scopedef.UserReadUserProfile)
+	require.ErrorIs(t, err, errordef.ErrRequestInvalid)
+}
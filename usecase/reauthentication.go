@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+)
+
+// ReauthenticationUsecase issues the short-lived tokens proving that a user
+// has just confirmed their identity. Users confirm their local or directory
+// password, the auth server issues the token of the users who verified
+// themselves again another way, such as a linked identity or a magic link.
+// The passkey assertions return their own token.
+type ReauthenticationUsecase struct {
+	tokenEngine token.Engine
+
+	reauthenticationDomain abstraction.ReauthenticationDomain
+	userDomain             abstraction.UserDomain
+	auditDomain            abstraction.AuditDomain
+
+	userRepo  abstraction.UserRepository
+	auditRepo abstraction.AuditLogRepository
+
+	// ldapDirectory is nil if LDAP is disabled.
+	ldapDirectory abstraction.LDAPDirectory
+}
+
+func NewReauthenticationUsecase(
+	tokenEngine token.Engine,
+	reauthenticationDomain abstraction.ReauthenticationDomain,
+	userDomain abstraction.UserDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+	ldapDirectory abstraction.LDAPDirectory,
+) *ReauthenticationUsecase {
+	return &ReauthenticationUsecase{
+		tokenEngine:            tokenEngine,
+		reauthenticationDomain: reauthenticationDomain,
+		userDomain:             userDomain,
+		auditDomain:            auditDomain,
+		userRepo:               userRepo,
+		auditRepo:              auditRepo,
+		ldapDirectory:          ldapDirectory,
+	}
+}
+
+// Reauthenticate returns a re-authentication token of the user. Users give
+// their password, the LDAP users the password of their directory entry. The
+// auth server, with the admin validate:user scope, gives no password.
+func (usecase *ReauthenticationUsecase) Reauthenticate(
+	ctx context.Context,
+	req *dto.ReauthenticateRequest,
+) (*dto.ReauthenticateResponse, error) {
+	isSelf := xcontext.RequestSubjectType(ctx) == enumdef.SubjectUser && xcontext.RequestSubjectID(ctx) == req.UserID
+	if !isSelf && scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	if usecase.userDomain.IsErased(user) {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+	}
+
+	method := domain.ReauthenticationMethodAuthServer
+	if isSelf {
+		if method, err = usecase.validatePassword(ctx, user, req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	claims := usecase.reauthenticationDomain.NewToken(user, method)
+	signedToken, err := usecase.tokenEngine.Generate(ctx, claims)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-generate-reauthentication-token")
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserReauthenticate,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"method": method})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewReauthenticateResponse(signedToken, time.Unix(int64(claims.ExpiresAt), 0)), nil
+}
+
+// validatePassword checks the password suiting the user and returns the
+// method of the re-authentication.
+func (usecase *ReauthenticationUsecase) validatePassword(
+	ctx context.Context,
+	user *domain.User,
+	password string,
+) (string, error) {
+	if password == "" {
+		return "", xerror.Enrich(errordef.ErrRequestInvalid, "require password")
+	}
+
+	if user.LDAPID != "" {
+		if usecase.ldapDirectory == nil {
+			return "", xerror.Enrich(errordef.ErrRequestInvalid, "the directory is disabled, re-authenticate through the auth server")
+		}
+
+		ok, err := usecase.ldapDirectory.Authenticate(ctx, user.LDAPID, password)
+		if err != nil {
+			return "", errordef.ErrServer.Hide(err, "failed-to-authenticate-ldap-user", "uid", user.ID)
+		}
+
+		if !ok {
+			return "", xerror.Enrich(errordef.ErrForbidden, "invalid password")
+		}
+
+		return domain.ReauthenticationMethodLDAP, nil
+	}
+
+	if !user.HasPassword {
+		return "", xerror.Enrich(errordef.ErrRequestInvalid, "the user has no password, re-authenticate through the auth server")
+	}
+
+	if user.PasswordResetRequired {
+		return "", xerror.Enrich(errordef.ErrRequestInvalid, "the password must be reset, re-authenticate through the auth server")
+	}
+
+	if err := usecase.userDomain.Validate(user.HashedPass, password); err != nil {
+		if errors.Is(err, domain.ErrMismatchedPassword) {
+			return "", xerror.Enrich(errordef.ErrForbidden, "invalid password")
+		}
+
+		return "", errordef.ErrServer.Hide(err, "failed-to-validate-password", "uid", user.ID)
+	}
+
+	return domain.ReauthenticationMethodPassword, nil
+}
+
+// requireReauthentication checks that the signed token is a re-authentication
+// token of the user which has not expired.
+func requireReauthentication(
+	ctx context.Context,
+	tokenEngine token.Engine,
+	reauthenticationDomain abstraction.ReauthenticationDomain,
+	user *domain.User,
+	signedToken string,
+) error {
+	if signedToken == "" {
+		return xerror.Enrich(errordef.ErrForbidden, "require a re-authentication token")
+	}
+
+	claims := &domain.ReauthenticationToken{}
+	if err := tokenEngine.Validate(ctx, signedToken, claims); err != nil {
+		return xerror.Enrich(errordef.ErrForbidden, "invalid or expired re-authentication token").
+			Hide(err, "failed-to-validate-reauthentication-token", "uid", user.ID)
+	}
+
+	if err := reauthenticationDomain.CheckToken(user, claims); err != nil {
+		return xerror.Enrich(errordef.ErrForbidden, "invalid or expired re-authentication token").
+			Hide(err, "failed-to-check-reauthentication-token", "uid", user.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+)
+
+type reauthenticationFixture struct {
+	userDomain *domain.UserDomain
+	userRepo   *fakeUserRepository
+	auditRepo  *fakeAuditLogRepository
+	admin      *domain.User
+
+	reauthentication *usecase.ReauthenticationUsecase
+	erasure          *usecase.UserErasureUsecase
+}
+
+func newReauthenticationFixture(t *testing.T) *reauthenticationFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	admin, err := userDomain.NewFirst("admin", "Adm1nP@ssw0rd")
+	require.NoError(t, err)
+
+	engine := token.NewJWTEngine()
+	require.NoError(t, engine.WithHMAC("secret"))
+
+	reauthenticationDomain := domain.NewReauthenticationDomain(node, time.Minute)
+	auditDomain := domain.NewAuditDomain(node)
+
+	f := &reauthenticationFixture{
+		userDomain: userDomain,
+		userRepo:   newFakeUserRepository(admin),
+		auditRepo:  &fakeAuditLogRepository{},
+		admin:      admin,
+	}
+
+	f.reauthentication = usecase.NewReauthenticationUsecase(
+		engine, reauthenticationDomain, userDomain, auditDomain, f.userRepo, f.auditRepo, nil)
+
+	f.erasure = usecase.NewUserErasureUsecase(
+		engine, reauthenticationDomain, userDomain, nil, nil, auditDomain,
+		f.userRepo, nil, nil, nil, f.auditRepo, nil, nil, nil, nil)
+
+	return f
+}
+
+func (f *reauthenticationFixture) addUser(t *testing.T, username string, change func(user *domain.User)) *domain.User {
+	user, err := f.userDomain.New(username, "P@ssw0rd123")
+	require.NoError(t, err)
+
+	if change != nil {
+		change(user)
+	}
+
+	require.NoError(t, f.userRepo.Create(context.Background(), user))
+	return user
+}
+
+func (f *reauthenticationFixture) reauthenticate(
+	ctx context.Context,
+	user *domain.User,
+	password string,
+) (*dto.ReauthenticateResponse, error) {
+	return f.reauthentication.Reauthenticate(ctx, &dto.ReauthenticateRequest{UserID: user.ID, Password: password})
+}
+
+func (f *reauthenticationFixture) scheduleErasure(user *domain.User, signedToken string) error {
+	ctx := newUserContext(user.ID, scopedef.UserReadUserProfile)
+	_, err := f.erasure.Schedule(ctx, &dto.UserErasureScheduleRequest{
+		UserID:                user.ID,
+		ReauthenticationToken: signedToken,
+	})
+	return err
+}
+
+func TestReauthenticateWithPasswordAllowsErasure(t *testing.T) {
+	f := newReauthenticationFixture(t)
+	user := f.addUser(t, "alice", nil)
+
+	resp, err := f.reauthenticate(newUserContext(user.ID), user, "P@ssw0rd123")
+	require.NoError(t, err)
+	require.True(t, resp.ExpiresAt.After(time.Now()))
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserReauthenticate)
+
+	require.ErrorIs(t, f.scheduleErasure(user, ""), errordef.ErrForbidden)
+	require.NoError(t, f.scheduleErasure(user, resp.Token))
+
+	user, err = f.userRepo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, user.EraseAfter.IsZero())
+}
+
+func TestReauthenticateRejectsWrongPassword(t *testing.T) {
+	f := newReauthenticationFixture(t)
+	user := f.addUser(t, "alice", nil)
+
+	_, err := f.reauthenticate(newUserContext(user.ID), user, "wrong-password")
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
+
+func TestReauthenticateRefusesPasswordOfAccountsWithoutOne(t *testing.T) {
+	testcases := map[string]func(user *domain.User){
+		"federated": func(user *domain.User) {
+			user.HasPassword = false
+		},
+		"force-reset": func(user *domain.User) {
+			user.PasswordResetRequired = true
+		},
+	}
+
+	for name, change := range testcases {
+		t.Run(name, func(t *testing.T) {
+			f := newReauthenticationFixture(t)
+			user := f.addUser(t, "alice", change)
+
+			_, err := f.reauthenticate(newUserContext(user.ID), user, "P@ssw0rd123")
+			require.ErrorIs(t, err, errordef.ErrRequestInvalid)
+
+			// The auth server re-authenticates them another way.
+			resp, err := f.reauthenticate(newUserContext(f.admin.ID, scopedef.AdminValidateUser), user, "")
+			require.NoError(t, err)
+			require.NoError(t, f.scheduleErasure(user, resp.Token))
+		})
+	}
+}
+
+func TestReauthenticationTokenBelongsToItsUser(t *testing.T) {
+	f := newReauthenticationFixture(t)
+	alice := f.addUser(t, "alice", nil)
+	bob := f.addUser(t, "bobby", nil)
+
+	resp, err := f.reauthenticate(newUserContext(alice.ID), alice, "P@ssw0rd123")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, f.scheduleErasure(bob, resp.Token), errordef.ErrForbidden)
+
+	// Only the auth server re-authenticates other users.
+	_, err = f.reauthenticate(newUserContext(alice.ID), bob, "")
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
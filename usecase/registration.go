@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// RegistrationUsecase lets end users register without an admin, according to
+// the registration mode. Registrations are rate limited per ip address and may
+// require a captcha, the registered users are pending until an admin approves
+// them, unless they were invited.
+type RegistrationUsecase struct {
+	tokenEngine     token.Engine
+	rateLimit       int
+	rateLimitWindow time.Duration
+
+	registrationDomain      abstraction.RegistrationDomain
+	invitationDomain        abstraction.InvitationDomain
+	emailVerificationDomain abstraction.EmailVerificationDomain
+	userDomain              abstraction.UserDomain
+	avatarDomain            abstraction.AvatarDomain
+	eventDomain             abstraction.EventDomain
+	outboxDomain            abstraction.OutboxDomain
+	auditDomain             abstraction.AuditDomain
+
+	userRepo       abstraction.UserRepository
+	invitationRepo abstraction.InvitationRepository
+	outboxRepo     abstraction.OutboxRepository
+	auditRepo      abstraction.AuditLogRepository
+	rateLimitRepo  abstraction.RateLimitRepository
+
+	// captchaVerifier is nil if no captcha is required.
+	captchaVerifier abstraction.CaptchaVerifier
+
+	// mailSender is nil if no email can be sent, it is required by the domain
+	// mode.
+	mailSender abstraction.MailSender
+}
+
+func NewRegistrationUsecase(
+	tokenEngine token.Engine,
+	rateLimit int,
+	rateLimitWindow time.Duration,
+	registrationDomain abstraction.RegistrationDomain,
+	invitationDomain abstraction.InvitationDomain,
+	emailVerificationDomain abstraction.EmailVerificationDomain,
+	userDomain abstraction.UserDomain,
+	avatarDomain abstraction.AvatarDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	invitationRepo abstraction.InvitationRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+	rateLimitRepo abstraction.RateLimitRepository,
+	captchaVerifier abstraction.CaptchaVerifier,
+	mailSender abstraction.MailSender,
+) *RegistrationUsecase {
+	return &RegistrationUsecase{
+		tokenEngine:             tokenEngine,
+		rateLimit:               rateLimit,
+		rateLimitWindow:         rateLimitWindow,
+		registrationDomain:      registrationDomain,
+		invitationDomain:        invitationDomain,
+		emailVerificationDomain: emailVerificationDomain,
+		userDomain:              userDomain,
+		avatarDomain:            avatarDomain,
+		eventDomain:             eventDomain,
+		outboxDomain:            outboxDomain,
+		auditDomain:             auditDomain,
+		userRepo:                userRepo,
+		invitationRepo:          invitationRepo,
+		outboxRepo:              outboxRepo,
+		auditRepo:               auditRepo,
+		rateLimitRepo:           rateLimitRepo,
+		captchaVerifier:         captchaVerifier,
+		mailSender:              mailSender,
+	}
+}
+
+func (usecase *RegistrationUsecase) GetPolicy(
+	ctx context.Context,
+	req *dto.RegistrationGetPolicyRequest,
+) (*dto.RegistrationGetPolicyResponse, error) {
+	return dto.NewRegistrationGetPolicyResponse(usecase.registrationDomain.Policy(), usecase.captchaVerifier != nil), nil
+}
+
+func (usecase *RegistrationUsecase) Register(
+	ctx context.Context,
+	req *dto.RegistrationRegisterRequest,
+) (*dto.RegistrationRegisterResponse, error) {
+	if !usecase.registrationDomain.IsOpen() {
+		return nil, xerror.Enrich(errordef.ErrNotFound, "self registration is disabled")
+	}
+
+	key := fmt.Sprintf("registration:%s", req.RemoteIP)
+	allowed, retryAfter, err := usecase.rateLimitRepo.Allow(ctx, key, usecase.rateLimit, usecase.rateLimitWindow)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-rate-limit", "key", key)
+	}
+
+	if !allowed {
+		return nil, xerror.Enrich(dto.ErrTooManyRequests, "too many requests, retry after %d seconds",
+			int(math.Ceil(retryAfter.Seconds())))
+	}
+
+	if usecase.captchaVerifier != nil {
+		ok, err := usecase.captchaVerifier.Verify(ctx, req.CaptchaToken, req.RemoteIP)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-verify-captcha")
+		}
+
+		if !ok {
+			return nil, xerror.Enrich(errordef.ErrForbidden, "invalid captcha")
+		}
+	}
+
+	user, err := usecase.userDomain.NewSelfRegistered(req.Username, req.Password, req.Email)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-user").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	var invitation *domain.Invitation
+	if usecase.registrationDomain.Policy().InvitationRequired && req.InvitationToken != "" {
+		if invitation, err = usecase.getInvitation(ctx, req.InvitationToken); err != nil {
+			return nil, err
+		}
+
+		// The invitation stands for the approval of the admin who created it.
+		user.Role = invitation.Role
+		usecase.userDomain.Approve(user)
+		if err := usecase.invitationDomain.Redeem(invitation, user.ID); err != nil {
+			return nil, errordef.DomainWrapper.Event(err, "failed-to-redeem-invitation").
+				Enrich(errordef.ErrForbidden).Error()
+		}
+	}
+
+	if err := usecase.registrationDomain.Authorize(user.Email, invitation); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-authorize-registration").
+			Enrich(errordef.ErrForbidden).Error()
+	}
+
+	emailOwner, err := usecase.checkDuplicated(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// The response does not tell that the email is taken, its owner is told
+	// instead.
+	if emailOwner != nil {
+		usecase.notifyEmailTaken(ctx, emailOwner)
+		return dto.NewRegistrationRegisterResponse(user, usecase.avatarDomain.DefaultURL(user.ID)), nil
+	}
+
+	if err := usecase.create(ctx, user, invitation, req.RemoteIP); err != nil {
+		return nil, err
+	}
+
+	if usecase.registrationDomain.RequiresEmailVerification() {
+		_, err := sendEmailVerification(ctx, usecase.tokenEngine, usecase.emailVerificationDomain,
+			usecase.mailSender, user)
+		if err != nil {
+			// The user is created, they ask for another link with the email
+			// verification api.
+			xcontext.Logger(ctx).Warn("failed-to-send-email-verification", "err", err, "uid", user.ID)
+		}
+	}
+
+	return dto.NewRegistrationRegisterResponse(user, usecase.avatarDomain.DefaultURL(user.ID)), nil
+}
+
+// create saves the registered user and redeems their invitation in a
+// transaction.
+func (usecase *RegistrationUsecase) create(
+	ctx context.Context,
+	user *domain.User,
+	invitation *domain.Invitation,
+	remoteIP string,
+) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return xerror.Enrich(errordef.ErrDuplicated, "the registration conflicts with another one, please retry")
+		}
+
+		return errordef.ErrServer.Hide(err, "failed-to-create-user")
+	}
+
+	metadata := map[string]string{
+		"username":  user.Username,
+		"remote_ip": remoteIP,
+	}
+
+	if invitation != nil {
+		if err := usecase.invitationRepo.Update(ctx, invitation); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			if errors.Is(err, abstraction.ErrStaleVersion) {
+				return xerror.Enrich(errordef.ErrForbidden, "the invitation has already been redeemed or revoked")
+			}
+
+			return errordef.ErrServer.Hide(err, "failed-to-update-invitation", "invitation_id", invitation.ID)
+		}
+
+		metadata["invitation_id"] = invitation.ID.String()
+	}
+
+	event, err := usecase.eventDomain.NewUserCreated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-user-created-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserSelfRegister,
+		domain.AuditTargetUser, user.ID.String(), metadata)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return nil
+}
+
+// Approve lets a pending user sign in, approving a user who is not pending
+// returns it unchanged.
+func (usecase *RegistrationUsecase) Approve(
+	ctx context.Context,
+	req *dto.RegistrationApproveRequest,
+) (*dto.RegistrationApproveResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if err := requireAdminRole(ctx, usecase.userRepo); err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	if !user.Pending {
+		return dto.NewRegistrationApproveResponse(user), nil
+	}
+
+	if usecase.registrationDomain.RequiresEmailVerification() && user.Email != "" && !user.EmailVerified {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the email of the user is not verified yet")
+	}
+
+	version := user.UpdatedAt
+	usecase.userDomain.Approve(user)
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Update(ctx, user, version); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the user has been changed, please retry")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-update-user", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserApprove,
+		domain.AuditTargetUser, user.ID.String(), nil)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewRegistrationApproveResponse(user), nil
+}
+
+// checkDuplicated rejects a username already taken, whatever its case, and
+// returns the user having the email if it is taken. The usernames are public,
+// the emails are not.
+func (usecase *RegistrationUsecase) checkDuplicated(ctx context.Context, user *domain.User) (*domain.User, error) {
+	taken, err := usecase.userRepo.FindExistingCanonicalUsernames(ctx, []string{user.Username})
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-find-existing-username", "username", user.Username)
+	}
+
+	if len(taken) > 0 {
+		return nil, xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed", user.Username)
+	}
+
+	if user.Email == "" {
+		return nil, nil
+	}
+
+	owner, err := usecase.userRepo.GetByEmail(ctx, user.Email)
+	if err == nil {
+		return owner, nil
+	}
+
+	if !errors.Is(err, errordef.ErrNotFound) {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user-by-email")
+	}
+
+	return nil, nil
+}
+
+// notifyEmailTaken tells the owner of the email that someone tried to register
+// with it. A failure is only logged, so it does not tell that the email is
+// taken either.
+func (usecase *RegistrationUsecase) notifyEmailTaken(ctx context.Context, owner *domain.User) {
+	if usecase.mailSender == nil || usecase.userDomain.IsErased(owner) {
+		return
+	}
+
+	if err := usecase.mailSender.Send(ctx, usecase.emailVerificationDomain.NewTakenMail(owner)); err != nil {
+		xcontext.Logger(ctx).Warn("failed-to-send-email-taken-mail", "err", err, "uid", owner.ID)
+	}
+}
+
+// getInvitation returns the invitation of the signed token.
+func (usecase *RegistrationUsecase) getInvitation(ctx context.Context, signedToken string) (*domain.Invitation, error) {
+	claims := &domain.InvitationToken{}
+	if err := usecase.tokenEngine.Validate(ctx, signedToken, claims); err != nil {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "invalid invitation token").
+			Hide(err, "failed-to-parse-invitation-token")
+	}
+
+	invitationID, err := snowflake.ParseString(claims.ID)
+	if err != nil {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "invalid invitation token")
+	}
+
+	invitation, err := usecase.invitationRepo.GetByID(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrForbidden, "invalid invitation token")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-invitation", "invitation_id", invitationID)
+	}
+
+	return invitation, nil
+}
@@ -0,0 +1,251 @@
+package usecase_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+)
+
+type registrationFixture struct {
+	engine           token.Engine
+	invitationDomain *domain.InvitationDomain
+	userRepo         *fakeUserRepository
+	invitationRepo   *fakeInvitationRepository
+	auditRepo        *fakeAuditLogRepository
+	mailSender       *fakeMailSender
+	admin            *domain.User
+
+	registration      *usecase.RegistrationUsecase
+	emailVerification *usecase.EmailVerificationUsecase
+}
+
+func newRegistrationFixture(t *testing.T, mode string, allowedDomains ...string) *registrationFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{})
+	require.NoError(t, err)
+
+	registrationDomain, err := domain.NewRegistrationDomain(mode, allowedDomains)
+	require.NoError(t, err)
+
+	admin, err := userDomain.NewFirst("admin", "Adm1nP@ssw0rd")
+	require.NoError(t, err)
+
+	engine := token.NewJWTEngine()
+	require.NoError(t, engine.WithHMAC("secret"))
+
+	emailVerificationDomain := domain.NewEmailVerificationDomain(node, time.Hour, "http://localhost/verify-email")
+	avatarDomain := domain.NewAvatarDomain(node, domain.AvatarPolicyRules{}, 0, "http://localhost")
+	outboxDomain := domain.NewOutboxDomain(node, 3, time.Second, time.Minute, time.Minute)
+	auditDomain := domain.NewAuditDomain(node)
+
+	f := &registrationFixture{
+		engine:           engine,
+		invitationDomain: domain.NewInvitationDomain(node, 24*time.Hour),
+		userRepo:         newFakeUserRepository(admin),
+		invitationRepo:   newFakeInvitationRepository(),
+		auditRepo:        &fakeAuditLogRepository{},
+		mailSender:       &fakeMailSender{},
+		admin:            admin,
+	}
+
+	f.registration = usecase.NewRegistrationUsecase(
+		engine, 10, time.Minute, registrationDomain, f.invitationDomain, emailVerificationDomain,
+		userDomain, avatarDomain, domain.NewEventDomain(node), outboxDomain, auditDomain,
+		f.userRepo, f.invitationRepo, newFakeOutboxRepository(), f.auditRepo, &fakeRateLimitRepository{},
+		nil, f.mailSender)
+
+	f.emailVerification = usecase.NewEmailVerificationUsecase(
+		engine, 10, time.Minute, emailVerificationDomain, userDomain, auditDomain,
+		f.userRepo, f.auditRepo, &fakeRateLimitRepository{}, f.mailSender)
+
+	return f
+}
+
+func (f *registrationFixture) register(
+	username string,
+	email string,
+	invitationToken string,
+) (*dto.RegistrationRegisterResponse, error) {
+	return f.registration.Register(newRequestContext(), &dto.RegistrationRegisterRequest{
+		Username:        username,
+		Password:        "P@ssw0rd123",
+		Email:           email,
+		InvitationToken: invitationToken,
+		RemoteIP:        "127.0.0.1",
+	})
+}
+
+func (f *registrationFixture) approve(user *domain.User) (*dto.RegistrationApproveResponse, error) {
+	ctx := newUserContext(f.admin.ID, scopedef.AdminCreateUser)
+	return f.registration.Approve(ctx, &dto.RegistrationApproveRequest{UserID: user.ID})
+}
+
+func (f *registrationFixture) getUser(t *testing.T, username string) *domain.User {
+	user, err := f.userRepo.GetByUsername(context.Background(), username)
+	require.NoError(t, err)
+	return user
+}
+
+var mailTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// mailToken returns the token of the link of the mail.
+func mailToken(t *testing.T, mail *domain.Mail) string {
+	match := mailTokenPattern.FindStringSubmatch(mail.Body)
+	require.Len(t, match, 2)
+
+	signedToken, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return signedToken
+}
+
+func TestRegisterOffMode(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeOff)
+
+	_, err := f.register("alice", "", "")
+	require.ErrorIs(t, err, errordef.ErrNotFound)
+}
+
+func TestRegisterOpenModeCreatesPendingUser(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeOpen)
+
+	resp, err := f.register("alice", "alice@example.com", "")
+	require.NoError(t, err)
+	require.True(t, resp.Pending)
+
+	user := f.getUser(t, "alice")
+	require.True(t, user.Pending)
+	require.False(t, user.EmailVerified)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserSelfRegister)
+
+	// The open mode does not require a verified email.
+	_, err = f.approve(user)
+	require.NoError(t, err)
+	require.False(t, f.getUser(t, "alice").Pending)
+}
+
+func TestRegisterTakenEmailLooksLikeSuccess(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeOpen)
+
+	_, err := f.register("alice", "alice@example.com", "")
+	require.NoError(t, err)
+
+	resp, err := f.register("mallory", "Alice@Example.com", "")
+	require.NoError(t, err)
+	require.True(t, resp.Pending)
+
+	_, err = f.userRepo.GetByUsername(context.Background(), "mallory")
+	require.ErrorIs(t, err, errordef.ErrNotFound)
+
+	// The owner of the email is told instead.
+	require.Len(t, f.mailSender.mails, 1)
+	require.Equal(t, "alice@example.com", f.mailSender.mails[0].To)
+}
+
+func TestRegisterTakenUsernameIsRejected(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeOpen)
+
+	_, err := f.register("alice", "", "")
+	require.NoError(t, err)
+
+	_, err = f.register("Alice", "", "")
+	require.ErrorIs(t, err, errordef.ErrDuplicated)
+}
+
+func TestRegisterDomainModeRequiresEmailVerification(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeDomain, "example.com")
+
+	resp, err := f.register("alice", "alice@example.com", "")
+	require.NoError(t, err)
+	require.True(t, resp.Pending)
+	require.Len(t, f.mailSender.mails, 1)
+
+	user := f.getUser(t, "alice")
+	_, err = f.approve(user)
+	require.ErrorIs(t, err, errordef.ErrRequestInvalid)
+
+	verifyResp, err := f.emailVerification.Verify(newRequestContext(), &dto.EmailVerificationVerifyRequest{
+		Token: mailToken(t, f.mailSender.mails[0]),
+	})
+	require.NoError(t, err)
+	require.True(t, verifyResp.EmailVerified)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserEmailVerify)
+
+	_, err = f.approve(user)
+	require.NoError(t, err)
+	require.False(t, f.getUser(t, "alice").Pending)
+}
+
+func TestEmailVerificationRejectsChangedEmail(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeDomain, "example.com")
+
+	_, err := f.register("alice", "alice@example.com", "")
+	require.NoError(t, err)
+
+	user := f.getUser(t, "alice")
+	user.Email = "alice@other.example.com"
+	f.userRepo.users[user.ID] = *user
+
+	_, err = f.emailVerification.Verify(newRequestContext(), &dto.EmailVerificationVerifyRequest{
+		Token: mailToken(t, f.mailSender.mails[0]),
+	})
+	require.ErrorIs(t, err, errordef.ErrRequestInvalid)
+	require.False(t, f.getUser(t, "alice").EmailVerified)
+}
+
+func TestEmailVerificationSendDoesNotTellUnknownEmails(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeDomain, "example.com")
+
+	_, err := f.register("alice", "alice@example.com", "")
+	require.NoError(t, err)
+	require.Len(t, f.mailSender.mails, 1)
+
+	_, err = f.emailVerification.Send(newRequestContext(), &dto.EmailVerificationSendRequest{
+		Email: "nobody@example.com",
+	})
+	require.NoError(t, err)
+	require.Len(t, f.mailSender.mails, 1)
+
+	_, err = f.emailVerification.Send(newRequestContext(), &dto.EmailVerificationSendRequest{
+		Email: "alice@example.com",
+	})
+	require.NoError(t, err)
+	require.Len(t, f.mailSender.mails, 2)
+}
+
+func TestRegisterInviteModeRedeemsInvitation(t *testing.T) {
+	f := newRegistrationFixture(t, domain.RegistrationModeInvite)
+
+	invitation, err := f.invitationDomain.New(f.admin.ID, "admin", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, f.invitationRepo.Create(context.Background(), invitation))
+
+	signedToken, err := f.engine.Generate(context.Background(), f.invitationDomain.NewToken(invitation))
+	require.NoError(t, err)
+
+	_, err = f.register("alice", "", "invalid-token")
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	resp, err := f.register("alice", "", signedToken)
+	require.NoError(t, err)
+	require.False(t, resp.Pending)
+
+	user := f.getUser(t, "alice")
+	require.Equal(t, enumdef.UserRoleAdmin, user.Role)
+
+	invitation, err = f.invitationRepo.GetByID(context.Background(), invitation.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, invitation.RedeemedBy)
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserSelfRegister)
+}
@@ -0,0 +1,332 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/user-service/usecase/dto/resource"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// ScimUsecase provisions the users through SCIM 2.0. Reading requires the
+// admin read:user.profile scope, writing requires the admin create:user scope.
+// The passwords are changed like ChangePassword, so the history is retained.
+type ScimUsecase struct {
+	userDomain   abstraction.UserDomain
+	scimDomain   abstraction.ScimDomain
+	eventDomain  abstraction.EventDomain
+	outboxDomain abstraction.OutboxDomain
+	auditDomain  abstraction.AuditDomain
+
+	userRepo            abstraction.UserRepository
+	outboxRepo          abstraction.OutboxRepository
+	auditRepo           abstraction.AuditLogRepository
+	passwordHistoryRepo abstraction.PasswordHistoryRepository
+}
+
+func NewScimUsecase(
+	userDomain abstraction.UserDomain,
+	scimDomain abstraction.ScimDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+	passwordHistoryRepo abstraction.PasswordHistoryRepository,
+) *ScimUsecase {
+	return &ScimUsecase{
+		userDomain:          userDomain,
+		scimDomain:          scimDomain,
+		eventDomain:         eventDomain,
+		outboxDomain:        outboxDomain,
+		auditDomain:         auditDomain,
+		userRepo:            userRepo,
+		outboxRepo:          outboxRepo,
+		auditRepo:           auditRepo,
+		passwordHistoryRepo: passwordHistoryRepo,
+	}
+}
+
+func (usecase *ScimUsecase) CreateUser(
+	ctx context.Context,
+	req *dto.ScimCreateUserRequest,
+) (*dto.ScimCreateUserResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	user, err := usecase.userDomain.NewProvisioned(req.UserName, req.Password)
+	if err != nil {
+		return nil, scimDomainError(err, "failed-to-new-user")
+	}
+
+	if req.DisplayName != "" {
+		if err := usecase.userDomain.SetDisplayName(user, req.DisplayName); err != nil {
+			return nil, scimDomainError(err, "failed-to-set-display-name")
+		}
+	}
+
+	if req.Active != nil && !*req.Active {
+		usecase.userDomain.SetActive(user, false)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrDuplicated) {
+			return nil, xerror.Enrich(errordef.ErrDuplicated, "userName %s has already existed", req.UserName)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-user")
+	}
+
+	event, err := usecase.eventDomain.NewUserCreated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-user-created-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserProvision,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"username": user.Username})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewScimCreateUserResponse(user), nil
+}
+
+func (usecase *ScimUsecase) GetUser(
+	ctx context.Context,
+	req *dto.ScimGetUserRequest,
+) (*dto.ScimGetUserResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminReadUserProfile).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	user, err := usecase.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewScimGetUserResponse(user), nil
+}
+
+func (usecase *ScimUsecase) ListUsers(
+	ctx context.Context,
+	req *dto.ScimListUsersRequest,
+) (*dto.ScimListUsersResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminReadUserProfile).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	var filter *domain.ScimFilter
+	if req.Filter != "" {
+		var err error
+		filter, err = usecase.scimDomain.ParseFilter(req.Filter)
+		if err != nil {
+			return nil, scimDomainError(err, "failed-to-parse-scim-filter")
+		}
+	}
+
+	users, total, err := usecase.userRepo.FindByScimFilter(ctx, filter, req.StartIndex-1, req.Count)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-find-users", "filter", req.Filter)
+	}
+
+	return dto.NewScimListUsersResponse(users, total, req.StartIndex), nil
+}
+
+func (usecase *ScimUsecase) ReplaceUser(
+	ctx context.Context,
+	req *dto.ScimReplaceUserRequest,
+) (*dto.ScimReplaceUserResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	user, err := usecase.getUserWithVersion(ctx, req.UserID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(req.UserName, user.Username) {
+		return nil, xerror.Enrich(dto.ErrScimMutability, "userName cannot be changed")
+	}
+
+	version := user.UpdatedAt
+	displayName := req.DisplayName
+	if displayName == "" {
+		displayName = user.Username
+	}
+
+	if err := usecase.userDomain.SetDisplayName(user, displayName); err != nil {
+		return nil, scimDomainError(err, "failed-to-set-display-name")
+	}
+
+	usecase.userDomain.SetActive(user, req.Active == nil || *req.Active)
+
+	if err := usecase.saveUser(ctx, user, version, req.Password); err != nil {
+		return nil, err
+	}
+
+	return dto.NewScimReplaceUserResponse(user), nil
+}
+
+func (usecase *ScimUsecase) PatchUser(
+	ctx context.Context,
+	req *dto.ScimPatchUserRequest,
+) (*dto.ScimPatchUserResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	patch, err := usecase.scimDomain.ParsePatch(req.Operations)
+	if err != nil {
+		return nil, scimDomainError(err, "failed-to-parse-scim-patch")
+	}
+
+	user, err := usecase.getUserWithVersion(ctx, req.UserID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	version := user.UpdatedAt
+	if patch.DisplayName != nil {
+		displayName := *patch.DisplayName
+		if displayName == "" {
+			displayName = user.Username
+		}
+
+		if err := usecase.userDomain.SetDisplayName(user, displayName); err != nil {
+			return nil, scimDomainError(err, "failed-to-set-display-name")
+		}
+	}
+
+	if patch.Active != nil {
+		usecase.userDomain.SetActive(user, *patch.Active)
+	}
+
+	password := ""
+	if patch.Password != nil {
+		password = *patch.Password
+	}
+
+	if err := usecase.saveUser(ctx, user, version, password); err != nil {
+		return nil, err
+	}
+
+	return dto.NewScimPatchUserResponse(user), nil
+}
+
+func (usecase *ScimUsecase) getUser(ctx context.Context, userID snowflake.ID) (*domain.User, error) {
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", userID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	return user, nil
+}
+
+// getUserWithVersion gets the user and checks that it is still at the version
+// known by the client.
+func (usecase *ScimUsecase) getUserWithVersion(ctx context.Context, userID snowflake.ID, version string) (*domain.User, error) {
+	user, err := usecase.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != "" && version != resource.NewScimUser(user).Version {
+		return nil, xerror.Enrich(dto.ErrScimVersionMismatched, "the user has been changed")
+	}
+
+	if usecase.userDomain.IsErased(user) {
+		return nil, xerror.Enrich(dto.ErrScimMutability, "the user has been erased")
+	}
+
+	return user, nil
+}
+
+// saveUser changes the password if it is not empty and saves the user if it
+// is still at version, then records the user.updated event and the audit log.
+func (usecase *ScimUsecase) saveUser(ctx context.Context, user *domain.User, version time.Time, password string) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if password != "" {
+		if err := setPassword(ctx, usecase.userDomain, usecase.passwordHistoryRepo, user, password); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return err
+		}
+	}
+
+	if err := usecase.userRepo.Update(ctx, user, version); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return xerror.Enrich(dto.ErrScimVersionMismatched, "the user has been changed")
+		}
+
+		return errordef.ErrServer.Hide(err, "failed-to-update-user", "uid", user.ID)
+	}
+
+	event, err := usecase.eventDomain.NewUserUpdated(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-user-updated-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserUpdate,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"display_name":     user.DisplayName,
+			"active":           strconv.FormatBool(user.Active),
+			"password_changed": strconv.FormatBool(password != ""),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return nil
+}
+
+// scimDomainError enriches a domain error with the matching SCIM error.
+func scimDomainError(err error, event string) error {
+	code := errordef.ErrRequestInvalid
+	switch {
+	case errors.Is(err, domain.ErrScimFilterInvalid):
+		code = dto.ErrScimFilterInvalid
+	case errors.Is(err, domain.ErrScimPathInvalid):
+		code = dto.ErrScimPathInvalid
+	case errors.Is(err, domain.ErrScimMutability):
+		code = dto.ErrScimMutability
+	case errors.Is(err, domain.ErrScimValueInvalid):
+		code = dto.ErrScimValueInvalid
+	}
+
+	return errordef.DomainWrapper.Event(err, event).Enrich(code).Error()
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/x/scope"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// The scopes below let users change the sensitive parts of their own account,
+// they are not defined by the shared scopes, so the auth server grants them as
+// any other scope of this service.
+var (
+	UserUpdateUserIdentity = scope.Define(scopedef.Engine, scope.New("todennus/update:user.identity"))
+	UserUpdateUserPasskey  = scope.Define(scopedef.Engine, scope.New("todennus/update:user.passkey"))
+	UserUpdateUserPassword = scope.Define(scopedef.Engine, scope.New("todennus/update:user.password"))
+	UserUpdateUserToken    = scope.Define(scopedef.Engine, scope.New("todennus/update:user.token"))
+)
+
+// personalAccessTokenForbiddenScopes cannot be granted to personal access
+// tokens, a leaked token must not be enough to take over the account.
+var personalAccessTokenForbiddenScopes = scope.NewScopes(
+	UserUpdateUserIdentity,
+	UserUpdateUserPasskey,
+	UserUpdateUserPassword,
+	UserUpdateUserToken,
+)
+
+// requireSelfScope returns an error unless the request is made by the user
+// with a token granted the scope.
+func requireSelfScope(ctx context.Context, userID snowflake.ID, s scope.Scoper) error {
+	if xcontext.RequestSubjectID(ctx) != userID || xcontext.RequestSubjectType(ctx) != enumdef.SubjectUser {
+		return xerror.Enrich(errordef.ErrForbidden, "require a user token")
+	}
+
+	if !xcontext.Scope(ctx).Contains(s) {
+		return xerror.Enrich(errordef.ErrForbidden, "require the %s scope", s.Scope())
+	}
+
+	return nil
+}
+
+// requireUserScope returns the requesting user if the request is made with a
+// user token granted the scope.
+func requireUserScope(ctx context.Context, s scope.Scoper) (snowflake.ID, error) {
+	userID, err := requireUserSubject(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := requireSelfScope(ctx, userID, s); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
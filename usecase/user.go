@@ -3,16 +3,21 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/todennus/shared/enumdef"
 	"github.com/todennus/shared/errordef"
 	"github.com/todennus/shared/scopedef"
 	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/usecase/abstraction"
 	"github.com/todennus/user-service/usecase/dto"
 	"github.com/todennus/x/lock"
 	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
 )
 
 type UserUsecase struct {
@@ -20,26 +25,66 @@ type UserUsecase struct {
 	shouldCreateAdmin bool
 
 	avatarPresignedURLExpiration time.Duration
-
-	userDomain abstraction.UserDomain
-	userRepo   abstraction.UserRepository
-	fileRepo   abstraction.FileRepository
+	degradedRead                 bool
+
+	usernameAvailabilityLimit  int
+	usernameAvailabilityWindow time.Duration
+
+	userDomain    abstraction.UserDomain
+	avatarDomain  abstraction.AvatarDomain
+	eventDomain   abstraction.EventDomain
+	outboxDomain  abstraction.OutboxDomain
+	auditDomain   abstraction.AuditDomain
+	userRepo      abstraction.UserRepository
+	fileRepo      abstraction.FileRepository
+	outboxRepo    abstraction.OutboxRepository
+	auditRepo     abstraction.AuditLogRepository
+	rateLimitRepo abstraction.RateLimitRepository
+
+	passwordHistoryRepo abstraction.PasswordHistoryRepository
+
+	// ldapDirectory is nil if LDAP is disabled.
+	ldapDirectory abstraction.LDAPDirectory
 }
 
 func NewUserUsecase(
 	locker lock.Locker,
 	avatarPresignedURLExpiration time.Duration,
+	degradedRead bool,
+	usernameAvailabilityLimit int,
+	usernameAvailabilityWindow time.Duration,
 	userDomain abstraction.UserDomain,
+	avatarDomain abstraction.AvatarDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
 	userRepo abstraction.UserRepository,
 	fileRepo abstraction.FileRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+	rateLimitRepo abstraction.RateLimitRepository,
+	passwordHistoryRepo abstraction.PasswordHistoryRepository,
+	ldapDirectory abstraction.LDAPDirectory,
 ) *UserUsecase {
 	return &UserUsecase{
 		adminLocker:                  locker,
 		avatarPresignedURLExpiration: avatarPresignedURLExpiration,
+		degradedRead:                 degradedRead,
+		usernameAvailabilityLimit:    usernameAvailabilityLimit,
+		usernameAvailabilityWindow:   usernameAvailabilityWindow,
 		shouldCreateAdmin:            true,
 		userRepo:                     userRepo,
 		userDomain:                   userDomain,
+		avatarDomain:                 avatarDomain,
+		eventDomain:                  eventDomain,
+		outboxDomain:                 outboxDomain,
+		auditDomain:                  auditDomain,
 		fileRepo:                     fileRepo,
+		outboxRepo:                   outboxRepo,
+		auditRepo:                    auditRepo,
+		rateLimitRepo:                rateLimitRepo,
+		passwordHistoryRepo:          passwordHistoryRepo,
+		ldapDirectory:                ldapDirectory,
 	}
 }
 
@@ -57,7 +102,20 @@ func (uc *UserUsecase) Register(
 			Enrich(errordef.ErrRequestInvalid).Error()
 	}
 
+	taken, err := uc.userRepo.FindExistingCanonicalUsernames(ctx, []string{user.Username})
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-find-existing-username", "username", user.Username)
+	}
+
+	if len(taken) > 0 {
+		return nil, xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed", req.Username)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
 	if err = uc.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
 		if errors.Is(err, errordef.ErrDuplicated) {
 			return nil, xerror.Enrich(errordef.ErrDuplicated, "username %s has already existed", req.Username)
 		}
@@ -65,7 +123,19 @@ func (uc *UserUsecase) Register(
 		return nil, errordef.ErrServer.Hide(err, "failed-to-create-user")
 	}
 
-	return dto.NewUserRegisterResponse(user), nil
+	if err := uc.recordUserCreated(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-user-created-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, uc.auditDomain, uc.auditRepo, domain.AuditUserRegister,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"username": user.Username})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return dto.NewUserRegisterResponse(user, uc.avatarDomain.DefaultURL(user.ID)), nil
 }
 
 func (uc *UserUsecase) RegisterFirst(
@@ -97,12 +167,28 @@ func (uc *UserUsecase) RegisterFirst(
 			Enrich(errordef.ErrRequestInvalid).Error()
 	}
 
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
 	if err = uc.userRepo.Create(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
 		return nil, errordef.ErrServer.Hide(err, "failed-to-create-first-user")
 	}
 
+	if err := uc.recordUserCreated(ctx, user); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-user-created-event", "uid", user.ID)
+	}
+
+	err = recordAudit(ctx, uc.auditDomain, uc.auditRepo, domain.AuditUserRegisterFirst,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{"username": user.Username})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
 	uc.shouldCreateAdmin = false
-	return dto.NewUserRegisterFirstResponse(user), nil
+	return dto.NewUserRegisterFirstResponse(user, uc.avatarDomain.DefaultURL(user.ID)), nil
 }
 
 func (usecase *UserUsecase) GetByID(
@@ -122,12 +208,9 @@ func (usecase *UserUsecase) GetByID(
 		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
 	}
 
-	var avatarURL string
-	if user.Avatar != 0 {
-		avatarURL, err = usecase.fileRepo.CreatePresignedURL(ctx, user.Avatar, usecase.avatarPresignedURLExpiration)
-		if err != nil {
-			return nil, errordef.ErrServer.Hide(err, "failed-to-get-presigned-url", "avatar", user.Avatar)
-		}
+	avatarURL, err := usecase.getAvatarURL(ctx, user)
+	if err != nil {
+		return nil, err
 	}
 
 	return dto.NewUserGetByIDResponse(ctx, user, avatarURL), nil
@@ -150,15 +233,99 @@ func (usecase *UserUsecase) GetByUsername(
 		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "username", req.Username)
 	}
 
-	var avatarURL string
-	if user.Avatar != 0 {
-		avatarURL, err = usecase.fileRepo.CreatePresignedURL(ctx, user.Avatar, usecase.avatarPresignedURLExpiration)
+	avatarURL, err := usecase.getAvatarURL(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewUserGetByUsernameResponse(ctx, user, avatarURL), nil
+}
+
+// Search returns the users matching the query by their username prefix, their
+// display name or a fuzzy match, the best matches first. The users are
+// filtered like GetByUsername.
+func (usecase *UserUsecase) Search(
+	ctx context.Context,
+	req *dto.UserSearchRequest,
+) (*dto.UserSearchResponse, error) {
+	query, err := usecase.userDomain.NormalizeSearchQuery(req.Query)
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "invalid-search-query").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = dto.DefaultUserSearchLimit
+	}
+
+	if limit < 0 || limit > dto.MaximumUserSearchLimit {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "limit must be in range [1, %d]", dto.MaximumUserSearchLimit)
+	}
+
+	users, err := usecase.userRepo.Search(ctx, query, limit)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-search-users", "query", query)
+	}
+
+	users = usecase.userDomain.RankSearch(users, query, limit)
+
+	avatarURLs := []string{}
+	for _, user := range users {
+		avatarURL, err := usecase.getAvatarURL(ctx, user)
 		if err != nil {
-			return nil, errordef.ErrServer.Hide(err, "failed-to-get-presigned-url", "avatar", user.Avatar)
+			return nil, err
 		}
+
+		avatarURLs = append(avatarURLs, avatarURL)
 	}
 
-	return dto.NewUserGetByUsernameResponse(ctx, user, avatarURL), nil
+	return dto.NewUserSearchResponse(ctx, users, avatarURLs), nil
+}
+
+// CheckUsername tells whether the username can be registered, the reasons if
+// not and a few available alternatives. Every requester is rate limited, so
+// the users cannot be enumerated through this check.
+func (usecase *UserUsecase) CheckUsername(
+	ctx context.Context,
+	req *dto.UserCheckUsernameRequest,
+) (*dto.UserCheckUsernameResponse, error) {
+	if req.Username == "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "require username")
+	}
+
+	key := fmt.Sprintf("username-availability:%s:%d", xcontext.RequestSubjectType(ctx), xcontext.RequestSubjectID(ctx))
+	allowed, retryAfter, err := usecase.rateLimitRepo.Allow(ctx, key,
+		usecase.usernameAvailabilityLimit, usecase.usernameAvailabilityWindow)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-rate-limit", "key", key)
+	}
+
+	if !allowed {
+		return nil, xerror.Enrich(dto.ErrTooManyRequests, "too many requests, retry after %d seconds",
+			int(math.Ceil(retryAfter.Seconds())))
+	}
+
+	reasons := usecase.userDomain.CheckUsername(req.Username)
+	if len(reasons) == 0 {
+		taken, err := usecase.userRepo.FindExistingCanonicalUsernames(ctx, []string{req.Username})
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-find-existing-username", "username", req.Username)
+		}
+
+		if len(taken) > 0 {
+			reasons = append(reasons, domain.UsernameTakenReason(req.Username))
+		}
+	}
+
+	suggestions := []string{}
+	if len(reasons) > 0 {
+		if suggestions, err = usecase.suggestUsernames(ctx, req.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	return dto.NewUserCheckUsernameResponse(req.Username, reasons, suggestions), nil
 }
 
 func (usecase *UserUsecase) ValidateCredentials(
@@ -176,18 +343,256 @@ func (usecase *UserUsecase) ValidateCredentials(
 	user, err := usecase.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, errordef.ErrNotFound) {
+			usecase.recordCredentialsFailed(ctx, "", req.Username, "unknown_username")
 			return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid username or password")
 		}
 
 		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "username", req.Username)
 	}
 
-	if err := usecase.userDomain.Validate(user.HashedPass, req.Password); err != nil {
+	if user.LDAPID != "" {
+		if err := usecase.validateLDAPCredentials(ctx, user, req.Password); err != nil {
+			return nil, err
+		}
+	} else if err := usecase.userDomain.Validate(user.HashedPass, req.Password); err != nil {
+		if errors.Is(err, domain.ErrMismatchedPassword) {
+			usecase.recordCredentialsFailed(ctx, user.ID.String(), req.Username, "wrong_password")
+		}
+
 		return nil, errordef.DomainWrapper.Event(err, "failed-to-validate-user-credentials").
 			EnrichWith(errordef.ErrCredentialsInvalid, "invalid username or password").
 			Error()
 	}
 
+	if !user.Active {
+		usecase.recordCredentialsFailed(ctx, user.ID.String(), req.Username, "inactive_user")
+		return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid username or password")
+	}
+
+	if user.Pending {
+		usecase.recordCredentialsFailed(ctx, user.ID.String(), req.Username, "pending_approval")
+		return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "the account is pending approval")
+	}
+
+	if user.PasswordResetRequired {
+		usecase.recordCredentialsFailed(ctx, user.ID.String(), req.Username, "password_reset_required")
+		return nil, xerror.Enrich(errordef.ErrCredentialsInvalid, "the password must be reset before signing in")
+	}
+
+	// An expired password is still valid here, the auth server must have the
+	// user change it before signing in.
+	status, expiresAt := usecase.userDomain.PasswordStatus(user)
+
 	ctx = xcontext.WithRequestSubjectID(ctx, user.ID)
-	return dto.NewUserValidateCredentialsResponse(user), nil
+	return dto.NewUserValidateCredentialsResponse(user, status, expiresAt), nil
+}
+
+// ChangePassword replaces the password of a user after confirming the current
+// one. Users change their own password with the password scope, the auth
+// server changes the password of a user on their behalf, for example when it
+// is expired. The new password cannot be one of the last passwords retained by
+// the password policy.
+func (usecase *UserUsecase) ChangePassword(
+	ctx context.Context,
+	req *dto.UserChangePasswordRequest,
+) (*dto.UserChangePasswordResponse, error) {
+	if xcontext.RequestSubjectType(ctx) == enumdef.SubjectUser && xcontext.RequestSubjectID(ctx) == req.UserID {
+		if err := requireSelfScope(ctx, req.UserID, UserUpdateUserPassword); err != nil {
+			return nil, err
+		}
+	} else if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminValidateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	user, err := usecase.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", req.UserID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", req.UserID)
+	}
+
+	if user.LDAPID != "" {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "the password is managed by the directory")
+	}
+
+	if err := usecase.userDomain.Validate(user.HashedPass, req.CurrentPassword); err != nil {
+		if errors.Is(err, domain.ErrMismatchedPassword) {
+			return nil, xerror.Enrich(errordef.ErrForbidden, "invalid password")
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-validate-password", "uid", user.ID)
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := changePassword(ctx, usecase.userDomain, usecase.userRepo, usecase.passwordHistoryRepo,
+		user, req.NewPassword); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserPasswordChange,
+		domain.AuditTargetUser, user.ID.String(), nil)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	_, expiresAt := usecase.userDomain.PasswordStatus(user)
+	return dto.NewUserChangePasswordResponse(expiresAt), nil
+}
+
+// changePassword sets the password of the user, refusing the passwords in its
+// history, and saves the user with the history. It must be called in a
+// transaction, the returned errors are ready to be returned to the client.
+func changePassword(
+	ctx context.Context,
+	userDomain abstraction.UserDomain,
+	userRepo abstraction.UserRepository,
+	passwordHistoryRepo abstraction.PasswordHistoryRepository,
+	user *domain.User,
+	password string,
+) error {
+	version := user.UpdatedAt
+	if err := setPassword(ctx, userDomain, passwordHistoryRepo, user, password); err != nil {
+		return err
+	}
+
+	if err := userRepo.Update(ctx, user, version); err != nil {
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return xerror.Enrich(errordef.ErrRequestInvalid, "the user has been changed, please retry")
+		}
+
+		return errordef.ErrServer.Hide(err, "failed-to-update-user", "uid", user.ID)
+	}
+
+	return nil
+}
+
+// setPassword sets the password of the user, refusing the passwords in its
+// history, and saves the history. The caller saves the user in the same
+// transaction.
+func setPassword(
+	ctx context.Context,
+	userDomain abstraction.UserDomain,
+	passwordHistoryRepo abstraction.PasswordHistoryRepository,
+	user *domain.User,
+	password string,
+) error {
+	histories, err := passwordHistoryRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return errordef.ErrServer.Hide(err, "failed-to-get-password-histories", "uid", user.ID)
+	}
+
+	replaced, err := userDomain.ChangePassword(user, password, histories)
+	if err != nil {
+		return errordef.DomainWrapper.Event(err, "failed-to-change-password").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	if replaced != nil {
+		if err := passwordHistoryRepo.Create(ctx, replaced); err != nil {
+			return errordef.ErrServer.Hide(err, "failed-to-create-password-history", "uid", user.ID)
+		}
+
+		histories = append([]*domain.PasswordHistory{replaced}, histories...)
+	}
+
+	evictedIDs := []snowflake.ID{}
+	for _, history := range userDomain.EvictPasswordHistory(histories) {
+		evictedIDs = append(evictedIDs, history.ID)
+	}
+
+	if err := passwordHistoryRepo.Delete(ctx, evictedIDs...); err != nil {
+		return errordef.ErrServer.Hide(err, "failed-to-delete-password-histories", "uid", user.ID)
+	}
+
+	return nil
+}
+
+// validateLDAPCredentials validates the password of a user sourced from LDAP
+// against the directory, the local password of this user is never used.
+func (usecase *UserUsecase) validateLDAPCredentials(ctx context.Context, user *domain.User, password string) error {
+	if usecase.ldapDirectory == nil {
+		usecase.recordCredentialsFailed(ctx, user.ID.String(), user.Username, "ldap_disabled")
+		return xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid username or password")
+	}
+
+	ok, err := usecase.ldapDirectory.Authenticate(ctx, user.LDAPID, password)
+	if err != nil {
+		return errordef.ErrServer.Hide(err, "failed-to-authenticate-ldap-user", "uid", user.ID)
+	}
+
+	if !ok {
+		usecase.recordCredentialsFailed(ctx, user.ID.String(), user.Username, "wrong_password")
+		return xerror.Enrich(errordef.ErrCredentialsInvalid, "invalid username or password")
+	}
+
+	return nil
+}
+
+// suggestUsernames returns the alternatives of the username which are not
+// taken yet.
+func (usecase *UserUsecase) suggestUsernames(ctx context.Context, username string) ([]string, error) {
+	candidates := usecase.userDomain.SuggestUsernames(username, 2*dto.UsernameSuggestionCount)
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+
+	taken, err := usecase.userRepo.FindExistingCanonicalUsernames(ctx, candidates)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-find-existing-username", "username", username)
+	}
+
+	suggestions := []string{}
+	for _, candidate := range candidates {
+		if len(suggestions) < dto.UsernameSuggestionCount && !slices.Contains(taken, domain.CanonicalUsername(candidate)) {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+
+	return suggestions, nil
+}
+
+func (usecase *UserUsecase) getAvatarURL(ctx context.Context, user *domain.User) (string, error) {
+	if user.Avatar == 0 {
+		return usecase.avatarDomain.DefaultURL(user.ID), nil
+	}
+
+	avatarURL, err := usecase.fileRepo.CreatePresignedURL(ctx, user.Avatar, usecase.avatarPresignedURLExpiration)
+	if err != nil {
+		if usecase.degradedRead && errors.Is(err, abstraction.ErrServiceUnavailable) {
+			xcontext.Logger(ctx).Warn("omit-avatar-url-due-to-unavailable-file-service",
+				"avatar", user.Avatar, "err", err)
+			return "", nil
+		}
+
+		return "", errordef.ErrServer.Hide(err, "failed-to-get-presigned-url", "avatar", user.Avatar)
+	}
+
+	return avatarURL, nil
+}
+
+func (usecase *UserUsecase) recordUserCreated(ctx context.Context, user *domain.User) error {
+	event, err := usecase.eventDomain.NewUserCreated(user)
+	if err != nil {
+		return err
+	}
+
+	return recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+}
+
+// recordCredentialsFailed audits a failed credentials validation through the
+// outbox, so a burst of failed validations does not wait for the audit chain.
+// A log which cannot be recorded does not change the result of the request.
+func (usecase *UserUsecase) recordCredentialsFailed(ctx context.Context, userID, username, reason string) {
+	err := recordAuditLater(ctx, usecase.auditDomain, usecase.outboxDomain, usecase.outboxRepo,
+		domain.AuditUserValidateCredentialsFailed, domain.AuditTargetUser, userID,
+		map[string]string{"username": username, "reason": reason})
+	if err != nil {
+		xcontext.Logger(ctx).Warn("failed-to-record-audit-log", "err", err, "username", username, "reason", reason)
+	}
 }
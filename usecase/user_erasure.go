@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/token"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// UserErasureUsecase anonymizes users instead of deleting them, so the other
+// services keep a valid reference to the user. An erasure is scheduled by the
+// user or an admin, it can be canceled during the grace period and is then
+// performed by the worker. An erased user cannot be restored.
+type UserErasureUsecase struct {
+	tokenEngine token.Engine
+
+	reauthenticationDomain abstraction.ReauthenticationDomain
+	userDomain             abstraction.UserDomain
+	eventDomain            abstraction.EventDomain
+	outboxDomain           abstraction.OutboxDomain
+	auditDomain            abstraction.AuditDomain
+
+	userRepo                abstraction.UserRepository
+	avatarHistoryRepo       abstraction.AvatarHistoryRepository
+	dataExportRepo          abstraction.DataExportRepository
+	outboxRepo              abstraction.OutboxRepository
+	auditRepo               abstraction.AuditLogRepository
+	personalAccessTokenRepo abstraction.PersonalAccessTokenRepository
+	linkedIdentityRepo      abstraction.LinkedIdentityRepository
+	passkeyRepo             abstraction.PasskeyRepository
+	passwordHistoryRepo     abstraction.PasswordHistoryRepository
+}
+
+func NewUserErasureUsecase(
+	tokenEngine token.Engine,
+	reauthenticationDomain abstraction.ReauthenticationDomain,
+	userDomain abstraction.UserDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	avatarHistoryRepo abstraction.AvatarHistoryRepository,
+	dataExportRepo abstraction.DataExportRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+	personalAccessTokenRepo abstraction.PersonalAccessTokenRepository,
+	linkedIdentityRepo abstraction.LinkedIdentityRepository,
+	passkeyRepo abstraction.PasskeyRepository,
+	passwordHistoryRepo abstraction.PasswordHistoryRepository,
+) *UserErasureUsecase {
+	return &UserErasureUsecase{
+		tokenEngine:             tokenEngine,
+		reauthenticationDomain:  reauthenticationDomain,
+		userDomain:              userDomain,
+		eventDomain:             eventDomain,
+		outboxDomain:            outboxDomain,
+		auditDomain:             auditDomain,
+		userRepo:                userRepo,
+		avatarHistoryRepo:       avatarHistoryRepo,
+		dataExportRepo:          dataExportRepo,
+		outboxRepo:              outboxRepo,
+		auditRepo:               auditRepo,
+		personalAccessTokenRepo: personalAccessTokenRepo,
+		linkedIdentityRepo:      linkedIdentityRepo,
+		passkeyRepo:             passkeyRepo,
+		passwordHistoryRepo:     passwordHistoryRepo,
+	}
+}
+
+// Schedule schedules the erasure of the user after the grace period. Users must
+// re-authenticate to schedule their own erasure, with the method suiting their
+// account: a password, a directory password, or any sign-in verified again by
+// the auth server for the federated or passwordless users. Scheduling an erasure
+// which is already scheduled or performed returns it unchanged.
+func (usecase *UserErasureUsecase) Schedule(
+	ctx context.Context,
+	req *dto.UserErasureScheduleRequest,
+) (*dto.UserErasureScheduleResponse, error) {
+	isSelf, err := usecase.authorize(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSelf {
+		err := requireReauthentication(ctx, usecase.tokenEngine, usecase.reauthenticationDomain,
+			user, req.ReauthenticationToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if usecase.userDomain.IsErased(user) || !user.EraseAfter.IsZero() {
+		return dto.NewUserErasureScheduleResponse(user), nil
+	}
+
+	if user.Role == enumdef.UserRoleAdmin {
+		n, err := usecase.userRepo.CountByRole(ctx, enumdef.UserRoleAdmin)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-count-admins")
+		}
+
+		if n <= 1 {
+			return nil, xerror.Enrich(errordef.ErrRequestInvalid, "cannot erase the last admin")
+		}
+	}
+
+	version := user.UpdatedAt
+	if err := usecase.userDomain.ScheduleErasure(user); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-schedule-erasure").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	if err := usecase.saveUser(ctx, user, version, domain.AuditUserErasureRequest, map[string]string{
+		"erase_after": user.EraseAfter.Format(time.RFC3339),
+	}); err != nil {
+		return nil, err
+	}
+
+	return dto.NewUserErasureScheduleResponse(user), nil
+}
+
+// Cancel cancels the scheduled erasure of the user during the grace period.
+func (usecase *UserErasureUsecase) Cancel(
+	ctx context.Context,
+	req *dto.UserErasureCancelRequest,
+) (*dto.UserErasureCancelResponse, error) {
+	if _, err := usecase.authorize(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	user, err := usecase.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !usecase.userDomain.IsErased(user) && user.EraseAfter.IsZero() {
+		return dto.NewUserErasureCancelResponse(user), nil
+	}
+
+	version := user.UpdatedAt
+	if err := usecase.userDomain.CancelErasure(user); err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-cancel-erasure").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	if err := usecase.saveUser(ctx, user, version, domain.AuditUserErasureCancel, nil); err != nil {
+		return nil, err
+	}
+
+	return dto.NewUserErasureCancelResponse(user), nil
+}
+
+// Process erases the users whose grace period has passed, it is run by the
+// worker. A user whose erasure was canceled in the meantime is skipped.
+func (usecase *UserErasureUsecase) Process(
+	ctx context.Context,
+	req *dto.UserErasureProcessRequest,
+) (*dto.UserErasureProcessResponse, error) {
+	users, err := usecase.userRepo.GetDueErasure(ctx, time.Now().UTC(), req.BatchSize)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-due-erasures")
+	}
+
+	resp := &dto.UserErasureProcessResponse{}
+	for _, user := range users {
+		if err := usecase.erase(ctx, user); err != nil {
+			if errors.Is(err, abstraction.ErrStaleVersion) {
+				continue
+			}
+
+			xcontext.Logger(ctx).Warn("failed-to-erase-user", "err", err, "uid", user.ID)
+			resp.Failed++
+			continue
+		}
+
+		resp.Erased++
+	}
+
+	return resp, nil
+}
+
+// erase anonymizes the user, releases its avatars, deletes its data exports
+// and scrubs the metadata of its audit logs in a single transaction.
+func (usecase *UserErasureUsecase) erase(ctx context.Context, user *domain.User) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	version := user.UpdatedAt
+	avatar := user.Avatar
+	username := user.Username
+
+	usecase.userDomain.Erase(user)
+	if err := usecase.userRepo.Update(ctx, user, version); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.releaseAvatars(ctx, user.ID, avatar); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.dataExportRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.personalAccessTokenRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.linkedIdentityRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.passkeyRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	if err := usecase.passwordHistoryRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	scrubbed, err := usecase.scrubAuditLogs(ctx, user.ID, username)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	event, err := usecase.eventDomain.NewUserErased(user)
+	if err == nil {
+		err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+	}
+
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserErase,
+		domain.AuditTargetUser, user.ID.String(), map[string]string{
+			"scrubbed_audit_logs": strconv.Itoa(scrubbed),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// releaseAvatars removes the current avatar and the avatar history of the
+// user, then releases their file references.
+func (usecase *UserErasureUsecase) releaseAvatars(ctx context.Context, userID, avatar snowflake.ID) error {
+	histories, err := usecase.avatarHistoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	dec := []snowflake.ID{}
+	if avatar != 0 {
+		if err := usecase.userRepo.RemoveAvatarByID(ctx, userID); err != nil {
+			return err
+		}
+
+		dec = append(dec, avatar)
+	}
+
+	historyIDs := []snowflake.ID{}
+	for _, history := range histories {
+		historyIDs = append(historyIDs, history.ID)
+		dec = append(dec, history.OwnershipID)
+	}
+
+	if err := usecase.avatarHistoryRepo.Delete(ctx, historyIDs...); err != nil {
+		return err
+	}
+
+	if len(dec) == 0 {
+		return nil
+	}
+
+	msg, err := usecase.outboxDomain.NewRefcountChange(nil, dec)
+	if err != nil {
+		return err
+	}
+
+	return usecase.outboxRepo.Create(ctx, msg)
+}
+
+// scrubAuditLogs removes the metadata of the audit logs whose actor or target
+// is the user, and of the failed validations of the username made while no
+// user had it, which hold the username without targeting the user. It returns
+// the number of scrubbed logs.
+func (usecase *UserErasureUsecase) scrubAuditLogs(
+	ctx context.Context,
+	userID snowflake.ID,
+	username string,
+) (int, error) {
+	byUser, err := usecase.scrubAuditLogBatches(ctx, func(afterSeq int64) ([]*domain.AuditLog, error) {
+		return usecase.auditRepo.GetByUser(ctx, userID, afterSeq, dto.UserErasureAuditLogBatchSize)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	byUsername, err := usecase.scrubAuditLogBatches(ctx, func(afterSeq int64) ([]*domain.AuditLog, error) {
+		return usecase.auditRepo.GetByUnknownUsername(ctx, username, afterSeq, dto.UserErasureAuditLogBatchSize)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return byUser + byUsername, nil
+}
+
+func (usecase *UserErasureUsecase) scrubAuditLogBatches(
+	ctx context.Context,
+	get func(afterSeq int64) ([]*domain.AuditLog, error),
+) (int, error) {
+	scrubbed := 0
+	afterSeq := int64(0)
+	for {
+		logs, err := get(afterSeq)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, log := range logs {
+			if !usecase.auditDomain.Scrub(log) {
+				continue
+			}
+
+			if err := usecase.auditRepo.Scrub(ctx, log); err != nil {
+				return 0, err
+			}
+
+			scrubbed++
+		}
+
+		if len(logs) < dto.UserErasureAuditLogBatchSize {
+			return scrubbed, nil
+		}
+
+		afterSeq = logs[len(logs)-1].Seq
+	}
+}
+
+// authorize allows users to manage their own erasure, and admins to manage the
+// erasure of any user. It reports whether the requester is the user.
+func (usecase *UserErasureUsecase) authorize(ctx context.Context, userID snowflake.ID) (bool, error) {
+	if xcontext.RequestSubjectType(ctx) == enumdef.SubjectUser && xcontext.RequestSubjectID(ctx) == userID {
+		return true, requireSelf(ctx, userID)
+	}
+
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return false, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	return false, requireAdminRole(ctx, usecase.userRepo)
+}
+
+func (usecase *UserErasureUsecase) getUser(ctx context.Context, userID snowflake.ID) (*domain.User, error) {
+	user, err := usecase.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found user with id %d", userID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-user", "uid", userID)
+	}
+
+	return user, nil
+}
+
+// saveUser saves the erasure schedule of the user if it is still at version,
+// then records the audit log.
+func (usecase *UserErasureUsecase) saveUser(
+	ctx context.Context,
+	user *domain.User,
+	version time.Time,
+	action string,
+	metadata map[string]string,
+) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.Update(ctx, user, version); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, abstraction.ErrStaleVersion) {
+			return xerror.Enrich(errordef.ErrRequestInvalid, "the user has been changed, please retry")
+		}
+
+		return errordef.ErrServer.Hide(err, "failed-to-update-user", "uid", user.ID)
+	}
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, action,
+		domain.AuditTargetUser, user.ID.String(), metadata)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "uid", user.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// UserExportUsecase writes every user in the format read by the import. The
+// users are read page by page, so the memory does not grow with the number of
+// users.
+type UserExportUsecase struct {
+	userDomain  abstraction.UserDomain
+	auditDomain abstraction.AuditDomain
+
+	userRepo  abstraction.UserRepository
+	auditRepo abstraction.AuditLogRepository
+}
+
+func NewUserExportUsecase(
+	userDomain abstraction.UserDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *UserExportUsecase {
+	return &UserExportUsecase{
+		userDomain:  userDomain,
+		auditDomain: auditDomain,
+		userRepo:    userRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// Export requires the admin role besides the admin read:user.profile scope,
+// the export may contain the password hashes.
+func (usecase *UserExportUsecase) Export(
+	ctx context.Context,
+	req *dto.UserExportRequest,
+) (*dto.UserExportResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminReadUserProfile).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if err := requireAdminRole(ctx, usecase.userRepo); err != nil {
+		return nil, err
+	}
+
+	return usecase.exportUsers(ctx, req)
+}
+
+// OperatorExport is run by the operator through the CLI, it is not exposed by
+// any API.
+func (usecase *UserExportUsecase) OperatorExport(
+	ctx context.Context,
+	req *dto.UserExportRequest,
+) (*dto.UserExportResponse, error) {
+	return usecase.exportUsers(ctx, req)
+}
+
+func (usecase *UserExportUsecase) exportUsers(
+	ctx context.Context,
+	req *dto.UserExportRequest,
+) (*dto.UserExportResponse, error) {
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = dto.DefaultUserExportBatchSize
+	}
+	batchSize = min(batchSize, dto.MaximumUserExportBatchSize)
+
+	if req.Format != domain.UserImportFormatCSV && req.Format != domain.UserImportFormatNDJSON {
+		return nil, xerror.Enrich(errordef.ErrRequestInvalid, "unsupported format %s", req.Format)
+	}
+
+	options := domain.UserExportOptions{IncludeHashes: req.IncludeHashes, IncludeAvatars: req.IncludeAvatars}
+
+	// The export is audited before anything is written, an export which fails
+	// halfway may still have leaked some users.
+	if err := usecase.recordExport(ctx, req); err != nil {
+		return nil, err
+	}
+
+	encoder, err := usecase.userDomain.NewExportEncoder(req.Format, req.Writer, options)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-write-export")
+	}
+
+	resp := &dto.UserExportResponse{}
+	afterID := snowflake.ID(0)
+	for {
+		users, err := usecase.userRepo.GetAfterID(ctx, afterID, batchSize)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-get-users", "after_id", afterID)
+		}
+
+		for _, user := range users {
+			// An erased user has no personal data left to migrate, and its
+			// pseudonym cannot be imported.
+			if usecase.userDomain.IsErased(user) {
+				continue
+			}
+
+			if err := encoder.Encode(user); err != nil {
+				return nil, errordef.ErrServer.Hide(err, "failed-to-write-export", "uid", user.ID)
+			}
+
+			resp.Exported++
+		}
+
+		if len(users) < batchSize {
+			break
+		}
+
+		afterID = users[len(users)-1].ID
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-write-export")
+	}
+
+	return resp, nil
+}
+
+func (usecase *UserExportUsecase) recordExport(ctx context.Context, req *dto.UserExportRequest) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserExport,
+		domain.AuditTargetUser, "", map[string]string{
+			"format":          req.Format,
+			"include_hashes":  strconv.FormatBool(req.IncludeHashes),
+			"include_avatars": strconv.FormatBool(req.IncludeAvatars),
+		})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return errordef.ErrServer.Hide(err, "failed-to-record-audit-log")
+	}
+
+	return nil
+}
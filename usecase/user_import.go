@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+// UserImportUsecase creates users in bulk from the files exported by another
+// system. Every row is validated by the user domain, a row failing the
+// validation does not prevent the other rows from being imported.
+type UserImportUsecase struct {
+	userDomain   abstraction.UserDomain
+	eventDomain  abstraction.EventDomain
+	outboxDomain abstraction.OutboxDomain
+	auditDomain  abstraction.AuditDomain
+
+	userRepo   abstraction.UserRepository
+	outboxRepo abstraction.OutboxRepository
+	auditRepo  abstraction.AuditLogRepository
+}
+
+func NewUserImportUsecase(
+	userDomain abstraction.UserDomain,
+	eventDomain abstraction.EventDomain,
+	outboxDomain abstraction.OutboxDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	outboxRepo abstraction.OutboxRepository,
+	auditRepo abstraction.AuditLogRepository,
+) *UserImportUsecase {
+	return &UserImportUsecase{
+		userDomain:   userDomain,
+		eventDomain:  eventDomain,
+		outboxDomain: outboxDomain,
+		auditDomain:  auditDomain,
+		userRepo:     userRepo,
+		outboxRepo:   outboxRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// Import requires the admin role besides the admin create:user scope, the
+// imported rows may create admins.
+func (usecase *UserImportUsecase) Import(
+	ctx context.Context,
+	req *dto.UserImportRequest,
+) (*dto.UserImportResponse, error) {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return nil, xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if err := requireAdminRole(ctx, usecase.userRepo); err != nil {
+		return nil, err
+	}
+
+	return usecase.importUsers(ctx, req)
+}
+
+// OperatorImport is run by the operator through the CLI, it is not exposed by
+// any API.
+func (usecase *UserImportUsecase) OperatorImport(
+	ctx context.Context,
+	req *dto.UserImportRequest,
+) (*dto.UserImportResponse, error) {
+	return usecase.importUsers(ctx, req)
+}
+
+func (usecase *UserImportUsecase) importUsers(
+	ctx context.Context,
+	req *dto.UserImportRequest,
+) (*dto.UserImportResponse, error) {
+	rows, err := usecase.userDomain.DecodeImport(req.Format, req.Reader)
+	if err != nil {
+		if errors.Is(err, domain.ErrImportFormatInvalid) {
+			return nil, errordef.DomainWrapper.Event(err, "failed-to-decode-import").
+				Enrich(errordef.ErrRequestInvalid).Error()
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-read-import")
+	}
+
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = dto.DefaultUserImportBatchSize
+	}
+	batchSize = min(batchSize, dto.MaximumUserImportBatchSize)
+
+	results := []*dto.UserImportResult{}
+	batch := []*domain.User{}
+	batchResults := []*dto.UserImportResult{}
+	seen := map[string]int{}
+	seenIDs := map[snowflake.ID]int{}
+	for _, row := range rows {
+		result := &dto.UserImportResult{Line: row.Line, Username: row.Username}
+		results = append(results, result)
+
+		if row.Err != nil {
+			failImportResult(result, row.Err.Error())
+			continue
+		}
+
+		if line, ok := seen[row.Username]; ok {
+			failImportResult(result, fmt.Sprintf("username %s is duplicated with line %d", row.Username, line))
+			continue
+		}
+		seen[row.Username] = row.Line
+
+		user, err := usecase.userDomain.NewImported(row)
+		if err != nil {
+			if !errors.Is(err, errordef.ErrDomainKnown) {
+				return nil, errordef.ErrServer.Hide(err, "failed-to-new-imported-user", "line", row.Line)
+			}
+
+			failImportResult(result, err.Error())
+			continue
+		}
+
+		if line, ok := seenIDs[user.ID]; ok {
+			failImportResult(result, fmt.Sprintf("id %s is duplicated with line %d", user.ID, line))
+			continue
+		}
+		seenIDs[user.ID] = row.Line
+
+		batch = append(batch, user)
+		batchResults = append(batchResults, result)
+		if len(batch) == batchSize {
+			if err := usecase.importBatch(ctx, batch, batchResults, req.DryRun); err != nil {
+				return nil, err
+			}
+
+			batch, batchResults = []*domain.User{}, []*dto.UserImportResult{}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := usecase.importBatch(ctx, batch, batchResults, req.DryRun); err != nil {
+			return nil, err
+		}
+	}
+
+	return dto.NewUserImportResponse(req.DryRun, results), nil
+}
+
+// importBatch creates the users of a batch in a single transaction. A batch
+// which cannot be saved fails all of its rows, the next batches are still
+// imported.
+func (usecase *UserImportUsecase) importBatch(
+	ctx context.Context,
+	users []*domain.User,
+	results []*dto.UserImportResult,
+	dryRun bool,
+) error {
+	usernames := []string{}
+	userIDs := []snowflake.ID{}
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+		userIDs = append(userIDs, user.ID)
+	}
+
+	existing, err := usecase.userRepo.FindExistingUsernames(ctx, usernames)
+	if err != nil {
+		return errordef.ErrServer.Hide(err, "failed-to-find-existing-usernames")
+	}
+
+	existingIDs, err := usecase.userRepo.FindExistingIDs(ctx, userIDs)
+	if err != nil {
+		return errordef.ErrServer.Hide(err, "failed-to-find-existing-ids")
+	}
+
+	newUsers := []*domain.User{}
+	newResults := []*dto.UserImportResult{}
+	for i, user := range users {
+		if slices.Contains(existing, user.Username) {
+			failImportResult(results[i], fmt.Sprintf("username %s has already existed", user.Username))
+			continue
+		}
+
+		if slices.Contains(existingIDs, user.ID) {
+			failImportResult(results[i], fmt.Sprintf("id %s has already existed", user.ID))
+			continue
+		}
+
+		newUsers = append(newUsers, user)
+		newResults = append(newResults, results[i])
+	}
+
+	if len(newUsers) == 0 {
+		return nil
+	}
+
+	if dryRun {
+		for _, result := range newResults {
+			result.Status = dto.UserImportStatusValid
+		}
+
+		return nil
+	}
+
+	if err := usecase.saveBatch(ctx, newUsers); err != nil {
+		xcontext.Logger(ctx).Warn("failed-to-save-import-batch", "err", err,
+			"first_line", newResults[0].Line, "last_line", newResults[len(newResults)-1].Line)
+
+		for _, result := range newResults {
+			failImportResult(result, "failed to save the batch of this row")
+		}
+
+		return nil
+	}
+
+	for i, result := range newResults {
+		result.Status = dto.UserImportStatusCreated
+		result.UserID = newUsers[i].ID
+	}
+
+	return nil
+}
+
+func (usecase *UserImportUsecase) saveBatch(ctx context.Context, users []*domain.User) error {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.userRepo.CreateMany(ctx, users); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return err
+	}
+
+	for _, user := range users {
+		event, err := usecase.eventDomain.NewUserCreated(user)
+		if err == nil {
+			err = recordEvent(ctx, usecase.outboxDomain, usecase.outboxRepo, event)
+		}
+
+		if err == nil {
+			err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditUserImport,
+				domain.AuditTargetUser, user.ID.String(), map[string]string{
+					"username":       user.Username,
+					"role":           user.Role.String(),
+					"password_reset": strconv.FormatBool(user.PasswordResetRequired),
+				})
+		}
+
+		if err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func failImportResult(result *dto.UserImportResult, reason string) {
+	result.Status = dto.UserImportStatusFailed
+	result.Error = reason
+}
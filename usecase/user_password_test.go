@@ -0,0 +1,246 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+const initialPassword = "P@ssw0rd-0"
+
+type userPasswordFixture struct {
+	userRepo            *fakeUserRepository
+	passwordHistoryRepo *fakePasswordHistoryRepository
+	auditRepo           *fakeAuditLogRepository
+	outboxRepo          *fakeOutboxRepository
+	alice               *domain.User
+
+	user *usecase.UserUsecase
+	scim *usecase.ScimUsecase
+}
+
+func newUserPasswordFixture(t *testing.T) *userPasswordFixture {
+	node := newSnowflakeNode()
+
+	userDomain, err := domain.NewUserDomain(node, time.Hour, nil, domain.PasswordPolicy{
+		MaxAge:        30 * 24 * time.Hour,
+		WarningWindow: 7 * 24 * time.Hour,
+		HistorySize:   3,
+	})
+	require.NoError(t, err)
+
+	alice, err := userDomain.NewFirst("alice", initialPassword)
+	require.NoError(t, err)
+
+	f := &userPasswordFixture{
+		userRepo:            newFakeUserRepository(alice),
+		passwordHistoryRepo: &fakePasswordHistoryRepository{},
+		auditRepo:           &fakeAuditLogRepository{},
+		outboxRepo:          newFakeOutboxRepository(),
+		alice:               alice,
+	}
+
+	avatarDomain := domain.NewAvatarDomain(node, domain.AvatarPolicyRules{}, 0, "http://localhost")
+	eventDomain := domain.NewEventDomain(node)
+	outboxDomain := domain.NewOutboxDomain(node, 3, time.Second, time.Minute, time.Minute)
+	auditDomain := domain.NewAuditDomain(node)
+
+	f.user = usecase.NewUserUsecase(
+		nil, time.Minute, false, 10, time.Minute, userDomain, avatarDomain, eventDomain, outboxDomain,
+		auditDomain, f.userRepo, &fakeFileRepository{}, f.outboxRepo, f.auditRepo, &fakeRateLimitRepository{},
+		f.passwordHistoryRepo, nil)
+
+	f.scim = usecase.NewScimUsecase(
+		userDomain, domain.NewScimDomain(), eventDomain, outboxDomain, auditDomain,
+		f.userRepo, f.outboxRepo, f.auditRepo, f.passwordHistoryRepo)
+
+	return f
+}
+
+// changePassword changes the password of alice as the auth server.
+func (f *userPasswordFixture) changePassword(currentPassword, newPassword string) error {
+	ctx := newUserContext(0, scopedef.AdminValidateUser)
+	_, err := f.user.ChangePassword(ctx, &dto.UserChangePasswordRequest{
+		UserID:          f.alice.ID,
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+	return err
+}
+
+func (f *userPasswordFixture) validate(t *testing.T, password string) *dto.UserValidateCredentialsResponse {
+	ctx := newUserContext(0, scopedef.AdminValidateUser)
+	resp, err := f.user.ValidateCredentials(ctx, &dto.UserValidateCredentialsRequest{
+		Username: "alice",
+		Password: password,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// setPasswordAge pretends that the password of alice was changed age ago.
+func (f *userPasswordFixture) setPasswordAge(age time.Duration) {
+	user := f.userRepo.users[f.alice.ID]
+	user.PasswordChangedAt = time.Now().Add(-age)
+	f.userRepo.users[f.alice.ID] = user
+}
+
+func (f *userPasswordFixture) getUser(t *testing.T) *domain.User {
+	user, err := f.userRepo.GetByID(context.Background(), f.alice.ID)
+	require.NoError(t, err)
+	return user
+}
+
+// requireHistory checks that the history holds the hashes of the passwords,
+// the refusal of these passwords is tested by the domain.
+func (f *userPasswordFixture) requireHistory(t *testing.T, passwords ...string) {
+	histories, err := f.passwordHistoryRepo.GetByUserID(context.Background(), f.alice.ID)
+	require.NoError(t, err)
+	require.Len(t, histories, len(passwords))
+
+	for i, password := range passwords {
+		require.NoError(t, domain.ValidatePassword(histories[i].HashedPass, password))
+	}
+}
+
+func TestValidateCredentialsReturnsPasswordStatus(t *testing.T) {
+	f := newUserPasswordFixture(t)
+
+	resp := f.validate(t, initialPassword)
+	require.Equal(t, domain.PasswordStatusValid, resp.PasswordStatus)
+
+	f.setPasswordAge(25 * 24 * time.Hour)
+	resp = f.validate(t, initialPassword)
+	require.Equal(t, domain.PasswordStatusExpiring, resp.PasswordStatus)
+
+	// An expired password is still valid, the auth server has the user change
+	// it.
+	f.setPasswordAge(31 * 24 * time.Hour)
+	resp = f.validate(t, initialPassword)
+	require.Equal(t, domain.PasswordStatusExpired, resp.PasswordStatus)
+	require.True(t, resp.PasswordExpiresAt.Before(time.Now()))
+
+	require.NoError(t, f.changePassword(initialPassword, "P@ssw0rd-1"))
+
+	resp = f.validate(t, "P@ssw0rd-1")
+	require.Equal(t, domain.PasswordStatusValid, resp.PasswordStatus)
+	require.True(t, resp.PasswordExpiresAt.After(time.Now().Add(29*24*time.Hour)))
+}
+
+func TestChangePasswordKeepsHistory(t *testing.T) {
+	f := newUserPasswordFixture(t)
+
+	require.NoError(t, f.changePassword(initialPassword, "P@ssw0rd-1"))
+	f.requireHistory(t, initialPassword)
+
+	require.NoError(t, f.changePassword("P@ssw0rd-1", "P@ssw0rd-2"))
+	f.requireHistory(t, "P@ssw0rd-1", initialPassword)
+
+	// The current password counts as one of the three retained passwords.
+	require.NoError(t, f.changePassword("P@ssw0rd-2", "P@ssw0rd-3"))
+	f.requireHistory(t, "P@ssw0rd-2", "P@ssw0rd-1")
+
+	require.NoError(t, f.changePassword("P@ssw0rd-3", initialPassword))
+	f.requireHistory(t, "P@ssw0rd-3", "P@ssw0rd-2")
+	require.Contains(t, f.auditRepo.actions(), domain.AuditUserPasswordChange)
+}
+
+func TestChangeOwnPasswordRequiresPasswordScope(t *testing.T) {
+	f := newUserPasswordFixture(t)
+
+	req := &dto.UserChangePasswordRequest{
+		UserID:          f.alice.ID,
+		CurrentPassword: initialPassword,
+		NewPassword:     "P@ssw0rd-1",
+	}
+
+	_, err := f.user.ChangePassword(newUserContext(f.alice.ID, scopedef.UserReadUserProfile), req)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.user.ChangePassword(newUserContext(f.alice.ID, usecase.UserUpdateUserPassword), req)
+	require.NoError(t, err)
+	require.Len(t, f.passwordHistoryRepo.histories, 1)
+}
+
+func TestPersonalAccessTokenCannotBeGrantedPasswordScope(t *testing.T) {
+	f := newPersonalAccessTokenFixture(t, 5)
+
+	_, err := f.create(f.admin, usecase.UserUpdateUserPassword)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
+
+func TestScimReplaceUserKeepsPasswordHistory(t *testing.T) {
+	f := newUserPasswordFixture(t)
+	ctx := newUserContext(0, scopedef.AdminCreateUser)
+
+	replace := func(password string) error {
+		_, err := f.scim.ReplaceUser(ctx, &dto.ScimReplaceUserRequest{
+			UserID:   f.alice.ID,
+			UserName: "alice",
+			Password: password,
+		})
+		return err
+	}
+
+	_, err := f.scim.ReplaceUser(ctx, &dto.ScimReplaceUserRequest{UserID: f.alice.ID, UserName: "alice"})
+	require.NoError(t, err)
+	f.requireHistory(t)
+
+	require.NoError(t, replace("P@ssw0rd-1"))
+	f.requireHistory(t, initialPassword)
+	require.True(t, f.getUser(t).PasswordChangedAt.After(f.alice.PasswordChangedAt))
+
+	f.validate(t, "P@ssw0rd-1")
+}
+
+func TestScimPatchUserKeepsPasswordHistory(t *testing.T) {
+	f := newUserPasswordFixture(t)
+	ctx := newUserContext(0, scopedef.AdminCreateUser)
+
+	patch := func(password string) error {
+		value, err := json.Marshal(password)
+		require.NoError(t, err)
+
+		_, err = f.scim.PatchUser(ctx, &dto.ScimPatchUserRequest{
+			UserID:     f.alice.ID,
+			Operations: []domain.ScimPatchOperation{{Op: "replace", Path: "password", Value: value}},
+		})
+		return err
+	}
+
+	require.NoError(t, patch("P@ssw0rd-1"))
+	require.NoError(t, patch("P@ssw0rd-2"))
+	require.NoError(t, patch("P@ssw0rd-3"))
+	f.requireHistory(t, "P@ssw0rd-2", "P@ssw0rd-1")
+
+	f.validate(t, "P@ssw0rd-3")
+}
+
+func TestValidateCredentialsAuditsFailuresThroughOutbox(t *testing.T) {
+	f := newUserPasswordFixture(t)
+	ctx := newUserContext(0, scopedef.AdminValidateUser)
+	req := &dto.UserValidateCredentialsRequest{Username: "bob", Password: initialPassword}
+
+	// The failure is appended to the audit chain by the relay, the request
+	// does not take the chain lock.
+	_, err := f.user.ValidateCredentials(ctx, req)
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+	require.Empty(t, f.auditRepo.logs)
+
+	msgs := f.outboxRepo.sorted()
+	require.Len(t, msgs, 1)
+	require.Equal(t, domain.OutboxTopicAuditLog, msgs[0].Topic)
+
+	// A failure which cannot be recorded does not change the result.
+	f.outboxRepo.failCreate = true
+	_, err = f.user.ValidateCredentials(ctx, req)
+	require.ErrorIs(t, err, errordef.ErrCredentialsInvalid)
+}
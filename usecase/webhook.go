@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/shared/xcontext"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/todennus/user-service/usecase/dto"
+	"github.com/todennus/x/xerror"
+	"github.com/xybor-x/snowflake"
+)
+
+type WebhookUsecase struct {
+	webhookDomain abstraction.WebhookDomain
+	auditDomain   abstraction.AuditDomain
+
+	userRepo       abstraction.UserRepository
+	webhookRepo    abstraction.WebhookRepository
+	deliveryRepo   abstraction.WebhookDeliveryRepository
+	deadLetterRepo abstraction.WebhookDeadLetterRepository
+	auditRepo      abstraction.AuditLogRepository
+	sender         abstraction.WebhookSender
+}
+
+func NewWebhookUsecase(
+	webhookDomain abstraction.WebhookDomain,
+	auditDomain abstraction.AuditDomain,
+	userRepo abstraction.UserRepository,
+	webhookRepo abstraction.WebhookRepository,
+	deliveryRepo abstraction.WebhookDeliveryRepository,
+	deadLetterRepo abstraction.WebhookDeadLetterRepository,
+	auditRepo abstraction.AuditLogRepository,
+	sender abstraction.WebhookSender,
+) *WebhookUsecase {
+	return &WebhookUsecase{
+		webhookDomain:  webhookDomain,
+		auditDomain:    auditDomain,
+		userRepo:       userRepo,
+		webhookRepo:    webhookRepo,
+		deliveryRepo:   deliveryRepo,
+		deadLetterRepo: deadLetterRepo,
+		auditRepo:      auditRepo,
+		sender:         sender,
+	}
+}
+
+func (usecase *WebhookUsecase) Create(
+	ctx context.Context,
+	req *dto.WebhookCreateRequest,
+) (*dto.WebhookCreateResponse, error) {
+	if err := usecase.requireAdmin(ctx, true); err != nil {
+		return nil, err
+	}
+
+	webhook, err := usecase.webhookDomain.New(req.URL, req.EventTypes, xcontext.RequestSubjectID(ctx))
+	if err != nil {
+		return nil, errordef.DomainWrapper.Event(err, "failed-to-new-webhook").
+			Enrich(errordef.ErrRequestInvalid).Error()
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.webhookRepo.Create(ctx, webhook); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-create-webhook")
+	}
+
+	err = recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditWebhookCreate,
+		domain.AuditTargetWebhook, webhook.ID.String(), map[string]string{"url": webhook.URL})
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "webhook_id", webhook.ID)
+	}
+
+	return dto.NewWebhookCreateResponse(webhook), nil
+}
+
+func (usecase *WebhookUsecase) List(
+	ctx context.Context,
+	req *dto.WebhookListRequest,
+) (*dto.WebhookListResponse, error) {
+	if err := usecase.requireAdmin(ctx, false); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := usecase.webhookRepo.GetAll(ctx)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-webhooks")
+	}
+
+	return dto.NewWebhookListResponse(webhooks), nil
+}
+
+func (usecase *WebhookUsecase) Delete(
+	ctx context.Context,
+	req *dto.WebhookDeleteRequest,
+) (*dto.WebhookDeleteResponse, error) {
+	if err := usecase.requireAdmin(ctx, true); err != nil {
+		return nil, err
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.webhookRepo.Delete(ctx, req.WebhookID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found webhook with id %d", req.WebhookID)
+		}
+
+		return nil, errordef.ErrServer.Hide(err, "failed-to-delete-webhook", "webhook_id", req.WebhookID)
+	}
+
+	err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditWebhookDelete,
+		domain.AuditTargetWebhook, req.WebhookID.String(), nil)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "webhook_id", req.WebhookID)
+	}
+
+	return dto.NewWebhookDeleteResponse(), nil
+}
+
+func (usecase *WebhookUsecase) ListDeliveryLogs(
+	ctx context.Context,
+	req *dto.WebhookListDeliveryLogsRequest,
+) (*dto.WebhookListDeliveryLogsResponse, error) {
+	if err := usecase.requireAdmin(ctx, false); err != nil {
+		return nil, err
+	}
+
+	logs, err := usecase.deliveryRepo.GetLogsByWebhookID(ctx, req.WebhookID, req.Limit)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-webhook-delivery-logs", "webhook_id", req.WebhookID)
+	}
+
+	return dto.NewWebhookListDeliveryLogsResponse(logs), nil
+}
+
+func (usecase *WebhookUsecase) ListDeadLetters(
+	ctx context.Context,
+	req *dto.WebhookListDeadLettersRequest,
+) (*dto.WebhookListDeadLettersResponse, error) {
+	if err := usecase.requireAdmin(ctx, false); err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := usecase.deadLetterRepo.GetByWebhookID(ctx, req.WebhookID)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-webhook-dead-letters", "webhook_id", req.WebhookID)
+	}
+
+	return dto.NewWebhookListDeadLettersResponse(deadLetters), nil
+}
+
+// requireAdmin allows the admins whose token is granted the admin
+// read:user.profile scope, as the webhooks receive the profile of every user.
+// Changing the webhooks also requires the admin create:user scope.
+func (usecase *WebhookUsecase) requireAdmin(ctx context.Context, change bool) error {
+	if scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminReadUserProfile).IsUnsatisfied() {
+		return xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	if change && scopedef.Eval(xcontext.Scope(ctx)).RequireAdmin(scopedef.AdminCreateUser).IsUnsatisfied() {
+		return xerror.Enrich(errordef.ErrForbidden, "insufficient scope")
+	}
+
+	return requireAdminRole(ctx, usecase.userRepo)
+}
+
+// Dispatch sends a batch of pending deliveries. Every attempt is logged, the
+// deliveries which used up their attempts are moved to the dead letters. The
+// deliveries are leased to this dispatcher before being sent, and the result
+// of each delivery is saved in its own transaction, so no transaction is held
+// while calling the webhooks.
+func (usecase *WebhookUsecase) Dispatch(
+	ctx context.Context,
+	req *dto.WebhookDispatchRequest,
+) (*dto.WebhookDispatchResponse, error) {
+	deliveries, err := usecase.claim(ctx, req.BatchSize)
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-claim-webhook-deliveries")
+	}
+
+	resp := dto.NewWebhookDispatchResponse()
+	webhooks := map[snowflake.ID]*domain.Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = usecase.webhookRepo.GetByID(ctx, delivery.WebhookID)
+			if err != nil {
+				return nil, errordef.ErrServer.Hide(err, "failed-to-get-webhook", "webhook_id", delivery.WebhookID)
+			}
+
+			webhooks[webhook.ID] = webhook
+		}
+
+		attemptedAt := time.Now()
+		signature := usecase.webhookDomain.Sign(webhook.Secret, attemptedAt, delivery.Payload)
+		statusCode, sendErr := usecase.sender.Send(ctx, webhook, delivery, signature)
+		if sendErr != nil {
+			xcontext.Logger(ctx).Warn("failed-to-deliver-webhook",
+				"err", sendErr, "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts+1)
+		}
+
+		log := usecase.webhookDomain.NewDeliveryLog(delivery, attemptedAt, statusCode, sendErr)
+		deadLetter, err := usecase.saveAttempt(ctx, delivery, log, sendErr)
+		if err != nil {
+			return nil, errordef.ErrServer.Hide(err, "failed-to-save-webhook-delivery", "delivery_id", delivery.ID)
+		}
+
+		switch {
+		case sendErr == nil:
+			resp.Delivered++
+		case deadLetter == nil:
+			resp.Failed++
+		default:
+			resp.Dead++
+		}
+	}
+
+	return resp, nil
+}
+
+// claim leases a batch of pending deliveries in a short transaction.
+func (usecase *WebhookUsecase) claim(ctx context.Context, batchSize int) ([]*domain.WebhookDelivery, error) {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	deliveries, err := usecase.deliveryRepo.ClaimPending(ctx, batchSize)
+	if err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	for _, delivery := range deliveries {
+		usecase.webhookDomain.Lease(delivery)
+		if err := usecase.deliveryRepo.Update(ctx, delivery); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, err
+		}
+	}
+
+	return deliveries, nil
+}
+
+// saveAttempt saves the log and the result of an attempt in a transaction of
+// its own. It returns the dead letter the delivery was moved to, if any.
+func (usecase *WebhookUsecase) saveAttempt(
+	ctx context.Context,
+	delivery *domain.WebhookDelivery,
+	log *domain.WebhookDeliveryLog,
+	sendErr error,
+) (*domain.WebhookDeadLetter, error) {
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	if err := usecase.deliveryRepo.CreateLog(ctx, log); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	if sendErr == nil {
+		usecase.webhookDomain.SetDelivered(delivery)
+		if err := usecase.deliveryRepo.Update(ctx, delivery); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	deadLetter := usecase.webhookDomain.SetFailed(delivery, sendErr)
+	if deadLetter == nil {
+		if err := usecase.deliveryRepo.Update(ctx, delivery); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	if err := usecase.deadLetterRepo.Create(ctx, deadLetter); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	if err := usecase.deliveryRepo.Delete(ctx, delivery.ID); err != nil {
+		ctx = xcontext.DBRollback(ctx)
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// Replay moves dead letters back to the pending deliveries with fresh
+// attempts.
+func (usecase *WebhookUsecase) Replay(
+	ctx context.Context,
+	req *dto.WebhookReplayRequest,
+) (*dto.WebhookReplayResponse, error) {
+	var deadLetters []*domain.WebhookDeadLetter
+	var err error
+	switch {
+	case req.DeadLetterID != 0:
+		var deadLetter *domain.WebhookDeadLetter
+		deadLetter, err = usecase.deadLetterRepo.GetByID(ctx, req.DeadLetterID)
+		if errors.Is(err, errordef.ErrNotFound) {
+			return nil, xerror.Enrich(errordef.ErrNotFound, "not found dead letter with id %d", req.DeadLetterID)
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	case req.WebhookID != 0:
+		deadLetters, err = usecase.deadLetterRepo.GetByWebhookID(ctx, req.WebhookID)
+	default:
+		deadLetters, err = usecase.deadLetterRepo.GetAll(ctx)
+	}
+
+	if err != nil {
+		return nil, errordef.ErrServer.Hide(err, "failed-to-get-webhook-dead-letters")
+	}
+
+	ctx = xcontext.WithDBTransaction(ctx)
+	defer xcontext.DBCommit(ctx)
+
+	for _, deadLetter := range deadLetters {
+		if err := usecase.deliveryRepo.Create(ctx, usecase.webhookDomain.Replay(deadLetter)); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, errordef.ErrServer.Hide(err, "failed-to-create-webhook-delivery", "dead_letter_id", deadLetter.ID)
+		}
+
+		if err := usecase.deadLetterRepo.Delete(ctx, deadLetter.ID); err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, errordef.ErrServer.Hide(err, "failed-to-delete-webhook-dead-letter", "dead_letter_id", deadLetter.ID)
+		}
+
+		err := recordAudit(ctx, usecase.auditDomain, usecase.auditRepo, domain.AuditWebhookReplay,
+			domain.AuditTargetWebhook, deadLetter.WebhookID.String(),
+			map[string]string{"dead_letter_id": deadLetter.ID.String(), "event_id": deadLetter.EventID.String()})
+		if err != nil {
+			ctx = xcontext.DBRollback(ctx)
+			return nil, errordef.ErrServer.Hide(err, "failed-to-record-audit-log", "dead_letter_id", deadLetter.ID)
+		}
+	}
+
+	return dto.NewWebhookReplayResponse(len(deadLetters)), nil
+}
@@ -0,0 +1,144 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/todennus/shared/enumdef"
+	"github.com/todennus/shared/errordef"
+	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/domain"
+	"github.com/todennus/user-service/usecase"
+	"github.com/todennus/user-service/usecase/dto"
+)
+
+type webhookFixture struct {
+	webhookDomain  *domain.WebhookDomain
+	userRepo       *fakeUserRepository
+	deliveryRepo   *fakeWebhookDeliveryRepository
+	deadLetterRepo *fakeWebhookDeadLetterRepository
+	sender         *fakeWebhookSender
+	webhook        *domain.Webhook
+
+	usecase *usecase.WebhookUsecase
+}
+
+func newWebhookFixture(t *testing.T, maxAttempts int, users ...*domain.User) *webhookFixture {
+	node := newSnowflakeNode()
+	webhookDomain := domain.NewWebhookDomain(node, maxAttempts, time.Millisecond, time.Millisecond, time.Minute)
+
+	webhook, err := webhookDomain.New("https://hooks.example.com", []string{domain.WebhookAllEvents}, 0)
+	require.NoError(t, err)
+
+	f := &webhookFixture{
+		webhookDomain:  webhookDomain,
+		userRepo:       newFakeUserRepository(users...),
+		deliveryRepo:   &fakeWebhookDeliveryRepository{},
+		deadLetterRepo: &fakeWebhookDeadLetterRepository{},
+		sender:         &fakeWebhookSender{},
+		webhook:        webhook,
+	}
+
+	f.usecase = usecase.NewWebhookUsecase(
+		webhookDomain, domain.NewAuditDomain(node), f.userRepo,
+		&fakeWebhookRepository{webhooks: []*domain.Webhook{webhook}},
+		f.deliveryRepo, f.deadLetterRepo, &fakeAuditLogRepository{}, f.sender)
+
+	return f
+}
+
+func (f *webhookFixture) addDelivery(t *testing.T) *domain.WebhookDelivery {
+	event, err := domain.NewEventDomain(newSnowflakeNode()).NewUserCreated(&domain.User{ID: 7, Username: "alice"})
+	require.NoError(t, err)
+
+	delivery, err := f.webhookDomain.NewDelivery(f.webhook, event)
+	require.NoError(t, err)
+	require.NoError(t, f.deliveryRepo.Create(context.Background(), delivery))
+	return delivery
+}
+
+func (f *webhookFixture) dispatch() (*dto.WebhookDispatchResponse, error) {
+	return f.usecase.Dispatch(context.Background(), &dto.WebhookDispatchRequest{BatchSize: 10})
+}
+
+func TestWebhookDispatchLeasesClaimedDeliveries(t *testing.T) {
+	f := newWebhookFixture(t, 3)
+	first := f.addDelivery(t)
+	second := f.addDelivery(t)
+
+	// The result of the second delivery cannot be saved, the first one stays
+	// delivered and the second one is leased, so another dispatcher does not
+	// send it again before the end of the lease.
+	f.deliveryRepo.failUpdate = second.ID
+	_, err := f.dispatch()
+	require.Error(t, err)
+
+	require.False(t, f.deliveryRepo.get(first.ID).DeliveredAt.IsZero())
+	require.True(t, f.deliveryRepo.get(second.ID).DeliveredAt.IsZero())
+	require.True(t, f.deliveryRepo.get(second.ID).NextAttemptAt.After(time.Now().Add(30*time.Second)))
+	require.Len(t, f.deliveryRepo.logs, 2)
+
+	f.deliveryRepo.failUpdate = 0
+	resp, err := f.dispatch()
+	require.NoError(t, err)
+	require.Zero(t, resp.Delivered+resp.Failed+resp.Dead)
+	require.Len(t, f.sender.sent, 2)
+}
+
+func TestWebhookDispatchMovesToDeadLetters(t *testing.T) {
+	f := newWebhookFixture(t, 2)
+	delivery := f.addDelivery(t)
+
+	f.sender.err = errFake
+	resp, err := f.dispatch()
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Failed)
+	require.Equal(t, 1, f.deliveryRepo.get(delivery.ID).Attempts)
+	require.Equal(t, errFake.Error(), f.deliveryRepo.get(delivery.ID).LastError)
+
+	time.Sleep(5 * time.Millisecond)
+	resp, err = f.dispatch()
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Dead)
+
+	require.Nil(t, f.deliveryRepo.get(delivery.ID))
+	require.Len(t, f.deadLetterRepo.deadLetters, 1)
+	require.Equal(t, delivery.EventID, f.deadLetterRepo.deadLetters[0].EventID)
+	require.Len(t, f.deliveryRepo.logs, 2)
+	require.Equal(t, 500, f.deliveryRepo.logs[1].StatusCode)
+}
+
+func TestWebhookRequiresAdminScope(t *testing.T) {
+	admin := &domain.User{ID: 1, Username: "admin", Role: enumdef.UserRoleAdmin}
+	f := newWebhookFixture(t, 3, admin)
+
+	req := &dto.WebhookCreateRequest{URL: "https://hooks.example.com", EventTypes: []string{domain.WebhookAllEvents}}
+
+	// The admin role is not enough, a token handed to another client must be
+	// granted the admin scopes.
+	_, err := f.usecase.Create(newUserContext(admin.ID, scopedef.UserReadUserProfile), req)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.usecase.List(newUserContext(admin.ID, scopedef.UserReadUserProfile), &dto.WebhookListRequest{})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.usecase.Create(newUserContext(admin.ID, scopedef.AdminReadUserProfile), req)
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+
+	_, err = f.usecase.List(newUserContext(admin.ID, scopedef.AdminReadUserProfile), &dto.WebhookListRequest{})
+	require.NoError(t, err)
+
+	_, err = f.usecase.Create(newUserContext(admin.ID, scopedef.AdminReadUserProfile, scopedef.AdminCreateUser), req)
+	require.NoError(t, err)
+}
+
+func TestWebhookRequiresAdminRole(t *testing.T) {
+	user := &domain.User{ID: 1, Username: "alice", Role: enumdef.UserRoleUser}
+	f := newWebhookFixture(t, 3, user)
+
+	ctx := newUserContext(user.ID, scopedef.AdminReadUserProfile, scopedef.AdminCreateUser)
+	_, err := f.usecase.List(ctx, &dto.WebhookListRequest{})
+	require.ErrorIs(t, err, errordef.ErrForbidden)
+}
@@ -2,30 +2,219 @@ package wiring
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/todennus/shared/config"
+	"github.com/todennus/shared/enumdef"
 	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/usecase/abstraction"
+	"github.com/xybor-x/enum"
+	"gopkg.in/yaml.v3"
 )
 
 type Domains struct {
 	abstraction.UserDomain
 	abstraction.AvatarDomain
+	abstraction.OutboxDomain
+	abstraction.EventDomain
+	abstraction.WebhookDomain
+	abstraction.AuditDomain
+	abstraction.ScimDomain
+	abstraction.DataExportDomain
+	abstraction.RegistrationDomain
+	abstraction.InvitationDomain
+	abstraction.PersonalAccessTokenDomain
+	abstraction.LinkedIdentityDomain
+	abstraction.PasskeyDomain
+	abstraction.MagicLinkDomain
+	abstraction.PasswordResetDomain
+	abstraction.ReauthenticationDomain
+	abstraction.EmailVerificationDomain
 }
 
-func InitializeDomains(ctx context.Context, config *config.Config) (*Domains, error) {
+func InitializeDomains(ctx context.Context, config *config.Config, variable *Variable) (*Domains, error) {
 	var err error
 	domains := &Domains{}
 
-	domains.UserDomain, err = domain.NewUserDomain(config.SnowflakeNode)
+	domains.UserDomain, err = domain.NewUserDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.Erasure.GracePeriod)*time.Millisecond,
+		variable.User.ReservedUsernames,
+		domain.PasswordPolicy{
+			MaxAge:        time.Duration(variable.Password.MaxAge) * time.Millisecond,
+			WarningWindow: time.Duration(variable.Password.WarningWindow) * time.Millisecond,
+			HistorySize:   variable.Password.HistorySize,
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	avatarPolicyRules := domain.AvatarPolicyRules{
+		Default: domain.AvatarPolicyRule{
+			AllowedTypes: config.Variable.User.AvatarAllowedTypes,
+			MaxSize:      config.Variable.User.AvatarMaxSize,
+		},
+	}
+
+	if variable.User.AvatarPolicyFile != "" {
+		avatarPolicyRules, err = loadAvatarPolicyFile(variable.User.AvatarPolicyFile, avatarPolicyRules.Default)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load avatar policy file, err=%w", err)
+		}
+	}
+
 	domains.AvatarDomain = domain.NewAvatarDomain(
-		config.Variable.User.AvatarAllowedTypes,
-		config.Variable.User.AvatarMaxSize,
+		config.SnowflakeNode,
+		avatarPolicyRules,
+		variable.User.AvatarHistorySize,
+		variable.User.DefaultAvatarBaseURL,
+	)
+
+	domains.OutboxDomain = domain.NewOutboxDomain(
+		config.SnowflakeNode,
+		variable.Outbox.MaxAttempts,
+		time.Duration(variable.Outbox.RetryBackoff)*time.Millisecond,
+		time.Duration(variable.Outbox.MaxRetryBackoff)*time.Millisecond,
+		time.Duration(variable.Outbox.ClaimLease)*time.Millisecond,
+	)
+
+	domains.EventDomain = domain.NewEventDomain(config.SnowflakeNode)
+
+	domains.WebhookDomain = domain.NewWebhookDomain(
+		config.SnowflakeNode,
+		variable.Webhook.MaxAttempts,
+		time.Duration(variable.Webhook.RetryBackoff)*time.Millisecond,
+		time.Duration(variable.Webhook.MaxRetryBackoff)*time.Millisecond,
+		time.Duration(variable.Webhook.ClaimLease)*time.Millisecond,
+	)
+
+	domains.AuditDomain = domain.NewAuditDomain(config.SnowflakeNode)
+	domains.ScimDomain = domain.NewScimDomain()
+	domains.DataExportDomain = domain.NewDataExportDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.DataExport.Expiration)*time.Millisecond,
+	)
+
+	domains.RegistrationDomain, err = domain.NewRegistrationDomain(
+		variable.User.RegistrationMode,
+		variable.User.RegistrationAllowedDomains,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	domains.InvitationDomain = domain.NewInvitationDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.Invitation.MaxExpiration)*time.Millisecond,
+	)
+
+	domains.PersonalAccessTokenDomain = domain.NewPersonalAccessTokenDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.PAT.MaxExpiration)*time.Millisecond,
+	)
+
+	domains.LinkedIdentityDomain = domain.NewLinkedIdentityDomain(
+		config.SnowflakeNode,
+		variable.User.LinkedIdentityProviders,
+	)
+
+	domains.PasskeyDomain = domain.NewPasskeyDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.WebAuthn.SessionTimeout)*time.Millisecond,
+	)
+
+	domains.MagicLinkDomain = domain.NewMagicLinkDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.MagicLink.Expiration)*time.Millisecond,
+		variable.MagicLink.URL,
+	)
+
+	domains.PasswordResetDomain = domain.NewPasswordResetDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.Password.ResetExpiration)*time.Millisecond,
+	)
+
+	domains.ReauthenticationDomain = domain.NewReauthenticationDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.User.ReauthenticationExpiration)*time.Millisecond,
+	)
+
+	domains.EmailVerificationDomain = domain.NewEmailVerificationDomain(
+		config.SnowflakeNode,
+		time.Duration(variable.EmailVerification.Expiration)*time.Millisecond,
+		variable.EmailVerification.URL,
 	)
 
 	return domains, nil
 }
+
+type avatarPolicyRuleFile struct {
+	AllowedTypes []string `yaml:"allowed_types"`
+	MaxSize      int64    `yaml:"max_size"`
+}
+
+func (rule avatarPolicyRuleFile) toRule() domain.AvatarPolicyRule {
+	return domain.AvatarPolicyRule{
+		AllowedTypes: rule.AllowedTypes,
+		MaxSize:      rule.MaxSize,
+	}
+}
+
+// avatarPolicyFile is the structure of the avatar policy file, for example:
+//
+//	default:
+//	  allowed_types: [image/png, image/jpeg]
+//	  max_size: 3145728
+//	verified:
+//	  allowed_types: [image/png, image/jpeg, image/webp]
+//	  max_size: 5242880
+//	roles:
+//	  admin:
+//	    allowed_types: [image/png, image/jpeg, image/gif, image/webp]
+//	    max_size: 10485760
+//
+// The rule of the user role wins over the verified rule, which applies to the
+// users who verified their email.
+type avatarPolicyFile struct {
+	Default  *avatarPolicyRuleFile           `yaml:"default"`
+	Verified *avatarPolicyRuleFile           `yaml:"verified"`
+	Roles    map[string]avatarPolicyRuleFile `yaml:"roles"`
+}
+
+func loadAvatarPolicyFile(path string, fallback domain.AvatarPolicyRule) (domain.AvatarPolicyRules, error) {
+	rules := domain.AvatarPolicyRules{Default: fallback}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+
+	file := avatarPolicyFile{}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return rules, err
+	}
+
+	if file.Default != nil {
+		rules.Default = file.Default.toRule()
+	}
+
+	if file.Verified != nil {
+		verified := file.Verified.toRule()
+		rules.Verified = &verified
+	}
+
+	rules.Roles = map[enumdef.UserRole]domain.AvatarPolicyRule{}
+	for name, rule := range file.Roles {
+		role, ok := enum.FromString[enumdef.UserRole](name)
+		if !ok {
+			return rules, fmt.Errorf("unknown role %s", name)
+		}
+
+		rules.Roles[role] = rule.toRule()
+	}
+
+	return rules, nil
+}
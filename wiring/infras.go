@@ -2,15 +2,20 @@ package wiring
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/todennus/migration/postgres"
 	"github.com/todennus/shared/authentication"
 	"github.com/todennus/shared/config"
 	"github.com/todennus/shared/scopedef"
+	"github.com/todennus/user-service/infras/certificate"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"gorm.io/gorm"
 )
@@ -20,9 +25,12 @@ type Infras struct {
 	GormPostgres *gorm.DB
 	Redis        *redis.Client
 	FilegRPCConn *grpc.ClientConn
+
+	// ServerTLS is nil if the gRPC server is not configured to serve TLS.
+	ServerTLS *tls.Config
 }
 
-func InitializeInfras(ctx context.Context, config *config.Config) (*Infras, error) {
+func InitializeInfras(ctx context.Context, config *config.Config, variable *Variable) (*Infras, error) {
 	infras := Infras{}
 	var err error
 
@@ -38,14 +46,45 @@ func InitializeInfras(ctx context.Context, config *config.Config) (*Infras, erro
 		Password: config.Secret.Redis.Password,
 	})
 
+	reloadInterval := time.Duration(variable.TLS.ReloadInterval) * time.Millisecond
+
+	fileCredentials := insecure.NewCredentials()
+	if variable.TLS.FileServiceEnabled {
+		tlsConfig, err := certificate.NewClientTLSConfig(certificate.ClientConfig{
+			CAFile:         variable.TLS.FileServiceCAFile,
+			CertFile:       variable.TLS.FileServiceCertFile,
+			KeyFile:        variable.TLS.FileServiceKeyFile,
+			ServerName:     variable.TLS.FileServiceServerName,
+			ReloadInterval: reloadInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls config of file service, err=%w", err)
+		}
+
+		fileCredentials = credentials.NewTLS(tlsConfig)
+	}
+
 	infras.FilegRPCConn, err = grpc.NewClient(
 		config.Variable.Service.FileGRPCAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(fileCredentials),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if variable.TLS.ServerCertFile != "" {
+		infras.ServerTLS, err = certificate.NewServerTLSConfig(certificate.ServerConfig{
+			CertFile:       variable.TLS.ServerCertFile,
+			KeyFile:        variable.TLS.ServerKeyFile,
+			ClientCAFile:   variable.TLS.ServerClientCAFile,
+			ClientAuth:     variable.TLS.ServerClientAuth,
+			ReloadInterval: reloadInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls config of server, err=%w", err)
+		}
+	}
+
 	infras.Auth = authentication.NewGrpcAuthorization((&clientcredentials.Config{
 		TokenURL:     config.Variable.Service.OAuth2TokenURL,
 		ClientID:     config.Secret.Service.ClientID,
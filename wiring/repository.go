@@ -2,22 +2,154 @@ package wiring
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/todennus/user-service/domain"
 	"github.com/todennus/user-service/infras/database/gorm"
+	"github.com/todennus/user-service/infras/database/redis"
+	"github.com/todennus/user-service/infras/service/captcha"
 	"github.com/todennus/user-service/infras/service/grpc"
+	"github.com/todennus/user-service/infras/service/ldap"
+	"github.com/todennus/user-service/infras/service/mail"
+	"github.com/todennus/user-service/infras/service/webauthn"
+	"github.com/todennus/user-service/infras/service/webhook"
 	"github.com/todennus/user-service/usecase/abstraction"
 )
 
 type Repositories struct {
 	abstraction.UserRepository
 	abstraction.FileRepository
+	abstraction.AvatarPolicyRepository
+	abstraction.AvatarHistoryRepository
+	abstraction.PasswordHistoryRepository
+	abstraction.OutboxRepository
+	abstraction.EventRepository
+	abstraction.WebhookRepository
+	abstraction.WebhookDeliveryRepository
+	abstraction.WebhookDeadLetterRepository
+	abstraction.WebhookSender
+	abstraction.AuditLogRepository
+	abstraction.DataExportRepository
+	abstraction.RateLimitRepository
+	abstraction.CaptchaVerifier
+	abstraction.InvitationRepository
+	abstraction.PersonalAccessTokenRepository
+	abstraction.LinkedIdentityRepository
+	abstraction.LDAPDirectory
+	abstraction.PasskeyRepository
+	abstraction.PasskeySessionRepository
+	abstraction.WebAuthnRelyingParty
+	abstraction.MagicLinkRepository
+	abstraction.MailSender
 }
 
-func InitializeRepositories(ctx context.Context, infras *Infras) (*Repositories, error) {
+func InitializeRepositories(ctx context.Context, variable *Variable, infras *Infras) (*Repositories, error) {
 	r := &Repositories{}
 
 	r.UserRepository = gorm.NewUserRepository(infras.GormPostgres)
-	r.FileRepository = grpc.NewFileRepository(infras.FilegRPCConn, infras.Auth)
+	r.FileRepository = grpc.NewFileRepository(infras.FilegRPCConn, infras.Auth, grpc.FileRepositoryConfig{
+		RegisterUploadTimeout:     time.Duration(variable.FileService.RegisterUploadTimeout) * time.Millisecond,
+		CreatePresignedURLTimeout: time.Duration(variable.FileService.CreatePresignedURLTimeout) * time.Millisecond,
+		ChangeRefcountTimeout:     time.Duration(variable.FileService.ChangeRefcountTimeout) * time.Millisecond,
+		RetryAttempts:             variable.FileService.RetryAttempts,
+		RetryBackoff:              time.Duration(variable.FileService.RetryBackoff) * time.Millisecond,
+		BreakerThreshold:          variable.FileService.BreakerThreshold,
+		BreakerCooldown:           time.Duration(variable.FileService.BreakerCooldown) * time.Millisecond,
+	})
+	r.AvatarPolicyRepository = redis.NewAvatarPolicyRepository(infras.Redis)
+	r.AvatarHistoryRepository = gorm.NewAvatarHistoryRepository(infras.GormPostgres)
+	r.PasswordHistoryRepository = gorm.NewPasswordHistoryRepository(infras.GormPostgres)
+	r.OutboxRepository = gorm.NewOutboxRepository(infras.GormPostgres)
+	r.EventRepository = redis.NewEventRepository(infras.Redis, variable.Event.Stream, variable.Event.MaxLen)
+	r.WebhookRepository = gorm.NewWebhookRepository(infras.GormPostgres)
+	r.WebhookDeliveryRepository = gorm.NewWebhookDeliveryRepository(infras.GormPostgres)
+	r.WebhookDeadLetterRepository = gorm.NewWebhookDeadLetterRepository(infras.GormPostgres)
+	r.WebhookSender = webhook.NewSender(time.Duration(variable.Webhook.Timeout) * time.Millisecond)
+	r.AuditLogRepository = gorm.NewAuditLogRepository(infras.GormPostgres)
+	r.DataExportRepository = gorm.NewDataExportRepository(infras.GormPostgres)
+	r.RateLimitRepository = redis.NewRateLimitRepository(infras.Redis)
+	r.InvitationRepository = gorm.NewInvitationRepository(infras.GormPostgres)
+	r.PersonalAccessTokenRepository = gorm.NewPersonalAccessTokenRepository(infras.GormPostgres)
+	r.LinkedIdentityRepository = gorm.NewLinkedIdentityRepository(infras.GormPostgres)
+	r.PasskeyRepository = gorm.NewPasskeyRepository(infras.GormPostgres)
+	r.PasskeySessionRepository = redis.NewPasskeySessionRepository(infras.Redis)
+	r.MagicLinkRepository = redis.NewMagicLinkRepository(infras.Redis)
+
+	relyingParty, err := webauthn.NewRelyingParty(webauthn.RelyingPartyConfig{
+		ID:               variable.WebAuthn.RPID,
+		DisplayName:      variable.WebAuthn.RPDisplayName,
+		Origins:          variable.WebAuthn.RPOrigins,
+		UserVerification: variable.WebAuthn.UserVerification,
+		ResidentKey:      variable.WebAuthn.ResidentKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.WebAuthnRelyingParty = relyingParty
+
+	switch variable.User.RegistrationCaptcha {
+	case "none":
+	case "fake":
+		r.CaptchaVerifier = captcha.NewFakeVerifier(variable.User.RegistrationCaptchaSecret)
+	case "siteverify":
+		r.CaptchaVerifier = captcha.NewSiteVerifyVerifier(
+			variable.User.RegistrationCaptchaURL,
+			variable.User.RegistrationCaptchaSecret,
+			time.Duration(variable.User.RegistrationCaptchaTimeout)*time.Millisecond,
+		)
+	default:
+		return nil, fmt.Errorf("unknown captcha verifier %s", variable.User.RegistrationCaptcha)
+	}
+
+	switch variable.Mail.Sender {
+	case "none":
+	case "log":
+		r.MailSender = mail.NewLogSender()
+	case "smtp":
+		r.MailSender = mail.NewSMTPSender(mail.SMTPSenderConfig{
+			Addr:     variable.Mail.SMTPAddr,
+			Username: variable.Mail.SMTPUsername,
+			Password: variable.Mail.SMTPPassword,
+			From:     variable.Mail.From,
+			Timeout:  time.Duration(variable.Mail.Timeout) * time.Millisecond,
+		})
+	default:
+		return nil, fmt.Errorf("unknown mail sender %s", variable.Mail.Sender)
+	}
+
+	if variable.User.RegistrationMode == domain.RegistrationModeDomain && r.MailSender == nil {
+		return nil, fmt.Errorf("the domain registration mode requires a mail sender")
+	}
+
+	switch variable.LDAP.Directory {
+	case "none":
+	case "memory":
+		directory, err := ldap.LoadMemoryDirectory(variable.LDAP.MemoryFile)
+		if err != nil {
+			return nil, err
+		}
+
+		r.LDAPDirectory = directory
+	case "ldap":
+		r.LDAPDirectory = ldap.NewDirectory(ldap.DirectoryConfig{
+			URL:                  variable.LDAP.URL,
+			StartTLS:             variable.LDAP.StartTLS,
+			BindDN:               variable.LDAP.BindDN,
+			BindPassword:         variable.LDAP.BindPassword,
+			BaseDN:               variable.LDAP.BaseDN,
+			UserFilter:           variable.LDAP.UserFilter,
+			GroupFilter:          variable.LDAP.GroupFilter,
+			IDAttribute:          variable.LDAP.IDAttribute,
+			UsernameAttribute:    variable.LDAP.UsernameAttribute,
+			DisplayNameAttribute: variable.LDAP.DisplayNameAttribute,
+			EmailAttribute:       variable.LDAP.EmailAttribute,
+			Timeout:              time.Duration(variable.LDAP.Timeout) * time.Millisecond,
+		})
+	default:
+		return nil, fmt.Errorf("unknown ldap directory %s", variable.LDAP.Directory)
+	}
 
 	return r, nil
 }
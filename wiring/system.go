@@ -9,6 +9,7 @@ import (
 
 type System struct {
 	Config       *config.Config
+	Variable     *Variable
 	Domains      *Domains
 	Infras       *Infras
 	Repositories *Repositories
@@ -21,30 +22,36 @@ func InitializeSystem(paths ...string) (*System, error) {
 		return nil, fmt.Errorf("failed to load variable and secrets, err=%w", err)
 	}
 
+	variable, err := LoadVariable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service variables, err=%w", err)
+	}
+
 	ctx := context.Background()
 
-	domains, err := InitializeDomains(ctx, config)
+	domains, err := InitializeDomains(ctx, config, variable)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize domains, err=%w", err)
 	}
 
-	infras, err := InitializeInfras(ctx, config)
+	infras, err := InitializeInfras(ctx, config, variable)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize infras, err=%w", err)
 	}
 
-	repositories, err := InitializeRepositories(ctx, infras)
+	repositories, err := InitializeRepositories(ctx, variable, infras)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize repositories, err=%w", err)
 	}
 
-	usecases, err := InitializeUsecases(ctx, config, infras, domains, repositories)
+	usecases, err := InitializeUsecases(ctx, config, variable, infras, domains, repositories)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize usecases, err=%w", err)
 	}
 
 	return &System{
 		Config:       config,
+		Variable:     variable,
 		Infras:       infras,
 		Repositories: repositories,
 		Domains:      domains,
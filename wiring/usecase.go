@@ -13,11 +13,30 @@ import (
 type Usecases struct {
 	abstraction.UserUsecase
 	abstraction.AvatarUsecase
+	abstraction.OutboxUsecase
+	abstraction.WebhookUsecase
+	abstraction.AuditUsecase
+	abstraction.ScimUsecase
+	abstraction.UserImportUsecase
+	abstraction.UserExportUsecase
+	abstraction.DataExportUsecase
+	abstraction.UserErasureUsecase
+	abstraction.RegistrationUsecase
+	abstraction.EmailVerificationUsecase
+	abstraction.InvitationUsecase
+	abstraction.PersonalAccessTokenUsecase
+	abstraction.LinkedIdentityUsecase
+	abstraction.LDAPUsecase
+	abstraction.PasskeyUsecase
+	abstraction.MagicLinkUsecase
+	abstraction.PasswordResetUsecase
+	abstraction.ReauthenticationUsecase
 }
 
 func InitializeUsecases(
 	ctx context.Context,
 	config *config.Config,
+	variable *Variable,
 	infras *Infras,
 	domains *Domains,
 	repositories *Repositories,
@@ -27,17 +46,260 @@ func InitializeUsecases(
 	uc.UserUsecase = usecase.NewUserUsecase(
 		lock.NewRedisLock(infras.Redis, "user-lock", 10*time.Second),
 		time.Duration(config.Variable.User.AvatarPresignedURLExpiration)*time.Second,
+		variable.FileService.DegradedRead,
+		variable.User.UsernameAvailabilityLimit,
+		time.Duration(variable.User.UsernameAvailabilityWindow)*time.Millisecond,
 		domains.UserDomain,
+		domains.AvatarDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
 		repositories.UserRepository,
 		repositories.FileRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+		repositories.RateLimitRepository,
+		repositories.PasswordHistoryRepository,
+		repositories.LDAPDirectory,
 	)
 
 	uc.AvatarUsecase = usecase.NewAvatarUsecase(
 		config.TokenEngine,
+		time.Duration(config.Variable.User.AvatarPresignedURLExpiration)*time.Second,
+		time.Duration(config.Variable.File.UploadTokenExpiration+config.Variable.File.TokenExpiration)*time.Second,
+		domains.AvatarDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		repositories.FileRepository,
+		repositories.UserRepository,
+		repositories.AvatarPolicyRepository,
+		repositories.AvatarHistoryRepository,
+		repositories.OutboxRepository,
+	)
+
+	uc.OutboxUsecase = usecase.NewOutboxUsecase(
+		domains.OutboxDomain,
+		domains.WebhookDomain,
+		domains.AuditDomain,
+		repositories.OutboxRepository,
+		repositories.FileRepository,
+		repositories.EventRepository,
+		repositories.WebhookRepository,
+		repositories.WebhookDeliveryRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.WebhookUsecase = usecase.NewWebhookUsecase(
+		domains.WebhookDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.WebhookRepository,
+		repositories.WebhookDeliveryRepository,
+		repositories.WebhookDeadLetterRepository,
+		repositories.AuditLogRepository,
+		repositories.WebhookSender,
+	)
+
+	uc.AuditUsecase = usecase.NewAuditUsecase(
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.ScimUsecase = usecase.NewScimUsecase(
+		domains.UserDomain,
+		domains.ScimDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+		repositories.PasswordHistoryRepository,
+	)
+
+	uc.UserImportUsecase = usecase.NewUserImportUsecase(
+		domains.UserDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.UserExportUsecase = usecase.NewUserExportUsecase(
+		domains.UserDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.DataExportUsecase = usecase.NewDataExportUsecase(
+		time.Duration(config.Variable.User.AvatarPresignedURLExpiration)*time.Second,
+		variable.DataExport.SyncLimit,
+		domains.DataExportDomain,
 		domains.AvatarDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AvatarHistoryRepository,
+		repositories.LinkedIdentityRepository,
+		repositories.AuditLogRepository,
+		repositories.DataExportRepository,
 		repositories.FileRepository,
+	)
+
+	uc.UserErasureUsecase = usecase.NewUserErasureUsecase(
+		config.TokenEngine,
+		domains.ReauthenticationDomain,
+		domains.UserDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AvatarHistoryRepository,
+		repositories.DataExportRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+		repositories.PersonalAccessTokenRepository,
+		repositories.LinkedIdentityRepository,
+		repositories.PasskeyRepository,
+		repositories.PasswordHistoryRepository,
+	)
+
+	uc.RegistrationUsecase = usecase.NewRegistrationUsecase(
+		config.TokenEngine,
+		variable.User.RegistrationRateLimit,
+		time.Duration(variable.User.RegistrationRateWindow)*time.Millisecond,
+		domains.RegistrationDomain,
+		domains.InvitationDomain,
+		domains.EmailVerificationDomain,
+		domains.UserDomain,
+		domains.AvatarDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
 		repositories.UserRepository,
+		repositories.InvitationRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+		repositories.RateLimitRepository,
+		repositories.CaptchaVerifier,
+		repositories.MailSender,
 	)
 
+	uc.EmailVerificationUsecase = usecase.NewEmailVerificationUsecase(
+		config.TokenEngine,
+		variable.EmailVerification.RateLimit,
+		time.Duration(variable.EmailVerification.RateWindow)*time.Millisecond,
+		domains.EmailVerificationDomain,
+		domains.UserDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+		repositories.RateLimitRepository,
+		repositories.MailSender,
+	)
+
+	uc.InvitationUsecase = usecase.NewInvitationUsecase(
+		config.TokenEngine,
+		time.Duration(variable.Invitation.DefaultExpiration)*time.Millisecond,
+		domains.InvitationDomain,
+		domains.UserDomain,
+		domains.AvatarDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
+		repositories.InvitationRepository,
+		repositories.UserRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.PersonalAccessTokenUsecase = usecase.NewPersonalAccessTokenUsecase(
+		time.Duration(variable.PAT.DefaultExpiration)*time.Millisecond,
+		variable.PAT.MaxPerUser,
+		domains.PersonalAccessTokenDomain,
+		domains.UserDomain,
+		domains.AuditDomain,
+		repositories.PersonalAccessTokenRepository,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.LinkedIdentityUsecase = usecase.NewLinkedIdentityUsecase(
+		domains.LinkedIdentityDomain,
+		domains.UserDomain,
+		domains.AvatarDomain,
+		domains.EventDomain,
+		domains.OutboxDomain,
+		domains.AuditDomain,
+		repositories.LinkedIdentityRepository,
+		repositories.UserRepository,
+		repositories.PasskeyRepository,
+		repositories.OutboxRepository,
+		repositories.AuditLogRepository,
+	)
+
+	uc.PasskeyUsecase = usecase.NewPasskeyUsecase(
+		config.TokenEngine,
+		variable.WebAuthn.MaxPerUser,
+		domains.PasskeyDomain,
+		domains.ReauthenticationDomain,
+		domains.AuditDomain,
+		repositories.PasskeyRepository,
+		repositories.PasskeySessionRepository,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+		repositories.WebAuthnRelyingParty,
+	)
+
+	uc.MagicLinkUsecase = usecase.NewMagicLinkUsecase(
+		config.TokenEngine,
+		variable.MagicLink.RateLimit,
+		time.Duration(variable.MagicLink.RateWindow)*time.Millisecond,
+		domains.MagicLinkDomain,
+		domains.UserDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+		repositories.MagicLinkRepository,
+		repositories.RateLimitRepository,
+		repositories.MailSender,
+	)
+
+	uc.PasswordResetUsecase = usecase.NewPasswordResetUsecase(
+		config.TokenEngine,
+		domains.PasswordResetDomain,
+		domains.UserDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+		repositories.PasswordHistoryRepository,
+	)
+
+	uc.ReauthenticationUsecase = usecase.NewReauthenticationUsecase(
+		config.TokenEngine,
+		domains.ReauthenticationDomain,
+		domains.UserDomain,
+		domains.AuditDomain,
+		repositories.UserRepository,
+		repositories.AuditLogRepository,
+		repositories.LDAPDirectory,
+	)
+
+	if repositories.LDAPDirectory != nil {
+		uc.LDAPUsecase = usecase.NewLDAPUsecase(
+			domains.UserDomain,
+			domains.EventDomain,
+			domains.OutboxDomain,
+			domains.AuditDomain,
+			repositories.UserRepository,
+			repositories.OutboxRepository,
+			repositories.AuditLogRepository,
+			repositories.LDAPDirectory,
+		)
+	}
+
 	return uc, nil
 }
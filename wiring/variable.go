@@ -0,0 +1,500 @@
+package wiring
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Variable contains the user-service specific variables which are not
+// covered by the shared config. They are loaded from the same environment as
+// the shared config.
+type Variable struct {
+	User        UserVariable        `envconfig:"user"`
+	Outbox      OutboxVariable      `envconfig:"outbox"`
+	FileService FileServiceVariable `envconfig:"file_service"`
+	TLS         TLSVariable         `envconfig:"tls"`
+	Event       EventVariable       `envconfig:"event"`
+	Webhook     WebhookVariable     `envconfig:"webhook"`
+	DataExport  DataExportVariable  `envconfig:"data_export"`
+	Erasure     ErasureVariable     `envconfig:"erasure"`
+	Invitation  InvitationVariable  `envconfig:"invitation"`
+	PAT         PATVariable         `envconfig:"pat"`
+	LDAP        LDAPVariable        `envconfig:"ldap"`
+	WebAuthn    WebAuthnVariable    `envconfig:"webauthn"`
+	Mail        MailVariable        `envconfig:"mail"`
+	MagicLink   MagicLinkVariable   `envconfig:"magic_link"`
+	Password    PasswordVariable    `envconfig:"password"`
+
+	EmailVerification EmailVerificationVariable `envconfig:"email_verification"`
+}
+
+func DefaultVariable() Variable {
+	return Variable{
+		User:        DefaultUserVariable(),
+		Outbox:      DefaultOutboxVariable(),
+		FileService: DefaultFileServiceVariable(),
+		TLS:         DefaultTLSVariable(),
+		Event:       DefaultEventVariable(),
+		Webhook:     DefaultWebhookVariable(),
+		DataExport:  DefaultDataExportVariable(),
+		Erasure:     DefaultErasureVariable(),
+		Invitation:  DefaultInvitationVariable(),
+		PAT:         DefaultPATVariable(),
+		LDAP:        DefaultLDAPVariable(),
+		WebAuthn:    DefaultWebAuthnVariable(),
+		Mail:        DefaultMailVariable(),
+		MagicLink:   DefaultMagicLinkVariable(),
+		Password:    DefaultPasswordVariable(),
+
+		EmailVerification: DefaultEmailVerificationVariable(),
+	}
+}
+
+type UserVariable struct {
+	// DefaultAvatarBaseURL is the public base url of this service, it is used
+	// to build the url of generated default avatars.
+	DefaultAvatarBaseURL string `envconfig:"default_avatar_base_url"`
+
+	// AvatarPolicyFile is the path to a yaml file defining the avatar policy
+	// per role. If it is empty, every user gets the policy defined by the
+	// shared USER_AVATAR_ALLOWED_TYPES and USER_AVATAR_MAX_SIZE variables.
+	AvatarPolicyFile string `envconfig:"avatar_policy_file"`
+
+	// AvatarHistorySize is the number of previous avatars kept per user. They
+	// keep holding their file reference until being evicted.
+	AvatarHistorySize int `envconfig:"avatar_history_size"`
+
+	// ReservedUsernames cannot be registered by users, they are compared
+	// case-insensitively. The first admin, provisioned and imported users may
+	// still take them.
+	ReservedUsernames []string `envconfig:"reserved_usernames"`
+
+	// A client checks the availability of at most UsernameAvailabilityLimit
+	// usernames per UsernameAvailabilityWindow.
+	UsernameAvailabilityLimit  int `envconfig:"username_availability_limit"`
+	UsernameAvailabilityWindow int `envconfig:"username_availability_window"` // in millisecond
+
+	// RegistrationMode is off, open, invite or domain. The invite mode requires
+	// an invitation token, the domain mode requires a verified email of one of
+	// RegistrationAllowedDomains and a mail sender.
+	RegistrationMode           string   `envconfig:"registration_mode"`
+	RegistrationAllowedDomains []string `envconfig:"registration_allowed_domains"`
+
+	// RegistrationCaptcha is none, fake or siteverify. The fake verifier only
+	// accepts RegistrationCaptchaSecret as the token, siteverify posts the
+	// token to RegistrationCaptchaURL (reCAPTCHA, hCaptcha or Turnstile).
+	RegistrationCaptcha        string `envconfig:"registration_captcha"`
+	RegistrationCaptchaURL     string `envconfig:"registration_captcha_url"`
+	RegistrationCaptchaSecret  string `envconfig:"registration_captcha_secret"`
+	RegistrationCaptchaTimeout int    `envconfig:"registration_captcha_timeout"` // in millisecond
+
+	// An ip address registers at most RegistrationRateLimit users per
+	// RegistrationRateWindow.
+	RegistrationRateLimit  int `envconfig:"registration_rate_limit"`
+	RegistrationRateWindow int `envconfig:"registration_rate_window"` // in millisecond
+
+	// LinkedIdentityProviders are the names of the external identity providers
+	// whose identities can be linked to users, e.g. github or google.
+	LinkedIdentityProviders []string `envconfig:"linked_identity_providers"`
+
+	// ReauthenticationExpiration is the lifetime of the re-authentication
+	// tokens required by the sensitive changes.
+	ReauthenticationExpiration int `envconfig:"reauthentication_expiration"` // in millisecond
+}
+
+func DefaultUserVariable() UserVariable {
+	return UserVariable{
+		DefaultAvatarBaseURL: "http://localhost:8081",
+		AvatarHistorySize:    5,
+		ReservedUsernames: []string{
+			"admin", "administrator", "root", "system", "support",
+			"help", "todennus", "anonymous", "null", "undefined",
+		},
+		UsernameAvailabilityLimit:  30,
+		UsernameAvailabilityWindow: 60 * 1000, // 1m
+		RegistrationMode:           "off",
+		RegistrationCaptcha:        "none",
+		RegistrationCaptchaTimeout: 3000, // 3s
+		RegistrationRateLimit:      5,
+		RegistrationRateWindow:     60 * 60 * 1000, // 1h
+		ReauthenticationExpiration: 5 * 60 * 1000,  // 5m
+	}
+}
+
+type OutboxVariable struct {
+	// MaxAttempts is the number of delivery attempts before the relay gives up
+	// a message. Dead messages can be revived by the avatar-check command.
+	MaxAttempts int `envconfig:"max_attempts"`
+
+	RetryBackoff    int `envconfig:"retry_backoff"`     // in millisecond, doubled after each attempt
+	MaxRetryBackoff int `envconfig:"max_retry_backoff"` // in millisecond
+
+	RelayInterval  int `envconfig:"relay_interval"` // in millisecond
+	RelayBatchSize int `envconfig:"relay_batch_size"`
+
+	// ClaimLease is the time a relay has to deliver the messages it claimed,
+	// the messages of a relay which stopped are delivered again after it.
+	ClaimLease int `envconfig:"claim_lease"` // in millisecond
+}
+
+func DefaultOutboxVariable() OutboxVariable {
+	return OutboxVariable{
+		MaxAttempts:     10,
+		RetryBackoff:    1000,           // 1s
+		MaxRetryBackoff: 10 * 60 * 1000, // 10m
+		RelayInterval:   1000,           // 1s
+		RelayBatchSize:  100,
+		ClaimLease:      5 * 60 * 1000, // 5m
+	}
+}
+
+type FileServiceVariable struct {
+	RegisterUploadTimeout     int `envconfig:"register_upload_timeout"`      // in millisecond
+	CreatePresignedURLTimeout int `envconfig:"create_presigned_url_timeout"` // in millisecond
+	ChangeRefcountTimeout     int `envconfig:"change_refcount_timeout"`      // in millisecond
+
+	RetryAttempts int `envconfig:"retry_attempts"`
+	RetryBackoff  int `envconfig:"retry_backoff"` // in millisecond, doubled after each attempt
+
+	// The circuit breaker opens after BreakerThreshold consecutive failures
+	// and lets a probe call through after BreakerCooldown. Set the threshold
+	// to zero to disable it.
+	BreakerThreshold int `envconfig:"breaker_threshold"`
+	BreakerCooldown  int `envconfig:"breaker_cooldown"` // in millisecond
+
+	// DegradedRead allows reading users while the file service is
+	// unavailable, the avatar url is omitted from the response.
+	DegradedRead bool `envconfig:"degraded_read"`
+}
+
+func DefaultFileServiceVariable() FileServiceVariable {
+	return FileServiceVariable{
+		RegisterUploadTimeout:     2000, // 2s
+		CreatePresignedURLTimeout: 500,  // 500ms
+		ChangeRefcountTimeout:     2000, // 2s
+		RetryAttempts:             3,
+		RetryBackoff:              50, // 50ms
+		BreakerThreshold:          5,
+		BreakerCooldown:           10000, // 10s
+		DegradedRead:              true,
+	}
+}
+
+type TLSVariable struct {
+	// The gRPC server serves TLS if ServerCertFile is set. ServerClientAuth is
+	// one of none, request or require, the latter enables mTLS.
+	ServerCertFile     string `envconfig:"server_cert_file"`
+	ServerKeyFile      string `envconfig:"server_key_file"`
+	ServerClientCAFile string `envconfig:"server_client_ca_file"`
+	ServerClientAuth   string `envconfig:"server_client_auth"`
+
+	// The connection to the file service uses TLS if FileServiceEnabled is
+	// true. The client certificate is only needed for mTLS.
+	FileServiceEnabled    bool   `envconfig:"file_service_enabled"`
+	FileServiceCAFile     string `envconfig:"file_service_ca_file"`
+	FileServiceCertFile   string `envconfig:"file_service_cert_file"`
+	FileServiceKeyFile    string `envconfig:"file_service_key_file"`
+	FileServiceServerName string `envconfig:"file_service_server_name"`
+
+	// ReloadInterval is how often the certificate files are checked for
+	// changes, zero disables hot reload.
+	ReloadInterval int `envconfig:"reload_interval"` // in millisecond
+}
+
+func DefaultTLSVariable() TLSVariable {
+	return TLSVariable{
+		ServerClientAuth: "none",
+		ReloadInterval:   60 * 1000, // 1m
+	}
+}
+
+type EventVariable struct {
+	// Stream is the Redis Stream which the domain events are published to.
+	Stream string `envconfig:"stream"`
+
+	// MaxLen approximately caps the stream length, older entries are trimmed.
+	MaxLen int64 `envconfig:"max_len"`
+}
+
+func DefaultEventVariable() EventVariable {
+	return EventVariable{
+		Stream: "todennus:user-events",
+		MaxLen: 100000,
+	}
+}
+
+type WebhookVariable struct {
+	// MaxAttempts is the number of delivery attempts before a delivery is
+	// moved to the dead letters.
+	MaxAttempts int `envconfig:"max_attempts"`
+
+	RetryBackoff    int `envconfig:"retry_backoff"`     // in millisecond, doubled after each attempt
+	MaxRetryBackoff int `envconfig:"max_retry_backoff"` // in millisecond
+
+	Timeout           int `envconfig:"timeout"` // in millisecond
+	DispatchBatchSize int `envconfig:"dispatch_batch_size"`
+
+	// ClaimLease is the time a dispatcher has to send the deliveries it
+	// claimed, it must cover DispatchBatchSize times Timeout.
+	ClaimLease int `envconfig:"claim_lease"` // in millisecond
+}
+
+func DefaultWebhookVariable() WebhookVariable {
+	return WebhookVariable{
+		MaxAttempts:       8,
+		RetryBackoff:      10 * 1000,      // 10s
+		MaxRetryBackoff:   60 * 60 * 1000, // 1h
+		Timeout:           5000,           // 5s
+		DispatchBatchSize: 20,
+		ClaimLease:        5 * 60 * 1000, // 5m
+	}
+}
+
+type DataExportVariable struct {
+	// SyncLimit is the number of audit logs of a user above which the archive
+	// is generated by the worker instead of within the request.
+	SyncLimit int `envconfig:"sync_limit"`
+
+	// Expiration is the duration during which a generated archive can be
+	// downloaded, it is deleted by the worker afterward.
+	Expiration int `envconfig:"expiration"` // in millisecond
+
+	ProcessBatchSize int `envconfig:"process_batch_size"`
+}
+
+func DefaultDataExportVariable() DataExportVariable {
+	return DataExportVariable{
+		SyncLimit:        1000,
+		Expiration:       7 * 24 * 60 * 60 * 1000, // 7d
+		ProcessBatchSize: 5,
+	}
+}
+
+type ErasureVariable struct {
+	// GracePeriod is the duration between the request of an erasure and the
+	// erasure, during which it can be canceled.
+	GracePeriod int `envconfig:"grace_period"` // in millisecond
+
+	ProcessBatchSize int `envconfig:"process_batch_size"`
+}
+
+func DefaultErasureVariable() ErasureVariable {
+	return ErasureVariable{
+		GracePeriod:      30 * 24 * 60 * 60 * 1000, // 30d
+		ProcessBatchSize: 10,
+	}
+}
+
+type InvitationVariable struct {
+	// DefaultExpiration is the validity of an invitation created without an
+	// expiration, no invitation can be valid for longer than MaxExpiration.
+	DefaultExpiration int `envconfig:"default_expiration"` // in millisecond
+	MaxExpiration     int `envconfig:"max_expiration"`     // in millisecond
+}
+
+func DefaultInvitationVariable() InvitationVariable {
+	return InvitationVariable{
+		DefaultExpiration: 7 * 24 * 60 * 60 * 1000,  // 7d
+		MaxExpiration:     30 * 24 * 60 * 60 * 1000, // 30d
+	}
+}
+
+type PATVariable struct {
+	// DefaultExpiration is the validity of a personal access token created
+	// without an expiration, no token can be valid for longer than
+	// MaxExpiration.
+	DefaultExpiration int `envconfig:"default_expiration"` // in millisecond
+	MaxExpiration     int `envconfig:"max_expiration"`     // in millisecond
+
+	// MaxPerUser is the number of tokens a user can have at the same time,
+	// the expired and revoked tokens are not counted.
+	MaxPerUser int `envconfig:"max_per_user"`
+}
+
+func DefaultPATVariable() PATVariable {
+	return PATVariable{
+		DefaultExpiration: 90 * 24 * 60 * 60 * 1000,  // 90d
+		MaxExpiration:     365 * 24 * 60 * 60 * 1000, // 1y
+		MaxPerUser:        20,
+	}
+}
+
+type LDAPVariable struct {
+	// Directory is none, memory or ldap. The memory directory holds the
+	// entries of MemoryFile in process, it stands in for an LDAP server in
+	// local and test deployments.
+	Directory  string `envconfig:"directory"`
+	MemoryFile string `envconfig:"memory_file"`
+
+	// URL is an ldap:// or ldaps:// url, StartTLS upgrades an ldap://
+	// connection. The directory is searched as BindDN, or anonymously if it
+	// is empty.
+	URL          string `envconfig:"url"`
+	StartTLS     bool   `envconfig:"start_tls"`
+	BindDN       string `envconfig:"bind_dn"`
+	BindPassword string `envconfig:"bind_password"`
+
+	// The users are the entries of the BaseDN subtree matching both
+	// UserFilter and GroupFilter, GroupFilter is optional.
+	BaseDN      string `envconfig:"base_dn"`
+	UserFilter  string `envconfig:"user_filter"`
+	GroupFilter string `envconfig:"group_filter"`
+
+	// IDAttribute must never change for an entry, objectGUID on Active
+	// Directory.
+	IDAttribute          string `envconfig:"id_attribute"`
+	UsernameAttribute    string `envconfig:"username_attribute"`
+	DisplayNameAttribute string `envconfig:"display_name_attribute"`
+	EmailAttribute       string `envconfig:"email_attribute"`
+
+	Timeout      int `envconfig:"timeout"`       // in millisecond
+	SyncInterval int `envconfig:"sync_interval"` // in millisecond
+}
+
+func DefaultLDAPVariable() LDAPVariable {
+	return LDAPVariable{
+		Directory:            "none",
+		UserFilter:           "(objectClass=person)",
+		IDAttribute:          "entryUUID",
+		UsernameAttribute:    "uid",
+		DisplayNameAttribute: "cn",
+		EmailAttribute:       "mail",
+		Timeout:              5000,           // 5s
+		SyncInterval:         60 * 60 * 1000, // 1h
+	}
+}
+
+type WebAuthnVariable struct {
+	// RPID is the domain the passkeys are bound to, it cannot change without
+	// losing every passkey. RPOrigins are the origins of the pages running the
+	// ceremonies, they must be on RPID or one of its subdomains.
+	RPID          string   `envconfig:"rp_id"`
+	RPDisplayName string   `envconfig:"rp_display_name"`
+	RPOrigins     []string `envconfig:"rp_origins"`
+
+	// UserVerification and ResidentKey are required, preferred or
+	// discouraged.
+	UserVerification string `envconfig:"user_verification"`
+	ResidentKey      string `envconfig:"resident_key"`
+
+	SessionTimeout int `envconfig:"session_timeout"` // in millisecond
+	MaxPerUser     int `envconfig:"max_per_user"`
+}
+
+func DefaultWebAuthnVariable() WebAuthnVariable {
+	return WebAuthnVariable{
+		RPID:             "localhost",
+		RPDisplayName:    "Todennus",
+		RPOrigins:        []string{"http://localhost:3000"},
+		UserVerification: "preferred",
+		ResidentKey:      "preferred",
+		SessionTimeout:   5 * 60 * 1000, // 5m
+		MaxPerUser:       10,
+	}
+}
+
+type MailVariable struct {
+	// Sender is none, log or smtp. The log sender writes the emails to the
+	// log, it stands in for a mail server in local deployments.
+	Sender string `envconfig:"sender"`
+	From   string `envconfig:"from"`
+
+	SMTPAddr     string `envconfig:"smtp_addr"`
+	SMTPUsername string `envconfig:"smtp_username"`
+	SMTPPassword string `envconfig:"smtp_password"`
+	Timeout      int    `envconfig:"timeout"` // in millisecond
+}
+
+func DefaultMailVariable() MailVariable {
+	return MailVariable{
+		Sender:  "none",
+		From:    "Todennus <no-reply@localhost>",
+		Timeout: 10000, // 10s
+	}
+}
+
+type MagicLinkVariable struct {
+	// URL is the page of the auth server redeeming the links, the token is
+	// given in its token query parameter.
+	URL        string `envconfig:"url"`
+	Expiration int    `envconfig:"expiration"` // in millisecond
+
+	// RateLimit is the number of links sent to an email per RateWindow.
+	RateLimit  int `envconfig:"rate_limit"`
+	RateWindow int `envconfig:"rate_window"` // in millisecond
+}
+
+func DefaultMagicLinkVariable() MagicLinkVariable {
+	return MagicLinkVariable{
+		URL:        "http://localhost:3000/magic-link",
+		Expiration: 15 * 60 * 1000, // 15m
+		RateLimit:  3,
+		RateWindow: 15 * 60 * 1000, // 15m
+	}
+}
+
+type EmailVerificationVariable struct {
+	// URL is the page verifying the emails, the token is given in its token
+	// query parameter.
+	URL        string `envconfig:"url"`
+	Expiration int    `envconfig:"expiration"` // in millisecond
+
+	// RateLimit is the number of links sent to an email per RateWindow.
+	RateLimit  int `envconfig:"rate_limit"`
+	RateWindow int `envconfig:"rate_window"` // in millisecond
+}
+
+func DefaultEmailVerificationVariable() EmailVerificationVariable {
+	return EmailVerificationVariable{
+		URL:        "http://localhost:3000/verify-email",
+		Expiration: 24 * 60 * 60 * 1000, // 1d
+		RateLimit:  3,
+		RateWindow: 15 * 60 * 1000, // 15m
+	}
+}
+
+type PasswordVariable struct {
+	// MaxAge is the age after which a password expires and must be changed,
+	// zero for never. The validation warns about the expiry during the
+	// WarningWindow before.
+	MaxAge        int `envconfig:"max_age"`        // in millisecond
+	WarningWindow int `envconfig:"warning_window"` // in millisecond
+
+	// HistorySize is the number of the last passwords, including the current
+	// one, which cannot be reused. Their hashes are kept until evicted.
+	HistorySize int `envconfig:"history_size"`
+
+	// ResetExpiration is the lifetime of the password reset tokens.
+	ResetExpiration int `envconfig:"reset_expiration"` // in millisecond
+}
+
+func DefaultPasswordVariable() PasswordVariable {
+	return PasswordVariable{
+		MaxAge:          0,
+		WarningWindow:   14 * 24 * 60 * 60 * 1000, // 14d
+		HistorySize:     5,
+		ResetExpiration: 24 * 60 * 60 * 1000, // 1d
+	}
+}
+
+func LoadVariable() (*Variable, error) {
+	variable := DefaultVariable()
+
+	sType := reflect.TypeOf(variable)
+	sValue := reflect.ValueOf(&variable).Elem()
+	for i := range sType.NumField() {
+		field := sType.Field(i)
+		prefix := field.Tag.Get("envconfig")
+		if prefix == "" {
+			prefix = strings.ToLower(field.Name)
+		}
+
+		if err := envconfig.Process(prefix, sValue.Field(i).Addr().Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &variable, nil
+}